@@ -11,5 +11,5 @@ cd build
 for i in 1 2 3 4 5
 do
 	mkdir -p datadir$i
-	./uranus --miner_start --datadir datadir$i --p2p_listenaddr :707$i --node_rpcport 800$i > datadir$i/uranus.log 2>&1 &
+	./uranus --miner_start --datadir datadir$i --p2p_listenaddr :707$i --p2p_nat none --node_rpcport 800$i --node_ethrpcport 854$i > datadir$i/uranus.log 2>&1 &
 done
\ No newline at end of file
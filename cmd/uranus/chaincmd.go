@@ -0,0 +1,332 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	cmdutils "github.com/UranusBlockStack/uranus/cmd/utils"
+	"github.com/UranusBlockStack/uranus/common/db"
+	"github.com/UranusBlockStack/uranus/common/log"
+	"github.com/UranusBlockStack/uranus/common/rlp"
+	"github.com/UranusBlockStack/uranus/core"
+	"github.com/UranusBlockStack/uranus/core/ledger"
+	"github.com/UranusBlockStack/uranus/core/types"
+	"github.com/UranusBlockStack/uranus/node"
+	"github.com/UranusBlockStack/uranus/server"
+	"github.com/spf13/cobra"
+)
+
+const (
+	progressInterval = 10 * time.Second // interval between the progress logs
+	importBatchSize  = 2500             // number of blocks inserted at once by the import
+)
+
+var errImportInterrupted = errors.New("import interrupted, run it again to resume")
+
+var (
+	noVerifySeal bool
+	rewindForce  bool
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export <file> [from] [to]",
+	Short: "Export the blockchain into a file",
+	Long:  `Export the RLP encoded blocks from the height from to the height to (default the whole chain) into the file, the file is gzipped if it ends with .gz.`,
+	Args:  cobra.RangeArgs(1, 3),
+	Run: func(cmd *cobra.Command, args []string) {
+		var heights []uint64
+		for _, arg := range args[1:] {
+			height, err := strconv.ParseUint(arg, 10, 64)
+			if err != nil {
+				log.Fatalf("Invalid block height: %v, err: %v", arg, err)
+			}
+			heights = append(heights, height)
+		}
+		runChainCmd(func(chain *core.BlockChain) error {
+			from, to := uint64(0), chain.CurrentBlock().Height().Uint64()
+			if len(heights) > 0 {
+				from = heights[0]
+			}
+			if len(heights) > 1 {
+				if heights[1] > to {
+					return fmt.Errorf("block #%d is above the head #%d", heights[1], to)
+				}
+				to = heights[1]
+			}
+			if from > to {
+				return fmt.Errorf("invalid range from #%d to #%d", from, to)
+			}
+			return exportChain(chain, args[0], from, to)
+		})
+	},
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import the blockchain from a file",
+	Long:  `Import the RLP encoded blocks of the file written by export, the blocks already in the chain are skipped so an interrupted import resumes where it stopped.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runChainCmd(func(chain *core.BlockChain) error {
+			if noVerifySeal {
+				log.Warn("Seal verification disabled, only import trusted archives")
+				chain.SetVerifySeal(false)
+			}
+			return importChain(chain, args[0])
+		})
+	},
+}
+
+var rewindCmd = &cobra.Command{
+	Use:   "rewind <height>",
+	Short: "Rewind the blockchain to a block height",
+	Long:  `Rewind the canonical chain to the block height, the later blocks are deleted with their transaction lookups and receipts. The chain isn't rewound below the confirmed height unless --force is given.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		height, err := strconv.ParseUint(args[0], 10, 64)
+		if err != nil {
+			log.Fatalf("Invalid block height: %v, err: %v", args[0], err)
+		}
+		runChainCmd(func(chain *core.BlockChain) error {
+			_, err := chain.SetHead(height, rewindForce)
+			return err
+		})
+	},
+}
+
+// addDataDirFlags adds the flags locating the data directory to the offline commands.
+func addDataDirFlags(cmd *cobra.Command) {
+	falgs := cmd.Flags()
+	falgs.StringVarP(&startConfig.NodeConfig.DataDir, "datadir", "d", cmdutils.DefaultDataDir(), "Data directory for the databases")
+	falgs.StringVarP(&startConfig.CfgFile, "config", "c", "", "YAML configuration file")
+}
+
+// addLedgerFlags adds the flags locating the ledger to the offline ledger commands.
+func addLedgerFlags(cmd *cobra.Command) {
+	addDataDirFlags(cmd)
+	falgs := cmd.Flags()
+	falgs.StringVar(&startConfig.UranusConfig.LedgerConfig.AncientDir, "ledger_ancientdir", startConfig.UranusConfig.LedgerConfig.AncientDir, "Directory of the frozen blocks (default = chaindata/ancient in the datadir)")
+	falgs.BoolVar(&startConfig.UranusConfig.LedgerConfig.AddressIndex, "ledger_addressindex", startConfig.UranusConfig.LedgerConfig.AddressIndex, "Maintain the index of the transactions by address")
+}
+
+// addChainFlags adds the flags locating the chain to the offline chain commands.
+func addChainFlags(cmd *cobra.Command) {
+	addLedgerFlags(cmd)
+	falgs := cmd.Flags()
+	falgs.StringVarP(&startConfig.GenesisFile, "genesis", "g", "", "Genesis JSON file")
+}
+
+// withDataDir locks the data directory without starting the node and runs the command
+// on it, the process exits on error.
+func withDataDir(fn func(ctx *node.Context) error) {
+	if err := unmarshalCfgFile(startConfig); err != nil {
+		log.Warnf("unmarshal config file err: %v ,use default configuration.", err)
+	}
+	log.SetConfig(startConfig.LogConfig)
+
+	stack := node.New(startConfig.NodeConfig)
+	ctx, err := stack.OpenDataDir()
+	if err != nil {
+		log.Fatalf("Failed to open data directory err: %v", err)
+	}
+	err = fn(ctx)
+	stack.CloseDataDir()
+	if err != nil {
+		log.Errorf("%v", err)
+		os.Exit(1)
+	}
+}
+
+// runDBCmd runs the command on the chain database of the data directory.
+func runDBCmd(fn func(chainDb db.Database) error) {
+	withDataDir(func(ctx *node.Context) error {
+		chainDb, err := server.CreateDB(ctx, startConfig.UranusConfig, "chaindata")
+		if err != nil {
+			return fmt.Errorf("failed to open chain database: %v", err)
+		}
+		defer chainDb.Close()
+		return fn(chainDb)
+	})
+}
+
+// runChainCmd runs the command on the blockchain of the data directory.
+func runChainCmd(fn func(chain *core.BlockChain) error) {
+	withDataDir(func(ctx *node.Context) error {
+		chain, chainDb, err := server.OpenChain(ctx, startConfig.UranusConfig)
+		if err != nil {
+			return fmt.Errorf("failed to open blockchain: %v", err)
+		}
+		defer chainDb.Close()
+		defer chain.Stop()
+		return fn(chain)
+	})
+}
+
+// runLedgerCmd runs the command on the ledger of the data directory, the head block isn't
+// loaded.
+func runLedgerCmd(fn func(l *ledger.Ledger, chainDb db.Database) error) {
+	withDataDir(func(ctx *node.Context) error {
+		l, chainDb, err := server.OpenLedger(ctx, startConfig.UranusConfig)
+		if err != nil {
+			return fmt.Errorf("failed to open ledger: %v", err)
+		}
+		defer chainDb.Close()
+		defer l.Close()
+		return fn(l, chainDb)
+	})
+}
+
+// exportChain writes the blocks from the height from to the height to into the file.
+func exportChain(chain *core.BlockChain, fn string, from, to uint64) error {
+	log.Infof("Exporting blockchain file: %v, from: %v, to: %v", fn, from, to)
+	fh, err := os.OpenFile(fn, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+
+	var (
+		writer io.Writer = fh
+		gz     *gzip.Writer
+	)
+	if strings.HasSuffix(fn, ".gz") {
+		gz = gzip.NewWriter(fh)
+		writer = gz
+	}
+	start, reported := time.Now(), time.Now()
+	for height := from; height <= to; height++ {
+		block := chain.GetBlockByHeight(height)
+		if block == nil {
+			return fmt.Errorf("export failed on #%d: block not found", height)
+		}
+		if err := rlp.Encode(writer, block); err != nil {
+			return err
+		}
+		if time.Since(reported) > progressInterval {
+			log.Infof("Exporting blocks exported: %v, height: %v, elapsed: %v", height-from+1, height, time.Since(start))
+			reported = time.Now()
+		}
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			return err
+		}
+	}
+	if err := fh.Sync(); err != nil {
+		return err
+	}
+	log.Infof("Exported blockchain file: %v, count: %v, elapsed: %v", fn, to-from+1, time.Since(start))
+	return nil
+}
+
+// importChain inserts the blocks of the file in batches, the blocks already in the chain
+// are skipped.
+func importChain(chain *core.BlockChain, fn string) error {
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+	return importFile(chain, fn, importBatchSize, interrupt)
+}
+
+// importFile imports the file in batches of the size, the import stops before the next
+// batch once interrupt is signalled.
+func importFile(chain *core.BlockChain, fn string, batchSize int, interrupt <-chan os.Signal) error {
+	log.Infof("Importing blockchain file: %v", fn)
+	fh, err := os.Open(fn)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+
+	var reader io.Reader = fh
+	if strings.HasSuffix(fn, ".gz") {
+		if reader, err = gzip.NewReader(fh); err != nil {
+			return err
+		}
+	}
+	var (
+		stream   = rlp.NewStream(reader, 0)
+		blocks   = make(types.Blocks, 0, batchSize)
+		imported = 0
+		skipped  = 0
+		start    = time.Now()
+		reported = time.Now()
+	)
+	insert := func() error {
+		select {
+		case <-interrupt:
+			return errImportInterrupted
+		default:
+		}
+		if _, err := chain.InsertChain(blocks); err != nil {
+			return fmt.Errorf("invalid block #%d: %v", blocks[0].Height(), err)
+		}
+		imported += len(blocks)
+		if time.Since(reported) > progressInterval {
+			log.Infof("Importing blocks imported: %v, skipped: %v, height: %v, elapsed: %v", imported, skipped, blocks[len(blocks)-1].Height(), time.Since(start))
+			reported = time.Now()
+		}
+		blocks = blocks[:0]
+		return nil
+	}
+	for n := 0; ; n++ {
+		block := new(types.Block)
+		if err := stream.Decode(block); err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("block %d: failed to parse: %v", n, err)
+		}
+		if chain.HasBlock(block.Hash()) {
+			skipped++
+			continue
+		}
+		if blocks = append(blocks, block); len(blocks) == batchSize {
+			if err := insert(); err != nil {
+				return err
+			}
+		}
+	}
+	if len(blocks) > 0 {
+		if err := insert(); err != nil {
+			return err
+		}
+	}
+	log.Infof("Imported blockchain file: %v, imported: %v, skipped: %v, head: %v, elapsed: %v", fn, imported, skipped, chain.CurrentBlock().Height(), time.Since(start))
+	return nil
+}
+
+func init() {
+	addChainFlags(exportCmd)
+	addChainFlags(importCmd)
+	importCmd.Flags().BoolVar(&noVerifySeal, "no-verify-seal", false, "Skip the seal verification of the imported blocks, only for trusted archives")
+	addChainFlags(rewindCmd)
+	rewindCmd.Flags().BoolVar(&rewindForce, "force", false, "Rewind below the confirmed height")
+
+	RootCmd.AddCommand(exportCmd)
+	RootCmd.AddCommand(importCmd)
+	RootCmd.AddCommand(rewindCmd)
+}
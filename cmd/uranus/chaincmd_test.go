@@ -0,0 +1,173 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/UranusBlockStack/uranus/common/db"
+	"github.com/UranusBlockStack/uranus/common/mclock"
+	"github.com/UranusBlockStack/uranus/consensus/dpos"
+	"github.com/UranusBlockStack/uranus/core"
+	"github.com/UranusBlockStack/uranus/core/ledger"
+	"github.com/UranusBlockStack/uranus/core/vm"
+	"github.com/UranusBlockStack/uranus/feed"
+)
+
+// testdata is the chain fixture shared with the core tests.
+var testdata = filepath.Join("..", "..", "core", "testdata")
+
+const testChainHeight = 30 // height of the head block of the chain.gz fixture
+
+// newTestChain opens a blockchain of the test genesis on a memory database.
+func newTestChain(t *testing.T) (*core.BlockChain, *dpos.Dpos) {
+	genesis, err := readGenesis(filepath.Join(testdata, "genesis.json"))
+	if err != nil {
+		t.Fatalf("failed to read genesis: %v", err)
+	}
+	chainDb := db.NewMemDatabase()
+	chainCfg, statedb, _, err := ledger.SetupGenesis(genesis, ledger.NewChain(chainDb))
+	if err != nil {
+		t.Fatalf("failed to setup genesis: %v", err)
+	}
+	engine := dpos.NewDpos(chainCfg, new(feed.TypeMux), chainDb, statedb, nil, mclock.System{})
+	chain, err := core.NewBlockChain(nil, chainCfg, statedb, chainDb, engine, &vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	engine.Init(chain)
+	return chain, engine
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "uranus-chaincmd-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestExportImport(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	src, _ := newTestChain(t)
+	defer src.Stop()
+	if err := importFile(src, filepath.Join(testdata, "chain.gz"), importBatchSize, nil); err != nil {
+		t.Fatalf("failed to import: %v", err)
+	}
+	if height := src.CurrentBlock().Height().Uint64(); height != testChainHeight {
+		t.Fatalf("head height mismatch: have %d, want %d", height, testChainHeight)
+	}
+
+	for _, name := range []string{"chain.rlp", "chain.rlp.gz"} {
+		fn := filepath.Join(dir, name)
+		if err := exportChain(src, fn, 0, testChainHeight); err != nil {
+			t.Fatalf("%s: failed to export: %v", name, err)
+		}
+		dst, _ := newTestChain(t)
+		if err := importFile(dst, fn, importBatchSize, nil); err != nil {
+			t.Fatalf("%s: failed to import: %v", name, err)
+		}
+		if have, want := dst.CurrentBlock().Hash(), src.CurrentBlock().Hash(); have != want {
+			t.Errorf("%s: head mismatch: have %x, want %x", name, have, want)
+		}
+		dst.Stop()
+	}
+}
+
+func TestImportResume(t *testing.T) {
+	const batchSize = 10
+
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	src, _ := newTestChain(t)
+	defer src.Stop()
+	if err := importFile(src, filepath.Join(testdata, "chain.gz"), importBatchSize, nil); err != nil {
+		t.Fatalf("failed to import: %v", err)
+	}
+	full := filepath.Join(dir, "chain.rlp")
+	if err := exportChain(src, full, 0, testChainHeight); err != nil {
+		t.Fatalf("failed to export: %v", err)
+	}
+	data, err := ioutil.ReadFile(full)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a file cut in the middle of a block keeps the batches before the cut
+	cut := filepath.Join(dir, "cut.rlp")
+	if err := ioutil.WriteFile(cut, data[:len(data)*2/3], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	chain, _ := newTestChain(t)
+	defer chain.Stop()
+	if err := importFile(chain, cut, batchSize, nil); err == nil {
+		t.Fatal("importing a cut file succeeded")
+	}
+	height := chain.CurrentBlock().Height().Uint64()
+	if height == 0 || height%batchSize != 0 || height >= testChainHeight {
+		t.Fatalf("cut import height mismatch: have %d", height)
+	}
+
+	// an interrupted import stops before inserting the next batch
+	interrupt := make(chan os.Signal, 1)
+	interrupt <- syscall.SIGINT
+	if err := importFile(chain, full, batchSize, interrupt); err != errImportInterrupted {
+		t.Fatalf("interrupted import error mismatch: have %v, want %v", err, errImportInterrupted)
+	}
+	if have := chain.CurrentBlock().Height().Uint64(); have != height {
+		t.Fatalf("interrupted import height mismatch: have %d, want %d", have, height)
+	}
+
+	// running it again resumes after the imported blocks
+	if err := importFile(chain, full, batchSize, nil); err != nil {
+		t.Fatalf("failed to resume: %v", err)
+	}
+	if have, want := chain.CurrentBlock().Hash(), src.CurrentBlock().Hash(); have != want {
+		t.Errorf("head mismatch: have %x, want %x", have, want)
+	}
+}
+
+func TestImportNoVerifySeal(t *testing.T) {
+	verified, verifiedEngine := newTestChain(t)
+	defer verified.Stop()
+	if err := importFile(verified, filepath.Join(testdata, "chain.gz"), importBatchSize, nil); err != nil {
+		t.Fatalf("failed to import: %v", err)
+	}
+
+	chain, engine := newTestChain(t)
+	defer chain.Stop()
+	chain.SetVerifySeal(false)
+	if err := importFile(chain, filepath.Join(testdata, "chain.gz"), importBatchSize, nil); err != nil {
+		t.Fatalf("failed to import: %v", err)
+	}
+
+	want, _ := verifiedEngine.GetConfirmedBlockNumber()
+	have, _ := engine.GetConfirmedBlockNumber()
+	if want.Sign() == 0 {
+		t.Fatal("confirmed height not updated by the verified import")
+	}
+	if have.Cmp(want) != 0 {
+		t.Errorf("confirmed height mismatch: have %v, want %v", have, want)
+	}
+}
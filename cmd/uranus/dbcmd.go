@@ -0,0 +1,219 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/UranusBlockStack/uranus/common/crypto"
+	"github.com/UranusBlockStack/uranus/common/db"
+	"github.com/UranusBlockStack/uranus/common/log"
+	"github.com/UranusBlockStack/uranus/common/mtp"
+	"github.com/UranusBlockStack/uranus/common/rlp"
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/core/ledger"
+	"github.com/UranusBlockStack/uranus/core/state"
+	"github.com/UranusBlockStack/uranus/core/types"
+	"github.com/spf13/cobra"
+)
+
+var migrateDryRun bool
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Low level operations on the chain database",
+	Long:  `Low level operations on the chain database of the data directory, the node must be stopped.`,
+}
+
+var dbMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Upgrade the chain database to the current schema",
+	Long:  `Upgrade the chain database to the schema of this release, an interrupted migration resumes where it stopped. The node migrates the database when it starts too, the --dry-run flag only walks the data and writes nothing.`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runDBCmd(func(chainDb db.Database) error {
+			version, ok := ledger.SchemaVersionOf(chainDb)
+			if !ok {
+				return fmt.Errorf("empty database, nothing to migrate")
+			}
+			log.Infof("Database schema version: %v, current: %v", version, ledger.SchemaVersion)
+			return ledger.Migrate(chainDb, migrateDryRun)
+		})
+	},
+}
+
+var dbInspectCmd = &cobra.Command{
+	Use:   "inspect",
+	Short: "Report the size of the chain database",
+	Long:  `Report the count and the size of the keys of each kind in the chain database, and the count of the nodes of the tries of the head block.`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runLedgerCmd(func(l *ledger.Ledger, chainDb db.Database) error {
+			keys, err := ledger.InspectDatabase(chainDb)
+			if err != nil {
+				return err
+			}
+			total := &ledger.KeyStats{Name: "Total"}
+			for _, stat := range keys {
+				total.Count += stat.Count
+				total.Size += stat.Size
+			}
+			report := struct {
+				Keys  []*ledger.KeyStats `json:"keys"`
+				Total *ledger.KeyStats   `json:"total"`
+				Tries *trieStats         `json:"tries,omitempty"`
+			}{Keys: keys, Total: total}
+
+			if head := l.GetBlockByHash(l.GetHeadBlockHash()); head == nil {
+				log.Warn("Head block missing, the tries aren't inspected")
+			} else if report.Tries, err = inspectTries(state.NewDatabase(chainDb), head); err != nil {
+				return fmt.Errorf("failed to inspect the tries of block #%d: %v", head.Height(), err)
+			}
+			return printJSON(report)
+		})
+	},
+}
+
+var dbCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Verify the canonical chain",
+	Long:  `Verify the headers, the bodies, the transaction and receipt lookups of the canonical blocks from the genesis and the availability of their state.`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runLedgerCmd(func(l *ledger.Ledger, chainDb db.Database) error {
+			result := l.CheckChain()
+			if err := printJSON(result); err != nil {
+				return err
+			}
+			if len(result.Issues) > 0 {
+				return fmt.Errorf("found %d issues, the last consistent block with its state is #%d", len(result.Issues), result.State)
+			}
+			return nil
+		})
+	},
+}
+
+var dbRepairCmd = &cobra.Command{
+	Use:   "repair",
+	Short: "Repair the canonical chain",
+	Long:  `Rebuild the missing lookups of the canonical blocks and rewind the head to the last consistent block with its state.`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runLedgerCmd(func(l *ledger.Ledger, chainDb db.Database) error {
+			result, err := l.RepairChain()
+			if err != nil {
+				return err
+			}
+			log.Infof("Repaired chain lookups: %v, head: %v", result.Repaired, result.Head)
+			return printJSON(result)
+		})
+	},
+}
+
+// trieStats is the count of the nodes of the tries of a block.
+type trieStats struct {
+	Height       uint64 `json:"height"`
+	Accounts     uint64 `json:"accounts"`
+	AccountNodes uint64 `json:"accountNodes"`
+	StorageNodes uint64 `json:"storageNodes"`
+	Contracts    uint64 `json:"contracts"`
+	DposNodes    uint64 `json:"dposNodes"`
+}
+
+// inspectTries counts the nodes of the state tries, the storage tries and the dpos tries
+// of the block.
+func inspectTries(statedb state.Database, block *types.Block) (*trieStats, error) {
+	stats := &trieStats{Height: block.Height().Uint64()}
+	emptyCodeHash := crypto.Keccak256(nil)
+
+	tr, err := statedb.OpenTrie(block.StateRoot())
+	if err != nil {
+		return nil, err
+	}
+	err = countNodes(tr.NodeIterator(nil), &stats.AccountNodes, func(it mtp.NodeIterator) error {
+		var account state.Account
+		if err := rlp.DecodeBytes(it.LeafBlob(), &account); err != nil {
+			return err
+		}
+		stats.Accounts++
+		if !bytes.Equal(account.CodeHash, emptyCodeHash) {
+			stats.Contracts++
+		}
+		storage, err := statedb.OpenStorageTrie(utils.BytesToHash(it.LeafKey()), account.Root)
+		if err != nil {
+			return err
+		}
+		return countNodes(storage.NodeIterator(nil), &stats.StorageNodes, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the dpos tries are opened without their key prefix, the iteration starts at the root
+	proto := block.BlockHeader().DposContext
+	for _, root := range []utils.Hash{proto.EpochHash, proto.DelegateHash, proto.VoteHash, proto.CandidateHash, proto.MintCntHash} {
+		tr, err := mtp.New(root, statedb.TrieDB())
+		if err != nil {
+			return nil, err
+		}
+		if err := countNodes(tr.NodeIterator(nil), &stats.DposNodes, nil); err != nil {
+			return nil, err
+		}
+	}
+	return stats, nil
+}
+
+// countNodes counts the stored nodes of the trie, the leaves are passed to the callback.
+func countNodes(it mtp.NodeIterator, count *uint64, onLeaf func(it mtp.NodeIterator) error) error {
+	for it.Next(true) {
+		if it.Hash() != (utils.Hash{}) {
+			*count++
+		}
+		if it.Leaf() && onLeaf != nil {
+			if err := onLeaf(it); err != nil {
+				return err
+			}
+		}
+	}
+	return it.Error()
+}
+
+// printJSON prints the value as indented JSON.
+func printJSON(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+func init() {
+	addDataDirFlags(dbMigrateCmd)
+	dbMigrateCmd.Flags().BoolVar(&migrateDryRun, "dry-run", false, "Walk the data without writing the migrated data")
+	addLedgerFlags(dbInspectCmd)
+	addLedgerFlags(dbCheckCmd)
+	addLedgerFlags(dbRepairCmd)
+
+	dbCmd.AddCommand(dbMigrateCmd)
+	dbCmd.AddCommand(dbInspectCmd)
+	dbCmd.AddCommand(dbCheckCmd)
+	dbCmd.AddCommand(dbRepairCmd)
+	RootCmd.AddCommand(dbCmd)
+}
@@ -51,6 +51,14 @@ func defaultUranusConfig() *server.UranusConfig {
 		StartMiner:   false,
 		MinerConfig:  defaultMinerConifg(),
 		TxPoolConfig: defaultTxPoolConfig(),
+		LedgerConfig: defaultLedgerConfig(),
+	}
+}
+
+func defaultLedgerConfig() *ledger.Config {
+	return &ledger.Config{
+		FreezeThreshold: ledger.DefaultFreezeThreshold,
+		Snapshot:        true,
 	}
 }
 
@@ -76,11 +84,16 @@ func defaultTxPoolConfig() *txpool.Config {
 
 func defaultNodeConfig() *node.Config {
 	return &node.Config{
-		Name: params.Identifier,
-		Host: "localhost",
-		Port: 8000,
-		Cors: []string{},
-		P2P:  defaultP2PConfig(),
+		Name:       params.Identifier,
+		Host:       "localhost",
+		Port:       8000,
+		Cors:       []string{},
+		Modules:    node.DefaultHTTPModules,
+		EthHost:    "localhost",
+		EthPort:    8545,
+		EthModules: node.DefaultEthModules,
+		IPCPath:    node.DefaultIPCPath,
+		P2P:        defaultP2PConfig(),
 	}
 }
 
@@ -88,6 +101,7 @@ func defaultP2PConfig() *p2p.Config {
 	return &p2p.Config{
 		ListenAddr: "127.0.0.1:7090",
 		MaxPeers:   25,
+		NATSpec:    "any",
 	}
 }
 
@@ -0,0 +1,108 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/UranusBlockStack/uranus/common/log"
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/consensus/dpos"
+	"github.com/UranusBlockStack/uranus/core"
+	"github.com/UranusBlockStack/uranus/core/types"
+	"github.com/spf13/cobra"
+)
+
+var dumpConfig = struct {
+	height  int64
+	address string
+	start   string
+	max     int
+	dpos    bool
+}{}
+
+var dumpCmd = &cobra.Command{
+	Use:   "dump",
+	Short: "Dump the state at a block height",
+	Long:  `Dump the accounts at the block height as JSON lines, sorted by the hashed addresses. A truncated dump is resumed with the start key logged at its end. The --dpos flag dumps the validators, candidates, votes and mint counts instead.`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runChainCmd(func(chain *core.BlockChain) error {
+			block := chain.CurrentBlock()
+			if dumpConfig.height >= 0 {
+				if block = chain.GetBlockByHeight(uint64(dumpConfig.height)); block == nil {
+					return fmt.Errorf("block #%d not found", dumpConfig.height)
+				}
+			}
+			statedb, err := chain.StateAt(block.StateRoot())
+			if err != nil {
+				return fmt.Errorf("state of block #%d not found: %v", block.Height(), err)
+			}
+
+			encoder := json.NewEncoder(os.Stdout)
+			switch {
+			case dumpConfig.dpos:
+				dposContext, err := types.NewDposContextFromProto(statedb.Database().TrieDB(), block.BlockHeader().DposContext)
+				if err != nil {
+					return err
+				}
+				dump, err := dpos.DumpContext(dposContext, statedb)
+				if err != nil {
+					return err
+				}
+				return encoder.Encode(dump)
+
+			case dumpConfig.address != "":
+				if !utils.IsHexAddr(dumpConfig.address) {
+					return fmt.Errorf("invalid address %v", dumpConfig.address)
+				}
+				account, ok, err := statedb.DumpAddress(utils.HexToAddress(dumpConfig.address))
+				if err != nil {
+					return err
+				}
+				if !ok {
+					return fmt.Errorf("account %v not found at block #%d", dumpConfig.address, block.Height())
+				}
+				return encoder.Encode(account)
+
+			default:
+				next, err := statedb.IterativeDump(utils.FromHex(dumpConfig.start), dumpConfig.max, os.Stdout)
+				if err != nil {
+					return err
+				}
+				if next != nil {
+					log.Infof("Dump truncated height: %v, resume with --start %v", block.Height(), utils.BytesToHex(next))
+				}
+				return nil
+			}
+		})
+	},
+}
+
+func init() {
+	addChainFlags(dumpCmd)
+	falgs := dumpCmd.Flags()
+	falgs.Int64Var(&dumpConfig.height, "height", -1, "Block height of the state (default the head block)")
+	falgs.StringVar(&dumpConfig.address, "address", "", "Dump the single account")
+	falgs.StringVar(&dumpConfig.start, "start", "", "Hashed address of the first account")
+	falgs.IntVar(&dumpConfig.max, "max", 0, "Maximum number of accounts (default unlimited)")
+	falgs.BoolVar(&dumpConfig.dpos, "dpos", false, "Dump the dpos context instead of the accounts")
+
+	RootCmd.AddCommand(dumpCmd)
+}
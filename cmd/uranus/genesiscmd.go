@@ -0,0 +1,84 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/UranusBlockStack/uranus/common/db"
+	"github.com/UranusBlockStack/uranus/common/log"
+	"github.com/UranusBlockStack/uranus/core/ledger"
+	"github.com/spf13/cobra"
+)
+
+var initCmd = &cobra.Command{
+	Use:   "init <genesis.json>",
+	Short: "Write the genesis block into the data directory",
+	Long:  `Write the genesis block and the chain config of the genesis file into the data directory without starting the node.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		genesis, err := readGenesis(args[0])
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		runDBCmd(func(chainDb db.Database) error {
+			_, _, hash, err := ledger.SetupGenesis(genesis, ledger.NewChain(chainDb))
+			if err != nil {
+				return fmt.Errorf("failed to write genesis block: %v", err)
+			}
+			want, err := genesis.Hash()
+			if err != nil {
+				return fmt.Errorf("invalid genesis: %v", err)
+			}
+			if hash != want {
+				return fmt.Errorf("database already contains an incompatible genesis block (have %v, new %v)", hash.Hex(), want.Hex())
+			}
+			log.Infof("Successfully wrote genesis block hash: %v", hash.Hex())
+			return nil
+		})
+	},
+}
+
+var dumpGenesisCmd = &cobra.Command{
+	Use:   "dumpgenesis",
+	Short: "Print the genesis of the data directory",
+	Long:  `Print the genesis and the chain config stored in the data directory, the default genesis when the data directory has no chain.`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runDBCmd(func(chainDb db.Database) error {
+			genesis := ledger.StoredGenesis(ledger.NewChain(chainDb))
+			if genesis == nil {
+				genesis = startConfig.UranusConfig.Genesis
+			}
+			data, err := json.MarshalIndent(genesis, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(data))
+			return nil
+		})
+	},
+}
+
+func init() {
+	addDataDirFlags(initCmd)
+	addDataDirFlags(dumpGenesisCmd)
+
+	RootCmd.AddCommand(initCmd)
+	RootCmd.AddCommand(dumpGenesisCmd)
+}
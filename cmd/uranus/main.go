@@ -99,15 +99,27 @@ func init() {
 	falgs.StringVar(&startConfig.LogConfig.Format, "log_format", "text", "Logging format: text,json.")
 
 	// node
-	falgs.StringVarP(&startConfig.NodeConfig.DataDir, "datadir", "d", cmdutils.DefaultDataDir(), "Data directory for the databases")
+	falgs.StringVarP(&startConfig.NodeConfig.DataDir, "datadir", "d", cmdutils.DefaultDataDir(), "Data directory for the databases (\":memory:\" = ephemeral node, nothing is kept on disk)")
 	falgs.StringVar(&startConfig.NodeConfig.Host, "node_rpchost", startConfig.NodeConfig.Host, "HTTP and RPC server listening interface")
 	falgs.IntVar(&startConfig.NodeConfig.Port, "node_rpcport", startConfig.NodeConfig.Port, "HTTP and RPC server listening port")
 	falgs.StringArrayVar(&startConfig.NodeConfig.Cors, "node_rpccors", startConfig.NodeConfig.Cors, "HTTP and RPC accept cross origin requests")
+	falgs.StringSliceVar(&startConfig.NodeConfig.Modules, "node_rpcmodules", startConfig.NodeConfig.Modules, "Namespaces exposed over HTTP and RPC, Wallet, Admin and Miner are always available over IPC")
+	falgs.StringVar(&startConfig.NodeConfig.JWTSecret, "node_rpcjwtsecret", startConfig.NodeConfig.JWTSecret, "Hex secret file for the JWT authentication of HTTP requests (empty = no authentication)")
+	falgs.StringVar(&startConfig.NodeConfig.IPCPath, "node_ipcpath", startConfig.NodeConfig.IPCPath, "IPC socket filename in the datadir or absolute path (empty = disabled)")
+	falgs.StringVar(&startConfig.NodeConfig.EthHost, "node_ethrpchost", startConfig.NodeConfig.EthHost, "Ethereum compatible JSON-RPC server listening interface (empty = disabled)")
+	falgs.IntVar(&startConfig.NodeConfig.EthPort, "node_ethrpcport", startConfig.NodeConfig.EthPort, "Ethereum compatible JSON-RPC server listening port")
+	falgs.StringSliceVar(&startConfig.NodeConfig.EthModules, "node_ethrpcmodules", startConfig.NodeConfig.EthModules, "Namespaces exposed over the ethereum compatible JSON-RPC server")
 
 	// p2p
 	falgs.StringVar(&startConfig.NodeConfig.P2P.ListenAddr, "p2p_listenaddr", startConfig.NodeConfig.P2P.ListenAddr, "p2p listening port")
 	falgs.IntVar(&startConfig.NodeConfig.P2P.MaxPeers, "p2p_maxpeers", startConfig.NodeConfig.P2P.MaxPeers, "maximum number of network peers")
 	falgs.StringArrayVar(&startConfig.NodeConfig.P2P.BootNodeStrs, "p2p_bootnodes", startConfig.NodeConfig.P2P.BootNodeStrs, "comma separated enode URLs for P2P discovery bootstrap")
+	falgs.StringArrayVar(&startConfig.NodeConfig.P2P.StaticNodeStrs, "p2p_staticnodes", startConfig.NodeConfig.P2P.StaticNodeStrs, "enode URLs always connected, also read from <datadir>/uranus/static-nodes.json")
+	falgs.StringArrayVar(&startConfig.NodeConfig.P2P.TrustedNodeStrs, "p2p_trustednodes", startConfig.NodeConfig.P2P.TrustedNodeStrs, "enode URLs accepted beyond the peer limits, also read from <datadir>/uranus/trusted-nodes.json")
+	falgs.StringArrayVar(&startConfig.NodeConfig.P2P.SentryNodeStrs, "p2p_sentrynodes", startConfig.NodeConfig.P2P.SentryNodeStrs, "enode URLs of the sentries, the validator disables discovery and only connects to them, also read from <datadir>/uranus/sentry-nodes.json")
+	falgs.StringArrayVar(&startConfig.NodeConfig.P2P.PrivateNodeStrs, "p2p_privatenodes", startConfig.NodeConfig.P2P.PrivateNodeStrs, "enode URLs of the validators behind this sentry, never gossiped in discovery, also read from <datadir>/uranus/private-nodes.json")
+	falgs.StringSliceVar(&startConfig.NodeConfig.P2P.NetRestrictStrs, "p2p_netrestrict", startConfig.NodeConfig.P2P.NetRestrictStrs, "comma separated CIDR masks, only the nodes in them are connected")
+	falgs.StringVar(&startConfig.NodeConfig.P2P.NATSpec, "p2p_nat", startConfig.NodeConfig.P2P.NATSpec, "NAT port mapping mechanism (any|none|upnp|pmp|extip:<IP>)")
 
 	// config file
 	falgs.StringVarP(&startConfig.CfgFile, "config", "c", "", "YAML configuration file")
@@ -122,6 +134,12 @@ func init() {
 	falgs.Uint64Var(&startConfig.UranusConfig.TxPoolConfig.GlobalQueue, "txpool_globalqueue", startConfig.UranusConfig.TxPoolConfig.GlobalQueue, "Minimum number of non-executable transaction slots for all accounts")
 	falgs.DurationVar(&startConfig.UranusConfig.TxPoolConfig.TimeoutDuration, "txpool_timeout", startConfig.UranusConfig.TxPoolConfig.TimeoutDuration, "Maximum amount of time non-executable transaction are queued")
 
+	// ledger
+	falgs.StringVar(&startConfig.UranusConfig.LedgerConfig.AncientDir, "ledger_ancientdir", startConfig.UranusConfig.LedgerConfig.AncientDir, "Directory of the frozen blocks (default = chaindata/ancient in the datadir)")
+	falgs.Uint64Var(&startConfig.UranusConfig.LedgerConfig.FreezeThreshold, "ledger_freezethreshold", startConfig.UranusConfig.LedgerConfig.FreezeThreshold, "Number of confirmed blocks kept out of the freezer")
+	falgs.BoolVar(&startConfig.UranusConfig.LedgerConfig.AddressIndex, "ledger_addressindex", startConfig.UranusConfig.LedgerConfig.AddressIndex, "Index the transactions by address")
+	falgs.BoolVar(&startConfig.UranusConfig.LedgerConfig.Snapshot, "ledger_snapshot", startConfig.UranusConfig.LedgerConfig.Snapshot, "Keep a flat snapshot of the state for fast state reads")
+
 	// miner
 	falgs.StringVar(&startConfig.UranusConfig.MinerConfig.CoinBaseAddr, "miner_conbase", "", "Public address for block mining rewards (default = first account created)")
 	falgs.StringVar(&startConfig.UranusConfig.MinerConfig.ExtraData, "miner_extradata", startConfig.UranusConfig.MinerConfig.ExtraData, "Block extra data set by the miner")
@@ -141,9 +159,16 @@ func init() {
 	viper.BindPFlag("rpc-host", falgs.Lookup("node_rpchost"))
 	viper.BindPFlag("rpc-port", falgs.Lookup("node_rpcport"))
 	viper.BindPFlag("rpc-cors", falgs.Lookup("node_rpccors"))
+	viper.BindPFlag("ethrpc-host", falgs.Lookup("node_ethrpchost"))
+	viper.BindPFlag("ethrpc-port", falgs.Lookup("node_ethrpcport"))
+	viper.BindPFlag("ethrpc-modules", falgs.Lookup("node_ethrpcmodules"))
+	viper.BindPFlag("rpc-modules", falgs.Lookup("node_rpcmodules"))
+	viper.BindPFlag("rpc-jwtsecret", falgs.Lookup("node_rpcjwtsecret"))
+	viper.BindPFlag("ipc-path", falgs.Lookup("node_ipcpath"))
 	// node.p2p
 	viper.BindPFlag("p2p-listenaddr", falgs.Lookup("p2p_listenaddr"))
 	viper.BindPFlag("p2p-maxpeers", falgs.Lookup("p2p_maxpeers"))
+	viper.BindPFlag("p2p-nat", falgs.Lookup("p2p_nat"))
 
 	// txpool
 	viper.BindPFlag("txpool-pricebump", falgs.Lookup("txpool_pricebump"))
@@ -154,6 +179,12 @@ func init() {
 	viper.BindPFlag("txpool-globalqueue", falgs.Lookup("txpool_globalqueue"))
 	viper.BindPFlag("txpool-timeout", falgs.Lookup("txpool_timeout"))
 
+	// ledger
+	viper.BindPFlag("ledger-ancientdir", falgs.Lookup("ledger_ancientdir"))
+	viper.BindPFlag("ledger-freezethreshold", falgs.Lookup("ledger_freezethreshold"))
+	viper.BindPFlag("ledger-addressindex", falgs.Lookup("ledger_addressindex"))
+	viper.BindPFlag("ledger-snapshot", falgs.Lookup("ledger_snapshot"))
+
 	//miner
 	viper.BindPFlag("miner-conbase", falgs.Lookup("miner_conbase"))
 	viper.BindPFlag("miner-extradata", falgs.Lookup("miner_extradata"))
@@ -203,6 +234,11 @@ func unmarshalCfgFile(startConfig *StartConfig) error {
 		return err
 	}
 
+	// ledger
+	if err := viper.Unmarshal(startConfig.UranusConfig.LedgerConfig); err != nil {
+		return err
+	}
+
 	// miner
 	if err := viper.Unmarshal(startConfig.UranusConfig.MinerConfig); err != nil {
 		return err
@@ -210,21 +246,30 @@ func unmarshalCfgFile(startConfig *StartConfig) error {
 
 	// Make sure we have a valid genesis JSON
 	if len(startConfig.GenesisFile) != 0 {
-		file, err := os.Open(startConfig.GenesisFile)
+		genesis, err := readGenesis(startConfig.GenesisFile)
 		if err != nil {
-			return fmt.Errorf("Failed to read genesis file: %v(%v)", startConfig.GenesisFile, err)
-		}
-		defer file.Close()
-
-		genesis := new(ledger.Genesis)
-		if err := json.NewDecoder(file).Decode(genesis); err != nil {
-			return fmt.Errorf("invalid genesis file: %v(%v)", startConfig.GenesisFile, err)
+			return err
 		}
 		startConfig.UranusConfig.Genesis = genesis
 	}
 	return nil
 }
 
+// readGenesis reads the genesis JSON file.
+func readGenesis(fn string) (*ledger.Genesis, error) {
+	file, err := os.Open(fn)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read genesis file: %v(%v)", fn, err)
+	}
+	defer file.Close()
+
+	genesis := new(ledger.Genesis)
+	if err := json.NewDecoder(file).Decode(genesis); err != nil {
+		return nil, fmt.Errorf("invalid genesis file: %v(%v)", fn, err)
+	}
+	return genesis, nil
+}
+
 // Execute adds all child commands to the root command sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
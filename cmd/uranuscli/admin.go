@@ -17,8 +17,13 @@
 package main
 
 import (
+	"os"
+	"strconv"
+	"time"
+
 	"github.com/UranusBlockStack/uranus/cmd/utils"
 	"github.com/UranusBlockStack/uranus/p2p"
+	"github.com/UranusBlockStack/uranus/rpcapi"
 	"github.com/spf13/cobra"
 	jww "github.com/spf13/jwalterweatherman"
 )
@@ -79,3 +84,78 @@ var nodeInfoCmd = &cobra.Command{
 		}
 	},
 }
+
+var banPeerCmd = &cobra.Command{
+	Use:   "banPeer <nodeurl or nodeid> [duration]",
+	Short: "Disconnects a remote node and refuses its connections for the duration, e.g. 90m, 24h by default.",
+	Long:  `Disconnects a remote node and refuses its connections for the duration, e.g. 90m, 24h by default.`,
+	Args:  cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		req := &rpcapi.BanPeerArgs{Node: args[0]}
+		if len(args) > 1 {
+			duration, err := time.ParseDuration(args[1])
+			if err != nil || duration < time.Second {
+				jww.ERROR.Printf("invalid duration %q", args[1])
+				os.Exit(1)
+			}
+			req.Duration = uint64(duration / time.Second)
+		}
+		var result bool
+		utils.ClientCall("Admin.BanPeer", req, &result)
+		utils.PrintJSON(result)
+	},
+}
+
+var unbanPeerCmd = &cobra.Command{
+	Use:   "unbanPeer <nodeurl or nodeid>",
+	Short: "Lifts the ban of a remote node.",
+	Long:  `Lifts the ban of a remote node.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var result bool
+		utils.ClientCall("Admin.UnbanPeer", args[0], &result)
+		utils.PrintJSON(result)
+	},
+}
+
+var listBansCmd = &cobra.Command{
+	Use:   "listBans",
+	Short: "List the remote nodes which are banned.",
+	Long:  `List the remote nodes which are banned.`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		result := []*p2p.BanInfo{}
+		utils.ClientCall("Admin.ListBans", nil, &result)
+		if utils.OneLine {
+			for i, item := range result {
+				jww.FEEDBACK.Print(i, ":", item.ID, " ", item.Until.Format(time.RFC3339))
+			}
+		} else {
+			utils.PrintJSONList(result)
+		}
+	},
+}
+
+var setHeadForce bool
+
+var setHeadCmd = &cobra.Command{
+	Use:   "setHead <height>",
+	Short: "Rewinds the canonical chain to the block height.",
+	Long:  `Rewinds the canonical chain to the block height, the later blocks are deleted and their transactions are returned to the txpool. The chain isn't rewound below the confirmed height unless --force is given.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		height, err := strconv.ParseUint(args[0], 10, 64)
+		if err != nil {
+			jww.ERROR.Printf("invalid block height %q", args[0])
+			os.Exit(1)
+		}
+		req := &rpcapi.SetHeadArgs{Height: height, Force: setHeadForce}
+		var result string
+		utils.ClientCall("Admin.SetHead", req, &result)
+		utils.PrintJSON(result)
+	},
+}
+
+func init() {
+	setHeadCmd.Flags().BoolVar(&setHeadForce, "force", false, "Rewind below the confirmed height.")
+}
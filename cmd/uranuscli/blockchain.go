@@ -19,6 +19,7 @@ package main
 import (
 	"os"
 	"strconv"
+	"time"
 
 	cmdutils "github.com/UranusBlockStack/uranus/cmd/utils"
 	"github.com/UranusBlockStack/uranus/common/utils"
@@ -102,3 +103,61 @@ var getTransactionReceiptCmd = &cobra.Command{
 		cmdutils.PrintJSON(result)
 	},
 }
+
+var historyConfig = struct {
+	from   uint64
+	limit  int
+	cursor string
+	full   bool
+}{}
+
+var historyCmd = &cobra.Command{
+	Use:   "history <address>",
+	Short: "Returns a page of the transactions of the address.",
+	Long:  `Returns a page of the transactions sent, received, created or acted by the address from the address index of the node, the next page starts at the returned next cursor.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		req := rpcapi.GetTransactionsByAddressArgs{
+			Address:    utils.HexToAddress(cmdutils.IsHexAddr(args[0])),
+			FromHeight: utils.Uint64(historyConfig.from),
+			Limit:      historyConfig.limit,
+			Cursor:     utils.FromHex(historyConfig.cursor),
+			FullTx:     historyConfig.full,
+		}
+		result := &rpcapi.AddressTransactions{}
+		cmdutils.ClientCall("BlockChain.GetTransactionsByAddress", req, &result)
+		cmdutils.PrintJSON(result)
+	},
+}
+
+func init() {
+	flags := historyCmd.Flags()
+	flags.Uint64Var(&historyConfig.from, "from", 0, "Block height of the first transaction.")
+	flags.IntVar(&historyConfig.limit, "limit", 100, "Maximum number of transactions.")
+	flags.StringVar(&historyConfig.cursor, "cursor", "", "Next cursor returned by the previous page, overrides --from.")
+	flags.BoolVar(&historyConfig.full, "full", false, "Returns the full transactions.")
+}
+
+var syncingCmd = &cobra.Command{
+	Use:   "syncing",
+	Short: "Returns the progress of the block synchronisation.",
+	Long:  `Returns the progress of the block synchronisation with the estimated time left.`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		result := &rpcapi.SyncStatus{}
+		cmdutils.ClientCall("BlockChain.Syncing", nil, &result)
+		cmdutils.PrintJSON(struct {
+			*rpcapi.SyncStatus
+			ETA string `json:"eta"`
+		}{result, syncETA(result).String()})
+	},
+}
+
+// syncETA estimates the time left from the import rate since the sync started.
+func syncETA(status *rpcapi.SyncStatus) time.Duration {
+	if !status.Syncing || status.ProcessedBlocks == 0 || status.HighestBlock <= status.CurrentBlock {
+		return 0
+	}
+	eta := status.Elapsed / time.Duration(status.ProcessedBlocks) * time.Duration(status.HighestBlock-status.CurrentBlock)
+	return eta.Round(time.Second)
+}
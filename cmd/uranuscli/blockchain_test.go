@@ -0,0 +1,43 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/UranusBlockStack/uranus/rpcapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncETA(t *testing.T) {
+	tests := []struct {
+		status *rpcapi.SyncStatus
+		eta    time.Duration
+	}{
+		// 100 blocks in 10s, 50 blocks left
+		{&rpcapi.SyncStatus{Syncing: true, CurrentBlock: 150, HighestBlock: 200, ProcessedBlocks: 100, Elapsed: 10 * time.Second}, 5 * time.Second},
+		{&rpcapi.SyncStatus{Syncing: true, CurrentBlock: 10, HighestBlock: 1010, ProcessedBlocks: 3, Elapsed: time.Second}, 333 * time.Second},
+		// nothing to estimate
+		{&rpcapi.SyncStatus{Syncing: false, CurrentBlock: 150, HighestBlock: 200, ProcessedBlocks: 100, Elapsed: 10 * time.Second}, 0},
+		{&rpcapi.SyncStatus{Syncing: true, CurrentBlock: 150, HighestBlock: 200, Elapsed: 10 * time.Second}, 0},
+		{&rpcapi.SyncStatus{Syncing: true, CurrentBlock: 200, HighestBlock: 200, ProcessedBlocks: 100, Elapsed: 10 * time.Second}, 0},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.eta, syncETA(test.status), "%+v", test.status)
+	}
+}
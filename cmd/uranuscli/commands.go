@@ -32,12 +32,18 @@ func AddCommands() {
 	RootCmd.AddCommand(addPeerCmd)
 	RootCmd.AddCommand(removePeerCmd)
 	RootCmd.AddCommand(nodeInfoCmd)
+	RootCmd.AddCommand(banPeerCmd)
+	RootCmd.AddCommand(unbanPeerCmd)
+	RootCmd.AddCommand(listBansCmd)
+	RootCmd.AddCommand(setHeadCmd)
 
 	// blockchain command
 	RootCmd.AddCommand(getBlockByHeightCmd)
 	RootCmd.AddCommand(getBlockByHashCmd)
 	RootCmd.AddCommand(getTransactionByHashCmd)
 	RootCmd.AddCommand(getTransactionReceiptCmd)
+	RootCmd.AddCommand(historyCmd)
+	RootCmd.AddCommand(syncingCmd)
 
 	// txpool command
 	RootCmd.AddCommand(getContentCmd)
@@ -45,12 +51,15 @@ func AddCommands() {
 
 	// uranus command
 	RootCmd.AddCommand(suggestGasPriceCmd)
+	RootCmd.AddCommand(suggestGasTipCapCmd)
 	RootCmd.AddCommand(getBalanceCmd)
 	RootCmd.AddCommand(getNonceCmd)
 	RootCmd.AddCommand(getCodeCmd)
 	RootCmd.AddCommand(sendRawTransactionCmd)
 	RootCmd.AddCommand(signAndSendTransactionCmd)
+	RootCmd.AddCommand(signTransactionCmd)
 	RootCmd.AddCommand(callCmd)
+	RootCmd.AddCommand(contractCmd)
 
 	// miner command
 	RootCmd.AddCommand(startMinerCmd)
@@ -65,4 +74,8 @@ func AddCommands() {
 	RootCmd.AddCommand(getDelegatorsCmd)
 	RootCmd.AddCommand(getConfirmedBlockNumberCmd)
 	RootCmd.AddCommand(getBFTConfirmedBlockNumberCmd)
+
+	// debug command
+	RootCmd.AddCommand(dumpStateCmd)
+	RootCmd.AddCommand(storageRangeAtCmd)
 }
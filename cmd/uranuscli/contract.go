@@ -0,0 +1,235 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	cmdutils "github.com/UranusBlockStack/uranus/cmd/utils"
+	"github.com/UranusBlockStack/uranus/common/abi"
+	"github.com/UranusBlockStack/uranus/common/crypto"
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/core/types"
+	"github.com/UranusBlockStack/uranus/rpcapi"
+	"github.com/spf13/cobra"
+	jww "github.com/spf13/jwalterweatherman"
+)
+
+var (
+	contractCallFrom   string
+	contractCallHeight string
+)
+
+// DeployedContract is the output of contract deploy.
+type DeployedContract struct {
+	*SignedTx
+	ContractAddress utils.Address `json:"contractAddress"`
+}
+
+// DecodedLog is a receipt log decoded by the contract ABI, unknown events are left undecoded.
+type DecodedLog struct {
+	Address utils.Address          `json:"address"`
+	Event   string                 `json:"event,omitempty"`
+	Args    map[string]interface{} `json:"args,omitempty"`
+	Topics  []utils.Hash           `json:"topics,omitempty"`
+	Data    utils.Bytes            `json:"data,omitempty"`
+}
+
+var contractCmd = &cobra.Command{
+	Use:   "contract",
+	Short: "Deploy and interact with a contract by its solidity ABI.",
+	Long: `Deploy and interact with a contract by its solidity ABI.
+Arguments are given as strings, arrays and tuples as JSON arrays, integers in decimal or 0x hex and bytes in 0x hex.`,
+}
+
+var contractDeployCmd = &cobra.Command{
+	Use:   "deploy <abi file> <bin file> <keystore file> [constructor args...]",
+	Short: "Deploy a contract signed with a local keystore file.",
+	Long:  `Deploy a contract signed with a local keystore file, --offline only prints the signed transaction. The passphrase is prompted for unless --passwordfile is given.`,
+	Args:  cobra.MinimumNArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		contract := loadABI(args[0])
+		bin, err := ioutil.ReadFile(args[1])
+		if err != nil {
+			jww.ERROR.Println(err)
+			os.Exit(1)
+		}
+		code, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(string(bin)), "0x"))
+		if err != nil {
+			jww.ERROR.Println("invalid bytecode:", err)
+			os.Exit(1)
+		}
+		values, err := contract.Constructor.Inputs.ParseValues(args[3:])
+		if err != nil {
+			jww.ERROR.Println(err)
+			os.Exit(1)
+		}
+		input, err := contract.Pack("", values...)
+		if err != nil {
+			jww.ERROR.Println(err)
+			os.Exit(1)
+		}
+
+		account := decryptKeystore(args[2])
+		signed := signTx(account, types.Binary, nil, append(code, input...))
+		if !signTxOffline {
+			sendSignedTx(signed)
+		}
+		cmdutils.PrintJSON(&DeployedContract{
+			SignedTx:        signed,
+			ContractAddress: crypto.CreateAddress(account.Address, uint64(signed.Nonce)),
+		})
+	},
+}
+
+var contractSendCmd = &cobra.Command{
+	Use:   "send <abi file> <contract address> <keystore file> <method> [args...]",
+	Short: "Send a transaction calling the contract method, signed with a local keystore file.",
+	Long:  `Send a transaction calling the contract method, signed with a local keystore file, --offline only prints the signed transaction. The passphrase is prompted for unless --passwordfile is given.`,
+	Args:  cobra.MinimumNArgs(4),
+	Run: func(cmd *cobra.Command, args []string) {
+		contract := loadABI(args[0])
+		to := utils.HexToAddress(cmdutils.IsHexAddr(args[1]))
+		input := packMethod(contract, args[3], args[4:])
+
+		account := decryptKeystore(args[2])
+		signed := signTx(account, types.Binary, []*utils.Address{&to}, input)
+		if signTxOffline {
+			cmdutils.PrintJSON(signed)
+			return
+		}
+		cmdutils.PrintJSON(sendSignedTx(signed))
+	},
+}
+
+var contractCallCmd = &cobra.Command{
+	Use:   "call <abi file> <contract address> <method> [args...]",
+	Short: "Call the contract method without a transaction and decode the return values.",
+	Long:  `Call the contract method without a transaction and decode the return values.`,
+	Args:  cobra.MinimumNArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		contract := loadABI(args[0])
+		to := utils.HexToAddress(cmdutils.IsHexAddr(args[1]))
+		req := &rpcapi.CallArgs{
+			Tos:  []*utils.Address{&to},
+			Data: packMethod(contract, args[2], args[3:]),
+		}
+		if contractCallFrom != "" {
+			req.From = utils.HexToAddress(cmdutils.IsHexAddr(contractCallFrom))
+		}
+		if contractCallHeight != "" {
+			req.BlockHeight = cmdutils.GetBlockheight(contractCallHeight)
+		}
+
+		result := new(utils.Bytes)
+		cmdutils.ClientCall("Uranus.Call", req, &result)
+		method, _ := contract.Method(args[2])
+		values, err := method.Outputs.Unpack(*result)
+		if err != nil {
+			jww.ERROR.Println(err)
+			os.Exit(1)
+		}
+		cmdutils.PrintJSON(namedValues(method.Outputs, values))
+	},
+}
+
+var contractLogsCmd = &cobra.Command{
+	Use:   "logs <abi file> <txhash>",
+	Short: "Decode the logs of the transaction receipt into the contract events.",
+	Long:  `Decode the logs of the transaction receipt into the contract events.`,
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		contract := loadABI(args[0])
+		var receipt struct {
+			Logs []*types.Log `json:"logs"`
+		}
+		cmdutils.ClientCall("BlockChain.GetTransactionReceipt", utils.HexToHash(cmdutils.IsHexHash(args[1])), &receipt)
+
+		logs := make([]*DecodedLog, 0, len(receipt.Logs))
+		for _, l := range receipt.Logs {
+			decoded := &DecodedLog{Address: l.Address}
+			if event, values, err := contract.DecodeLog(l.Topics, l.Data); err == nil {
+				decoded.Event, decoded.Args = event.Name, values
+			} else {
+				decoded.Topics, decoded.Data = l.Topics, l.Data
+			}
+			logs = append(logs, decoded)
+		}
+		cmdutils.PrintJSONList(logs)
+	},
+}
+
+func init() {
+	addTxFlags(contractDeployCmd.Flags())
+	addTxFlags(contractSendCmd.Flags())
+	contractCallCmd.Flags().StringVar(&contractCallFrom, "from", "", "Sender address of the call.")
+	contractCallCmd.Flags().StringVar(&contractCallHeight, "height", "", "Block height of the state, latest if not given.")
+
+	contractCmd.AddCommand(contractDeployCmd)
+	contractCmd.AddCommand(contractSendCmd)
+	contractCmd.AddCommand(contractCallCmd)
+	contractCmd.AddCommand(contractLogsCmd)
+}
+
+func loadABI(file string) *abi.ABI {
+	f, err := os.Open(file)
+	if err != nil {
+		jww.ERROR.Println(err)
+		os.Exit(1)
+	}
+	defer f.Close()
+	contract, err := abi.JSON(f)
+	if err != nil {
+		jww.ERROR.Println(err)
+		os.Exit(1)
+	}
+	return contract
+}
+
+func packMethod(contract *abi.ABI, name string, args []string) []byte {
+	method, err := contract.Method(name)
+	if err != nil {
+		jww.ERROR.Println(err)
+		os.Exit(1)
+	}
+	values, err := method.Inputs.ParseValues(args)
+	if err != nil {
+		jww.ERROR.Println(err)
+		os.Exit(1)
+	}
+	input, err := contract.Pack(name, values...)
+	if err != nil {
+		jww.ERROR.Println(err)
+		os.Exit(1)
+	}
+	return input
+}
+
+// namedValues returns the values by name, or in order if any of them is unnamed.
+func namedValues(args abi.Arguments, values []interface{}) interface{} {
+	named := make(map[string]interface{}, len(args))
+	for i, arg := range args {
+		if arg.Name == "" {
+			return values
+		}
+		named[arg.Name] = values[i]
+	}
+	return named
+}
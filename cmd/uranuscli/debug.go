@@ -0,0 +1,61 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	cmdutils "github.com/UranusBlockStack/uranus/cmd/utils"
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/core/state"
+	"github.com/UranusBlockStack/uranus/rpcapi"
+	"github.com/spf13/cobra"
+)
+
+var dumpStateCmd = &cobra.Command{
+	Use:   "dumpState <height> [address]",
+	Short: "Returns the first page of the accounts and the dpos context by height, or a single account.",
+	Long:  `Returns the first page of the accounts and the dpos context by height, or a single account.`,
+	Args:  cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		req := rpcapi.DumpStateArgs{BlockHeight: cmdutils.GetBlockheight(args[0])}
+		if len(args) > 1 {
+			addr := utils.HexToAddress(cmdutils.IsHexAddr(args[1]))
+			req.Address = &addr
+		}
+		result := rpcapi.StateDump{}
+		cmdutils.ClientCall("Debug.DumpState", req, &result)
+		cmdutils.PrintJSON(result)
+	},
+}
+
+var storageRangeAtCmd = &cobra.Command{
+	Use:   "storageRangeAt <height> <address> [start]",
+	Short: "Returns a page of the storage of the account by height from the hashed key start.",
+	Long:  `Returns a page of the storage of the account by height from the hashed key start.`,
+	Args:  cobra.RangeArgs(2, 3),
+	Run: func(cmd *cobra.Command, args []string) {
+		req := rpcapi.StorageRangeArgs{
+			BlockHeight: cmdutils.GetBlockheight(args[0]),
+			Address:     utils.HexToAddress(cmdutils.IsHexAddr(args[1])),
+		}
+		if len(args) > 2 {
+			req.Start = utils.HexToHash(args[2])
+		}
+		result := state.StorageRange{}
+		cmdutils.ClientCall("Debug.StorageRangeAt", req, &result)
+		cmdutils.PrintJSON(result)
+	},
+}
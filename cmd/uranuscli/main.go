@@ -35,11 +35,17 @@ var RootCmd = &cobra.Command{
 	// has an action associated with it:
 	Run: func(cmd *cobra.Command, args []string) {
 	},
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// an explicit server URL means HTTP
+		cmdutils.UseHTTP = cmd.Flags().Changed("curl")
+	},
 }
 
 func init() {
 	utils.EnvParse()
 	RootCmd.PersistentFlags().StringVarP(&cmdutils.CoreURL, "curl", "c", *cmdutils.DefaultCoreURL, "uranus server URL.")
+	RootCmd.PersistentFlags().StringVar(&cmdutils.IPCPath, "ipcpath", cmdutils.DefaultIPCPath, "uranus IPC socket, used when it exists and --curl isn't given.")
+	RootCmd.PersistentFlags().StringVar(&cmdutils.JWTSecret, "jwtsecret", "", "Hex secret file for the JWT authentication of HTTP requests.")
 	RootCmd.PersistentFlags().BoolVarP(&cmdutils.OneLine, "oneline", "o", false, "Streamline pattern, Output less and better content.")
 }
 
@@ -0,0 +1,273 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+
+	cmdutils "github.com/UranusBlockStack/uranus/cmd/utils"
+	"github.com/UranusBlockStack/uranus/common/rlp"
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/core/types"
+	"github.com/UranusBlockStack/uranus/params"
+	"github.com/UranusBlockStack/uranus/rpcapi"
+	"github.com/UranusBlockStack/uranus/wallet"
+	"github.com/spf13/cobra"
+	jww "github.com/spf13/jwalterweatherman"
+	"github.com/spf13/pflag"
+)
+
+var txTypes = map[string]types.TxType{
+	"binary":          types.Binary,
+	"logincandidate":  types.LoginCandidate,
+	"logoutcandidate": types.LogoutCandidate,
+	"delegate":        types.Delegate,
+	"undelegate":      types.UnDelegate,
+	"redeem":          types.Redeem,
+}
+
+var (
+	signTxType     string
+	signTxTos      []string
+	signTxValue    string
+	signTxNonce    string
+	signTxGas      uint64
+	signTxGasPrice string
+	signTxMaxFee   string
+	signTxTip      string
+	signTxData     string
+	signTxOffline  bool
+	signTxSend     bool
+	signTxPassword string
+)
+
+// SignedTx is the output of signTransaction.
+type SignedTx struct {
+	From  utils.Address `json:"from"`
+	Nonce utils.Uint64  `json:"nonce"`
+	Hash  utils.Hash    `json:"hash"`
+	Raw   utils.Bytes   `json:"raw"`
+}
+
+var signTransactionCmd = &cobra.Command{
+	Use:   "signTransaction <keystore file>",
+	Short: "Build and sign a transaction with a local keystore file.",
+	Long: `Build and sign a transaction with a local keystore file, the raw hex is accepted by sendRawTransaction.
+Missing nonce and gas price are fetched from the node, --offline signs without any node connection and requires them as flags.
+The passphrase is prompted for unless --passwordfile is given.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if signTxOffline && signTxSend {
+			jww.ERROR.Println("--send can't be used with --offline")
+			os.Exit(1)
+		}
+		txType, ok := txTypes[strings.ToLower(signTxType)]
+		if !ok {
+			jww.ERROR.Printf("unknown transaction type %q", signTxType)
+			os.Exit(1)
+		}
+		var tos []*utils.Address
+		for _, to := range signTxTos {
+			addr := utils.HexToAddress(cmdutils.IsHexAddr(to))
+			tos = append(tos, &addr)
+		}
+		var data []byte
+		if signTxData != "" {
+			var err error
+			if data, err = utils.Decode(signTxData); err != nil {
+				jww.ERROR.Println("invalid data:", err)
+				os.Exit(1)
+			}
+		}
+
+		account := decryptKeystore(args[0])
+		signed := signTx(account, txType, tos, data)
+		if signTxSend {
+			cmdutils.PrintJSON(sendSignedTx(signed))
+			return
+		}
+		if cmdutils.OneLine {
+			jww.FEEDBACK.Print(utils.ToHex(signed.Raw))
+		} else {
+			cmdutils.PrintJSON(signed)
+		}
+	},
+}
+
+func init() {
+	flags := signTransactionCmd.Flags()
+	flags.StringVar(&signTxType, "type", "binary", "Transaction type: binary, loginCandidate, logoutCandidate, delegate, unDelegate or redeem.")
+	flags.StringSliceVar(&signTxTos, "to", nil, "Recipient address, repeat it for the candidates of a delegate transaction.")
+	flags.StringVar(&signTxData, "data", "", "Hex payload of the transaction.")
+	flags.BoolVar(&signTxSend, "send", false, "Send the signed transaction to the node.")
+	addTxFlags(flags)
+}
+
+// addTxFlags adds the flags of the transaction fields shared by the commands signing with a keystore file.
+func addTxFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&signTxValue, "value", "0", "Amount of wei to transfer.")
+	flags.StringVar(&signTxNonce, "nonce", "", "Transaction nonce, fetched from the node if not given.")
+	flags.Uint64Var(&signTxGas, "gas", 90000, "Gas limit.")
+	flags.StringVar(&signTxGasPrice, "gasprice", "", "Gas price in wei, fetched from the node if neither it nor --maxfee/--tip is given.")
+	flags.StringVar(&signTxMaxFee, "maxfee", "", "Max fee per gas in wei of a dynamic fee transaction.")
+	flags.StringVar(&signTxTip, "tip", "", "Max priority fee per gas in wei of a dynamic fee transaction.")
+	flags.BoolVar(&signTxOffline, "offline", false, "Air-gapped mode, never connect to the node.")
+	flags.StringVar(&signTxPassword, "passwordfile", "", "File of the keystore passphrase, prompted for if not given.")
+}
+
+// decryptKeystore decrypts the keystore file with the passphrase of --passwordfile or the
+// prompted one.
+func decryptKeystore(file string) *wallet.Account {
+	keyjson, err := ioutil.ReadFile(file)
+	if err != nil {
+		jww.ERROR.Println(err)
+		os.Exit(1)
+	}
+	passphrase, err := cmdutils.ReadPassphrase(signTxPassword)
+	if err != nil {
+		jww.ERROR.Println(err)
+		os.Exit(1)
+	}
+	account, err := wallet.DecryptKey(keyjson, passphrase)
+	if err != nil {
+		jww.ERROR.Println(err)
+		os.Exit(1)
+	}
+	return account
+}
+
+// signTx builds the transaction from the flags and signs it with the account.
+func signTx(account *wallet.Account, txType types.TxType, tos []*utils.Address, data []byte) *SignedTx {
+	tx, err := buildTransaction(account.Address, txType, tos, data)
+	if err != nil {
+		jww.ERROR.Println(err)
+		os.Exit(1)
+	}
+	if err := tx.SignTx(types.Signer{}, account.PrivateKey); err != nil {
+		jww.ERROR.Println(err)
+		os.Exit(1)
+	}
+	raw, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		jww.ERROR.Println(err)
+		os.Exit(1)
+	}
+	return &SignedTx{From: account.Address, Nonce: utils.Uint64(tx.Nonce()), Hash: tx.Hash(), Raw: raw}
+}
+
+func sendSignedTx(signed *SignedTx) *utils.Hash {
+	result := &utils.Hash{}
+	cmdutils.ClientCall("Uranus.SendRawTransaction", signed.Raw, &result)
+	return result
+}
+
+func buildTransaction(from utils.Address, txType types.TxType, tos []*utils.Address, data []byte) (*types.Transaction, error) {
+	value, err := parseBig("value", signTxValue)
+	if err != nil {
+		return nil, err
+	}
+	if len(tos) == 0 && txType == types.Binary && len(data) == 0 {
+		return nil, fmt.Errorf("contract creation without any data provided")
+	}
+
+	var nonce uint64
+	if signTxNonce != "" {
+		if nonce, err = strconv.ParseUint(signTxNonce, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid nonce: %v", err)
+		}
+	} else if signTxOffline {
+		return nil, fmt.Errorf("--nonce is required in offline mode")
+	} else {
+		result := new(utils.Uint64)
+		pending := rpcapi.PendingBlockHeight
+		cmdutils.ClientCall("Uranus.GetNonce", &rpcapi.GetNonceArgs{GetBalanceArgs: rpcapi.GetBalanceArgs{Address: from, BlockHeight: &pending}}, &result)
+		nonce = uint64(*result)
+	}
+
+	var tx *types.Transaction
+	if signTxMaxFee != "" || signTxTip != "" {
+		if signTxGasPrice != "" {
+			return nil, fmt.Errorf("both --gasprice and (--maxfee or --tip) specified")
+		}
+		feeCap, tipCap, err := dynamicFee(signTxMaxFee, signTxTip)
+		if err != nil {
+			return nil, err
+		}
+		tx = types.NewDynamicFeeTransaction(txType, nonce, value, signTxGas, feeCap, tipCap, data, tos...)
+	} else {
+		var gasPrice *big.Int
+		if signTxGasPrice != "" {
+			if gasPrice, err = parseBig("gasprice", signTxGasPrice); err != nil {
+				return nil, err
+			}
+		} else if signTxOffline {
+			return nil, fmt.Errorf("--gasprice or --maxfee and --tip are required in offline mode")
+		} else {
+			result := new(utils.Big)
+			cmdutils.ClientCall("Uranus.SuggestGasPrice", nil, &result)
+			gasPrice = result.ToInt()
+		}
+		tx = types.NewTransaction(txType, nonce, value, signTxGas, gasPrice, data, tos...)
+	}
+	return tx, tx.Validate(params.DefaultChainConfig)
+}
+
+// dynamicFee fills in the missing fee caps from the node, maxFee = 2 * baseFee + tip.
+func dynamicFee(maxFee, tip string) (feeCap, tipCap *big.Int, err error) {
+	if tip != "" {
+		if tipCap, err = parseBig("tip", tip); err != nil {
+			return nil, nil, err
+		}
+	} else if signTxOffline {
+		return nil, nil, fmt.Errorf("--tip is required in offline mode")
+	} else {
+		result := new(utils.Big)
+		cmdutils.ClientCall("Uranus.SuggestGasTipCap", nil, &result)
+		tipCap = result.ToInt()
+	}
+
+	if maxFee != "" {
+		if feeCap, err = parseBig("maxfee", maxFee); err != nil {
+			return nil, nil, err
+		}
+	} else if signTxOffline {
+		return nil, nil, fmt.Errorf("--maxfee is required in offline mode")
+	} else {
+		var head struct {
+			BaseFee *utils.Big `json:"baseFee"`
+		}
+		cmdutils.ClientCall("BlockChain.GetBlockByHeight", &rpcapi.GetBlockByHeightArgs{}, &head)
+		feeCap = new(big.Int).Set(tipCap)
+		if head.BaseFee != nil {
+			feeCap.Add(feeCap, new(big.Int).Mul(head.BaseFee.ToInt(), big.NewInt(2)))
+		}
+	}
+	return feeCap, tipCap, nil
+}
+
+func parseBig(name, s string) (*big.Int, error) {
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok || n.Sign() < 0 {
+		return nil, fmt.Errorf("invalid %s: %q", name, s)
+	}
+	return n, nil
+}
@@ -0,0 +1,129 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/UranusBlockStack/uranus/common/crypto"
+	"github.com/UranusBlockStack/uranus/common/rlp"
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/core/types"
+	"github.com/UranusBlockStack/uranus/wallet"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeKeystore writes a new encrypted key and its password file into the directory.
+func writeKeystore(t *testing.T, dir string) (utils.Address, string) {
+	key, _ := crypto.GenerateKey()
+	account := wallet.Account{Address: crypto.PubkeyToAddress(key.PublicKey), PrivateKey: key}
+	keyjson, err := wallet.EncryptKey(account, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "key.json")
+	if err := ioutil.WriteFile(file, keyjson, 0600); err != nil {
+		t.Fatal(err)
+	}
+	signTxPassword = filepath.Join(dir, "password")
+	if err := ioutil.WriteFile(signTxPassword, []byte("secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return account.Address, file
+}
+
+// resetSignFlags sets the offline flags of a legacy transaction.
+func resetSignFlags() {
+	signTxValue, signTxNonce, signTxGas = "7", "3", 21000
+	signTxGasPrice, signTxMaxFee, signTxTip = "5", "", ""
+	signTxOffline, signTxSend = true, false
+}
+
+func decodeSigned(t *testing.T, signed *SignedTx) *types.Transaction {
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(signed.Raw, tx); err != nil {
+		t.Fatalf("failed to decode the signed transaction: %v", err)
+	}
+	return tx
+}
+
+func TestSignTransactionOffline(t *testing.T) {
+	dir, err := ioutil.TempDir("", "uranuscli-sign")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func() { signTxPassword = "" }()
+	from, file := writeKeystore(t, dir)
+	to := utils.BytesToAddress([]byte{1})
+
+	// a legacy transaction signed with the passphrase of the file
+	resetSignFlags()
+	account := decryptKeystore(file)
+	assert.Equal(t, from, account.Address)
+	signed := signTx(account, types.Binary, []*utils.Address{&to}, nil)
+	tx := decodeSigned(t, signed)
+	sender, err := tx.Sender(types.Signer{})
+	assert.NoError(t, err)
+	assert.Equal(t, from, sender)
+	assert.Equal(t, from, signed.From)
+	assert.Equal(t, tx.Hash(), signed.Hash)
+	assert.Equal(t, uint64(3), tx.Nonce())
+	assert.Equal(t, big.NewInt(7), tx.Value())
+	assert.Equal(t, big.NewInt(5), tx.GasPrice())
+	assert.False(t, tx.IsDynamicFee())
+
+	// a dynamic fee transaction
+	signTxGasPrice, signTxMaxFee, signTxTip = "", "20", "2"
+	tx = decodeSigned(t, signTx(account, types.Binary, []*utils.Address{&to}, nil))
+	assert.True(t, tx.IsDynamicFee())
+	assert.Equal(t, big.NewInt(20), tx.GasFeeCap())
+	assert.Equal(t, big.NewInt(2), tx.GasTipCap())
+}
+
+func TestBuildTransactionOfflineErrors(t *testing.T) {
+	to := utils.BytesToAddress([]byte{1})
+	tos := []*utils.Address{&to}
+
+	resetSignFlags()
+	signTxNonce = ""
+	_, err := buildTransaction(utils.Address{}, types.Binary, tos, nil)
+	assert.Error(t, err)
+
+	resetSignFlags()
+	signTxGasPrice = ""
+	_, err = buildTransaction(utils.Address{}, types.Binary, tos, nil)
+	assert.Error(t, err)
+
+	resetSignFlags()
+	signTxMaxFee = "20"
+	_, err = buildTransaction(utils.Address{}, types.Binary, tos, nil)
+	assert.Error(t, err)
+
+	resetSignFlags()
+	_, err = buildTransaction(utils.Address{}, types.Binary, nil, nil)
+	assert.Error(t, err)
+
+	resetSignFlags()
+	signTxValue = "-1"
+	_, err = buildTransaction(utils.Address{}, types.Binary, tos, nil)
+	assert.Error(t, err)
+}
@@ -40,6 +40,18 @@ var suggestGasPriceCmd = &cobra.Command{
 	},
 }
 
+var suggestGasTipCapCmd = &cobra.Command{
+	Use:   "suggestGasTipCap ",
+	Short: "Return suggest priority fee of dynamic fee transaction.",
+	Long:  `Return suggest priority fee of dynamic fee transaction.`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		result := new(utils.Big)
+		cmdutils.ClientCall("Uranus.SuggestGasTipCap", nil, &result)
+		cmdutils.PrintJSON(result)
+	},
+}
+
 var getBalanceCmd = &cobra.Command{
 	Use:   "getBalance <address> [height]",
 	Short: "returns the amount of wei for the given address in the state of the given block number.",
@@ -0,0 +1,52 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadPassphraseFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "uranus-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for content, want := range map[string]string{
+		"secret":           "secret",
+		"secret\n":         "secret",
+		"secret\r\nmore\n": "secret",
+		" spaced secret\n": " spaced secret",
+		"":                 "",
+	} {
+		file := filepath.Join(dir, "password")
+		if err := ioutil.WriteFile(file, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		passphrase, err := ReadPassphrase(file)
+		assert.NoError(t, err)
+		assert.Equal(t, want, passphrase, "%q", content)
+	}
+	_, err = ReadPassphrase(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
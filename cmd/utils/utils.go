@@ -19,6 +19,7 @@ package utils
 import (
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"math/big"
 	"os"
 	"os/user"
@@ -29,6 +30,7 @@ import (
 	"strings"
 
 	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/node"
 	"github.com/UranusBlockStack/uranus/params"
 	urpc "github.com/UranusBlockStack/uranus/rpc"
 	"github.com/UranusBlockStack/uranus/rpcapi"
@@ -40,15 +42,40 @@ var (
 	DefaultCoreURL = utils.EnvString("URANUS_URL", "http://localhost:8000")
 	urlPrefix      = "http://"
 	OneLine        bool // Streamline pattern, Output less and better content
+
+	IPCPath        string
+	DefaultIPCPath = filepath.Join(DefaultDataDir(), params.Identifier, node.DefaultIPCPath)
+	JWTSecret      string // hex secret file for the JWT authentication of HTTP requests
+	UseHTTP        bool   // connect over HTTP even if the IPC socket exists
 )
 
-// MustRPCClient Wraper rpc's client
+// MustRPCClient Wraper rpc's client, the IPC socket is preferred when it exists on the same host.
 func MustRPCClient() *urpc.Client {
+	if !UseHTTP && IPCPath != "" {
+		if _, err := os.Stat(IPCPath); err == nil {
+			client, err := urpc.DialIPC(IPCPath)
+			if err != nil {
+				jww.ERROR.Println(err)
+				os.Exit(1)
+			}
+			return client
+		}
+	}
+
 	if !strings.HasPrefix(CoreURL, urlPrefix) {
 		CoreURL = urlPrefix + CoreURL
 	}
 
-	client, err := urpc.DialHTTP(CoreURL)
+	var secret []byte
+	if JWTSecret != "" {
+		data, err := ioutil.ReadFile(JWTSecret)
+		if err != nil {
+			jww.ERROR.Println(err)
+			os.Exit(1)
+		}
+		secret = utils.FromHex(strings.TrimSpace(string(data)))
+	}
+	client, err := urpc.DialHTTPWithJWT(CoreURL, secret)
 	if err != nil {
 		jww.ERROR.Println(err)
 		os.Exit(1)
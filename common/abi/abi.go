@@ -0,0 +1,246 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package abi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/UranusBlockStack/uranus/common/crypto"
+	"github.com/UranusBlockStack/uranus/common/utils"
+)
+
+var (
+	ErrMethodNotFound = errors.New("abi: method not found")
+	ErrEventNotFound  = errors.New("abi: event not found")
+)
+
+// Method is a contract function or the constructor.
+type Method struct {
+	Name     string
+	Inputs   Arguments
+	Outputs  Arguments
+	Constant bool   // view or pure, it doesn't modify the state
+	Payable  bool   // it accepts value
+	Sig      string // canonical signature, e.g. transfer(address,uint256)
+	ID       []byte // first 4 bytes of the signature hash
+}
+
+// Event is a contract event.
+type Event struct {
+	Name      string
+	Inputs    Arguments
+	Anonymous bool
+	Sig       string     // canonical signature, e.g. Transfer(address,address,uint256)
+	ID        utils.Hash // signature hash, the first topic of the non-anonymous event
+}
+
+// ABI is the parsed solidity contract ABI, overloaded methods and events are named
+// as foo, foo0, foo1 in order of appearance.
+type ABI struct {
+	Constructor Method
+	Methods     map[string]Method
+	Events      map[string]Event
+}
+
+type abiField struct {
+	Type            string    `json:"type"`
+	Name            string    `json:"name"`
+	Inputs          Arguments `json:"inputs"`
+	Outputs         Arguments `json:"outputs"`
+	Anonymous       bool      `json:"anonymous"`
+	Constant        bool      `json:"constant"`
+	Payable         bool      `json:"payable"`
+	StateMutability string    `json:"stateMutability"`
+}
+
+// JSON returns the ABI parsed from the solidity JSON interface.
+func JSON(reader io.Reader) (*ABI, error) {
+	var fields []abiField
+	if err := json.NewDecoder(reader).Decode(&fields); err != nil {
+		return nil, err
+	}
+	abi := &ABI{Methods: make(map[string]Method), Events: make(map[string]Event)}
+	for _, field := range fields {
+		if err := field.Inputs.parseTypes(); err != nil {
+			return nil, err
+		}
+		if err := field.Outputs.parseTypes(); err != nil {
+			return nil, err
+		}
+		switch field.Type {
+		case "constructor":
+			abi.Constructor = Method{
+				Inputs:  field.Inputs,
+				Payable: field.Payable || field.StateMutability == "payable",
+			}
+		case "function", "":
+			method := Method{
+				Name:     field.Name,
+				Inputs:   field.Inputs,
+				Outputs:  field.Outputs,
+				Constant: field.Constant || field.StateMutability == "view" || field.StateMutability == "pure",
+				Payable:  field.Payable || field.StateMutability == "payable",
+				Sig:      signature(field.Name, field.Inputs),
+			}
+			method.ID = crypto.Keccak256([]byte(method.Sig))[:4]
+			abi.Methods[overloadedName(field.Name, func(name string) bool { _, ok := abi.Methods[name]; return ok })] = method
+		case "event":
+			event := Event{
+				Name:      field.Name,
+				Inputs:    field.Inputs,
+				Anonymous: field.Anonymous,
+				Sig:       signature(field.Name, field.Inputs),
+			}
+			event.ID = crypto.Keccak256Hash([]byte(event.Sig))
+			abi.Events[overloadedName(field.Name, func(name string) bool { _, ok := abi.Events[name]; return ok })] = event
+		}
+	}
+	return abi, nil
+}
+
+// Method returns the method by name or by canonical signature.
+func (abi *ABI) Method(name string) (Method, error) {
+	if method, ok := abi.Methods[name]; ok {
+		return method, nil
+	}
+	for _, method := range abi.Methods {
+		if method.Sig == name {
+			return method, nil
+		}
+	}
+	return Method{}, ErrMethodNotFound
+}
+
+// Pack encodes the call data of the method, an empty name encodes the constructor arguments
+// which are appended to the contract bytecode.
+func (abi *ABI) Pack(name string, values ...interface{}) ([]byte, error) {
+	if name == "" {
+		return abi.Constructor.Inputs.Pack(values...)
+	}
+	method, err := abi.Method(name)
+	if err != nil {
+		return nil, err
+	}
+	args, err := method.Inputs.Pack(values...)
+	if err != nil {
+		return nil, err
+	}
+	return append(utils.CopyBytes(method.ID), args...), nil
+}
+
+// Unpack decodes the return values of the method.
+func (abi *ABI) Unpack(name string, data []byte) ([]interface{}, error) {
+	method, err := abi.Method(name)
+	if err != nil {
+		return nil, err
+	}
+	return method.Outputs.Unpack(data)
+}
+
+// EventByID returns the event whose signature hash is the given topic.
+func (abi *ABI) EventByID(topic utils.Hash) (*Event, error) {
+	for _, event := range abi.Events {
+		if !event.Anonymous && event.ID == topic {
+			return &event, nil
+		}
+	}
+	return nil, ErrEventNotFound
+}
+
+// DecodeLog decodes the topics and data of a log into the named values of a known event.
+func (abi *ABI) DecodeLog(topics []utils.Hash, data []byte) (*Event, map[string]interface{}, error) {
+	if len(topics) == 0 {
+		return nil, nil, ErrEventNotFound
+	}
+	event, err := abi.EventByID(topics[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	values, err := event.Decode(topics, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	return event, values, nil
+}
+
+// Decode decodes the topics and data of the event log. The indexed values of dynamic
+// types are only stored as their hash, they are returned as utils.Hash.
+func (e *Event) Decode(topics []utils.Hash, data []byte) (map[string]interface{}, error) {
+	if !e.Anonymous {
+		if len(topics) == 0 || topics[0] != e.ID {
+			return nil, ErrEventNotFound
+		}
+		topics = topics[1:]
+	}
+	var (
+		values     = make(map[string]interface{})
+		nonIndexed Arguments
+		topicIdx   int
+	)
+	for i, arg := range e.Inputs {
+		name := arg.Name
+		if name == "" {
+			name = fmt.Sprint(i)
+		}
+		if !arg.Indexed {
+			nonIndexed = append(nonIndexed, Argument{Name: name, Type: arg.Type})
+			continue
+		}
+		if topicIdx >= len(topics) {
+			return nil, fmt.Errorf("abi: %s requires %d topics", e.Name, topicIdx+1)
+		}
+		topic := topics[topicIdx]
+		topicIdx++
+		if arg.Type.isDynamic() || arg.Type.Kind == ArrayTy || arg.Type.Kind == TupleTy {
+			values[name] = topic
+			continue
+		}
+		v, err := arg.Type.unpackAt(topic.Bytes(), 0)
+		if err != nil {
+			return nil, err
+		}
+		values[name] = v
+	}
+	unpacked, err := nonIndexed.Unpack(data)
+	if err != nil {
+		return nil, err
+	}
+	for i, arg := range nonIndexed {
+		values[arg.Name] = unpacked[i]
+	}
+	return values, nil
+}
+
+func signature(name string, args Arguments) string {
+	types := make([]string, len(args))
+	for i, arg := range args {
+		types[i] = arg.Type.String()
+	}
+	return name + "(" + strings.Join(types, ",") + ")"
+}
+
+func overloadedName(name string, exist func(string) bool) string {
+	overloaded := name
+	for i := 0; exist(overloaded); i++ {
+		overloaded = fmt.Sprintf("%s%d", name, i)
+	}
+	return overloaded
+}
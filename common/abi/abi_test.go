@@ -0,0 +1,163 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package abi
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+const testABI = `[
+	{"type":"constructor","inputs":[{"name":"supply","type":"uint256"}]},
+	{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]},
+	{"type":"function","name":"balanceOf","stateMutability":"view","inputs":[{"name":"owner","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"f","inputs":[{"name":"a","type":"uint256"},{"name":"b","type":"uint32[]"},{"name":"c","type":"bytes10"},{"name":"d","type":"bytes"}],"outputs":[]},
+	{"type":"function","name":"g","inputs":[{"name":"a","type":"uint256[][]"},{"name":"b","type":"string[]"}],"outputs":[]},
+	{"type":"function","name":"g","inputs":[{"name":"a","type":"int8"}],"outputs":[{"name":"p","type":"tuple","components":[{"name":"x","type":"int256"},{"name":"y","type":"string"}]}]},
+	{"type":"event","name":"Transfer","inputs":[{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"value","type":"uint256","indexed":false},{"name":"memo","type":"string","indexed":false}]}
+]`
+
+func words(ws ...string) []byte {
+	return utils.HexToBytes(strings.Join(ws, ""))
+}
+
+func TestJSON(t *testing.T) {
+	abi, err := JSON(strings.NewReader(testABI))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "a9059cbb", utils.BytesToHex(abi.Methods["transfer"].ID))
+	assert.True(t, abi.Methods["balanceOf"].Constant)
+	assert.False(t, abi.Methods["transfer"].Constant)
+	assert.Equal(t, "g(uint256[][],string[])", abi.Methods["g"].Sig)
+	assert.Equal(t, "g(int8)", abi.Methods["g0"].Sig)
+	assert.Equal(t, "Transfer(address,address,uint256,string)", abi.Events["Transfer"].Sig)
+
+	m, err := abi.Method("g(int8)")
+	assert.NoError(t, err)
+	assert.Equal(t, "g", m.Name)
+	_, err = abi.Method("h")
+	assert.Equal(t, ErrMethodNotFound, err)
+}
+
+func TestPackUnpack(t *testing.T) {
+	abi, err := JSON(strings.NewReader(testABI))
+	assert.NoError(t, err)
+
+	// examples of the solidity ABI specification
+	enc, err := abi.Pack("f", big.NewInt(0x123), []interface{}{uint32(0x456), uint32(0x789)}, []byte("1234567890"), []byte("Hello, world!"))
+	assert.NoError(t, err)
+	assert.Equal(t, append(utils.HexToBytes("8be65246"), words(
+		"0000000000000000000000000000000000000000000000000000000000000123",
+		"0000000000000000000000000000000000000000000000000000000000000080",
+		"3132333435363738393000000000000000000000000000000000000000000000",
+		"00000000000000000000000000000000000000000000000000000000000000e0",
+		"0000000000000000000000000000000000000000000000000000000000000002",
+		"0000000000000000000000000000000000000000000000000000000000000456",
+		"0000000000000000000000000000000000000000000000000000000000000789",
+		"000000000000000000000000000000000000000000000000000000000000000d",
+		"48656c6c6f2c20776f726c642100000000000000000000000000000000000000",
+	)...), enc)
+
+	values, err := abi.Methods["f"].Inputs.Unpack(enc[4:])
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		big.NewInt(0x123),
+		[]interface{}{big.NewInt(0x456), big.NewInt(0x789)},
+		utils.Bytes("1234567890"),
+		utils.Bytes("Hello, world!"),
+	}, values)
+
+	enc, err = abi.Pack("g", [][]interface{}{{1, 2}, {3}}, []string{"one", "two", "three"})
+	assert.NoError(t, err)
+	assert.Equal(t, append(utils.HexToBytes("2289b18c"), words(
+		"0000000000000000000000000000000000000000000000000000000000000040",
+		"0000000000000000000000000000000000000000000000000000000000000140",
+		"0000000000000000000000000000000000000000000000000000000000000002",
+		"0000000000000000000000000000000000000000000000000000000000000040",
+		"00000000000000000000000000000000000000000000000000000000000000a0",
+		"0000000000000000000000000000000000000000000000000000000000000002",
+		"0000000000000000000000000000000000000000000000000000000000000001",
+		"0000000000000000000000000000000000000000000000000000000000000002",
+		"0000000000000000000000000000000000000000000000000000000000000001",
+		"0000000000000000000000000000000000000000000000000000000000000003",
+		"0000000000000000000000000000000000000000000000000000000000000003",
+		"0000000000000000000000000000000000000000000000000000000000000060",
+		"00000000000000000000000000000000000000000000000000000000000000a0",
+		"00000000000000000000000000000000000000000000000000000000000000e0",
+		"0000000000000000000000000000000000000000000000000000000000000003",
+		"6f6e650000000000000000000000000000000000000000000000000000000000",
+		"0000000000000000000000000000000000000000000000000000000000000003",
+		"74776f0000000000000000000000000000000000000000000000000000000000",
+		"0000000000000000000000000000000000000000000000000000000000000005",
+		"7468726565000000000000000000000000000000000000000000000000000000",
+	)...), enc)
+
+	values, err = abi.Methods["g"].Inputs.Unpack(enc[4:])
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		[]interface{}{[]interface{}{big.NewInt(1), big.NewInt(2)}, []interface{}{big.NewInt(3)}},
+		[]interface{}{"one", "two", "three"},
+	}, values)
+
+	// tuple output with a negative integer
+	out, err := abi.Methods["g0"].Outputs.Pack([]interface{}{big.NewInt(-5), "x"})
+	assert.NoError(t, err)
+	values, err = abi.Unpack("g0", out)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{[]interface{}{big.NewInt(-5), "x"}}, values)
+
+	_, err = abi.Pack("g0", 128)
+	assert.Error(t, err)
+	_, err = abi.Pack("transfer", utils.Address{})
+	assert.Error(t, err)
+	_, err = abi.Unpack("balanceOf", []byte{1, 2})
+	assert.Error(t, err)
+
+	ctor, err := abi.Pack("", big.NewInt(1000))
+	assert.NoError(t, err)
+	assert.Equal(t, words("00000000000000000000000000000000000000000000000000000000000003e8"), ctor)
+}
+
+func TestDecodeLog(t *testing.T) {
+	abi, err := JSON(strings.NewReader(testABI))
+	assert.NoError(t, err)
+
+	from := utils.HexToAddress("0x970e8128ab834e8eac17ab8e3812f010678cf791")
+	to := utils.HexToAddress("0x67b3419a16ac67b06d8318d76d31efda05702273")
+	data, err := Arguments{abi.Events["Transfer"].Inputs[2], abi.Events["Transfer"].Inputs[3]}.Pack(big.NewInt(100), "memo")
+	assert.NoError(t, err)
+	topics := []utils.Hash{abi.Events["Transfer"].ID, utils.BytesToHash(from.Bytes()), utils.BytesToHash(to.Bytes())}
+
+	event, values, err := abi.DecodeLog(topics, data)
+	assert.NoError(t, err)
+	assert.Equal(t, "Transfer", event.Name)
+	assert.Equal(t, map[string]interface{}{
+		"from":  from,
+		"to":    to,
+		"value": big.NewInt(100),
+		"memo":  "memo",
+	}, values)
+
+	_, _, err = abi.DecodeLog(topics[1:], data)
+	assert.Equal(t, ErrEventNotFound, err)
+	_, _, err = abi.DecodeLog(topics[:2], data)
+	assert.Error(t, err)
+}
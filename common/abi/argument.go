@@ -0,0 +1,140 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package abi
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/UranusBlockStack/uranus/common/utils"
+)
+
+// Argument is an input or output of a method or event.
+type Argument struct {
+	Name       string     `json:"name"`
+	TypeName   string     `json:"type"`
+	Components []Argument `json:"components,omitempty"`
+	Indexed    bool       `json:"indexed,omitempty"`
+	Type       *Type      `json:"-"`
+}
+
+// Arguments is the list of arguments of a method or event.
+type Arguments []Argument
+
+func (args Arguments) parseTypes() error {
+	for i := range args {
+		t, err := NewType(args[i].TypeName, args[i].Components)
+		if err != nil {
+			return err
+		}
+		args[i].Type = t
+	}
+	return nil
+}
+
+func (args Arguments) types() []*Type {
+	types := make([]*Type, len(args))
+	for i, arg := range args {
+		types[i] = arg.Type
+	}
+	return types
+}
+
+// Pack encodes the values of the arguments.
+func (args Arguments) Pack(values ...interface{}) ([]byte, error) {
+	if len(values) != len(args) {
+		return nil, fmt.Errorf("abi: argument count mismatch: %d for %d", len(values), len(args))
+	}
+	return packSequence(args.types(), values)
+}
+
+// Unpack decodes the values of the arguments.
+func (args Arguments) Unpack(data []byte) ([]interface{}, error) {
+	if len(args) > 0 && len(data) == 0 {
+		return nil, errShortData
+	}
+	return unpackSequence(args.types(), data, 0)
+}
+
+// ParseValues converts the command line strings to the values of the arguments.
+// Arrays and tuples are given as JSON arrays, e.g. ["0x01", "0x02"].
+func (args Arguments) ParseValues(strs []string) ([]interface{}, error) {
+	if len(strs) != len(args) {
+		return nil, fmt.Errorf("abi: argument count mismatch: %d for %d", len(strs), len(args))
+	}
+	values := make([]interface{}, len(args))
+	for i, arg := range args {
+		v, err := ParseValue(arg.Type, strs[i])
+		if err != nil {
+			return nil, fmt.Errorf("argument %d(%s): %v", i, arg.Name, err)
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// ParseValue converts the string to the value of the given type.
+func ParseValue(t *Type, s string) (interface{}, error) {
+	switch t.Kind {
+	case IntTy, UintTy:
+		n, ok := new(big.Int).SetString(s, 0)
+		if !ok {
+			return nil, fmt.Errorf("invalid integer %q", s)
+		}
+		return n, nil
+	case BoolTy:
+		return strconv.ParseBool(s)
+	case AddressTy:
+		if !utils.IsHexAddr(s) {
+			return nil, fmt.Errorf("invalid address %q", s)
+		}
+		return utils.HexToAddress(s), nil
+	case FixedBytesTy, BytesTy:
+		return utils.Decode(s)
+	case StringTy:
+		return s, nil
+	}
+
+	var raws []json.RawMessage
+	if err := json.Unmarshal([]byte(s), &raws); err != nil {
+		return nil, fmt.Errorf("%v requires a JSON array: %v", t, err)
+	}
+	values := make([]interface{}, len(raws))
+	for i, raw := range raws {
+		elem := t.Elem
+		if t.Kind == TupleTy {
+			if len(raws) != len(t.TupleElems) {
+				return nil, fmt.Errorf("%v requires %d elements", t, len(t.TupleElems))
+			}
+			elem = t.TupleElems[i]
+		}
+		str := string(raw)
+		if len(raw) > 0 && raw[0] == '"' {
+			if err := json.Unmarshal(raw, &str); err != nil {
+				return nil, err
+			}
+		}
+		v, err := ParseValue(elem, str)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return values, nil
+}
@@ -0,0 +1,186 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package abi
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/UranusBlockStack/uranus/common/math"
+	"github.com/UranusBlockStack/uranus/common/utils"
+)
+
+// pack encodes the value of the given type.
+func (t *Type) pack(v interface{}) ([]byte, error) {
+	switch t.Kind {
+	case IntTy, UintTy:
+		n, err := toBig(v)
+		if err != nil {
+			return nil, err
+		}
+		if err := t.checkInt(n); err != nil {
+			return nil, err
+		}
+		return math.PaddedBigBytes(math.U256(new(big.Int).Set(n)), wordSize), nil
+	case BoolTy:
+		b, ok := v.(bool)
+		if !ok {
+			return nil, typeError(t, v)
+		}
+		word := make([]byte, wordSize)
+		if b {
+			word[wordSize-1] = 1
+		}
+		return word, nil
+	case AddressTy:
+		var addr utils.Address
+		switch a := v.(type) {
+		case utils.Address:
+			addr = a
+		case *utils.Address:
+			addr = *a
+		default:
+			return nil, typeError(t, v)
+		}
+		return utils.LeftPadBytes(addr.Bytes(), wordSize), nil
+	case FixedBytesTy:
+		b, err := toBytes(v)
+		if err != nil || len(b) != t.Size {
+			return nil, typeError(t, v)
+		}
+		return utils.RightPadBytes(b, wordSize), nil
+	case BytesTy, StringTy:
+		var b []byte
+		if s, ok := v.(string); ok && t.Kind == StringTy {
+			b = []byte(s)
+		} else if t.Kind == BytesTy {
+			var err error
+			if b, err = toBytes(v); err != nil {
+				return nil, typeError(t, v)
+			}
+		} else {
+			return nil, typeError(t, v)
+		}
+		enc := math.PaddedBigBytes(big.NewInt(int64(len(b))), wordSize)
+		return append(enc, utils.RightPadBytes(b, (len(b)+wordSize-1)/wordSize*wordSize)...), nil
+	case SliceTy, ArrayTy:
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			return nil, typeError(t, v)
+		}
+		if t.Kind == ArrayTy && rv.Len() != t.Size {
+			return nil, fmt.Errorf("abi: %v requires %d elements, got %d", t, t.Size, rv.Len())
+		}
+		types, values := make([]*Type, rv.Len()), make([]interface{}, rv.Len())
+		for i := range values {
+			types[i], values[i] = t.Elem, rv.Index(i).Interface()
+		}
+		enc, err := packSequence(types, values)
+		if err != nil {
+			return nil, err
+		}
+		if t.Kind == SliceTy {
+			enc = append(math.PaddedBigBytes(big.NewInt(int64(rv.Len())), wordSize), enc...)
+		}
+		return enc, nil
+	case TupleTy:
+		values, ok := v.([]interface{})
+		if !ok || len(values) != len(t.TupleElems) {
+			return nil, typeError(t, v)
+		}
+		return packSequence(t.TupleElems, values)
+	}
+	return nil, fmt.Errorf("abi: unknown type %v", t)
+}
+
+// packSequence encodes the heads of the values followed by the tails of the dynamic values.
+func packSequence(types []*Type, values []interface{}) ([]byte, error) {
+	headSize := 0
+	for _, t := range types {
+		headSize += t.headSize()
+	}
+	var head, tail []byte
+	for i, t := range types {
+		enc, err := t.pack(values[i])
+		if err != nil {
+			return nil, err
+		}
+		if t.isDynamic() {
+			head = append(head, math.PaddedBigBytes(big.NewInt(int64(headSize+len(tail))), wordSize)...)
+			tail = append(tail, enc...)
+		} else {
+			head = append(head, enc...)
+		}
+	}
+	return append(head, tail...), nil
+}
+
+func (t *Type) checkInt(n *big.Int) error {
+	if t.Kind == UintTy {
+		if n.Sign() < 0 || n.BitLen() > t.Size {
+			return fmt.Errorf("abi: %v out of range for %v", n, t)
+		}
+		return nil
+	}
+	limit := new(big.Int).Lsh(big.NewInt(1), uint(t.Size-1))
+	if n.Cmp(limit) >= 0 || n.Cmp(new(big.Int).Neg(limit)) < 0 {
+		return fmt.Errorf("abi: %v out of range for %v", n, t)
+	}
+	return nil
+}
+
+func toBig(v interface{}) (*big.Int, error) {
+	switch n := v.(type) {
+	case *big.Int:
+		return n, nil
+	case big.Int:
+		return &n, nil
+	case *utils.Big:
+		return n.ToInt(), nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return big.NewInt(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return new(big.Int).SetUint64(rv.Uint()), nil
+	}
+	return nil, fmt.Errorf("abi: cannot use %T as integer", v)
+}
+
+func toBytes(v interface{}) ([]byte, error) {
+	switch b := v.(type) {
+	case []byte:
+		return b, nil
+	case utils.Bytes:
+		return b, nil
+	case utils.Hash:
+		return b.Bytes(), nil
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Array && rv.Type().Elem().Kind() == reflect.Uint8 {
+		b := make([]byte, rv.Len())
+		reflect.Copy(reflect.ValueOf(b), rv)
+		return b, nil
+	}
+	return nil, fmt.Errorf("abi: cannot use %T as bytes", v)
+}
+
+func typeError(t *Type, v interface{}) error {
+	return fmt.Errorf("abi: cannot use %T as type %v", v, t)
+}
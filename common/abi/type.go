@@ -0,0 +1,175 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package abi
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Kind is the kind of a solidity type.
+type Kind int
+
+const (
+	IntTy Kind = iota
+	UintTy
+	BoolTy
+	AddressTy
+	FixedBytesTy
+	BytesTy
+	StringTy
+	SliceTy
+	ArrayTy
+	TupleTy
+)
+
+// wordSize is the size of a slot in the ABI encoding.
+const wordSize = 32
+
+// Type is a solidity type of the contract ABI.
+type Type struct {
+	Kind Kind
+	Size int   // bits of an integer, bytes of a fixed bytes, length of a fixed array
+	Elem *Type // element of a slice or array
+
+	TupleElems []*Type
+	TupleNames []string
+
+	stringKind string // canonical type used in signatures
+}
+
+var typeRegex = regexp.MustCompile(`^([a-z]+)([0-9]*)$`)
+
+// NewType parses the solidity type, components are required for a tuple.
+func NewType(t string, components []Argument) (*Type, error) {
+	// arrays are parsed from the last dimension, uint256[2][] is a slice of uint256[2]
+	if strings.HasSuffix(t, "]") {
+		i := strings.LastIndex(t, "[")
+		if i < 0 {
+			return nil, fmt.Errorf("abi: invalid array type %q", t)
+		}
+		elem, err := NewType(t[:i], components)
+		if err != nil {
+			return nil, err
+		}
+		if length := t[i+1 : len(t)-1]; length != "" {
+			n, err := strconv.Atoi(length)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("abi: invalid array length %q", t)
+			}
+			return &Type{Kind: ArrayTy, Size: n, Elem: elem, stringKind: elem.stringKind + t[i:]}, nil
+		}
+		return &Type{Kind: SliceTy, Elem: elem, stringKind: elem.stringKind + "[]"}, nil
+	}
+
+	if t == "tuple" {
+		if len(components) == 0 {
+			return nil, fmt.Errorf("abi: tuple without components")
+		}
+		typ := &Type{Kind: TupleTy}
+		kinds := make([]string, 0, len(components))
+		for _, c := range components {
+			elem, err := NewType(c.TypeName, c.Components)
+			if err != nil {
+				return nil, err
+			}
+			typ.TupleElems = append(typ.TupleElems, elem)
+			typ.TupleNames = append(typ.TupleNames, c.Name)
+			kinds = append(kinds, elem.stringKind)
+		}
+		typ.stringKind = "(" + strings.Join(kinds, ",") + ")"
+		return typ, nil
+	}
+
+	matches := typeRegex.FindStringSubmatch(t)
+	if matches == nil {
+		return nil, fmt.Errorf("abi: invalid type %q", t)
+	}
+	base, size := matches[1], 0
+	if matches[2] != "" {
+		size, _ = strconv.Atoi(matches[2])
+	}
+	switch base {
+	case "int", "uint":
+		if matches[2] == "" {
+			size = 256
+		}
+		if size == 0 || size > 256 || size%8 != 0 {
+			return nil, fmt.Errorf("abi: invalid integer type %q", t)
+		}
+		kind := IntTy
+		if base == "uint" {
+			kind = UintTy
+		}
+		return &Type{Kind: kind, Size: size, stringKind: base + strconv.Itoa(size)}, nil
+	case "bool", "address", "string":
+		if matches[2] != "" {
+			return nil, fmt.Errorf("abi: invalid type %q", t)
+		}
+		kind := map[string]Kind{"bool": BoolTy, "address": AddressTy, "string": StringTy}[base]
+		return &Type{Kind: kind, stringKind: base}, nil
+	case "bytes":
+		if matches[2] == "" {
+			return &Type{Kind: BytesTy, stringKind: "bytes"}, nil
+		}
+		if size == 0 || size > 32 {
+			return nil, fmt.Errorf("abi: invalid fixed bytes type %q", t)
+		}
+		return &Type{Kind: FixedBytesTy, Size: size, stringKind: t}, nil
+	}
+	return nil, fmt.Errorf("abi: unsupported type %q", t)
+}
+
+// String returns the canonical type used in signatures.
+func (t *Type) String() string { return t.stringKind }
+
+// isDynamic returns whether the encoding of the type is stored in the tail.
+func (t *Type) isDynamic() bool {
+	switch t.Kind {
+	case BytesTy, StringTy, SliceTy:
+		return true
+	case ArrayTy:
+		return t.Elem.isDynamic()
+	case TupleTy:
+		for _, elem := range t.TupleElems {
+			if elem.isDynamic() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// headSize returns the size of the type in the head of an encoding.
+func (t *Type) headSize() int {
+	if t.isDynamic() {
+		return wordSize
+	}
+	switch t.Kind {
+	case ArrayTy:
+		return t.Size * t.Elem.headSize()
+	case TupleTy:
+		size := 0
+		for _, elem := range t.TupleElems {
+			size += elem.headSize()
+		}
+		return size
+	}
+	return wordSize
+}
@@ -0,0 +1,118 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package abi
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewType(t *testing.T) {
+	tests := []struct {
+		typ       string
+		canonical string
+		dynamic   bool
+		headSize  int
+	}{
+		{"uint", "uint256", false, 32},
+		{"int8", "int8", false, 32},
+		{"address", "address", false, 32},
+		{"bytes32", "bytes32", false, 32},
+		{"bytes", "bytes", true, 32},
+		{"string", "string", true, 32},
+		{"uint256[3]", "uint256[3]", false, 96},
+		{"uint256[2][3]", "uint256[2][3]", false, 192},
+		{"string[2]", "string[2]", true, 32},
+		{"bool[]", "bool[]", true, 32},
+		{"uint[2][]", "uint256[2][]", true, 32},
+	}
+	for _, test := range tests {
+		typ, err := NewType(test.typ, nil)
+		assert.NoError(t, err, test.typ)
+		assert.Equal(t, test.canonical, typ.String())
+		assert.Equal(t, test.dynamic, typ.isDynamic(), test.typ)
+		assert.Equal(t, test.headSize, typ.headSize(), test.typ)
+	}
+
+	typ, err := NewType("uint256[2][]", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, SliceTy, typ.Kind)
+	assert.Equal(t, ArrayTy, typ.Elem.Kind)
+	assert.Equal(t, 2, typ.Elem.Size)
+
+	tuple, err := NewType("tuple[]", []Argument{{Name: "a", TypeName: "address"}, {Name: "b", TypeName: "bytes"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "(address,bytes)[]", tuple.String())
+
+	for _, invalid := range []string{"uint7", "uint264", "bytes33", "bytes0", "int[0]", "int[", "boolean", "address2", "tuple", "fixed128x18"} {
+		_, err := NewType(invalid, nil)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestParseValue(t *testing.T) {
+	typ, _ := NewType("int16", nil)
+	v, err := ParseValue(typ, "-0x10")
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(-16), v)
+
+	typ, _ = NewType("address[2]", nil)
+	v, err = ParseValue(typ, `["0x970e8128ab834e8eac17ab8e3812f010678cf791", "0x67b3419a16ac67b06d8318d76d31efda05702273"]`)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		utils.HexToAddress("0x970e8128ab834e8eac17ab8e3812f010678cf791"),
+		utils.HexToAddress("0x67b3419a16ac67b06d8318d76d31efda05702273"),
+	}, v)
+
+	typ, _ = NewType("uint8[][]", nil)
+	v, err = ParseValue(typ, `[[1, 2], []]`)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{[]interface{}{big.NewInt(1), big.NewInt(2)}, []interface{}{}}, v)
+
+	typ, _ = NewType("bool", nil)
+	_, err = ParseValue(typ, "yes")
+	assert.Error(t, err)
+}
+
+func TestIntRange(t *testing.T) {
+	int8Type, _ := NewType("int8", nil)
+	uint8Type, _ := NewType("uint8", nil)
+
+	for _, n := range []int64{-128, -1, 0, 127} {
+		enc, err := int8Type.pack(big.NewInt(n))
+		assert.NoError(t, err)
+		v, err := int8Type.unpack(enc, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, big.NewInt(n).Cmp(v.(*big.Int)), "%d", n)
+	}
+	for _, n := range []int64{-129, 128} {
+		_, err := int8Type.pack(big.NewInt(n))
+		assert.Error(t, err)
+	}
+	_, err := uint8Type.pack(-1)
+	assert.Error(t, err)
+	_, err = uint8Type.pack(256)
+	assert.Error(t, err)
+
+	// a word out of the range of the type is rejected
+	enc, _ := int8Type.pack(big.NewInt(-1))
+	_, err = uint8Type.unpack(enc, 0)
+	assert.Error(t, err)
+}
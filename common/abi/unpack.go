@@ -0,0 +1,140 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package abi
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/UranusBlockStack/uranus/common/math"
+	"github.com/UranusBlockStack/uranus/common/utils"
+)
+
+var errShortData = errors.New("abi: data too short")
+
+// unpack decodes the value of the given type stored at offset of data. Integers are
+// returned as *big.Int, bytes as utils.Bytes and arrays or tuples as []interface{}.
+func (t *Type) unpack(data []byte, offset int) (interface{}, error) {
+	if t.isDynamic() {
+		pos, err := readOffset(data, offset)
+		if err != nil {
+			return nil, err
+		}
+		return t.unpackAt(data, pos)
+	}
+	return t.unpackAt(data, offset)
+}
+
+// unpackAt decodes the value whose encoding starts at offset of data.
+func (t *Type) unpackAt(data []byte, offset int) (interface{}, error) {
+	switch t.Kind {
+	case SliceTy, ArrayTy:
+		length, start := t.Size, offset
+		if t.Kind == SliceTy {
+			n, err := readOffset(data, offset)
+			if err != nil {
+				return nil, err
+			}
+			length, start = n, offset+wordSize
+		}
+		types := make([]*Type, length)
+		for i := range types {
+			types[i] = t.Elem
+		}
+		return unpackSequence(types, data, start)
+	case TupleTy:
+		return unpackSequence(t.TupleElems, data, offset)
+	case BytesTy, StringTy:
+		length, err := readOffset(data, offset)
+		if err != nil {
+			return nil, err
+		}
+		if offset+wordSize+length > len(data) {
+			return nil, errShortData
+		}
+		b := utils.CopyBytes(data[offset+wordSize : offset+wordSize+length])
+		if t.Kind == StringTy {
+			return string(b), nil
+		}
+		return utils.Bytes(b), nil
+	}
+
+	if offset+wordSize > len(data) {
+		return nil, errShortData
+	}
+	word := data[offset : offset+wordSize]
+	switch t.Kind {
+	case IntTy, UintTy:
+		n := new(big.Int).SetBytes(word)
+		if t.Kind == IntTy {
+			n = math.S256(n)
+		}
+		if err := t.checkInt(n); err != nil {
+			return nil, err
+		}
+		return n, nil
+	case BoolTy:
+		for _, b := range word[:wordSize-1] {
+			if b != 0 {
+				return nil, fmt.Errorf("abi: invalid bool")
+			}
+		}
+		switch word[wordSize-1] {
+		case 0:
+			return false, nil
+		case 1:
+			return true, nil
+		}
+		return nil, fmt.Errorf("abi: invalid bool")
+	case AddressTy:
+		return utils.BytesToAddress(word), nil
+	case FixedBytesTy:
+		return utils.Bytes(utils.CopyBytes(word[:t.Size])), nil
+	}
+	return nil, fmt.Errorf("abi: unknown type %v", t)
+}
+
+// unpackSequence decodes the values whose heads start at offset of data.
+func unpackSequence(types []*Type, data []byte, offset int) ([]interface{}, error) {
+	if offset > len(data) {
+		return nil, errShortData
+	}
+	values := make([]interface{}, 0, len(types))
+	head := offset
+	for _, t := range types {
+		v, err := t.unpack(data[offset:], head-offset)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+		head += t.headSize()
+	}
+	return values, nil
+}
+
+// readOffset reads a word used as an offset or a length.
+func readOffset(data []byte, offset int) (int, error) {
+	if offset < 0 || offset+wordSize > len(data) {
+		return 0, errShortData
+	}
+	n := new(big.Int).SetBytes(data[offset : offset+wordSize])
+	if !n.IsInt64() || n.Int64() > int64(len(data)) {
+		return 0, fmt.Errorf("abi: offset %v out of range", n)
+	}
+	return int(n.Int64()), nil
+}
@@ -0,0 +1,143 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/syndtr/goleveldb/leveldb/filter"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+)
+
+const (
+	// LevelDBEngine is the persistent storage engine, the default one.
+	LevelDBEngine = "leveldb"
+	// MemoryEngine keeps the database in memory, the content is lost when it's closed.
+	MemoryEngine = "memory"
+)
+
+var (
+	errUnknownEngine = errors.New("unknown database engine")
+
+	enginesLock sync.RWMutex
+	engines     = make(map[string]Opener)
+)
+
+func init() {
+	Register(LevelDBEngine, func(path string, options *Options) (Database, error) {
+		return NewLDBWithOptions(path, options)
+	})
+	Register(MemoryEngine, func(path string, options *Options) (Database, error) {
+		return NewMemDatabase(), nil
+	})
+}
+
+// Options is the storage engine and the tuning of a database, the zero values are the
+// defaults of the engine.
+type Options struct {
+	Engine              string `mapstructure:"engine"`
+	Cache               int    `mapstructure:"cache"`                // MiB of block cache and write buffer
+	Handles             int    `mapstructure:"handles"`              // open file handles
+	CompactionTableSize int    `mapstructure:"compaction-tablesize"` // MiB of a compacted table
+	CompactionL0Trigger int    `mapstructure:"compaction-l0trigger"` // level-0 tables triggering a compaction
+}
+
+// Override returns a copy of the options with the fields set in other.
+func (o Options) Override(other *Options) *Options {
+	if other == nil {
+		return &o
+	}
+	if other.Engine != "" {
+		o.Engine = other.Engine
+	}
+	if other.Cache != 0 {
+		o.Cache = other.Cache
+	}
+	if other.Handles != 0 {
+		o.Handles = other.Handles
+	}
+	if other.CompactionTableSize != 0 {
+		o.CompactionTableSize = other.CompactionTableSize
+	}
+	if other.CompactionL0Trigger != 0 {
+		o.CompactionL0Trigger = other.CompactionL0Trigger
+	}
+	return &o
+}
+
+// LevelDB returns the LevelDB options of the tuning.
+func (o *Options) LevelDB() *opt.Options {
+	return &opt.Options{
+		OpenFilesCacheCapacity: o.Handles,
+		BlockCacheCapacity:     o.Cache / 2 * opt.MiB,
+		WriteBuffer:            o.Cache / 4 * opt.MiB,
+		CompactionTableSize:    o.CompactionTableSize * opt.MiB,
+		CompactionL0Trigger:    o.CompactionL0Trigger,
+		Filter:                 filter.NewBloomFilter(10),
+	}
+}
+
+// Opener opens the database of an engine at the path.
+type Opener func(path string, options *Options) (Database, error)
+
+// Register makes a storage engine available by name, it panics if the name is taken.
+func Register(engine string, opener Opener) {
+	enginesLock.Lock()
+	defer enginesLock.Unlock()
+
+	if opener == nil {
+		panic("db: register nil opener of engine " + engine)
+	}
+	if _, ok := engines[engine]; ok {
+		panic("db: engine registered twice " + engine)
+	}
+	engines[engine] = opener
+}
+
+// Engines returns the names of the registered storage engines.
+func Engines() []string {
+	enginesLock.RLock()
+	defer enginesLock.RUnlock()
+
+	names := make([]string, 0, len(engines))
+	for name := range engines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Open opens the database at the path with the engine of the options, LevelDB if unset.
+func Open(path string, options *Options) (Database, error) {
+	if options == nil {
+		options = &Options{}
+	}
+	engine := options.Engine
+	if engine == "" {
+		engine = LevelDBEngine
+	}
+	enginesLock.RLock()
+	opener, ok := engines[engine]
+	enginesLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%v: %v", errUnknownEngine, engine)
+	}
+	return opener(path, options)
+}
@@ -0,0 +1,66 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestOpen(t *testing.T) {
+	if engines := Engines(); len(engines) != 2 || engines[0] != LevelDBEngine || engines[1] != MemoryEngine {
+		t.Fatalf("got engines %q", engines)
+	}
+	if _, err := Open("", &Options{Engine: "unknown"}); err == nil {
+		t.Fatal("opened an unknown engine")
+	}
+
+	db, err := Open("", &Options{Engine: MemoryEngine})
+	if err != nil {
+		t.Fatalf("open memory failed: %v", err)
+	}
+	if _, ok := db.(*MemDatabase); !ok {
+		t.Fatalf("got %T expected memory database", db)
+	}
+
+	dir, err := ioutil.TempDir("", "test_engine")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err = Open(dir, Options{Cache: 16, Handles: 16}.Override(&Options{CompactionTableSize: 4}))
+	if err != nil {
+		t.Fatalf("open leveldb failed: %v", err)
+	}
+	defer db.Close()
+	if _, ok := db.(*LDB); !ok {
+		t.Fatalf("got %T expected leveldb database", db)
+	}
+}
+
+func TestOptionsOverride(t *testing.T) {
+	defaults := Options{Cache: 512, Handles: 1024}
+	options := defaults.Override(&Options{Engine: MemoryEngine, Handles: 64, CompactionL0Trigger: 8})
+	want := Options{Engine: MemoryEngine, Cache: 512, Handles: 64, CompactionL0Trigger: 8}
+	if *options != want {
+		t.Fatalf("got %+v expected %+v", *options, want)
+	}
+	if options = defaults.Override(nil); *options != defaults {
+		t.Fatalf("got %+v expected %+v", *options, defaults)
+	}
+}
@@ -16,6 +16,8 @@
 
 package db
 
+import "github.com/syndtr/goleveldb/leveldb/iterator"
+
 // IdealBatchSize Code using batches should try to add this much data to the batch.
 const IdealBatchSize = 100 * 1024
 
@@ -37,6 +39,15 @@ type Database interface {
 	Has(key []byte) (bool, error)
 	Close()
 	NewBatch() Batch
+	NewIteratorWithPrefix(prefix []byte) iterator.Iterator
+}
+
+// Snapshot is a read-only view of a database at the time it was taken.
+type Snapshot interface {
+	Get(key []byte) ([]byte, error)
+	Has(key []byte) (bool, error)
+	NewIteratorWithPrefix(prefix []byte) iterator.Iterator
+	Release()
 }
 
 // Batch is a write-only database.
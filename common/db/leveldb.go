@@ -22,9 +22,7 @@ import (
 	"github.com/UranusBlockStack/uranus/common/log"
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/errors"
-	"github.com/syndtr/goleveldb/leveldb/filter"
 	"github.com/syndtr/goleveldb/leveldb/iterator"
-	"github.com/syndtr/goleveldb/leveldb/opt"
 	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
@@ -43,19 +41,20 @@ type LDB struct {
 
 // NewLDB returns a LevelDB wrapped object.
 func NewLDB(file string, cache int, handles int) (*LDB, error) {
-	if cache < 16 {
-		cache = 16
+	return NewLDBWithOptions(file, &Options{Cache: cache, Handles: handles})
+}
+
+// NewLDBWithOptions returns a LevelDB wrapped object tuned by the options.
+func NewLDBWithOptions(file string, options *Options) (*LDB, error) {
+	tuned := *options
+	if tuned.Cache < 16 {
+		tuned.Cache = 16
 	}
-	if handles < 16 {
-		handles = 16
+	if tuned.Handles < 16 {
+		tuned.Handles = 16
 	}
-	log.Infof("Allocated cache and file handles cache: %v,handles: %v", cache, handles)
-	db, err := leveldb.OpenFile(file, &opt.Options{
-		OpenFilesCacheCapacity: handles,
-		BlockCacheCapacity:     cache / 2 * opt.MiB,
-		WriteBuffer:            cache / 4 * opt.MiB,
-		Filter:                 filter.NewBloomFilter(10),
-	})
+	log.Infof("Allocated cache and file handles cache: %v,handles: %v", tuned.Cache, tuned.Handles)
+	db, err := leveldb.OpenFile(file, tuned.LevelDB())
 	if _, corrupted := err.(*errors.ErrCorrupted); corrupted {
 		db, err = leveldb.RecoverFile(file, nil)
 	}
@@ -106,6 +105,15 @@ func (db *LDB) NewIteratorWithPrefix(prefix []byte) iterator.Iterator {
 	return db.db.NewIterator(util.BytesPrefix(prefix), nil)
 }
 
+// NewSnapshot returns a read-only view of the current content of the database.
+func (db *LDB) NewSnapshot() (Snapshot, error) {
+	snap, err := db.db.GetSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	return &ldbSnapshot{snap: snap}, nil
+}
+
 func (db *LDB) Close() {
 	err := db.db.Close()
 	if err == nil {
@@ -123,6 +131,30 @@ func (db *LDB) NewBatch() Batch {
 	return &ldbBatch{db: db.db, b: new(leveldb.Batch)}
 }
 
+type ldbSnapshot struct {
+	snap *leveldb.Snapshot
+}
+
+func (s *ldbSnapshot) Has(key []byte) (bool, error) {
+	return s.snap.Has(key, nil)
+}
+
+func (s *ldbSnapshot) Get(key []byte) ([]byte, error) {
+	dat, err := s.snap.Get(key, nil)
+	if err != nil && err != leveldb.ErrNotFound {
+		return nil, err
+	}
+	return dat, nil
+}
+
+func (s *ldbSnapshot) NewIteratorWithPrefix(prefix []byte) iterator.Iterator {
+	return s.snap.NewIterator(util.BytesPrefix(prefix), nil)
+}
+
+func (s *ldbSnapshot) Release() {
+	s.snap.Release()
+}
+
 type ldbBatch struct {
 	db   *leveldb.DB
 	b    *leveldb.Batch
@@ -183,8 +215,32 @@ func (dt *table) Delete(key []byte) error {
 	return dt.db.Delete(append([]byte(dt.prefix), key...))
 }
 
+// NewIteratorWithPrefix returns a iterator to iterate values with a prefix in the table,
+// the keys of the iterator are stripped of the table prefix.
+func (dt *table) NewIteratorWithPrefix(prefix []byte) iterator.Iterator {
+	it := dt.db.NewIteratorWithPrefix(append([]byte(dt.prefix), prefix...))
+	return &tableIterator{Iterator: it, prefix: dt.prefix}
+}
+
 func (dt *table) Close() {}
 
+type tableIterator struct {
+	iterator.Iterator
+	prefix string
+}
+
+func (it *tableIterator) Seek(key []byte) bool {
+	return it.Iterator.Seek(append([]byte(it.prefix), key...))
+}
+
+func (it *tableIterator) Key() []byte {
+	key := it.Iterator.Key()
+	if key == nil {
+		return nil
+	}
+	return key[len(it.prefix):]
+}
+
 type tableBatch struct {
 	batch  Batch
 	prefix string
@@ -180,3 +180,15 @@ func testParallelPutGet(db Database, t *testing.T) {
 	}
 	wg.Wait()
 }
+
+func TestLDB_Iterator(t *testing.T) {
+	db, remove := newTestLDB()
+	defer remove()
+	testIterator(db, t)
+}
+
+func TestLDB_Snapshot(t *testing.T) {
+	db, remove := newTestLDB()
+	defer remove()
+	testSnapshot(db, db.NewSnapshot, t)
+}
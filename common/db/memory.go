@@ -0,0 +1,258 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"sync"
+
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/syndtr/goleveldb/leveldb/comparer"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/memdb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// MemDatabase is an ephemeral key-value store, it behaves like LDB but the content is
+// lost when it's closed.
+//
+// The content is kept sorted in a skiplist. Iterators and snapshots share the current
+// skiplist, the next write after they are taken copies it so they don't see the write.
+type MemDatabase struct {
+	db     *memdb.DB
+	shared int // live iterators and snapshots of db
+	lock   sync.RWMutex
+}
+
+func NewMemDatabase() *MemDatabase {
+	return NewMemDatabaseWithCap(0)
+}
+
+func NewMemDatabaseWithCap(size int) *MemDatabase {
+	return &MemDatabase{
+		db: memdb.New(comparer.DefaultComparer, size),
+	}
+}
+
+func (db *MemDatabase) Put(key []byte, value []byte) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	return db.writable().Put(key, value)
+}
+
+func (db *MemDatabase) Has(key []byte) (bool, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	return db.db.Contains(key), nil
+}
+
+// Get returns the given key if it's present, a missing key isn't an error like in LDB.
+func (db *MemDatabase) Get(key []byte) ([]byte, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	return memGet(db.db, key)
+}
+
+func (db *MemDatabase) Keys() [][]byte {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	keys := make([][]byte, 0, db.db.Len())
+	it := db.db.NewIterator(nil)
+	defer it.Release()
+	for it.Next() {
+		keys = append(keys, utils.CopyBytes(it.Key()))
+	}
+	return keys
+}
+
+func (db *MemDatabase) Delete(key []byte) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	return memDelete(db.writable(), key)
+}
+
+// NewIterator returns an iterator of the whole database sorted by key.
+func (db *MemDatabase) NewIterator() iterator.Iterator {
+	return db.NewIteratorWithPrefix(nil)
+}
+
+// NewIteratorWithPrefix returns a iterator to iterate values with a prefix, sorted by
+// key. Like the LevelDB iterators, it isn't affected by the later writes.
+func (db *MemDatabase) NewIteratorWithPrefix(prefix []byte) iterator.Iterator {
+	sorted, release := db.share()
+	it := sorted.NewIterator(util.BytesPrefix(prefix))
+	it.SetReleaser(releaseFunc(release))
+	return it
+}
+
+// NewSnapshot returns a read-only view of the current content of the database.
+func (db *MemDatabase) NewSnapshot() (Snapshot, error) {
+	sorted, release := db.share()
+	return &memSnapshot{db: sorted, release: release}, nil
+}
+
+func (db *MemDatabase) Close() {}
+
+func (db *MemDatabase) NewBatch() Batch {
+	return &memBatch{db: db}
+}
+
+func (db *MemDatabase) Len() int {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	return db.db.Len()
+}
+
+// share marks the current skiplist as read by an iterator or a snapshot, until the
+// returned function is called.
+func (db *MemDatabase) share() (*memdb.DB, func()) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	sorted := db.db
+	db.shared++
+
+	var once sync.Once
+	return sorted, func() {
+		once.Do(func() {
+			db.lock.Lock()
+			defer db.lock.Unlock()
+
+			// a write has already replaced the skiplist, nothing reads the new one
+			if db.db == sorted {
+				db.shared--
+			}
+		})
+	}
+}
+
+// writable returns a skiplist that can be written without being seen by the
+// iterators and snapshots, copying the current one if they share it. The caller
+// must hold the write lock.
+func (db *MemDatabase) writable() *memdb.DB {
+	if db.shared == 0 {
+		return db.db
+	}
+	sorted := memdb.New(comparer.DefaultComparer, db.db.Size())
+	it := db.db.NewIterator(nil)
+	for it.Next() {
+		sorted.Put(it.Key(), it.Value())
+	}
+	it.Release()
+
+	db.db, db.shared = sorted, 0
+	return sorted
+}
+
+// memGet returns a copy of the value of key, or nil if it's missing.
+func memGet(sorted *memdb.DB, key []byte) ([]byte, error) {
+	value, err := sorted.Get(key)
+	if err == memdb.ErrNotFound {
+		return nil, nil
+	}
+	return utils.CopyBytes(value), err
+}
+
+// memDelete deletes key, a missing key isn't an error.
+func memDelete(sorted *memdb.DB, key []byte) error {
+	if err := sorted.Delete(key); err != nil && err != memdb.ErrNotFound {
+		return err
+	}
+	return nil
+}
+
+// releaseFunc calls itself when an iterator is released.
+type releaseFunc func()
+
+func (f releaseFunc) Release() { f() }
+
+type memSnapshot struct {
+	db      *memdb.DB
+	release func()
+}
+
+func (s *memSnapshot) Has(key []byte) (bool, error) {
+	return s.db.Contains(key), nil
+}
+
+func (s *memSnapshot) Get(key []byte) ([]byte, error) {
+	return memGet(s.db, key)
+}
+
+func (s *memSnapshot) NewIteratorWithPrefix(prefix []byte) iterator.Iterator {
+	return s.db.NewIterator(util.BytesPrefix(prefix))
+}
+
+func (s *memSnapshot) Release() {
+	s.release()
+}
+
+type kv struct {
+	k, v []byte
+	del  bool
+}
+
+type memBatch struct {
+	db     *MemDatabase
+	writes []kv
+	size   int
+}
+
+func (b *memBatch) Put(key, value []byte) error {
+	b.writes = append(b.writes, kv{utils.CopyBytes(key), utils.CopyBytes(value), false})
+	b.size += len(value)
+	return nil
+}
+
+func (b *memBatch) Delete(key []byte) error {
+	b.writes = append(b.writes, kv{utils.CopyBytes(key), nil, true})
+	b.size += 1
+	return nil
+}
+
+func (b *memBatch) Write() error {
+	b.db.lock.Lock()
+	defer b.db.lock.Unlock()
+
+	sorted := b.db.writable()
+	for _, kv := range b.writes {
+		if kv.del {
+			if err := memDelete(sorted, kv.k); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := sorted.Put(kv.k, kv.v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *memBatch) ValueSize() int {
+	return b.size
+}
+
+func (b *memBatch) Reset() {
+	b.writes = b.writes[:0]
+	b.size = 0
+}
@@ -0,0 +1,145 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+)
+
+func TestMemDatabase_PutGet(t *testing.T) {
+	testPutGet(NewMemDatabase(), t)
+}
+
+func TestMemDatabase_ParallelPutGet(t *testing.T) {
+	testParallelPutGet(NewMemDatabase(), t)
+}
+
+func TestMemDatabase_Iterator(t *testing.T) {
+	testIterator(NewMemDatabase(), t)
+}
+
+func TestMemDatabase_Snapshot(t *testing.T) {
+	db := NewMemDatabase()
+	testSnapshot(db, db.NewSnapshot, t)
+}
+
+var iteratorKeys = []string{"a", "b1", "b2", "b3", "c", "tb1", "tb2"}
+
+// checkIterator checks the keys of the iterator and releases it.
+func checkIterator(t *testing.T, it iterator.Iterator, want []string) {
+	defer it.Release()
+	var got []string
+	for it.Next() {
+		if !bytes.HasSuffix(it.Value(), it.Key()) {
+			t.Fatalf("value %q of key %q", it.Value(), it.Key())
+		}
+		got = append(got, string(it.Key()))
+	}
+	if err := it.Error(); err != nil {
+		t.Fatalf("iterator failed: %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got keys %q expected %q", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("got keys %q expected %q", got, want)
+		}
+	}
+}
+
+func testIterator(db Database, t *testing.T) {
+	for i := len(iteratorKeys) - 1; i >= 0; i-- {
+		db.Put([]byte(iteratorKeys[i]), []byte("v"+iteratorKeys[i]))
+	}
+	checkIterator(t, db.NewIteratorWithPrefix(nil), iteratorKeys)
+	checkIterator(t, db.NewIteratorWithPrefix([]byte("b")), []string{"b1", "b2", "b3"})
+	checkIterator(t, db.NewIteratorWithPrefix([]byte("d")), nil)
+
+	// seeking inside the prefix
+	it := db.NewIteratorWithPrefix([]byte("b"))
+	if !it.Seek([]byte("b2")) || string(it.Key()) != "b2" {
+		t.Fatalf("seek failed, got %q", it.Key())
+	}
+	checkIterator(t, it, []string{"b3"})
+
+	// the iterator isn't affected by the later writes
+	it = db.NewIteratorWithPrefix([]byte("b"))
+	db.Delete([]byte("b2"))
+	db.Put([]byte("b4"), []byte("vb4"))
+	checkIterator(t, it, []string{"b1", "b2", "b3"})
+	checkIterator(t, db.NewIteratorWithPrefix([]byte("b")), []string{"b1", "b3", "b4"})
+
+	// the keys of a table are stripped of its prefix
+	table := NewTable(db, "t")
+	checkIterator(t, table.NewIteratorWithPrefix(nil), []string{"b1", "b2"})
+	it = table.NewIteratorWithPrefix([]byte("b"))
+	if !it.Seek([]byte("b2")) || string(it.Key()) != "b2" {
+		t.Fatalf("seek in table failed, got %q", it.Key())
+	}
+	checkIterator(t, it, nil)
+}
+
+func testSnapshot(db Database, newSnapshot func() (Snapshot, error), t *testing.T) {
+	db.Put([]byte("a"), []byte("va"))
+	db.Put([]byte("b"), []byte("vb"))
+	snap, err := newSnapshot()
+	if err != nil {
+		t.Fatalf("snapshot failed: %v", err)
+	}
+	defer snap.Release()
+
+	db.Put([]byte("a"), []byte("x"))
+	db.Delete([]byte("b"))
+	db.Put([]byte("c"), []byte("vc"))
+	if data, err := snap.Get([]byte("a")); err != nil || !bytes.Equal(data, []byte("va")) {
+		t.Fatalf("got %q, %v expected va", data, err)
+	}
+	if has, err := snap.Has([]byte("b")); err != nil || !has {
+		t.Fatalf("deleted key missing in snapshot: %v", err)
+	}
+	if data, err := snap.Get([]byte("c")); err != nil || data != nil {
+		t.Fatalf("got later key %q, %v", data, err)
+	}
+	checkIterator(t, snap.NewIteratorWithPrefix(nil), []string{"a", "b"})
+}
+
+func TestMemDatabase_SharedUntilWrite(t *testing.T) {
+	db := NewMemDatabase()
+	db.Put([]byte("a"), []byte("va"))
+
+	it := db.NewIterator()
+	snap, _ := db.NewSnapshot()
+	sorted := db.db
+	if db.Put([]byte("b"), []byte("vb")); db.db == sorted {
+		t.Fatal("write didn't copy the shared skiplist")
+	}
+	it.Release()
+	snap.Release()
+
+	// nothing reads the copy, the writes go to it in place
+	sorted = db.db
+	it = db.NewIterator()
+	it.Release()
+	if db.Put([]byte("c"), []byte("vc")); db.db != sorted {
+		t.Fatal("write copied a skiplist no longer shared")
+	}
+	checkIterator(t, db.NewIterator(), []string{"a", "b", "c"})
+}
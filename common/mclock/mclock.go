@@ -0,0 +1,162 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package mclock provides the time source of the consensus, the system clock or a
+// simulated clock driven by tests.
+package mclock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock is the time source of the miner and the consensus engine.
+type Clock interface {
+	Now() time.Time
+	Sleep(time.Duration)
+	After(time.Duration) <-chan time.Time
+}
+
+// System is the clock of the operating system.
+type System struct{}
+
+// Now returns the current time.
+func (System) Now() time.Time {
+	return time.Now()
+}
+
+// Sleep blocks for the duration.
+func (System) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+// After returns a channel receiving the time once the duration elapsed.
+func (System) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// Simulated is a clock which only advances when Run is called, the timers fire in the
+// order of their deadlines.
+type Simulated struct {
+	mu        sync.Mutex
+	cond      *sync.Cond
+	now       time.Time
+	timers    []*simTimer
+	fired     []chan time.Time // timers fired by the last Run
+	scheduled uint64           // count of the timers ever scheduled
+}
+
+type simTimer struct {
+	at time.Time
+	ch chan time.Time
+}
+
+// NewSimulated creates a simulated clock starting at the time.
+func NewSimulated(start time.Time) *Simulated {
+	s := &Simulated{now: start}
+	s.cond = sync.NewCond(&s.mu)
+	return s
+}
+
+// Now returns the current simulated time.
+func (s *Simulated) Now() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.now
+}
+
+// Sleep blocks until the clock advanced by the duration.
+func (s *Simulated) Sleep(d time.Duration) {
+	<-s.After(d)
+}
+
+// After returns a channel receiving the simulated time once the clock advanced by the duration.
+func (s *Simulated) After(d time.Duration) <-chan time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- s.now
+		return ch
+	}
+	s.scheduled++
+	at := s.now.Add(d)
+	i := sort.Search(len(s.timers), func(i int) bool { return s.timers[i].at.After(at) })
+	s.timers = append(s.timers, nil)
+	copy(s.timers[i+1:], s.timers[i:])
+	s.timers[i] = &simTimer{at: at, ch: ch}
+	s.cond.Broadcast()
+	return ch
+}
+
+// Run advances the clock by the duration, firing the expired timers one by one.
+func (s *Simulated) Run(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	end := s.now.Add(d)
+	s.fired = s.fired[:0]
+	for len(s.timers) > 0 && !s.timers[0].at.After(end) {
+		t := s.timers[0]
+		s.timers = s.timers[1:]
+		s.now = t.at
+		t.ch <- t.at
+		s.fired = append(s.fired, t.ch)
+	}
+	s.now = end
+}
+
+// Idle reports whether the timers fired by the last Run were all received.
+func (s *Simulated) Idle() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, ch := range s.fired {
+		if len(ch) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Scheduled returns the count of the timers scheduled since the clock was created.
+func (s *Simulated) Scheduled() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.scheduled
+}
+
+// ActiveTimers returns the count of the timers which didn't fire yet.
+func (s *Simulated) ActiveTimers() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.timers)
+}
+
+// WaitForTimers blocks until at least n timers are pending.
+func (s *Simulated) WaitForTimers(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for len(s.timers) < n {
+		s.cond.Wait()
+	}
+}
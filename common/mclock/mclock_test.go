@@ -0,0 +1,106 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package mclock
+
+import (
+	"testing"
+	"time"
+)
+
+var _ Clock = System{}
+var _ Clock = (*Simulated)(nil)
+
+func TestSimulatedAfter(t *testing.T) {
+	start := time.Unix(1000, 0)
+	s := NewSimulated(start)
+
+	late := s.After(3 * time.Second)
+	early := s.After(time.Second)
+	if n := s.ActiveTimers(); n != 2 {
+		t.Fatalf("active timers mismatch: have %d, want 2", n)
+	}
+
+	s.Run(2 * time.Second)
+	select {
+	case at := <-early:
+		if want := start.Add(time.Second); !at.Equal(want) {
+			t.Fatalf("early timer fired at %v, want %v", at, want)
+		}
+	default:
+		t.Fatal("early timer didn't fire")
+	}
+	select {
+	case <-late:
+		t.Fatal("late timer fired too early")
+	default:
+	}
+	if now := s.Now(); !now.Equal(start.Add(2 * time.Second)) {
+		t.Fatalf("clock mismatch: have %v, want %v", now, start.Add(2*time.Second))
+	}
+
+	s.Run(time.Second)
+	select {
+	case <-late:
+	default:
+		t.Fatal("late timer didn't fire")
+	}
+	if n := s.ActiveTimers(); n != 0 {
+		t.Fatalf("active timers mismatch: have %d, want 0", n)
+	}
+}
+
+func TestSimulatedSleep(t *testing.T) {
+	s := NewSimulated(time.Unix(0, 0))
+	done := make(chan struct{})
+	go func() {
+		s.Sleep(time.Minute)
+		close(done)
+	}()
+
+	s.WaitForTimers(1)
+	s.Run(time.Minute - time.Nanosecond)
+	select {
+	case <-done:
+		t.Fatal("sleep returned too early")
+	case <-time.After(10 * time.Millisecond):
+	}
+	s.Run(time.Nanosecond)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("sleep didn't return")
+	}
+}
+
+func TestSimulatedIdle(t *testing.T) {
+	s := NewSimulated(time.Unix(0, 0))
+	timer := s.After(time.Second)
+	s.After(time.Minute)
+	if n := s.Scheduled(); n != 2 {
+		t.Fatalf("scheduled timers mismatch: have %d, want 2", n)
+	}
+
+	// the clock is busy until the fired timer is received
+	s.Run(time.Second)
+	if s.Idle() {
+		t.Fatal("idle with a fired timer not received")
+	}
+	<-timer
+	if !s.Idle() {
+		t.Fatal("busy after the fired timer was received")
+	}
+}
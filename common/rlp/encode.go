@@ -529,9 +529,17 @@ func makeStructWriter(typ reflect.Type) (writer, error) {
 	if err != nil {
 		return nil, err
 	}
+	firstOptional := firstOptionalField(fields)
 	writer := func(val reflect.Value, w *encbuf) error {
+		// optional fields are only written up to the last non-zero one.
+		lastField := len(fields) - 1
+		for ; lastField >= firstOptional; lastField-- {
+			if !isZeroValue(val.Field(fields[lastField].index)) {
+				break
+			}
+		}
 		lh := w.list()
-		for _, f := range fields {
+		for _, f := range fields[:lastField+1] {
 			if err := f.info.writer(val.Field(f.index), w); err != nil {
 				return err
 			}
@@ -542,6 +550,11 @@ func makeStructWriter(typ reflect.Type) (writer, error) {
 	return writer, nil
 }
 
+// isZeroValue reports whether v holds the zero value of its type.
+func isZeroValue(v reflect.Value) bool {
+	return reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface())
+}
+
 func makePtrWriter(typ reflect.Type) (writer, error) {
 	etypeinfo, err := cachedTypeInfo1(typ.Elem(), tags{})
 	if err != nil {
@@ -7,12 +7,13 @@ import (
 	"fmt"
 	"math/big"
 	"sort"
-	"time"
+	"sync/atomic"
 
 	"github.com/UranusBlockStack/uranus/common/crypto"
 	"github.com/UranusBlockStack/uranus/common/crypto/sha3"
 	"github.com/UranusBlockStack/uranus/common/db"
 	"github.com/UranusBlockStack/uranus/common/log"
+	"github.com/UranusBlockStack/uranus/common/mclock"
 	"github.com/UranusBlockStack/uranus/common/mtp"
 	"github.com/UranusBlockStack/uranus/common/rlp"
 	"github.com/UranusBlockStack/uranus/common/utils"
@@ -24,23 +25,10 @@ import (
 	lru "github.com/hashicorp/golang-lru"
 )
 
-var Option = &option{
-	BlockInterval:    int64(500 * time.Millisecond), // 500 ms
-	BlockRepeat:      12,
-	MaxValidatorSize: 3,
-	MinStartQuantity: new(big.Int).Mul(big.NewInt(1000000), big.NewInt(1e18)),
-	DelayEpcho:       2,
-}
-
-func (opt *option) consensusSize() int64 {
-	return opt.MaxValidatorSize*2/3 + 1
-}
-
-func (opt *option) epochInterval() int64 {
-	return opt.BlockInterval * opt.BlockRepeat * opt.MaxValidatorSize
-}
+const defaultDelayEpcho = 2
 
-type option struct {
+// Config is the timing and election parameters of a dpos chain.
+type Config struct {
 	BlockInterval    int64
 	BlockRepeat      int64
 	MaxValidatorSize int64
@@ -48,6 +36,44 @@ type option struct {
 	DelayEpcho       int64
 }
 
+// NewConfig derives the consensus parameters from the chain config.
+func NewConfig(cfg *params.ChainConfig) *Config {
+	c := &Config{
+		BlockInterval:    cfg.BlockInterval,
+		BlockRepeat:      cfg.BlockRepeat,
+		MaxValidatorSize: cfg.MaxValidatorSize,
+		MinStartQuantity: cfg.MinStartQuantity,
+		DelayEpcho:       cfg.DelayEpcho,
+	}
+	if c.DelayEpcho <= 0 {
+		c.DelayEpcho = defaultDelayEpcho
+	}
+	return c
+}
+
+func (c *Config) consensusSize() int64 {
+	return c.MaxValidatorSize*2/3 + 1
+}
+
+func (c *Config) epochInterval() int64 {
+	return c.BlockInterval * c.BlockRepeat * c.MaxValidatorSize
+}
+
+// Slot returns the start of the slot the time falls in.
+func (c *Config) Slot(now int64) int64 {
+	return int64((now-c.BlockInterval/10)/c.BlockInterval) * c.BlockInterval
+}
+
+// PrevSlot returns the start of the slot before the time.
+func (c *Config) PrevSlot(now int64) int64 {
+	return c.Slot(now) - c.BlockInterval
+}
+
+// NextSlot returns the start of the slot after the time.
+func (c *Config) NextSlot(now int64) int64 {
+	return c.Slot(now) + c.BlockInterval
+}
+
 const (
 	extraSeal = 65 // Fixed number of extra-data suffix bytes reserved for signer seal
 )
@@ -63,12 +89,13 @@ var (
 	ErrNilBlockHeader             = errors.New("nil block header returned")
 )
 var (
-	timeOfFirstBlock   = int64(0)
 	confirmedBlockHead = []byte("confirmed-block-head")
 )
 
 type SignerFn func(utils.Address, []byte) ([]byte, error)
 type Dpos struct {
+	timeOfFirstBlock     int64 // atomic, first for the 64 bit alignment
+	config               *Config
 	eventMux             *feed.TypeMux
 	chainDb              db.Database
 	db                   state.Database
@@ -76,22 +103,36 @@ type Dpos struct {
 	confirmedBlockHeader *types.BlockHeader
 	bftConfirmeds        *lru.Cache
 	coinbase             utils.Address
+	clock                mclock.Clock
 }
 
-func NewDpos(eventMux *feed.TypeMux, chainDb db.Database, db state.Database, signFn SignerFn) *Dpos {
+func NewDpos(config *params.ChainConfig, eventMux *feed.TypeMux, chainDb db.Database, db state.Database, signFn SignerFn, clock mclock.Clock) *Dpos {
 	d := &Dpos{
+		config:   NewConfig(config),
 		eventMux: eventMux,
 		chainDb:  chainDb,
 		db:       db,
 		signFn:   signFn,
+		clock:    clock,
 	}
 	return d
 }
+
+// Config returns the consensus parameters of the chain.
+func (d *Dpos) Config() *Config {
+	return d.config
+}
+
+// Clock returns the time source the blocks are minted on.
+func (d *Dpos) Clock() mclock.Clock {
+	return d.clock
+}
 func (dpos *Dpos) Init(chain consensus.IChainReader) {
 	dpos.confirmedBlockHeader, _ = dpos.loadConfirmedBlockHeader(chain)
+	// subscribe before returning, the confirmations posted after Init aren't lost
+	dpos.bftConfirmeds, _ = lru.New(int(chain.Config().MaxValidatorSize))
+	sub := dpos.eventMux.Subscribe(types.Confirmed{})
 	go func() {
-		dpos.bftConfirmeds, _ = lru.New(int(chain.Config().MaxValidatorSize))
-		sub := dpos.eventMux.Subscribe(types.Confirmed{})
 		for ev := range sub.Chan() {
 			switch ev.Data.(type) {
 			case types.Confirmed:
@@ -113,27 +154,15 @@ func (dpos *Dpos) handleConfirmed(chain consensus.IChainReader, confirmed *types
 	}
 }
 
-func Slot(now int64) int64 {
-	return int64((now-Option.BlockInterval/10)/Option.BlockInterval) * Option.BlockInterval
-}
-
-func PrevSlot(now int64) int64 {
-	return Slot(now) - Option.BlockInterval
-}
-
-func NextSlot(now int64) int64 {
-	return Slot(now) + Option.BlockInterval
-}
-
 // update counts in MintCntTrie for the miner of newBlock
-func updateMintCnt(parentBlockTime, currentBlockTime int64, validator utils.Address, dposContext *types.DposContext) {
+func (c *Config) updateMintCnt(parentBlockTime, currentBlockTime int64, validator utils.Address, dposContext *types.DposContext) {
 	currentMintCntTrie := dposContext.MintCntTrie()
-	currentEpoch := parentBlockTime / Option.epochInterval()
+	currentEpoch := parentBlockTime / c.epochInterval()
 	currentEpochBytes := make([]byte, 8)
 	binary.BigEndian.PutUint64(currentEpochBytes, uint64(currentEpoch))
 
 	cnt := int64(1)
-	newEpoch := currentBlockTime / Option.epochInterval()
+	newEpoch := currentBlockTime / c.epochInterval()
 	// still during the currentEpochID
 	if currentEpoch == newEpoch {
 		iter := mtp.NewIterator(currentMintCntTrie.NodeIterator(currentEpochBytes))
@@ -158,7 +187,7 @@ func updateMintCnt(parentBlockTime, currentBlockTime int64, validator utils.Addr
 
 func sigHash(header *types.BlockHeader) (hash utils.Hash) {
 	hasher := sha3.NewKeccak256()
-	rlp.Encode(hasher, []interface{}{
+	fields := []interface{}{
 		header.PreviousHash,
 		header.Miner,
 		header.StateRoot,
@@ -173,7 +202,11 @@ func sigHash(header *types.BlockHeader) (hash utils.Hash) {
 		header.ExtraData[:len(header.ExtraData)-extraSeal], // Yes, this will panic if extra is too short
 		header.Nonce,
 		header.DposContext.Root(),
-	})
+	}
+	if header.BaseFee != nil {
+		fields = append(fields, header.BaseFee)
+	}
+	rlp.Encode(hasher, fields)
 	hasher.Sum(hash[:0])
 	return hash
 }
@@ -242,16 +275,11 @@ func (d *Dpos) VerifySeal(chain consensus.IChainReader, header *types.BlockHeade
 		return ErrMismatchSignerAndValidator
 	}
 
-	epchoHeader := d.EpchoBlockHeader(chain, header.TimeStamp.Int64(), parent)
-	statedb, err := state.New(epchoHeader.StateRoot, d.db)
-	if err != nil {
-		return err
-	}
-	dposContext, err := types.NewDposContextFromProto(statedb.Database().TrieDB(), epchoHeader.DposContext)
+	dposContext, err := d.epochDposContext(chain, header.TimeStamp.Int64(), parent)
 	if err != nil {
 		return err
 	}
-	epochContext := &EpochContext{DposContext: dposContext}
+	epochContext := d.epochContext(dposContext, nil, 0)
 	validator, err := epochContext.lookupValidator(header.TimeStamp.Int64())
 	if err != nil {
 		return err
@@ -262,6 +290,35 @@ func (d *Dpos) VerifySeal(chain consensus.IChainReader, header *types.BlockHeade
 	return d.updateConfirmedBlockHeader(chain, epochContext.DposContext.IsDpos())
 }
 
+// UpdateConfirmed updates the confirmed block header like VerifySeal does, it's used
+// when the seal verification is skipped.
+func (d *Dpos) UpdateConfirmed(chain consensus.IChainReader, header *types.BlockHeader) error {
+	if header == nil || header.Height == nil {
+		return consensus.ErrUnknownBlock
+	}
+
+	parent := chain.GetBlockByHash(header.PreviousHash)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+
+	dposContext, err := d.epochDposContext(chain, header.TimeStamp.Int64(), parent)
+	if err != nil {
+		return err
+	}
+	return d.updateConfirmedBlockHeader(chain, dposContext.IsDpos())
+}
+
+// epochDposContext returns the dpos context of the epoch block for the timestamp.
+func (d *Dpos) epochDposContext(chain consensus.IChainReader, timestamp int64, parent *types.Block) (*types.DposContext, error) {
+	epchoHeader := d.EpchoBlockHeader(chain, timestamp, parent)
+	statedb, err := state.New(epchoHeader.StateRoot, d.db)
+	if err != nil {
+		return nil, err
+	}
+	return types.NewDposContextFromProto(statedb.Database().TrieDB(), epchoHeader.DposContext)
+}
+
 func (d *Dpos) updateConfirmedBlockHeader(chain consensus.IChainReader, dpos bool) error {
 	if d.confirmedBlockHeader == nil {
 		header, err := d.loadConfirmedBlockHeader(chain)
@@ -291,20 +348,20 @@ func (d *Dpos) updateConfirmedBlockHeader(chain consensus.IChainReader, dpos boo
 	curHeader := chain.CurrentBlock().BlockHeader()
 	for d.confirmedBlockHeader.Hash() != curHeader.Hash() &&
 		d.confirmedBlockHeader.Height.Uint64() < curHeader.Height.Uint64() {
-		curEpoch := curHeader.TimeStamp.Int64() / Option.epochInterval()
+		curEpoch := curHeader.TimeStamp.Int64() / d.config.epochInterval()
 		if curEpoch != epoch {
 			epoch = curEpoch
 			validatorMap = make(map[utils.Address]bool)
 		}
 		// fast return
-		// if block number difference less Option.consensusSize()-witnessNum
+		// if block number difference less d.config.consensusSize()-witnessNum
 		// there is no need to check block is confirmed
-		if curHeader.Height.Int64()-d.confirmedBlockHeader.Height.Int64() < int64(Option.consensusSize()-int64(len(validatorMap))) {
+		if curHeader.Height.Int64()-d.confirmedBlockHeader.Height.Int64() < int64(d.config.consensusSize()-int64(len(validatorMap))) {
 			log.Debug("Dpos fast return", "current", curHeader.Height.String(), "confirmed", d.confirmedBlockHeader.Height.String(), "witnessCount", len(validatorMap))
 			return nil
 		}
 		validatorMap[curHeader.Miner] = true
-		if int64(len(validatorMap)) >= Option.consensusSize() {
+		if int64(len(validatorMap)) >= d.config.consensusSize() {
 			d.confirmedBlockHeader = curHeader
 			if err := d.storeConfirmedBlockHeader(chain.CurrentBlock()); err != nil {
 				log.Errorf("dpos set confirmed block header success", "currentHeader", d.confirmedBlockHeader.Height, err)
@@ -361,6 +418,29 @@ func (d *Dpos) storeConfirmedBlockHeader(lastBlock *types.Block) error {
 	return d.chainDb.Put(confirmedBlockHead, d.confirmedBlockHeader.Hash().Bytes())
 }
 
+// ResetConfirmedBlockHeader lowers the confirmed block header to the header the chain was
+// rewound to, the confirmations above it are dropped.
+func (d *Dpos) ResetConfirmedBlockHeader(header *types.BlockHeader) error {
+	if d.bftConfirmeds != nil {
+		for _, key := range d.bftConfirmeds.Keys() {
+			if height, ok := d.bftConfirmeds.Peek(key); ok && height.(uint64) > header.Height.Uint64() {
+				d.bftConfirmeds.Remove(key)
+			}
+		}
+	}
+	if d.confirmedBlockHeader != nil && d.confirmedBlockHeader.Height.Cmp(header.Height) <= 0 {
+		return nil
+	}
+	if d.confirmedBlockHeader == nil {
+		// a record of a missing block isn't loaded, it is overwritten as well
+		if data, err := d.chainDb.Get(confirmedBlockHead); err != nil || len(data) == 0 {
+			return err
+		}
+	}
+	d.confirmedBlockHeader = header
+	return d.chainDb.Put(confirmedBlockHead, header.Hash().Bytes())
+}
+
 func (d *Dpos) GetConfirmedBlockNumber() (*big.Int, error) {
 	header := d.confirmedBlockHeader
 	if header == nil {
@@ -371,6 +451,9 @@ func (d *Dpos) GetConfirmedBlockNumber() (*big.Int, error) {
 
 func (dpos *Dpos) GetBFTConfirmedBlockNumber() (*big.Int, error) {
 	irreversibles := UInt64Slice{}
+	if dpos.bftConfirmeds == nil {
+		return big.NewInt(0), nil
+	}
 	keys := dpos.bftConfirmeds.Keys()
 	for _, key := range keys {
 		if irreversible, ok := dpos.bftConfirmeds.Get(key); ok {
@@ -389,7 +472,7 @@ func (dpos *Dpos) GetBFTConfirmedBlockNumber() (*big.Int, error) {
 }
 
 func (d *Dpos) EpchoBlockHeader(chain consensus.IChainReader, timestamp int64, lastBlock *types.Block) *types.BlockHeader {
-	timestamp = timestamp - Option.DelayEpcho*Option.epochInterval()
+	timestamp = timestamp - d.config.DelayEpcho*d.config.epochInterval()
 	header := lastBlock.BlockHeader()
 	for {
 		if header.TimeStamp.Int64() < timestamp || header.Height.Uint64() == 0 {
@@ -402,15 +485,15 @@ func (d *Dpos) EpchoBlockHeader(chain consensus.IChainReader, timestamp int64, l
 }
 
 func (d *Dpos) CheckValidator(chain consensus.IChainReader, lastBlock *types.Block, coinbase utils.Address, now int64) error {
-	prevSlot := PrevSlot(now)
-	nextSlot := NextSlot(now)
+	prevSlot := d.config.PrevSlot(now)
+	nextSlot := d.config.NextSlot(now)
 	if lastBlock.Time().Int64() >= nextSlot {
 		return ErrMintFutureBlock
 	}
-	if lastBlock.Time().Int64() != prevSlot && nextSlot-now >= 5*Option.BlockInterval/10 {
+	if lastBlock.Time().Int64() != prevSlot && nextSlot-now >= 5*d.config.BlockInterval/10 {
 		return ErrWaitForPrevBlock
 	}
-	if now%Option.BlockInterval != 0 {
+	if now%d.config.BlockInterval != 0 {
 		return ErrInvalidMintBlockTime
 	}
 
@@ -423,7 +506,7 @@ func (d *Dpos) CheckValidator(chain consensus.IChainReader, lastBlock *types.Blo
 	if err != nil {
 		return err
 	}
-	epochContext := &EpochContext{DposContext: dposContext}
+	epochContext := d.epochContext(dposContext, nil, 0)
 	validator, err := epochContext.lookupValidator(now)
 	if err != nil {
 		return err
@@ -435,21 +518,12 @@ func (d *Dpos) CheckValidator(chain consensus.IChainReader, lastBlock *types.Blo
 }
 
 func (d *Dpos) Finalize(chain consensus.IChainReader, header *types.BlockHeader, state *state.StateDB, txs []*types.Transaction, actions []*types.Action, receipts []*types.Receipt, dposContext *types.DposContext) (*types.Block, error) {
-
-	epochContext := &EpochContext{
-		Statedb:     state,
-		DposContext: dposContext,
-		TimeStamp:   header.TimeStamp.Int64(),
-	}
+	d.loadTimeOfFirstBlock(chain)
+	epochContext := d.epochContext(dposContext, state, header.TimeStamp.Int64())
 	parent := chain.GetBlockByHash(header.PreviousHash)
-	if timeOfFirstBlock == 0 {
-		if firstBlock := chain.GetBlockByHeight(1); firstBlock != nil {
-			timeOfFirstBlock = firstBlock.BlockHeader().TimeStamp.Int64()
-		}
-	}
 
 	//update mint count trie
-	updateMintCnt(parent.BlockHeader().TimeStamp.Int64(), header.TimeStamp.Int64(), header.Miner, dposContext)
+	d.config.updateMintCnt(parent.BlockHeader().TimeStamp.Int64(), header.TimeStamp.Int64(), header.Miner, dposContext)
 
 	// Accumulate block rewards and commit the final state root
 	state.AddBalance(header.Miner, params.BlockReward)
@@ -472,6 +546,26 @@ func (d *Dpos) Finalize(chain consensus.IChainReader, header *types.BlockHeader,
 	return types.NewBlock(header, txs, actions, receipts), nil
 }
 
+// loadTimeOfFirstBlock caches the time of the first block of the chain once it exists.
+func (d *Dpos) loadTimeOfFirstBlock(chain consensus.IChainReader) {
+	if atomic.LoadInt64(&d.timeOfFirstBlock) == 0 {
+		if firstBlock := chain.GetBlockByHeight(1); firstBlock != nil {
+			atomic.StoreInt64(&d.timeOfFirstBlock, firstBlock.BlockHeader().TimeStamp.Int64())
+		}
+	}
+}
+
+// epochContext returns the context of the epoch at the timestamp under the parameters of the chain.
+func (d *Dpos) epochContext(dposContext *types.DposContext, statedb *state.StateDB, timestamp int64) *EpochContext {
+	return &EpochContext{
+		TimeStamp:        timestamp,
+		DposContext:      dposContext,
+		Statedb:          statedb,
+		config:           d.config,
+		timeOfFirstBlock: atomic.LoadInt64(&d.timeOfFirstBlock),
+	}
+}
+
 // UInt64Slice attaches the methods of sort.Interface to []uint64, sorting in increasing order.
 type UInt64Slice []uint64
 
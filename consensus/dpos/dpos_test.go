@@ -0,0 +1,108 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package dpos
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/UranusBlockStack/uranus/consensus"
+	"github.com/UranusBlockStack/uranus/core/types"
+	"github.com/UranusBlockStack/uranus/params"
+)
+
+// firstBlockChain is a chain reader knowing the first block only.
+type firstBlockChain struct {
+	consensus.IChainReader
+	first *types.Block
+}
+
+func (c *firstBlockChain) GetBlockByHeight(height uint64) *types.Block {
+	if height == 1 {
+		return c.first
+	}
+	return nil
+}
+
+func newFirstBlockChain(timestamp int64) *firstBlockChain {
+	header := &types.BlockHeader{Height: big.NewInt(1), TimeStamp: big.NewInt(timestamp)}
+	return &firstBlockChain{first: types.NewBlockWithBlockHeader(header)}
+}
+
+func TestEnginesIndependentTiming(t *testing.T) {
+	var (
+		fast = NewDpos(&params.ChainConfig{BlockInterval: int64(time.Second), BlockRepeat: 1, MaxValidatorSize: 3}, nil, nil, nil, nil, nil)
+		slow = NewDpos(&params.ChainConfig{BlockInterval: int64(3 * time.Second), BlockRepeat: 2, MaxValidatorSize: 5}, nil, nil, nil, nil, nil)
+		now  = int64(10500 * time.Millisecond)
+	)
+	tests := []struct {
+		engine         *Dpos
+		slot, nextSlot int64
+		epoch          int64
+	}{
+		{fast, int64(10 * time.Second), int64(11 * time.Second), int64(3 * time.Second)},
+		{slow, int64(9 * time.Second), int64(12 * time.Second), int64(30 * time.Second)},
+	}
+	for i, test := range tests {
+		config := test.engine.Config()
+		if slot := config.Slot(now); slot != test.slot {
+			t.Errorf("engine %d: slot %v, want %v", i, slot, test.slot)
+		}
+		if next := config.NextSlot(now); next != test.nextSlot {
+			t.Errorf("engine %d: next slot %v, want %v", i, next, test.nextSlot)
+		}
+		if prev := config.PrevSlot(now); prev != test.slot-config.BlockInterval {
+			t.Errorf("engine %d: previous slot %v, want %v", i, prev, test.slot-config.BlockInterval)
+		}
+		if epoch := config.epochInterval(); epoch != test.epoch {
+			t.Errorf("engine %d: epoch interval %v, want %v", i, epoch, test.epoch)
+		}
+	}
+
+	// the time of the first block is cached per engine
+	fast.loadTimeOfFirstBlock(newFirstBlockChain(100))
+	slow.loadTimeOfFirstBlock(newFirstBlockChain(300))
+	fast.loadTimeOfFirstBlock(newFirstBlockChain(500))
+	for i, test := range []struct {
+		engine *Dpos
+		first  int64
+	}{{fast, 100}, {slow, 300}} {
+		ec := test.engine.epochContext(nil, nil, now)
+		if ec.timeOfFirstBlock != test.first {
+			t.Errorf("engine %d: time of the first block %v, want %v", i, ec.timeOfFirstBlock, test.first)
+		}
+		if ec.config != test.engine.Config() {
+			t.Errorf("engine %d: epoch context with the config of another engine", i)
+		}
+	}
+}
+
+func TestEngineWithoutFirstBlock(t *testing.T) {
+	engine := NewDpos(&params.ChainConfig{BlockInterval: int64(time.Second)}, nil, nil, nil, nil, nil)
+	engine.loadTimeOfFirstBlock(&firstBlockChain{})
+	if ec := engine.epochContext(nil, nil, 0); ec.timeOfFirstBlock != 0 {
+		t.Fatalf("time of the first block %v before it exists", ec.timeOfFirstBlock)
+	}
+	engine.loadTimeOfFirstBlock(newFirstBlockChain(100))
+	if ec := engine.epochContext(nil, nil, 0); ec.timeOfFirstBlock != 100 {
+		t.Fatalf("time of the first block %v, want 100", ec.timeOfFirstBlock)
+	}
+	if engine.Config().DelayEpcho != defaultDelayEpcho {
+		t.Fatalf("delay epochs %v, want the default %v", engine.Config().DelayEpcho, defaultDelayEpcho)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package dpos
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/UranusBlockStack/uranus/common/mtp"
+	"github.com/UranusBlockStack/uranus/common/rlp"
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/core/state"
+	"github.com/UranusBlockStack/uranus/core/types"
+)
+
+// CandidateDump is a candidate with its delegators.
+type CandidateDump struct {
+	Address     utils.Address   `json:"address"`
+	Weight      uint64          `json:"weight"`
+	DegradeTime uint64          `json:"degradeTime"`
+	Delegators  []utils.Address `json:"delegators"`
+}
+
+// VoteDump is the vote of a delegator with its locked balance.
+type VoteDump struct {
+	Delegator         utils.Address   `json:"delegator"`
+	Candidates        []utils.Address `json:"candidates"`
+	LockedBalance     *utils.Big      `json:"lockedBalance"`
+	DelegateTimestamp *utils.Big      `json:"delegateTimestamp"`
+}
+
+// MintCntDump is the count of the blocks minted by a validator during an epoch.
+type MintCntDump struct {
+	Epoch     uint64        `json:"epoch"`
+	Validator utils.Address `json:"validator"`
+	Count     uint64        `json:"count"`
+}
+
+// ContextDump is the dpos context of a block.
+type ContextDump struct {
+	Validators []utils.Address  `json:"validators"`
+	Candidates []*CandidateDump `json:"candidates"`
+	Votes      []*VoteDump      `json:"votes"`
+	MintCnts   []*MintCntDump   `json:"mintCnts"`
+}
+
+// DumpContext returns the validators, the candidates, the votes and the mint counts of the
+// dpos context, the locked balances are read from the state of the same block.
+func DumpContext(dposContext *types.DposContext, statedb *state.StateDB) (*ContextDump, error) {
+	validators, err := dposContext.GetValidators()
+	if err != nil {
+		return nil, err
+	}
+	dump := &ContextDump{
+		Validators: validators,
+		Candidates: []*CandidateDump{},
+		Votes:      []*VoteDump{},
+		MintCnts:   []*MintCntDump{},
+	}
+
+	candidates, err := dposContext.GetCandidates()
+	if err != nil {
+		return nil, err
+	}
+	for _, candidate := range candidates {
+		delegators, err := dposContext.GetDelegators(candidate.Addr)
+		if err != nil {
+			return nil, err
+		}
+		dump.Candidates = append(dump.Candidates, &CandidateDump{
+			Address:     candidate.Addr,
+			Weight:      candidate.Weight,
+			DegradeTime: candidate.DegradeTime,
+			Delegators:  delegators,
+		})
+	}
+
+	voteIt := mtp.NewIterator(dposContext.VoteTrie().NodeIterator(nil))
+	for voteIt.Next() {
+		delegator := utils.BytesToAddress(voteIt.Key)
+		vote := &VoteDump{
+			Delegator:         delegator,
+			LockedBalance:     (*utils.Big)(statedb.GetLockedBalance(delegator)),
+			DelegateTimestamp: (*utils.Big)(statedb.GetDelegateTimestamp(delegator)),
+		}
+		if err := rlp.DecodeBytes(voteIt.Value, &vote.Candidates); err != nil {
+			return nil, fmt.Errorf("invalid vote of %v: %v", delegator.Hex(), err)
+		}
+		dump.Votes = append(dump.Votes, vote)
+	}
+	if voteIt.Err != nil {
+		return nil, voteIt.Err
+	}
+
+	// the mint count keys end with the epoch followed by the validator address
+	mintCntIt := mtp.NewIterator(dposContext.MintCntTrie().NodeIterator(nil))
+	for mintCntIt.Next() {
+		key, size := mintCntIt.Key, 8+len(utils.Address{})
+		if len(key) < size || len(mintCntIt.Value) != 8 {
+			return nil, fmt.Errorf("invalid mint count %x", key)
+		}
+		key = key[len(key)-size:]
+		dump.MintCnts = append(dump.MintCnts, &MintCntDump{
+			Epoch:     binary.BigEndian.Uint64(key[:8]),
+			Validator: utils.BytesToAddress(key[8:]),
+			Count:     binary.BigEndian.Uint64(mintCntIt.Value),
+		})
+	}
+	return dump, mintCntIt.Err
+}
@@ -21,15 +21,18 @@ type EpochContext struct {
 	TimeStamp   int64
 	DposContext *types.DposContext
 	Statedb     *state.StateDB
+
+	config           *Config
+	timeOfFirstBlock int64
 }
 
 func (ec *EpochContext) lookupValidator(now int64) (validator utils.Address, err error) {
 	validator = utils.Address{}
-	offset := (now - Option.BlockInterval) % Option.epochInterval()
-	// if offset%Option.BlockInterval != 0 {
+	offset := (now - ec.config.BlockInterval) % ec.config.epochInterval()
+	// if offset%ec.config.BlockInterval != 0 {
 	// 	return utils.Address{}, ErrInvalidMintBlockTime
 	// }
-	offset /= Option.BlockInterval * Option.BlockRepeat
+	offset /= ec.config.BlockInterval * ec.config.BlockRepeat
 
 	validators, err := ec.DposContext.GetValidators()
 	if err != nil {
@@ -49,9 +52,9 @@ func (ec *EpochContext) lookupValidator(now int64) (validator utils.Address, err
 }
 
 func (ec *EpochContext) tryElect(genesis, parent *types.BlockHeader) error {
-	genesisEpoch := genesis.TimeStamp.Int64() / Option.epochInterval()
-	prevEpoch := parent.TimeStamp.Int64() / Option.epochInterval()
-	currentEpoch := ec.TimeStamp / Option.epochInterval()
+	genesisEpoch := genesis.TimeStamp.Int64() / ec.config.epochInterval()
+	prevEpoch := parent.TimeStamp.Int64() / ec.config.epochInterval()
+	currentEpoch := ec.TimeStamp / ec.config.epochInterval()
 	prevEpochIsGenesis := prevEpoch == genesisEpoch
 	if prevEpochIsGenesis && prevEpoch < currentEpoch {
 		prevEpoch = currentEpoch - 1
@@ -71,7 +74,7 @@ func (ec *EpochContext) tryElect(genesis, parent *types.BlockHeader) error {
 		if err != nil {
 			return err
 		}
-		if int64(len(votes)) < Option.consensusSize() || total.Cmp(Option.MinStartQuantity) < 0 {
+		if int64(len(votes)) < ec.config.consensusSize() || total.Cmp(ec.config.MinStartQuantity) < 0 {
 			//log.Warn("dpos not activated")
 			return nil
 		}
@@ -80,8 +83,8 @@ func (ec *EpochContext) tryElect(genesis, parent *types.BlockHeader) error {
 			candidates = append(candidates, &sortableAddress{candidate, cnt})
 		}
 		sort.Sort(candidates)
-		if int64(len(candidates)) > Option.MaxValidatorSize {
-			candidates = candidates[:Option.MaxValidatorSize]
+		if int64(len(candidates)) > ec.config.MaxValidatorSize {
+			candidates = candidates[:ec.config.MaxValidatorSize]
 		}
 
 		// shuffle candidates
@@ -97,7 +100,7 @@ func (ec *EpochContext) tryElect(genesis, parent *types.BlockHeader) error {
 		}
 
 		ec.DposContext.SetValidators(sortedValidators)
-		firstEpcho := timeOfFirstBlock / Option.epochInterval()
+		firstEpcho := ec.timeOfFirstBlock / ec.config.epochInterval()
 		log.Infof("Come to new epoch prevEpoch %v nextEpoch %v, validators %v", i-firstEpcho+1, i-firstEpcho+2, sortedValidators)
 
 	}
@@ -156,13 +159,13 @@ func (ec *EpochContext) kickoutValidator(timestamp int64, epoch int64) error {
 		return errors.New("no validator could be kickout")
 	}
 
-	epochDuration := Option.epochInterval()
+	epochDuration := ec.config.epochInterval()
 	// First epoch duration may lt epoch interval,
 	// while the first block time wouldn't always align with epoch interval,
 	// so caculate the first epoch duartion with first block time instead of epoch interval,
 	// prevent the validators were kickout incorrectly.
-	if ec.TimeStamp-timeOfFirstBlock < Option.epochInterval() {
-		epochDuration = ec.TimeStamp - timeOfFirstBlock
+	if ec.TimeStamp-ec.timeOfFirstBlock < ec.config.epochInterval() {
+		epochDuration = ec.TimeStamp - ec.timeOfFirstBlock
 	}
 
 	needKickoutValidators := sortableAddresses{}
@@ -186,7 +189,7 @@ func (ec *EpochContext) kickoutValidator(timestamp int64, epoch int64) error {
 		if err := rlp.DecodeBytes(candidate, candidateInfo); err != nil {
 			return err
 		}
-		if cnt < epochDuration/Option.BlockInterval/Option.MaxValidatorSize/2 {
+		if cnt < epochDuration/ec.config.BlockInterval/ec.config.MaxValidatorSize/2 {
 			if candidateInfo.Weight > 10 {
 				candidateInfo.Weight -= 10
 				candidateInfo.DegradeTime = uint64(timestamp)
@@ -216,14 +219,14 @@ func (ec *EpochContext) kickoutValidator(timestamp int64, epoch int64) error {
 	iter := mtp.NewIterator(ec.DposContext.CandidateTrie().NodeIterator(nil))
 	for iter.Next() {
 		candidateCount++
-		if candidateCount >= needKickoutValidatorCnt+Option.consensusSize() {
+		if candidateCount >= needKickoutValidatorCnt+ec.config.consensusSize() {
 			break
 		}
 	}
 
 	for i, validator := range needKickoutValidators {
-		// ensure candidate count greater than or equal to Option.consensusSize()
-		if candidateCount <= Option.consensusSize() {
+		// ensure candidate count greater than or equal to ec.config.consensusSize()
+		if candidateCount <= ec.config.consensusSize() {
 			log.Info("No more candidate can be kickout", "prevEpochID", epoch, "candidateCount", candidateCount, "needKickoutCount", len(needKickoutValidators)-i)
 			return nil
 		}
@@ -25,13 +25,13 @@ import (
 
 	"github.com/UranusBlockStack/uranus/common/db"
 	"github.com/UranusBlockStack/uranus/common/log"
+	"github.com/UranusBlockStack/uranus/common/mclock"
 	"github.com/UranusBlockStack/uranus/common/mtp"
 	"github.com/UranusBlockStack/uranus/common/utils"
 	"github.com/UranusBlockStack/uranus/consensus"
 	"github.com/UranusBlockStack/uranus/consensus/dpos"
 	"github.com/UranusBlockStack/uranus/core/types"
 	"github.com/UranusBlockStack/uranus/feed"
-	"github.com/UranusBlockStack/uranus/node/protocols"
 	"github.com/UranusBlockStack/uranus/params"
 )
 
@@ -56,12 +56,17 @@ type UMiner struct {
 	currentWork *Work
 	engine      consensus.Engine
 	config      *params.ChainConfig
+	clock       mclock.Clock
 
 	mux *feed.TypeMux
 }
 
 func NewUranusMiner(mux *feed.TypeMux, config *params.ChainConfig, minerCfg *Config, uranus consensus.IUranus, engine consensus.Engine, db db.Database) *UMiner {
 	coinbase := utils.HexToAddress(minerCfg.CoinBaseAddr)
+	var clock mclock.Clock = mclock.System{}
+	if dpos, ok := engine.(*dpos.Dpos); ok && dpos.Clock() != nil {
+		clock = dpos.Clock() // blocks are minted on the slots of the engine clock
+	}
 	uminer := &UMiner{
 		mux:       mux,
 		config:    config,
@@ -73,25 +78,26 @@ func NewUranusMiner(mux *feed.TypeMux, config *params.ChainConfig, minerCfg *Con
 		coinbase:  coinbase,
 		engine:    engine,
 		db:        db,
+		clock:     clock,
 	}
 	go uminer.loop()
 	return uminer
 }
 
 func (m *UMiner) loop() {
-	events := m.mux.Subscribe(protocols.StartEvent{}, protocols.DoneEvent{}, protocols.FailedEvent{})
+	events := m.mux.Subscribe(feed.SyncStartEvent{}, feed.SyncDoneEvent{}, feed.SyncFailedEvent{})
 	minning := int32(0)
 out:
 	for ev := range events.Chan() {
 		switch ev.Data.(type) {
-		case protocols.StartEvent:
+		case feed.SyncStartEvent:
 			atomic.StoreInt32(&m.canStart, 0)
 			minning = atomic.LoadInt32(&m.mining)
 			if minning == 1 {
 				log.Warnf("Mining operation maybe aborted due to sync operation")
 				m.Stop()
 			}
-		case protocols.DoneEvent, protocols.FailedEvent:
+		case feed.SyncDoneEvent, feed.SyncFailedEvent:
 			atomic.StoreInt32(&m.canStart, 1)
 			if minning == 1 {
 				log.Warnf("Mining operation maybe start due to sync done or sync failed")
@@ -174,8 +180,8 @@ out:
 					acc, _ := tx.Sender(m.currentWork.signer)
 					txs[acc] = append(txs[acc], tx)
 				}
-				txset := types.NewTransactionsByPriceAndNonce(m.currentWork.signer, txs)
-				m.currentWork.applyTransactions(m.uranus, txset, time.Now().Add(time.Minute).UnixNano())
+				txset := types.NewTransactionsByPriceAndNonce(m.currentWork.signer, txs, m.currentWork.Block.BaseFee())
+				m.currentWork.applyTransactions(m.uranus, txset, m.clock.Now().Add(time.Minute).UnixNano())
 			}
 		case <-chainBlockSub.Err():
 			break out
@@ -229,23 +235,22 @@ func calcGasLimit(parent *types.Block) uint64 {
 	return limit
 }
 
+// mintLoop tries to mint a block at every slot boundary of the clock.
 func (m *UMiner) mintLoop() {
 	defer m.wg.Done()
-	ticker := time.NewTicker(time.Second)
-	if _, ok := m.engine.(*dpos.Dpos); !ok {
-		ticker.Stop()
-	} else {
-		ticker.Stop()
-		time.Sleep(time.Duration(dpos.Option.BlockInterval - int64(time.Now().UnixNano())%dpos.Option.BlockInterval))
-		ticker = time.NewTicker(time.Duration(dpos.Option.BlockInterval))
-		defer ticker.Stop()
+	engine, ok := m.engine.(*dpos.Dpos)
+	if !ok {
+		<-m.stopCh
+		return
 	}
 
+	config := engine.Config()
 	for {
+		interval := config.BlockInterval
 		select {
-		case now := <-ticker.C:
-			timestamp := dpos.NextSlot(now.UnixNano())
-			if err := m.engine.(*dpos.Dpos).CheckValidator(m.uranus, m.uranus.CurrentBlock(), m.coinbase, timestamp); err != nil {
+		case now := <-m.clock.After(time.Duration(interval - m.clock.Now().UnixNano()%interval)):
+			timestamp := config.NextSlot(now.UnixNano())
+			if err := engine.CheckValidator(m.uranus, m.uranus.CurrentBlock(), m.coinbase, timestamp); err != nil {
 				switch err {
 				case dpos.ErrWaitForPrevBlock,
 					dpos.ErrMintFutureBlock,
@@ -271,6 +276,15 @@ func (m *UMiner) mintLoop() {
 	}
 }
 
+// blockInterval returns the slot length of the engine, the one of the chain config for
+// the engines without slots.
+func (m *UMiner) blockInterval() int64 {
+	if engine, ok := m.engine.(*dpos.Dpos); ok {
+		return engine.Config().BlockInterval
+	}
+	return m.config.BlockInterval
+}
+
 func (m *UMiner) mintBlock(timestamp int64) {
 outer:
 	for {
@@ -286,7 +300,7 @@ outer:
 		if _, ok := err.(*mtp.MissingNodeError); !ok {
 			log.Errorf("Failed to mint the block, err %v", err)
 		}
-		time.Sleep(time.Duration(dpos.Option.BlockInterval / 10))
+		m.clock.Sleep(time.Duration(m.blockInterval() / 10))
 	}
 }
 
@@ -306,6 +320,9 @@ func (m *UMiner) generateBlock(timestamp int64) error {
 		Difficulty:   difficult,
 		ExtraData:    m.extraData,
 	}
+	if m.uranus.Config().IsBaseFee(header.Height) {
+		header.BaseFee = types.CalcBaseFee(parent.BlockHeader())
+	}
 	var dposContext *types.DposContext = nil
 	if _, ok := m.engine.(*dpos.Dpos); ok {
 		var err error
@@ -314,7 +331,7 @@ func (m *UMiner) generateBlock(timestamp int64) error {
 			return err
 		}
 	}
-	m.currentWork = NewWork(types.NewBlockWithBlockHeader(header), parent.Height().Uint64(), stateDB, dposContext)
+	m.currentWork = NewWork(types.NewBlockWithBlockHeader(header), parent.Height().Uint64(), stateDB, dposContext, m.clock)
 
 	actions := m.uranus.Actions()
 
@@ -325,8 +342,8 @@ func (m *UMiner) generateBlock(timestamp int64) error {
 		return fmt.Errorf("Failed to fetch pending transactions, err: %s", err.Error())
 	}
 
-	txs := types.NewTransactionsByPriceAndNonce(m.currentWork.signer, pending)
-	interval := dpos.Option.BlockInterval
+	txs := types.NewTransactionsByPriceAndNonce(m.currentWork.signer, pending, header.BaseFee)
+	interval := m.blockInterval()
 	err = m.currentWork.applyTransactions(m.uranus, txs, timestamp+interval-interval/10)
 	if err != nil {
 		return fmt.Errorf("failed to apply transaction %s", err)
@@ -17,9 +17,8 @@
 package miner
 
 import (
-	"time"
-
 	"github.com/UranusBlockStack/uranus/common/log"
+	"github.com/UranusBlockStack/uranus/common/mclock"
 	"github.com/UranusBlockStack/uranus/common/utils"
 	"github.com/UranusBlockStack/uranus/consensus"
 	"github.com/UranusBlockStack/uranus/core/executor"
@@ -43,9 +42,10 @@ type Work struct {
 	tcount   int // tx count in cycle
 
 	dposContext *types.DposContext
+	clock       mclock.Clock
 }
 
-func NewWork(blk *types.Block, height uint64, state *state.StateDB, dposContext *types.DposContext) *Work {
+func NewWork(blk *types.Block, height uint64, state *state.StateDB, dposContext *types.DposContext, clock mclock.Clock) *Work {
 	return &Work{
 		Block:       blk,
 		Height:      height,
@@ -53,6 +53,7 @@ func NewWork(blk *types.Block, height uint64, state *state.StateDB, dposContext
 		gasUsed:     new(uint64),
 		signer:      types.Signer{},
 		dposContext: dposContext,
+		clock:       clock,
 	}
 }
 
@@ -73,7 +74,7 @@ func (w *Work) applyTransactions(blockchain consensus.IBlockChain, txs *types.Tr
 			break
 		}
 
-		if time.Now().UnixNano() > timestamp {
+		if w.clock.Now().UnixNano() > timestamp {
 			log.Warn("Not enough time for further transactions")
 			break
 		}
@@ -114,6 +115,11 @@ func (w *Work) applyTransactions(blockchain consensus.IBlockChain, txs *types.Tr
 			log.Debugf("Skipping account with hight nonce sender: %v, nonce: %v", from, tx.Nonce())
 			txs.Pop()
 
+		case executor.ErrFeeCapTooLow, executor.ErrTxTypeNotSupported:
+			// Transaction can't pay the base fee and later nonces depend on it, skip account
+			log.Debugf("Skipping account with underpriced transaction sender: %v, hash: %v", from, tx.Hash())
+			txs.Pop()
+
 		case nil:
 			// Everything ok, collect the logs and shift in the next transaction from the same account
 			coalescedLogs = append(coalescedLogs, logs...)
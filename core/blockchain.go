@@ -17,6 +17,7 @@
 package core
 
 import (
+	"errors"
 	"fmt"
 	"math/big"
 	"math/rand"
@@ -62,20 +63,38 @@ type BlockChain struct {
 	sideBlockFeed       feed.Feed
 	SideBlockscription  feed.Subscription
 
-	executor *exec.Executor
-	engine   consensus.Engine
+	executor   *exec.Executor
+	engine     consensus.Engine
+	verifySeal bool
 
 	chainmu sync.RWMutex
 	quit    chan struct{} // blockchain quit channel
+	wg      sync.WaitGroup
 }
 
+const (
+	freezerRecheckInterval = time.Minute      // interval between the freezer runs
+	indexLogInterval       = 10 * time.Second // interval between the address index progress logs
+	maxSnapshotLayers      = 128              // blocks of the flat state kept in memory at most
+)
+
+var (
+	// ErrBelowConfirmed is returned rewinding the chain below the confirmed height without
+	// forcing it.
+	ErrBelowConfirmed  = errors.New("rewind below the confirmed height")
+	errRewindAboveHead = errors.New("rewind height is not below the head")
+)
+
 // NewBlockChain returns a fully initialised block chain using information available in the database.
 func NewBlockChain(cfg *ledger.Config, chainCfg *params.ChainConfig, statedb state.Database, db db.Database, engine consensus.Engine, vmCfg *vm.Config) (*BlockChain, error) {
 	stateCache := statedb
-	ledger := ledger.New(cfg, db, func(hash utils.Hash) bool {
+	ledger, err := ledger.New(cfg, db, func(hash utils.Hash) bool {
 		_, err := stateCache.OpenTrie(hash)
 		return err == nil
 	})
+	if err != nil {
+		return nil, err
+	}
 	bc := &BlockChain{
 		config:     chainCfg,
 		vmConfig:   vmCfg,
@@ -83,16 +102,26 @@ func NewBlockChain(cfg *ledger.Config, chainCfg *params.ChainConfig, statedb sta
 		Ledger:     ledger,
 		validator:  blockValidator.New(ledger, engine),
 		engine:     engine,
+		verifySeal: true,
 		quit:       make(chan struct{}),
 	}
 	bc.executor = exec.NewExecutor(chainCfg, ledger, bc, engine)
 
 	// check chain before blockchian service start.
 	if err := bc.preCheck(); err != nil {
+		ledger.Close()
 		return nil, err
 	}
+	if cfg != nil && cfg.Snapshot {
+		stateCache.EnableSnapshots(bc.CurrentBlock().StateRoot())
+	}
 
+	bc.wg.Add(1)
 	go bc.loop()
+	if cfg != nil && cfg.AddressIndex {
+		bc.wg.Add(1)
+		go bc.indexAddresses()
+	}
 	return bc, nil
 }
 
@@ -100,17 +129,25 @@ func (bc *BlockChain) SetAddActionInterface(tp exec.ITxPool) {
 	bc.executor.SetTxPool(tp)
 }
 
+// SetVerifySeal sets whether the seals of the inserted blocks are verified, it is only
+// turned off to import trusted archives.
+func (bc *BlockChain) SetVerifySeal(verify bool) {
+	bc.verifySeal = verify
+}
+
 func (bc *BlockChain) preCheck() error {
 	bc.genesisBlock = bc.GetBlockByHeight(0)
 	if bc.genesisBlock == nil {
 		return ledger.ErrNoGenesis
 	}
-	bc.loadLastState()
-	return nil
+	return bc.loadLastState()
 }
 
-func (bc *BlockChain) loadLastState() {
-	currentBlock := bc.CheckLastBlock(bc.genesisBlock)
+func (bc *BlockChain) loadLastState() error {
+	currentBlock, err := bc.CheckLastBlock(bc.genesisBlock)
+	if err != nil {
+		return err
+	}
 Head:
 	if _, err := state.New(currentBlock.StateRoot(), bc.stateCache); err != nil {
 		log.Warnf("Head state missing, repairing chain height: %v,hash: %v", currentBlock.Height(), currentBlock.Hash())
@@ -121,30 +158,193 @@ Head:
 	bc.currentBlock.Store(currentBlock)
 	blockTd := bc.GetTd(currentBlock.Hash())
 	log.Infof("Loaded most recent local full block number: %v,hash: %v,td: %v", currentBlock.Height(), currentBlock.Hash(), blockTd)
-	return
+	return nil
 }
 
 func (bc *BlockChain) loop() {
+	defer bc.wg.Done()
 	futureTimer := time.NewTicker(5 * time.Second)
 	defer futureTimer.Stop()
+	freezeTimer := time.NewTicker(freezerRecheckInterval)
+	defer freezeTimer.Stop()
 	for {
 		select {
 		case <-futureTimer.C:
 			bc.processBlocks()
+		case <-freezeTimer.C:
+			confirmed, ok := bc.confirmedHeight()
+			if ok {
+				bc.freeze(confirmed)
+			}
+			bc.flattenSnapshots(confirmed)
 		case <-bc.quit:
-			bc.chainBlockscription.Unsubscribe()
 			log.Info("blockchain service stop.")
 			return
 		}
 	}
 }
 
+// indexAddresses backfills the address index with the canonical blocks up to the head
+// batch by batch, the blocks written meanwhile are indexed as they are written.
+func (bc *BlockChain) indexAddresses() {
+	defer bc.wg.Done()
+	start, logged := time.Now(), time.Now()
+	for {
+		bc.chainmu.Lock()
+		head := bc.CurrentBlock().Height().Uint64()
+		done, err := bc.IndexAddresses(head)
+		bc.chainmu.Unlock()
+		if err != nil {
+			log.Errorf("Failed to index addresses err: %v", err)
+			return
+		}
+		if done {
+			log.Infof("Address index complete head: %v, elapsed: %v", head, time.Since(start))
+			return
+		}
+		if time.Since(logged) > indexLogInterval {
+			log.Infof("Indexing addresses head: %v, elapsed: %v", head, time.Since(start))
+			logged = time.Now()
+		}
+		select {
+		case <-bc.quit:
+			return
+		default:
+		}
+	}
+}
+
+// confirmedHeight returns the height of the last block confirmed by the engine, false if
+// the engine doesn't confirm the blocks.
+func (bc *BlockChain) confirmedHeight() (uint64, bool) {
+	confirmer, ok := bc.engine.(interface {
+		GetConfirmedBlockNumber() (*big.Int, error)
+	})
+	if !ok {
+		return 0, false
+	}
+	confirmed, err := confirmer.GetConfirmedBlockNumber()
+	if err != nil || confirmed == nil {
+		return 0, false
+	}
+	return confirmed.Uint64(), true
+}
+
+// irreversibleHeight returns the height the chain can't be rewound below, the BFT
+// confirmed height of the engine or the confirmed header it recorded.
+func (bc *BlockChain) irreversibleHeight() uint64 {
+	confirmer, ok := bc.engine.(interface {
+		GetBFTConfirmedBlockNumber() (*big.Int, error)
+	})
+	if ok {
+		if confirmed, err := confirmer.GetBFTConfirmedBlockNumber(); err == nil && confirmed != nil && confirmed.Sign() > 0 {
+			return confirmed.Uint64()
+		}
+	}
+	confirmed, _ := bc.confirmedHeight()
+	return confirmed
+}
+
+// SetHead rewinds the canonical chain to the block of the height, the later blocks are
+// deleted with their transaction lookups and receipts. It refuses to rewind below the
+// confirmed height unless forced, and returns the transactions of the dropped blocks.
+func (bc *BlockChain) SetHead(height uint64, force bool) (types.Transactions, error) {
+	target, txs, err := bc.setHead(height, force)
+	if err != nil {
+		return nil, err
+	}
+	// the miner and the txpool move to the new head
+	bc.PostEvent(feed.BlockAndLogsEvent{Block: target})
+	return txs, nil
+}
+
+func (bc *BlockChain) setHead(height uint64, force bool) (*types.Block, types.Transactions, error) {
+	bc.chainmu.Lock()
+	defer bc.chainmu.Unlock()
+
+	head := bc.CurrentBlock()
+	if height >= head.Height().Uint64() {
+		return nil, nil, fmt.Errorf("%v: height %d, head %d", errRewindAboveHead, height, head.Height())
+	}
+	if confirmed := bc.irreversibleHeight(); height < confirmed && !force {
+		return nil, nil, fmt.Errorf("%v: height %d, confirmed %d", ErrBelowConfirmed, height, confirmed)
+	}
+	target := bc.GetBlockByHeight(height)
+	if target == nil {
+		return nil, nil, fmt.Errorf("missing block height: %d", height)
+	}
+	if _, err := bc.stateCache.OpenTrie(target.StateRoot()); err != nil {
+		return nil, nil, fmt.Errorf("missing state height: %d, err: %v", height, err)
+	}
+
+	var dropped types.Blocks
+	for block := head; block != nil && block.Height().Uint64() > height; block = bc.GetBlock(block.PreviousHash()) {
+		dropped = append(dropped, block)
+	}
+	var txs types.Transactions
+	for i := len(dropped) - 1; i >= 0; i-- {
+		txs = append(txs, dropped[i].Transactions()...)
+	}
+
+	if err := bc.RewindChain(height + 1); err != nil {
+		return nil, nil, err
+	}
+	bc.currentBlock.Store(target)
+	if resetter, ok := bc.engine.(interface {
+		ResetConfirmedBlockHeader(*types.BlockHeader) error
+	}); ok {
+		if err := resetter.ResetConfirmedBlockHeader(target.BlockHeader()); err != nil {
+			return nil, nil, err
+		}
+	}
+	if snaps := bc.stateCache.Snapshots(); snaps != nil && !snaps.Has(target.StateRoot()) {
+		log.Warnf("State snapshot missing, regenerating height: %v, hash: %v", target.Height(), target.Hash())
+		snaps.Rebuild(target.StateRoot())
+	}
+	log.Warnf("Chain rewound height: %v, hash: %v, dropped blocks: %v, txs: %v", height, target.Hash(), len(dropped), len(txs))
+	return target, txs, nil
+}
+
+// freeze moves the blocks confirmed by the engine for long enough into the freezer.
+func (bc *BlockChain) freeze(confirmed uint64) {
+	if err := bc.Freeze(confirmed); err != nil {
+		log.Errorf("Failed to freeze blocks err: %v", err)
+	}
+}
+
+// flattenSnapshots writes the flat state of the canonical blocks up to the confirmed
+// height into the database, at most maxSnapshotLayers blocks are kept in memory.
+func (bc *BlockChain) flattenSnapshots(confirmed uint64) {
+	snaps := bc.stateCache.Snapshots()
+	if snaps == nil {
+		return
+	}
+	if head := bc.CurrentBlock().Height().Uint64(); head > confirmed+maxSnapshotLayers {
+		confirmed = head - maxSnapshotLayers
+	}
+	block := bc.GetBlockByHeight(confirmed)
+	if block == nil || !snaps.Has(block.StateRoot()) {
+		return
+	}
+	if err := snaps.Flatten(block.StateRoot()); err != nil {
+		log.Errorf("Failed to flatten state snapshot height: %v, err: %v", confirmed, err)
+	}
+}
+
 // Stop stops the blockchain service.
 func (bc *BlockChain) Stop() {
 	if bc.chainBlockscription != nil {
 		bc.chainBlockscription.Unsubscribe()
 	}
 	close(bc.quit)
+	bc.wg.Wait()
+	if snaps := bc.stateCache.Snapshots(); snaps != nil {
+		snaps.Close()
+	}
+	bc.flattenSnapshots(bc.CurrentBlock().Height().Uint64())
+	if err := bc.Ledger.Close(); err != nil {
+		log.Errorf("Failed to close the freezer err: %v", err)
+	}
 	log.Info("Blockchain manager stopped")
 }
 
@@ -202,7 +402,7 @@ func (bc *BlockChain) InsertChain(blocks types.Blocks) (int, error) {
 }
 
 func (bc *BlockChain) insertChain(block *types.Block) (interface{}, []*types.Log, error) {
-	err := bc.validator.ValidateHeader(bc, block.BlockHeader(), true)
+	err := bc.validator.ValidateHeader(bc, block.BlockHeader(), bc.verifySeal)
 	if err == nil {
 		err = bc.validator.ValidateTxs(block)
 	}
@@ -348,12 +548,22 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts types.Rec
 	}
 
 	bc.WriteBlockAndReceipts(block, receipts)
+	if reorg {
+		// the reorg indexes the new chain below the block
+		bc.WriteAddressIndex(block, receipts)
+	}
 
 	if !status && reorg {
 		// Set new head.
 		bc.WriteLegitimateHashAndHeadBlockHash(block.Height().Uint64(), block.Hash())
 		bc.currentBlock.Store(block)
 	}
+	if snaps := bc.stateCache.Snapshots(); reorg && snaps != nil && !snaps.Has(block.StateRoot()) {
+		// the parent of the head is below the flat state after a deep reorganisation, the
+		// flat state is generated in the background
+		log.Warnf("State snapshot missing, regenerating height: %v, hash: %v", block.Height(), block.Hash())
+		snaps.Rebuild(block.StateRoot())
+	}
 
 	bc.RemoveFutureBlock(block.Hash())
 	return status, nil
@@ -410,6 +620,9 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 			return fmt.Errorf("Invalid new chain")
 		}
 	}
+	if commonBlock.Height().Uint64()+1 < bc.Ancients() {
+		return fmt.Errorf("%v: reorg from %d", ledger.ErrFrozenBoundary, commonBlock.Height().Uint64())
+	}
 	// Ensure the user sees large reorgs
 	if len(oldChain) > 0 && len(newChain) > 0 {
 		logFn := log.Debugf
@@ -421,10 +634,18 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 		log.Errorf("Impossible reorg, please file an issue oldnum: %v, oldhash: %v, newheight: %v, newhash: %v", oldBlock.Height(), oldBlock.Hash(), newBlock.Height(), newBlock.Hash())
 	}
 
+	// Unwind the address index of the old chain
+	for _, block := range oldChain {
+		bc.DeleteAddressIndex(block)
+	}
 	// Insert the new chain, taking care of the proper incremental order
 	for i := len(newChain) - 1; i >= 0; i-- {
 		bc.WriteLegitimateHashAndHeadBlockHash(newChain[i].Height().Uint64(), newChain[i].Hash())
 		bc.currentBlock.Store(newChain[i])
+		// the first block of the new chain has no receipts yet, its writer indexes it
+		if i > 0 {
+			bc.WriteAddressIndex(newChain[i], bc.GetReceipts(newChain[i].Hash()))
+		}
 	}
 
 	return nil
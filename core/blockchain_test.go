@@ -16,19 +16,182 @@
 
 package core
 
-// func TestTheLastBlock(t *testing.T) {
-// 	cpum := cpuminer.NewCpuMiner()
-// 	_, blockchain, err := newLegitimate(cpum, 0)
-// 	if err != nil {
-// 		t.Fatalf("failed to create pristine chain: %v", err)
-// 	}
-// 	defer blockchain.Stop()
-
-// 	blocks := makeBlocks(blockchain.CurrentBlock(), 1, cpum, blockchain.GetDB(), 0)
-
-// 	if _, err := blockchain.InsertChain(blocks); err != nil {
-// 		t.Fatalf("Failed to insert block: %v", err)
-// 	}
-
-// 	assert.Equal(t, blocks[len(blocks)-1].Hash().Hex(), blockchain.GetHeadBlockHash().Hex())
-// }
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/UranusBlockStack/uranus/common/crypto"
+	"github.com/UranusBlockStack/uranus/common/db"
+	"github.com/UranusBlockStack/uranus/common/mclock"
+	"github.com/UranusBlockStack/uranus/common/rlp"
+	"github.com/UranusBlockStack/uranus/consensus/dpos"
+	"github.com/UranusBlockStack/uranus/core/ledger"
+	"github.com/UranusBlockStack/uranus/core/types"
+	"github.com/UranusBlockStack/uranus/core/vm"
+	"github.com/UranusBlockStack/uranus/feed"
+)
+
+const testChainHeight = 30 // height of the head block of testdata/chain.gz
+
+// testChain is a blockchain of testdata/chain.gz on a memory database.
+type testChain struct {
+	*BlockChain
+	engine  *dpos.Dpos
+	mux     *feed.TypeMux
+	chainDb db.Database
+}
+
+func newTestChain(t *testing.T) *testChain {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", "genesis.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	genesis := new(ledger.Genesis)
+	if err := json.Unmarshal(data, genesis); err != nil {
+		t.Fatalf("invalid genesis: %v", err)
+	}
+	chainDb := db.NewMemDatabase()
+	chainCfg, statedb, _, err := ledger.SetupGenesis(genesis, ledger.NewChain(chainDb))
+	if err != nil {
+		t.Fatalf("failed to setup genesis: %v", err)
+	}
+	mux := new(feed.TypeMux)
+	engine := dpos.NewDpos(chainCfg, mux, chainDb, statedb, nil, mclock.System{})
+	chain, err := NewBlockChain(nil, chainCfg, statedb, chainDb, engine, &vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	engine.Init(chain)
+
+	fh, err := os.Open(filepath.Join("testdata", "chain.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fh.Close()
+	reader, err := gzip.NewReader(fh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var blocks types.Blocks
+	for stream := rlp.NewStream(reader, 0); ; {
+		block := new(types.Block)
+		if err := stream.Decode(block); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("invalid block: %v", err)
+		}
+		if block.Height().Sign() > 0 {
+			blocks = append(blocks, block)
+		}
+	}
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert: %v", err)
+	}
+	return &testChain{BlockChain: chain, engine: engine, mux: mux, chainDb: chainDb}
+}
+
+// confirm posts the BFT confirmations of the block of the height by n validators and
+// waits for the engine to count them.
+func (tc *testChain) confirm(t *testing.T, height uint64, n int) {
+	block := tc.GetBlockByHeight(height)
+	for i := 0; i < n; i++ {
+		key, _ := crypto.GenerateKey()
+		confirmed := types.Confirmed{BlockHash: block.Hash(), BlockHeight: height, Address: crypto.PubkeyToAddress(key.PublicKey)}
+		hash := confirmed.Hash()
+		confirmed.Signature, _ = crypto.Sign(hash[:], key)
+		tc.mux.Post(confirmed)
+	}
+	// the engine takes the confirmations one by one from an unbuffered subscription,
+	// the unsigned one is delivered after the previous ones are counted
+	tc.mux.Post(types.Confirmed{})
+	if bft, _ := tc.engine.GetBFTConfirmedBlockNumber(); bft.Uint64() != height {
+		t.Fatalf("confirmation of #%d not counted, BFT confirmed height %v", height, bft)
+	}
+}
+
+func TestSetHeadConfirmed(t *testing.T) {
+	chain := newTestChain(t)
+	defer chain.Stop()
+	if height := chain.CurrentBlock().Height().Uint64(); height != testChainHeight {
+		t.Fatalf("head height mismatch: have %d, want %d", height, testChainHeight)
+	}
+	chain.confirm(t, 20, 1)
+
+	// the BFT confirmed height overrides the confirmed header of the engine
+	if _, err := chain.SetHead(25, false); err != nil {
+		t.Fatalf("failed to rewind above the BFT confirmed height: %v", err)
+	}
+	if _, err := chain.SetHead(10, false); err == nil || !strings.HasPrefix(err.Error(), ErrBelowConfirmed.Error()) {
+		t.Fatalf("rewind below the BFT confirmed height error mismatch: have %v, want %v", err, ErrBelowConfirmed)
+	}
+	if height := chain.CurrentBlock().Height().Uint64(); height != 25 {
+		t.Fatalf("refused rewind moved the head: have %d, want 25", height)
+	}
+
+	if _, err := chain.SetHead(10, true); err != nil {
+		t.Fatalf("failed to force the rewind: %v", err)
+	}
+	if height := chain.CurrentBlock().Height().Uint64(); height != 10 {
+		t.Fatalf("head height mismatch: have %d, want 10", height)
+	}
+	if chain.GetBlockByHeight(11) != nil {
+		t.Errorf("block above the head kept")
+	}
+	// the confirmations above the head are dropped
+	if bft, _ := chain.engine.GetBFTConfirmedBlockNumber(); bft.Sign() != 0 {
+		t.Errorf("BFT confirmed height kept above the head: %v", bft)
+	}
+}
+
+func TestSetHeadResetConfirmed(t *testing.T) {
+	chain := newTestChain(t)
+	defer chain.Stop()
+
+	confirmed, _ := chain.engine.GetConfirmedBlockNumber()
+	if confirmed.Uint64() <= 10 {
+		t.Fatalf("confirmed height too low: %v", confirmed)
+	}
+	if _, err := chain.SetHead(10, false); err == nil {
+		t.Fatal("rewind below the confirmed header succeeded")
+	}
+	if _, err := chain.SetHead(10, true); err != nil {
+		t.Fatalf("failed to force the rewind: %v", err)
+	}
+	if confirmed, _ := chain.engine.GetConfirmedBlockNumber(); confirmed.Uint64() != 10 {
+		t.Errorf("confirmed height mismatch: have %v, want 10", confirmed)
+	}
+
+	// the lowered confirmed header is read back from the database
+	engine := dpos.NewDpos(chain.Config(), new(feed.TypeMux), chain.chainDb, chain.stateCache, nil, mclock.System{})
+	engine.Init(chain)
+	if confirmed, _ := engine.GetConfirmedBlockNumber(); confirmed.Uint64() != 10 {
+		t.Errorf("stored confirmed height mismatch: have %v, want 10", confirmed)
+	}
+}
+
+func TestSetHeadEvent(t *testing.T) {
+	chain := newTestChain(t)
+	defer chain.Stop()
+
+	events := make(chan feed.BlockAndLogsEvent, 1)
+	sub := chain.SubscribeChainBlockEvent(events)
+	defer sub.Unsubscribe()
+	if _, err := chain.SetHead(25, true); err != nil {
+		t.Fatalf("failed to rewind: %v", err)
+	}
+	select {
+	case ev := <-events:
+		if ev.Block.Hash() != chain.CurrentBlock().Hash() {
+			t.Errorf("head event block mismatch: have #%d, want #25", ev.Block.Height())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no head event after the rewind")
+	}
+}
@@ -26,4 +26,12 @@ var (
 	// ErrNonceTooHigh is returned if the nonce of a transaction is higher than the
 	// next one expected based on the local chain.
 	ErrNonceTooHigh = errors.New("nonce too high")
+
+	// ErrFeeCapTooLow is returned if the transaction fee cap is less than the
+	// the base fee of the block.
+	ErrFeeCapTooLow = errors.New("max fee per gas less than block base fee")
+
+	// ErrTxTypeNotSupported is returned if a dynamic fee transaction is included
+	// before the base fee fork.
+	ErrTxTypeNotSupported = errors.New("dynamic fee transaction not supported before base fee fork")
 )
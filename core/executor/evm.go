@@ -45,7 +45,10 @@ func NewEVMContext(tx *types.Transaction, bheader *types.BlockHeader, ledger *le
 		Time:        new(big.Int).Set(bheader.TimeStamp),
 		Difficulty:  new(big.Int).Set(bheader.Difficulty),
 		GasLimit:    bheader.GasLimit,
-		GasPrice:    new(big.Int).Set(tx.GasPrice()),
+		GasPrice:    tx.EffectiveGasPrice(bheader.BaseFee),
+	}
+	if bheader.BaseFee != nil {
+		vm.BaseFee = new(big.Int).Set(bheader.BaseFee)
 	}
 	vm.GetHash = func(n uint64) utils.Hash {
 		for header := ledger.GetHeader(bheader.PreviousHash); header != nil; header = ledger.GetHeader(header.PreviousHash) {
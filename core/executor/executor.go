@@ -106,6 +106,18 @@ func (e *Executor) ExecTransaction(author *utils.Address,
 		err    error
 	)
 
+	if header.BaseFee == nil && tx.IsDynamicFee() {
+		return nil, 0, ErrTxTypeNotSupported
+	}
+	if header.BaseFee != nil && tx.GasFeeCap().Cmp(header.BaseFee) < 0 {
+		return nil, 0, ErrFeeCapTooLow
+	}
+	if tx.IsEthSigned() {
+		if err := tx.Validate(e.config); err != nil {
+			return nil, 0, err
+		}
+	}
+
 	if tx.Type() == types.Binary {
 
 		// Create a new context to be used in the EVM environment
@@ -119,7 +131,7 @@ func (e *Executor) ExecTransaction(author *utils.Address,
 		}
 	} else {
 		var vmerr error
-		_, gas, failed, vmerr = e.applyDposMessage(header.TimeStamp, dposContext, tx, statedb, gp)
+		_, gas, failed, vmerr = e.applyDposMessage(header, dposContext, tx, statedb, gp)
 		if vmerr == vm.ErrInsufficientBalance {
 			return nil, 0, vmerr
 		}
@@ -142,14 +154,18 @@ func (e *Executor) ExecTransaction(author *utils.Address,
 	return receipt, gas, err
 }
 
-func (e *Executor) applyDposMessage(timestamp *big.Int, dposContext *types.DposContext, tx *types.Transaction, statedb *state.StateDB, gp *utils.GasPool) ([]byte, uint64, bool, error) {
+func (e *Executor) applyDposMessage(header *types.BlockHeader, dposContext *types.DposContext, tx *types.Transaction, statedb *state.StateDB, gp *utils.GasPool) ([]byte, uint64, bool, error) {
+	timestamp := header.TimeStamp
 	gas, _ := txpool.IntrinsicGas(tx.Payload(), false)
 	from, _ := tx.Sender(types.Signer{})
-	feeval := new(big.Int).Mul(new(big.Int).SetUint64(gas), tx.GasPrice())
+	feeval := new(big.Int).Mul(new(big.Int).SetUint64(gas), tx.EffectiveGasPrice(header.BaseFee))
 	if statedb.GetBalance(from).Cmp(feeval) < 0 {
 		return nil, gas, false, errInsufficientBalanceForGas
 	}
 	statedb.SubBalance(from, feeval)
+	if header.BaseFee != nil {
+		payBaseFee(e.config, statedb, new(big.Int).Mul(new(big.Int).SetUint64(gas), header.BaseFee))
+	}
 	statedb.SetNonce(from, tx.Nonce()+1)
 	if err := gp.SubGas(gas); err != nil {
 		return nil, 0, false, err
@@ -203,6 +219,14 @@ func (e *Executor) applyDposMessage(timestamp *big.Int, dposContext *types.DposC
 	return nil, gas, true, nil
 }
 
+// payBaseFee sends the base fee to the treasury in chain config, it is burned when no treasury configured.
+func payBaseFee(config *params.ChainConfig, statedb vm.StateDB, fee *big.Int) {
+	if config == nil || config.FeeTreasury == "" {
+		return
+	}
+	statedb.AddBalance(utils.HexToAddress(config.FeeTreasury), fee)
+}
+
 func (e *Executor) addAction(sender utils.Address, tx *types.Transaction) {
 	a := types.NewAction(tx.Hash(), sender, big.NewInt(time.Now().Unix()), e.chain.Config().DelayDuration)
 	e.tp.AddAction(a)
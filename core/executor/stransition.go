@@ -53,7 +53,7 @@ func NewStateTransition(evm *vm.EVM, tx *types.Transaction, gp *utils.GasPool) *
 		evm:      evm,
 		from:     from,
 		tx:       tx,
-		gasPrice: tx.GasPrice(),
+		gasPrice: new(big.Int).Set(evm.GasPrice),
 		value:    tx.Value(),
 		data:     tx.Payload(),
 		state:    evm.StateDB,
@@ -155,11 +155,24 @@ func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bo
 		}
 	}
 	st.refundGas()
-	st.state.AddBalance(st.evm.Coinbase, new(big.Int).Mul(new(big.Int).SetUint64(st.gasUsed()), st.gasPrice))
+	st.payFee()
 
 	return ret, st.gasUsed(), vmerr != nil, err
 }
 
+// payFee pays the base fee to the treasury (or burns it) and the remaining tip to the coinbase.
+func (st *StateTransition) payFee() {
+	gasUsed := new(big.Int).SetUint64(st.gasUsed())
+	tip := new(big.Int).Set(st.gasPrice)
+	if baseFee := st.evm.BaseFee; baseFee != nil {
+		if tip.Sub(tip, baseFee); tip.Sign() < 0 {
+			tip.SetUint64(0)
+		}
+		payBaseFee(st.evm.ChainConfig(), st.state, new(big.Int).Mul(gasUsed, baseFee))
+	}
+	st.state.AddBalance(st.evm.Coinbase, tip.Mul(tip, gasUsed))
+}
+
 func (st *StateTransition) refundGas() {
 	// Apply refund counter, capped to half of the used gas.
 	refund := st.gasUsed() / 2
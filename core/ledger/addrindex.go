@@ -0,0 +1,246 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package ledger
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/UranusBlockStack/uranus/common/db"
+	"github.com/UranusBlockStack/uranus/common/log"
+	"github.com/UranusBlockStack/uranus/common/rlp"
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/core/types"
+)
+
+const (
+	// MaxAddressTxs is the count of the transactions returned at most by a page of the
+	// address index.
+	MaxAddressTxs = 1000
+
+	addressIndexBatch = 1000 // blocks indexed at most by a batch of the backfill
+
+	addressIndexComplete = ^uint64(0) // backfill progress of the complete index
+)
+
+// AddressTx is a transaction of an address at a position of a block, the position of a
+// dpos action follows the transactions of its block.
+type AddressTx struct {
+	BlockHeight uint64     `json:"blockHeight"`
+	Index       uint64     `json:"index"`
+	TxHash      utils.Hash `json:"txHash"`
+}
+
+// addressIndex returns the entries of the address index of the block, the senders, the
+// recipients and the created contracts of the transactions and the senders of the actions.
+func addressIndex(block *types.Block, receipts types.Receipts) map[string]utils.Hash {
+	var (
+		entries = make(map[string]utils.Hash)
+		height  = block.Height().Uint64()
+		signer  = types.Signer{}
+	)
+	for i, tx := range block.Transactions() {
+		index := uint64(i)
+		if from, err := tx.Sender(signer); err == nil {
+			entries[string(keyAddressTx(from, height, index))] = tx.Hash()
+		}
+		for _, to := range tx.Tos() {
+			if to != nil {
+				entries[string(keyAddressTx(*to, height, index))] = tx.Hash()
+			}
+		}
+		if i < len(receipts) && receipts[i].ContractAddress != (utils.Address{}) {
+			entries[string(keyAddressTx(receipts[i].ContractAddress, height, index))] = tx.Hash()
+		}
+	}
+	for i, action := range block.Actions() {
+		index := uint64(len(block.Transactions()) + i)
+		entries[string(keyAddressTx(action.Sender, height, index))] = action.TxHash
+	}
+	return entries
+}
+
+// WriteAddressIndex indexes the transactions of the canonical block by address if the
+// address index is enabled.
+func (l *Ledger) WriteAddressIndex(block *types.Block, receipts types.Receipts) {
+	if !l.addressIndex {
+		l.resetAddressIndex()
+		return
+	}
+	batch := l.chain.db.NewBatch()
+	for key, hash := range addressIndex(block, receipts) {
+		batch.Put([]byte(key), hash.Bytes())
+	}
+	if err := batch.Write(); err != nil {
+		log.Fatalf("Failed to store address index err: %v", err)
+	}
+}
+
+// DeleteAddressIndex removes the transactions of the block from the address index, the
+// receipts of the block are read before it's deleted.
+func (l *Ledger) DeleteAddressIndex(block *types.Block) {
+	if !l.addressIndex {
+		return
+	}
+	batch := l.chain.db.NewBatch()
+	for key := range addressIndex(block, l.chain.getReceipts(block.Hash())) {
+		batch.Delete([]byte(key))
+	}
+	if err := batch.Write(); err != nil {
+		log.Fatalf("Failed to delete address index err: %v", err)
+	}
+}
+
+// resetAddressIndex forgets the backfill progress once a block is written without the
+// index, the stale entries are cleared and the index is rebuilt when it's enabled again.
+func (l *Ledger) resetAddressIndex() {
+	if l.addressIndexReset {
+		return
+	}
+	if has, _ := l.chain.db.Has(keyAddressIndexTail); has {
+		log.Warn("Address index disabled, it is rebuilt when enabled again")
+		if err := l.chain.db.Delete(keyAddressIndexTail); err != nil {
+			log.Fatalf("Failed to delete address index progress err: %v", err)
+		}
+	}
+	l.addressIndexReset = true
+}
+
+// IndexAddresses backfills the address index with a batch of the canonical blocks up to
+// the head, it returns true once the index is complete. The blocks above the head are
+// indexed as they are written. A backfill without progress starts with clearing the
+// entries left by a previous index, the blocks unwound while it was disabled are among
+// them.
+func (l *Ledger) IndexAddresses(head uint64) (bool, error) {
+	if !l.addressIndex {
+		return true, errAddressIndexDisabled
+	}
+	if has, _ := l.chain.db.Has(keyAddressIndexTail); !has {
+		if err := l.chain.deleteAddressIndex(); err != nil {
+			return false, err
+		}
+	}
+	next := l.chain.getAddressIndexTail()
+	if next == addressIndexComplete {
+		return true, nil
+	}
+	limit := next + addressIndexBatch
+	if limit > head+1 {
+		limit = head + 1
+	}
+	for ; next < limit; next++ {
+		block := l.GetBlockByHeight(next)
+		if block == nil {
+			return false, fmt.Errorf("block #%d missing", next)
+		}
+		l.WriteAddressIndex(block, l.chain.getReceipts(block.Hash()))
+	}
+	if next > head {
+		next = addressIndexComplete
+	}
+	l.chain.putAddressIndexTail(next)
+	return next == addressIndexComplete, nil
+}
+
+// GetTransactionsByAddress returns a page of the transactions of the address from the
+// height, sorted by position. The next page starts at the returned cursor, the cursor
+// overrides the height. The history isn't returned until the backfill is complete.
+func (l *Ledger) GetTransactionsByAddress(addr utils.Address, fromHeight uint64, limit int, cursor []byte) ([]*AddressTx, []byte, error) {
+	if !l.addressIndex {
+		return nil, nil, errAddressIndexDisabled
+	}
+	if next := l.chain.getAddressIndexTail(); next != addressIndexComplete {
+		return nil, nil, fmt.Errorf("%v, indexed below #%d", errAddressIndexIncomplete, next)
+	}
+	if limit <= 0 || limit > MaxAddressTxs {
+		limit = MaxAddressTxs
+	}
+	prefix := append(append([]byte{}, keyAddressTxPrefix...), addr.Bytes()...)
+	start := keyAddressTx(addr, fromHeight, 0)
+	if len(cursor) == 16 {
+		start = append(append([]byte{}, prefix...), cursor...)
+	}
+
+	it := l.chain.db.NewIteratorWithPrefix(prefix)
+	defer it.Release()
+	txs := []*AddressTx{}
+	for ok := it.Seek(start); ok; ok = it.Next() {
+		key := it.Key()[len(prefix):]
+		if len(key) != 16 {
+			continue
+		}
+		if len(txs) == limit {
+			return txs, utils.CopyBytes(key), it.Error()
+		}
+		txs = append(txs, &AddressTx{
+			BlockHeight: binary.BigEndian.Uint64(key[:8]),
+			Index:       binary.BigEndian.Uint64(key[8:]),
+			TxHash:      utils.BytesToHash(it.Value()),
+		})
+	}
+	return txs, nil, it.Error()
+}
+
+// deleteAddressIndex deletes all the entries of the address index, the trie nodes sharing
+// the prefix are told apart by the key length.
+func (c *Chain) deleteAddressIndex() error {
+	it := c.db.NewIteratorWithPrefix(keyAddressTxPrefix)
+	defer it.Release()
+	batch := c.db.NewBatch()
+	size := len(keyAddressTx(utils.Address{}, 0, 0))
+	for it.Next() {
+		if len(it.Key()) != size {
+			continue
+		}
+		if err := batch.Delete(utils.CopyBytes(it.Key())); err != nil {
+			return err
+		}
+		if batch.ValueSize() >= db.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+func (c *Chain) getAddressIndexTail() uint64 {
+	data, _ := c.db.Get(keyAddressIndexTail)
+	if len(data) == 0 {
+		return 0
+	}
+	var next uint64
+	if err := rlp.DecodeBytes(data, &next); err != nil {
+		log.Errorf("Invalid address index progress RLP err: %v", err)
+		return 0
+	}
+	return next
+}
+
+func (c *Chain) putAddressIndexTail(next uint64) {
+	data, err := rlp.EncodeToBytes(next)
+	if err != nil {
+		log.Fatalf("Failed to RLP encode address index progress err: %v", err)
+	}
+	if err := c.db.Put(keyAddressIndexTail, data); err != nil {
+		log.Fatalf("Failed to store address index progress err: %v", err)
+	}
+}
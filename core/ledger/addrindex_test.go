@@ -0,0 +1,185 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package ledger
+
+import (
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/UranusBlockStack/uranus/common/crypto"
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddressIndex(t *testing.T) {
+	dir, ldb := createTestDB(t)
+	defer os.RemoveAll(dir)
+	defer ldb.Close()
+
+	// the genesis and 5 blocks written without the index
+	ledger, err := New(&Config{}, ldb, nil)
+	if err != nil {
+		t.Fatalf("failed to create ledger: %v", err)
+	}
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	to, contract := utils.BytesToAddress([]byte{1}), utils.BytesToAddress([]byte{2})
+	blocks := []*types.Block{types.NewBlock(&types.BlockHeader{Height: big.NewInt(0), Difficulty: big.NewInt(1)}, nil, nil, nil)}
+	for i := 1; i <= 5; i++ {
+		tx := types.NewTransaction(types.Binary, uint64(i), big.NewInt(1), 21000, big.NewInt(1), nil, &to)
+		tx.SignTx(types.Signer{}, key)
+		header := &types.BlockHeader{PreviousHash: blocks[i-1].Hash(), Height: big.NewInt(int64(i)), Difficulty: big.NewInt(1)}
+		blocks = append(blocks, types.NewBlock(header, []*types.Transaction{tx, tx}, nil, nil))
+	}
+	for _, block := range blocks {
+		receipts := types.Receipts{}
+		for range block.Transactions() {
+			receipts = append(receipts, &types.Receipt{ContractAddress: contract, Logs: []*types.Log{}})
+		}
+		ledger.WriteBlockAndTd(block, new(big.Int).Add(block.Height(), big.NewInt(1)))
+		ledger.WriteBlockAndReceipts(block, receipts)
+		ledger.WriteLegitimateHashAndHeadBlockHash(block.Height().Uint64(), block.Hash())
+		ledger.WriteAddressIndex(block, receipts)
+	}
+	_, _, err = ledger.GetTransactionsByAddress(to, 0, 0, nil)
+	assert.Equal(t, errAddressIndexDisabled, err)
+
+	// the index is enabled and backfilled
+	ledger, err = New(&Config{AddressIndex: true}, ldb, nil)
+	if err != nil {
+		t.Fatalf("failed to create ledger: %v", err)
+	}
+	// the history isn't returned before the backfill is complete
+	_, _, err = ledger.GetTransactionsByAddress(to, 0, 0, nil)
+	assert.Contains(t, err.Error(), errAddressIndexIncomplete.Error())
+
+	done, err := ledger.IndexAddresses(5)
+	assert.NoError(t, err)
+	assert.True(t, done)
+	done, err = ledger.IndexAddresses(5)
+	assert.NoError(t, err)
+	assert.True(t, done)
+
+	// pages of 3 transactions of the recipient
+	var (
+		all  []*AddressTx
+		txs  []*AddressTx
+		next []byte
+	)
+	for {
+		txs, next, err = ledger.GetTransactionsByAddress(to, 0, 3, next)
+		assert.NoError(t, err)
+		assert.True(t, len(txs) <= 3)
+		all = append(all, txs...)
+		if next == nil {
+			break
+		}
+	}
+	if assert.Len(t, all, 10) {
+		for i, tx := range all {
+			assert.Equal(t, uint64(i/2+1), tx.BlockHeight)
+			assert.Equal(t, uint64(i%2), tx.Index)
+			assert.Equal(t, blocks[i/2+1].Transactions()[i%2].Hash(), tx.TxHash)
+		}
+	}
+	txs, _, err = ledger.GetTransactionsByAddress(from, 0, 0, nil)
+	assert.NoError(t, err)
+	assert.Len(t, txs, 10)
+	txs, _, err = ledger.GetTransactionsByAddress(contract, 4, 0, nil)
+	assert.NoError(t, err)
+	assert.Len(t, txs, 4)
+
+	// the rewound blocks are removed from the index
+	assert.NoError(t, ledger.RewindChain(3))
+	txs, _, err = ledger.GetTransactionsByAddress(to, 0, 0, nil)
+	assert.NoError(t, err)
+	assert.Len(t, txs, 4)
+	assert.Equal(t, uint64(2), txs[3].BlockHeight)
+}
+
+func TestAddressIndexReenable(t *testing.T) {
+	dir, ldb := createTestDB(t)
+	defer os.RemoveAll(dir)
+	defer ldb.Close()
+
+	key, _ := crypto.GenerateKey()
+	to, other := utils.BytesToAddress([]byte{1}), utils.BytesToAddress([]byte{2})
+	newBlock := func(parent *types.Block, to utils.Address) *types.Block {
+		tx := types.NewTransaction(types.Binary, parent.Height().Uint64(), big.NewInt(1), 21000, big.NewInt(1), nil, &to)
+		tx.SignTx(types.Signer{}, key)
+		header := &types.BlockHeader{PreviousHash: parent.Hash(), Height: new(big.Int).Add(parent.Height(), big.NewInt(1)), Difficulty: big.NewInt(1)}
+		return types.NewBlock(header, []*types.Transaction{tx}, nil, nil)
+	}
+	write := func(ledger *Ledger, block *types.Block) {
+		receipts := types.Receipts{}
+		for range block.Transactions() {
+			receipts = append(receipts, &types.Receipt{Logs: []*types.Log{}})
+		}
+		ledger.WriteBlockAndTd(block, new(big.Int).Add(block.Height(), big.NewInt(1)))
+		ledger.WriteBlockAndReceipts(block, receipts)
+		ledger.WriteLegitimateHashAndHeadBlockHash(block.Height().Uint64(), block.Hash())
+		ledger.WriteAddressIndex(block, receipts)
+	}
+
+	// the genesis and 3 blocks written with the complete index
+	ledger, err := New(&Config{AddressIndex: true}, ldb, nil)
+	if err != nil {
+		t.Fatalf("failed to create ledger: %v", err)
+	}
+	blocks := []*types.Block{types.NewBlock(&types.BlockHeader{Height: big.NewInt(0), Difficulty: big.NewInt(1)}, nil, nil, nil)}
+	for i := 1; i <= 3; i++ {
+		blocks = append(blocks, newBlock(blocks[i-1], to))
+	}
+	for _, block := range blocks {
+		write(ledger, block)
+	}
+	done, err := ledger.IndexAddresses(3)
+	assert.NoError(t, err)
+	assert.True(t, done)
+
+	// the block 3 is reorged while the index is disabled
+	ledger, err = New(&Config{}, ldb, nil)
+	if err != nil {
+		t.Fatalf("failed to create ledger: %v", err)
+	}
+	ledger.DeleteAddressIndex(blocks[3])
+	write(ledger, newBlock(blocks[2], other))
+
+	// the re-enabled index doesn't keep the transaction of the reorged block
+	ledger, err = New(&Config{AddressIndex: true}, ldb, nil)
+	if err != nil {
+		t.Fatalf("failed to create ledger: %v", err)
+	}
+	_, _, err = ledger.GetTransactionsByAddress(to, 0, 0, nil)
+	assert.Error(t, err)
+	done, err = ledger.IndexAddresses(3)
+	assert.NoError(t, err)
+	assert.True(t, done)
+
+	txs, _, err := ledger.GetTransactionsByAddress(to, 0, 0, nil)
+	assert.NoError(t, err)
+	if assert.Len(t, txs, 2) {
+		assert.Equal(t, uint64(2), txs[1].BlockHeight)
+	}
+	txs, _, err = ledger.GetTransactionsByAddress(other, 0, 0, nil)
+	assert.NoError(t, err)
+	if assert.Len(t, txs, 1) {
+		assert.Equal(t, uint64(3), txs[0].BlockHeight)
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package ledger
+
+import (
+	"fmt"
+
+	"github.com/UranusBlockStack/uranus/common/log"
+	"github.com/UranusBlockStack/uranus/common/rlp"
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/core/types"
+)
+
+// txLookup replaces the stored transaction once its block is frozen.
+type txLookup struct {
+	BlockHeight uint64
+	TxIndex     uint64
+}
+
+func (c *Chain) getTxLookup(txHash utils.Hash) *txLookup {
+	data, _ := c.db.Get(keyTxLookup(txHash))
+	if len(data) == 0 {
+		return nil
+	}
+	lookup := new(txLookup)
+	if err := rlp.DecodeBytes(data, lookup); err != nil {
+		log.Errorf("Invalid transaction lookup RLP hash: %v, err: %v", txHash, err)
+		return nil
+	}
+	return lookup
+}
+
+func (c *Chain) putTxLookup(txHash utils.Hash, lookup *txLookup) {
+	data, err := rlp.EncodeToBytes(lookup)
+	if err != nil {
+		log.Fatalf("Failed to RLP encode transaction lookup err: %v", err)
+	}
+	if err := c.db.Put(keyTxLookup(txHash), data); err != nil {
+		log.Fatalf("Failed to store transaction lookup err: %v", err)
+	}
+}
+
+// Ancients returns the count of the frozen blocks.
+func (c *Chain) Ancients() uint64 {
+	if c.ancients == nil {
+		return 0
+	}
+	return c.ancients.Ancients()
+}
+
+// frozenHeight returns the height of the block if it is frozen.
+func (c *Chain) frozenHeight(blockHash utils.Hash) (uint64, bool) {
+	if c.ancients == nil {
+		return 0, false
+	}
+	height := c.getHeaderHeight(blockHash)
+	if height == nil || *height >= c.ancients.Ancients() {
+		return 0, false
+	}
+	data, err := c.ancients.ancient(freezerHashTable, *height)
+	if err != nil || utils.BytesToHash(data) != blockHash {
+		return 0, false
+	}
+	return *height, true
+}
+
+// getAncient returns the item of the frozen block, nil if the block isn't frozen.
+func (c *Chain) getAncient(kind string, blockHash utils.Hash) []byte {
+	height, ok := c.frozenHeight(blockHash)
+	if !ok {
+		return nil
+	}
+	data, err := c.ancients.ancient(kind, height)
+	if err != nil {
+		log.Errorf("Failed to read frozen %s height: %v, err: %v", kind, height, err)
+		return nil
+	}
+	return data
+}
+
+func (c *Chain) getAncientTransactions(blockHash utils.Hash) types.StorageTxs {
+	data := c.getAncient(freezerBodyTable, blockHash)
+	if len(data) == 0 {
+		return nil
+	}
+	var txs types.Transactions
+	if err := rlp.DecodeBytes(data, &txs); err != nil {
+		log.Errorf("Invalid frozen block body RLP hash: %v, err: %v", blockHash, err)
+		return nil
+	}
+	height, _ := c.frozenHeight(blockHash)
+	stxs := make(types.StorageTxs, len(txs))
+	for i, tx := range txs {
+		stxs[i] = types.NewStorageTx(blockHash, height, uint64(i), tx)
+	}
+	return stxs
+}
+
+func (c *Chain) getAncientReceipts(blockHash utils.Hash) types.Receipts {
+	data := c.getAncient(freezerReceiptTable, blockHash)
+	if len(data) == 0 {
+		return nil
+	}
+	var storage []*types.ReceiptForStorage
+	if err := rlp.DecodeBytes(data, &storage); err != nil {
+		log.Errorf("Invalid frozen receipts RLP hash: %v, err: %v", blockHash, err)
+		return nil
+	}
+	receipts := make(types.Receipts, len(storage))
+	for i, receipt := range storage {
+		receipts[i] = (*types.Receipt)(receipt)
+	}
+	return receipts
+}
+
+// freeze moves the canonical blocks below the limit into the freezer and deletes their
+// headers, bodies and receipts from the key-value store. The transactions are replaced
+// by their position in the frozen block.
+func (c *Chain) freeze(limit uint64) error {
+	if c.ancients == nil {
+		return nil
+	}
+	var frozen []*types.Block
+	for height := c.ancients.Ancients(); height < limit; height++ {
+		hash := c.getLegitimateHash(height)
+		block := c.getBlock(hash)
+		if block == nil {
+			return fmt.Errorf("canonical block #%d missing", height)
+		}
+		header, err := rlp.EncodeToBytes(block.BlockHeader())
+		if err != nil {
+			return err
+		}
+		body, err := rlp.EncodeToBytes(block.Transactions())
+		if err != nil {
+			return err
+		}
+		storage := []*types.ReceiptForStorage{}
+		for _, receipt := range c.getReceipts(hash) {
+			storage = append(storage, (*types.ReceiptForStorage)(receipt))
+		}
+		receipts, err := rlp.EncodeToBytes(storage)
+		if err != nil {
+			return err
+		}
+		if err := c.ancients.appendAncient(height, hash.Bytes(), header, body, receipts); err != nil {
+			return err
+		}
+		frozen = append(frozen, block)
+	}
+	if len(frozen) == 0 {
+		return nil
+	}
+	// the frozen blocks must be on the disk before they are dropped from the store
+	if err := c.ancients.sync(); err != nil {
+		return err
+	}
+	for _, block := range frozen {
+		for i, tx := range block.Transactions() {
+			c.putTxLookup(tx.Hash(), &txLookup{BlockHeight: block.Height().Uint64(), TxIndex: uint64(i)})
+		}
+		c.deleteReceipts(block.Hash())
+		c.deleteTransactions(block.Hash())
+		if err := c.db.Delete(keyHeader(block.Hash())); err != nil {
+			log.Fatalf("Failed to delete header err: %v", err)
+		}
+	}
+	return nil
+}
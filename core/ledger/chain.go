@@ -30,7 +30,8 @@ import (
 )
 
 type Chain struct {
-	db db.Database
+	db       db.Database
+	ancients *freezer // nil if the blocks are never frozen
 }
 
 // NewChain return Chain store schema
@@ -66,7 +67,8 @@ func (c *Chain) deleteBlock(blockHash utils.Hash) {
 
 func (c *Chain) HasHeader(blockHash utils.Hash) bool {
 	if has, err := c.db.Has(keyHeader(blockHash)); !has || err != nil {
-		return false
+		_, frozen := c.frozenHeight(blockHash)
+		return frozen
 	}
 	return true
 }
@@ -77,7 +79,9 @@ func (c *Chain) getHeader(blockHash utils.Hash) *types.BlockHeader {
 		log.Fatalf("Failed to get header RLP hash: %v, err: %v", blockHash, err)
 	}
 	if len(data) == 0 {
-		return nil
+		if data = c.getAncient(freezerHeaderTable, blockHash); len(data) == 0 {
+			return nil
+		}
 	}
 	header := new(types.BlockHeader)
 	if err := rlp.Decode(bytes.NewReader(data), header); err != nil {
@@ -125,7 +129,7 @@ func (c *Chain) getTransactions(txHash utils.Hash) types.StorageTxs {
 		log.Fatalf("Failed to get transactions hashs RLP hash: %v, err: %v", txHash, err)
 	}
 	if len(data) == 0 {
-		return nil
+		return c.getAncientTransactions(txHash)
 	}
 	hashs := new([]utils.Hash)
 	if err := rlp.Decode(bytes.NewReader(data), hashs); err != nil {
@@ -149,6 +153,11 @@ func (c *Chain) getTransaction(txHash utils.Hash) *types.StorageTx {
 		log.Fatalf("Failed to get transaction RLP hash: %v, err: %v", txHash, err)
 	}
 	if len(data) == 0 {
+		if lookup := c.getTxLookup(txHash); lookup != nil {
+			if txs := c.getAncientTransactions(c.getLegitimateHash(lookup.BlockHeight)); lookup.TxIndex < uint64(len(txs)) {
+				return txs[lookup.TxIndex]
+			}
+		}
 		return nil
 	}
 	tx := new(types.StorageTx)
@@ -225,7 +234,7 @@ func (c *Chain) getReceipts(blockHash utils.Hash) types.Receipts {
 		log.Fatalf("Failed to get transactions hashs RLP hash: %v,err: %v", blockHash, err)
 	}
 	if len(data) == 0 {
-		return nil
+		return c.getAncientReceipts(blockHash)
 	}
 	hashs := new([]utils.Hash)
 	if err := rlp.Decode(bytes.NewReader(data), hashs); err != nil {
@@ -250,6 +259,11 @@ func (c *Chain) getReceipt(txHash utils.Hash) *types.Receipt {
 		log.Fatalf("Failed to get receipts RLP hash: %v,err: %v", txHash, err)
 	}
 	if len(data) == 0 {
+		if lookup := c.getTxLookup(txHash); lookup != nil {
+			if receipts := c.getAncientReceipts(c.getLegitimateHash(lookup.BlockHeight)); lookup.TxIndex < uint64(len(receipts)) {
+				return receipts[lookup.TxIndex]
+			}
+		}
 		return nil
 	}
 	storageReceipt := new(types.ReceiptForStorage)
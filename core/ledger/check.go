@@ -0,0 +1,244 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package ledger
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/UranusBlockStack/uranus/common/log"
+	"github.com/UranusBlockStack/uranus/common/rlp"
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/core/types"
+)
+
+const checkLogInterval = 10 * time.Second // interval between the progress logs
+
+// ChainIssue is an inconsistency of a canonical block, the missing lookups are
+// repairable, the repair rebuilds them from the block.
+type ChainIssue struct {
+	Height     uint64     `json:"height"`
+	Hash       utils.Hash `json:"hash"`
+	Reason     string     `json:"reason"`
+	Repairable bool       `json:"repairable"`
+}
+
+// ChainCheck is the result of the check of the canonical chain.
+type ChainCheck struct {
+	Head       uint64        `json:"head"`       // height of the head block
+	Canonical  uint64        `json:"canonical"`  // count of the canonical hashes from the genesis
+	Consistent uint64        `json:"consistent"` // height of the last block consistent with its ancestors
+	State      uint64        `json:"state"`      // height of the last consistent block with its state
+	Repaired   int           `json:"repaired"`   // count of the rebuilt lookups
+	Issues     []*ChainIssue `json:"issues"`
+}
+
+// CheckChain walks the canonical chain from the genesis and verifies the headers, the
+// bodies, the transaction and receipt lookups of the blocks and the state availability.
+func (l *Ledger) CheckChain() *ChainCheck {
+	return l.checkChain(false)
+}
+
+// RepairChain rebuilds the missing lookups of the canonical chain and rewinds the head to
+// the last consistent block with its state.
+func (l *Ledger) RepairChain() (*ChainCheck, error) {
+	result := l.checkChain(true)
+	c := l.chain
+
+	// the rewind walks from the head, it is moved to the highest block linked to the target
+	head := result.State
+	for {
+		hash := c.getLegitimateHash(head + 1)
+		header := c.getHeader(hash)
+		if header == nil || header.PreviousHash != c.getLegitimateHash(head) {
+			break
+		}
+		head++
+	}
+	if head > result.State || c.getHeadBlockHash() != c.getLegitimateHash(result.State) {
+		c.putHeadBlockHash(c.getLegitimateHash(head))
+		if err := l.RewindChain(result.State + 1); err != nil {
+			return result, err
+		}
+	}
+	// the canonical hashes above the broken blocks aren't reachable by the rewind
+	for height := head + 1; ; height++ {
+		hash := c.getLegitimateHash(height)
+		if hash == (utils.Hash{}) {
+			break
+		}
+		l.DeleteBlock(hash)
+		c.deleteLegitimateHash(height)
+	}
+	l.cache.cleanAll()
+	result.Head = result.State
+	return result, nil
+}
+
+func (l *Ledger) checkChain(repair bool) *ChainCheck {
+	var (
+		c      = l.chain
+		result = &ChainCheck{Issues: []*ChainIssue{}}
+		frozen = c.Ancients()
+		broken bool
+		parent utils.Hash
+		logged = time.Now()
+	)
+	for height := uint64(0); ; height++ {
+		hash := c.getLegitimateHash(height)
+		if hash == (utils.Hash{}) {
+			break
+		}
+		result.Canonical++
+		found, issues := c.checkBlock(height, hash, parent, height < frozen, repair)
+		for _, issue := range issues {
+			if issue.Repairable && repair {
+				result.Repaired++
+			} else if !issue.Repairable {
+				broken = true
+			}
+		}
+		result.Issues = append(result.Issues, issues...)
+		if !broken {
+			result.Consistent = height
+		}
+		if parent = (utils.Hash{}); found {
+			parent = hash
+		}
+		if time.Since(logged) > checkLogInterval {
+			log.Infof("Checking chain height: %v, issues: %v", height, len(result.Issues))
+			logged = time.Now()
+		}
+	}
+
+	// the state of the head may be missing after a crash
+	result.State = result.Consistent
+	for l.hasState != nil {
+		header := c.getHeader(c.getLegitimateHash(result.State))
+		if header != nil && l.hasState(header.StateRoot) {
+			break
+		}
+		result.Issues = append(result.Issues, &ChainIssue{Height: result.State, Hash: c.getLegitimateHash(result.State), Reason: "state missing"})
+		if result.State == 0 {
+			break
+		}
+		result.State--
+	}
+
+	headHash := c.getHeadBlockHash()
+	head := c.getHeader(headHash)
+	switch {
+	case head == nil:
+		result.Issues = append(result.Issues, &ChainIssue{Hash: headHash, Reason: "head block missing"})
+	case c.getLegitimateHash(head.Height.Uint64()) != headHash:
+		result.Head = head.Height.Uint64()
+		result.Issues = append(result.Issues, &ChainIssue{Height: result.Head, Hash: headHash, Reason: "head block not canonical"})
+	default:
+		result.Head = head.Height.Uint64()
+	}
+	return result
+}
+
+// checkBlock verifies the canonical block and returns whether its header is found, the
+// missing lookups are rebuilt if repair is set.
+func (c *Chain) checkBlock(height uint64, hash, parent utils.Hash, frozen, repair bool) (bool, []*ChainIssue) {
+	var issues []*ChainIssue
+	report := func(repairable bool, format string, args ...interface{}) {
+		issues = append(issues, &ChainIssue{Height: height, Hash: hash, Reason: fmt.Sprintf(format, args...), Repairable: repairable})
+	}
+
+	header := c.getHeader(hash)
+	if header == nil {
+		report(false, "header missing")
+		return false, issues
+	}
+	if header.Hash() != hash || header.Height.Uint64() != height {
+		report(false, "header mismatch")
+		return false, issues
+	}
+	if height > 0 && header.PreviousHash != parent {
+		report(false, "previous hash mismatch")
+	}
+	if h := c.getHeaderHeight(hash); h == nil || *h != height {
+		report(true, "header height missing")
+		if repair {
+			if err := c.db.Put(keyHeaderHeight(hash), utils.EncodeUint64ToByte(height)); err != nil {
+				log.Fatalf("Failed to store hash to number mapping err: %v", err)
+			}
+		}
+	}
+	if c.getTd(hash) == nil {
+		var ptd *big.Int
+		if height == 0 {
+			ptd = new(big.Int)
+		} else if parent != (utils.Hash{}) {
+			ptd = c.getTd(parent)
+		}
+		report(ptd != nil, "total difficulty missing")
+		if repair && ptd != nil {
+			c.putTd(hash, new(big.Int).Add(ptd, header.Difficulty))
+		}
+	}
+
+	if frozen {
+		txs, receipts := c.getAncientTransactions(hash), c.getAncientReceipts(hash)
+		if txs == nil || receipts == nil || len(txs) != len(receipts) {
+			report(false, "frozen body or receipts missing")
+			return true, issues
+		}
+		for i, tx := range txs {
+			lookup := c.getTxLookup(tx.Tx.Hash())
+			if lookup == nil || lookup.BlockHeight != height || lookup.TxIndex != uint64(i) {
+				report(true, "transaction lookup %v missing", tx.Tx.Hash().Hex())
+				if repair {
+					c.putTxLookup(tx.Tx.Hash(), &txLookup{BlockHeight: height, TxIndex: uint64(i)})
+				}
+			}
+		}
+		return true, issues
+	}
+
+	data, _ := c.db.Get(keyTxHashs(hash))
+	if len(data) == 0 {
+		report(false, "transactions missing")
+		return true, issues
+	}
+	var hashs []utils.Hash
+	if err := rlp.DecodeBytes(data, &hashs); err != nil {
+		report(false, "invalid transactions: %v", err)
+		return true, issues
+	}
+	for i, txHash := range hashs {
+		data, _ := c.db.Get(keyTransacton(txHash))
+		stx := new(types.StorageTx)
+		if len(data) == 0 || rlp.DecodeBytes(data, stx) != nil {
+			report(false, "transaction %v missing", txHash.Hex())
+			continue
+		}
+		if stx.BlockHash != hash || stx.BlockHeight != height || stx.TxIndex != uint64(i) {
+			report(true, "transaction lookup %v mismatch", txHash.Hex())
+			if repair {
+				c.putTransaction(txHash, hash, uint64(i), height, stx.Tx)
+			}
+		}
+		if has, err := c.db.Has(keyReceipt(txHash)); !has || err != nil {
+			report(false, "receipt %v missing", txHash.Hex())
+		}
+	}
+	return true, issues
+}
@@ -0,0 +1,109 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package ledger
+
+import (
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckAndRepairChain(t *testing.T) {
+	dir, ldb := createTestDB(t)
+	defer os.RemoveAll(dir)
+	defer ldb.Close()
+
+	// the state of the head block is lost
+	missingState := utils.Hash{5}
+	ledger, err := New(&Config{}, ldb, func(root utils.Hash) bool { return root != missingState })
+	if err != nil {
+		t.Fatalf("failed to create ledger: %v", err)
+	}
+
+	// a chain of the genesis and 5 blocks with a transaction each
+	blocks := []*types.Block{types.NewBlock(&types.BlockHeader{Height: big.NewInt(0), Difficulty: big.NewInt(1)}, nil, nil, nil)}
+	for i := 1; i <= 5; i++ {
+		to := utils.BytesToAddress([]byte{byte(i)})
+		tx := types.NewTransaction(types.Binary, uint64(i), big.NewInt(1), 21000, big.NewInt(1), nil, &to)
+		header := &types.BlockHeader{PreviousHash: blocks[i-1].Hash(), Height: big.NewInt(int64(i)), Difficulty: big.NewInt(1), StateRoot: utils.Hash{byte(i)}}
+		blocks = append(blocks, types.NewBlock(header, []*types.Transaction{tx}, nil, nil))
+	}
+	for _, block := range blocks {
+		receipts := types.Receipts{}
+		for _, tx := range block.Transactions() {
+			receipts = append(receipts, &types.Receipt{TransactionHash: tx.Hash(), GasUsed: 21000, Logs: []*types.Log{}})
+		}
+		ledger.WriteBlockAndTd(block, new(big.Int).Add(block.Height(), big.NewInt(1)))
+		ledger.WriteBlockAndReceipts(block, receipts)
+		ledger.WriteLegitimateHashAndHeadBlockHash(block.Height().Uint64(), block.Hash())
+	}
+
+	result := ledger.CheckChain()
+	assert.Equal(t, uint64(5), result.Head)
+	assert.Equal(t, uint64(6), result.Canonical)
+	assert.Equal(t, uint64(5), result.Consistent)
+	assert.Equal(t, uint64(4), result.State)
+	assert.Len(t, result.Issues, 1)
+
+	// a lost height mapping, a stale transaction lookup and a lost receipt
+	ldb.Delete(keyHeaderHeight(blocks[2].Hash()))
+	tx := blocks[3].Transactions()[0]
+	ledger.chain.putTransaction(tx.Hash(), blocks[1].Hash(), 7, 1, tx)
+	ldb.Delete(keyReceipt(blocks[4].Transactions()[0].Hash()))
+
+	result = ledger.CheckChain()
+	assert.Equal(t, uint64(5), result.Head)
+	assert.Equal(t, uint64(3), result.Consistent)
+	assert.Equal(t, uint64(3), result.State)
+	assert.Len(t, result.Issues, 3)
+	assert.Equal(t, 0, result.Repaired)
+
+	result, err = ledger.RepairChain()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.Repaired)
+	assert.Equal(t, uint64(3), result.Head)
+	assert.Equal(t, blocks[3].Hash(), ledger.GetHeadBlockHash())
+	assert.Nil(t, ledger.GetBlockByHeight(4))
+	assert.Nil(t, ledger.GetBlockByHeight(5))
+	stx := ledger.GetTransactionByHash(tx.Hash())
+	if assert.NotNil(t, stx) {
+		assert.Equal(t, blocks[3].Hash(), stx.BlockHash)
+		assert.Equal(t, uint64(0), stx.TxIndex)
+	}
+
+	result = ledger.CheckChain()
+	assert.Equal(t, uint64(3), result.Head)
+	assert.Equal(t, uint64(4), result.Canonical)
+	assert.Empty(t, result.Issues)
+
+	stats, err := InspectDatabase(ldb)
+	assert.NoError(t, err)
+	for _, stat := range stats {
+		switch stat.Name {
+		case "Headers", "Block transactions", "Canonical hashes":
+			assert.Equal(t, uint64(4), stat.Count, stat.Name)
+		case "Transactions", "Receipts":
+			assert.Equal(t, uint64(3), stat.Count, stat.Name)
+		case "Others":
+			assert.Equal(t, uint64(0), stat.Count, stat.Name)
+		}
+	}
+}
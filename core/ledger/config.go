@@ -22,10 +22,25 @@ type Config struct {
 	txsCacheLimit    int
 	blockCacheLimit  int
 	futureBlockLimit int
+
+	// AncientDir is the directory of the frozen blocks, the blocks are never frozen if empty.
+	AncientDir string `mapstructure:"ledger-ancientdir"`
+	// FreezeThreshold is the count of the confirmed blocks kept in the key-value store.
+	FreezeThreshold uint64 `mapstructure:"ledger-freezethreshold"`
+	// AddressIndex enables the index of the transactions by address.
+	AddressIndex bool `mapstructure:"ledger-addressindex"`
+	// Snapshot enables the flat state read before the state tries, a missing flat state is
+	// generated in the background.
+	Snapshot bool `mapstructure:"ledger-snapshot"`
 }
 
 const (
 	maxTimeFutureBlocks = 30
+
+	// DefaultFreezeThreshold keeps about half a day of blocks out of the freezer.
+	DefaultFreezeThreshold = 90000
+
+	maxFreezeBatch = 30000 // blocks frozen at most in a single run
 )
 
 func (c *Config) check() {
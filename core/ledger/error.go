@@ -25,4 +25,16 @@ var (
 	ErrLDBNotFound     = errors.New("leveldb: not found")
 	ErrNoGenesis       = errors.New("Genesis not found in chain")
 	errGenesisNoConfig = errors.New("genesis has no chain configuration")
+
+	errGenesisDupCandidate = errors.New("duplicate genesis candidate")
+	errGenesisTooManyVotes = errors.New("genesis delegator votes too many candidates")
+
+	ErrFrozenBoundary    = errors.New("blocks below the frozen boundary can't be changed")
+	errOutOfBounds       = errors.New("out of bounds")
+	errOutOrderInsertion = errors.New("the append operation is out-order")
+
+	ErrSchemaTooNew = errors.New("database schema is newer than supported")
+
+	errAddressIndexDisabled   = errors.New("address index disabled")
+	errAddressIndexIncomplete = errors.New("address index incomplete")
 )
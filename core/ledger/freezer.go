@@ -0,0 +1,283 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package ledger
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+)
+
+// The tables of the freezer, the item i of every table belongs to the block at height i.
+const (
+	freezerHashTable    = "hashes"
+	freezerHeaderTable  = "headers"
+	freezerBodyTable    = "bodies"
+	freezerReceiptTable = "receipts"
+)
+
+var freezerTables = []string{freezerHashTable, freezerHeaderTable, freezerBodyTable, freezerReceiptTable}
+
+const indexEntrySize = 8 // end offset of an item in the data file
+
+// freezerTable is an append-only flat file of items with an index of their end offsets.
+type freezerTable struct {
+	data  *os.File
+	index *os.File
+	items uint64 // count of the items
+	size  uint64 // length of the data file
+	lock  sync.RWMutex
+}
+
+// newFreezerTable opens the table, the items not fully written by a crash are dropped.
+func newFreezerTable(dir, name string) (*freezerTable, error) {
+	data, err := os.OpenFile(filepath.Join(dir, name+".dat"), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	index, err := os.OpenFile(filepath.Join(dir, name+".idx"), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		data.Close()
+		return nil, err
+	}
+	t := &freezerTable{data: data, index: index}
+	if err := t.repair(); err != nil {
+		t.close()
+		return nil, err
+	}
+	return t, nil
+}
+
+// repair truncates the index to whole entries pointing into the data file and the data
+// file to the end of the last item.
+func (t *freezerTable) repair() error {
+	istat, err := t.index.Stat()
+	if err != nil {
+		return err
+	}
+	dstat, err := t.data.Stat()
+	if err != nil {
+		return err
+	}
+	items := uint64(istat.Size()) / indexEntrySize
+	for ; items > 0; items-- {
+		end, err := t.offset(items)
+		if err != nil {
+			return err
+		}
+		if end <= uint64(dstat.Size()) {
+			break
+		}
+	}
+	return t.truncate(items)
+}
+
+// offset returns the end offset of the item count in the data file.
+func (t *freezerTable) offset(items uint64) (uint64, error) {
+	if items == 0 {
+		return 0, nil
+	}
+	var entry [indexEntrySize]byte
+	if _, err := t.index.ReadAt(entry[:], int64((items-1)*indexEntrySize)); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(entry[:]), nil
+}
+
+// truncate drops the items from the count on.
+func (t *freezerTable) truncate(items uint64) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	end, err := t.offset(items)
+	if err != nil {
+		return err
+	}
+	if err := t.index.Truncate(int64(items * indexEntrySize)); err != nil {
+		return err
+	}
+	if err := t.data.Truncate(int64(end)); err != nil {
+		return err
+	}
+	t.items, t.size = items, end
+	return nil
+}
+
+// append adds the item at the end of the table, the data is written before the index so a
+// crash in between is repaired on open.
+func (t *freezerTable) append(item []byte) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if _, err := t.data.WriteAt(item, int64(t.size)); err != nil {
+		return err
+	}
+	var entry [indexEntrySize]byte
+	binary.BigEndian.PutUint64(entry[:], t.size+uint64(len(item)))
+	if _, err := t.index.WriteAt(entry[:], int64(t.items*indexEntrySize)); err != nil {
+		return err
+	}
+	t.items++
+	t.size += uint64(len(item))
+	return nil
+}
+
+// retrieve returns the item at the position.
+func (t *freezerTable) retrieve(item uint64) ([]byte, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if item >= t.items {
+		return nil, errOutOfBounds
+	}
+	start, err := t.offset(item)
+	if err != nil {
+		return nil, err
+	}
+	end, err := t.offset(item + 1)
+	if err != nil {
+		return nil, err
+	}
+	blob := make([]byte, end-start)
+	if _, err := t.data.ReadAt(blob, int64(start)); err != nil {
+		return nil, err
+	}
+	return blob, nil
+}
+
+func (t *freezerTable) sync() error {
+	if err := t.data.Sync(); err != nil {
+		return err
+	}
+	return t.index.Sync()
+}
+
+func (t *freezerTable) close() error {
+	derr, ierr := t.data.Close(), t.index.Close()
+	if derr != nil {
+		return derr
+	}
+	return ierr
+}
+
+// freezer keeps the finalized blocks in append-only tables outside of the key-value store.
+type freezer struct {
+	frozen uint64 // atomic, count of the frozen blocks, first for the 64 bit alignment
+	tables map[string]*freezerTable
+	lock   sync.Mutex // serialises the appends
+}
+
+// newFreezer opens the tables in the directory and truncates them to the blocks written
+// to all of them.
+func newFreezer(dir string) (*freezer, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	f := &freezer{tables: make(map[string]*freezerTable)}
+	for _, name := range freezerTables {
+		table, err := newFreezerTable(dir, name)
+		if err != nil {
+			f.close()
+			return nil, fmt.Errorf("failed to open freezer table %s: %v", name, err)
+		}
+		f.tables[name] = table
+	}
+	frozen := f.tables[freezerHashTable].items
+	for _, table := range f.tables {
+		if table.items < frozen {
+			frozen = table.items
+		}
+	}
+	for _, table := range f.tables {
+		if err := table.truncate(frozen); err != nil {
+			f.close()
+			return nil, err
+		}
+	}
+	f.frozen = frozen
+	return f, nil
+}
+
+// Ancients returns the count of the frozen blocks, they are the blocks below this height.
+func (f *freezer) Ancients() uint64 {
+	return atomic.LoadUint64(&f.frozen)
+}
+
+// ancient returns the item of the table for the block at the height.
+func (f *freezer) ancient(kind string, height uint64) ([]byte, error) {
+	table := f.tables[kind]
+	if table == nil {
+		return nil, fmt.Errorf("unknown freezer table %s", kind)
+	}
+	if height >= f.Ancients() {
+		return nil, errOutOfBounds
+	}
+	return table.retrieve(height)
+}
+
+// appendAncient adds the block at the height following the frozen ones.
+func (f *freezer) appendAncient(height uint64, hash, header, body, receipts []byte) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if frozen := f.Ancients(); height != frozen {
+		return fmt.Errorf("%v: have %d, want %d", errOutOrderInsertion, height, frozen)
+	}
+	items := map[string][]byte{
+		freezerHashTable:    hash,
+		freezerHeaderTable:  header,
+		freezerBodyTable:    body,
+		freezerReceiptTable: receipts,
+	}
+	for _, name := range freezerTables {
+		if err := f.tables[name].append(items[name]); err != nil {
+			// drop the partially written block
+			for _, table := range f.tables {
+				table.truncate(height)
+			}
+			return err
+		}
+	}
+	atomic.StoreUint64(&f.frozen, height+1)
+	return nil
+}
+
+// sync flushes the tables to the disk.
+func (f *freezer) sync() error {
+	for _, table := range f.tables {
+		if err := table.sync(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *freezer) close() error {
+	var errs []error
+	for _, table := range f.tables {
+		if err := table.close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) != 0 {
+		return fmt.Errorf("%v", errs)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package ledger
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFreezerTableRepair(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary : %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	table, err := newFreezerTable(dir, "test")
+	if err != nil {
+		t.Fatalf("failed to open table: %v", err)
+	}
+	items := [][]byte{{0x01}, {0x02, 0x02}, {0x03, 0x03, 0x03}}
+	for _, item := range items {
+		if err := table.append(item); err != nil {
+			t.Fatalf("failed to append item: %v", err)
+		}
+	}
+	for i, item := range items {
+		if blob, err := table.retrieve(uint64(i)); err != nil || !bytes.Equal(blob, item) {
+			t.Fatalf("item #%d: have %x, %v, want %x", i, blob, err, item)
+		}
+	}
+	if _, err := table.retrieve(uint64(len(items))); err != errOutOfBounds {
+		t.Fatalf("retrieve out of bounds: have %v, want %v", err, errOutOfBounds)
+	}
+	table.close()
+
+	// a crash in the middle of the last item
+	if err := os.Truncate(filepath.Join(dir, "test.dat"), 4); err != nil {
+		t.Fatalf("failed to truncate data: %v", err)
+	}
+	table, err = newFreezerTable(dir, "test")
+	if err != nil {
+		t.Fatalf("failed to reopen table: %v", err)
+	}
+	assert.Equal(t, uint64(2), table.items)
+	assert.Equal(t, uint64(3), table.size)
+	table.close()
+
+	// a crash in the middle of an index entry
+	if err := os.Truncate(filepath.Join(dir, "test.idx"), indexEntrySize+3); err != nil {
+		t.Fatalf("failed to truncate index: %v", err)
+	}
+	table, err = newFreezerTable(dir, "test")
+	if err != nil {
+		t.Fatalf("failed to reopen table: %v", err)
+	}
+	defer table.close()
+	assert.Equal(t, uint64(1), table.items)
+	blob, err := table.retrieve(0)
+	assert.NoError(t, err)
+	assert.Equal(t, items[0], blob)
+}
+
+func TestLedgerFreeze(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary : %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	dbdir, ldb := createTestDB(t)
+	defer os.RemoveAll(dbdir)
+	defer ldb.Close()
+
+	ledger, err := New(&Config{AncientDir: dir, FreezeThreshold: 2}, ldb, func(hash utils.Hash) bool { return true })
+	if err != nil {
+		t.Fatalf("failed to create ledger: %v", err)
+	}
+	defer ledger.Close()
+
+	// a chain of the genesis and 5 blocks with a transaction each
+	blocks := []*types.Block{types.NewBlock(&types.BlockHeader{Height: big.NewInt(0)}, nil, nil, nil)}
+	for i := 1; i <= 5; i++ {
+		to := utils.BytesToAddress([]byte{byte(i)})
+		tx := types.NewTransaction(types.Binary, uint64(i), big.NewInt(1), 21000, big.NewInt(1), nil, &to)
+		header := &types.BlockHeader{PreviousHash: blocks[i-1].Hash(), Height: big.NewInt(int64(i))}
+		blocks = append(blocks, types.NewBlock(header, []*types.Transaction{tx}, nil, nil))
+	}
+	for _, block := range blocks {
+		receipts := types.Receipts{}
+		for _, tx := range block.Transactions() {
+			receipts = append(receipts, &types.Receipt{TransactionHash: tx.Hash(), GasUsed: 21000, Logs: []*types.Log{}})
+		}
+		ledger.WriteBlockAndReceipts(block, receipts)
+		ledger.WriteLegitimateHashAndHeadBlockHash(block.Height().Uint64(), block.Hash())
+	}
+
+	// nothing is frozen until the confirmed blocks pass the threshold
+	assert.NoError(t, ledger.Freeze(2))
+	assert.Equal(t, uint64(0), ledger.Ancients())
+
+	assert.NoError(t, ledger.Freeze(5))
+	assert.Equal(t, uint64(3), ledger.Ancients())
+
+	for _, block := range blocks[:3] {
+		// the frozen blocks are gone from the key-value store
+		if data, _ := ledger.chain.db.Get(keyHeader(block.Hash())); len(data) != 0 {
+			t.Fatalf("block #%d: header still in the store", block.Height())
+		}
+		have := ledger.GetBlockByHeight(block.Height().Uint64())
+		if have == nil || have.Hash() != block.Hash() {
+			t.Fatalf("block #%d: frozen block missing", block.Height())
+		}
+		assert.Equal(t, len(block.Transactions()), len(ledger.GetReceipts(block.Hash())))
+		for i, tx := range block.Transactions() {
+			stx := ledger.GetTransactionByHash(tx.Hash())
+			if stx == nil || stx.Tx.Hash() != tx.Hash() {
+				t.Fatalf("block #%d: frozen transaction missing", block.Height())
+			}
+			assert.Equal(t, block.Hash(), stx.BlockHash)
+			assert.Equal(t, uint64(i), stx.TxIndex)
+			receipt := ledger.GetReceipt(tx.Hash())
+			if receipt == nil || receipt.TransactionHash != tx.Hash() {
+				t.Fatalf("block #%d: frozen receipt missing", block.Height())
+			}
+		}
+	}
+
+	// the frozen blocks can't be rewound
+	assert.Error(t, ledger.RewindChain(2))
+	assert.NoError(t, ledger.RewindChain(4))
+	assert.Equal(t, blocks[3].Hash(), ledger.GetHeadBlockHash())
+	assert.Nil(t, ledger.GetBlockByHeight(4))
+
+	// nor reset to the genesis when the head block is missing
+	ledger.WriteLegitimateHashAndHeadBlockHash(5, blocks[5].Hash())
+	_, err = ledger.CheckLastBlock(blocks[0])
+	assert.Error(t, err)
+	assert.Equal(t, blocks[5].Hash(), ledger.GetHeadBlockHash())
+	if have := ledger.GetBlockByHeight(3); have == nil || have.Hash() != blocks[3].Hash() {
+		t.Fatal("canonical block dropped by the failed reset")
+	}
+}
@@ -41,6 +41,7 @@ func (g Genesis) MarshalJSON() ([]byte, error) {
 		PreviousHash utils.Hash            `json:"previousHash"`
 		Validator    utils.Address         `json:"validator"`
 		Alloc        GenesisAlloc          `json:"alloc"`
+		Candidates   []GenesisCandidate    `json:"candidates,omitempty"`
 	}
 
 	var enc genesisJ
@@ -61,6 +62,7 @@ func (g Genesis) MarshalJSON() ([]byte, error) {
 			enc.Alloc[k] = v
 		}
 	}
+	enc.Candidates = g.Candidates
 	return json.Marshal(&enc)
 }
 
@@ -78,6 +80,7 @@ func (g *Genesis) UnmarshalJSON(input []byte) error {
 		GasUsed      *math.HexOrDecimal64  `json:"gasUsed"`
 		PreviousHash *utils.Hash           `json:"previousHash"`
 		Alloc        GenesisAlloc          `json:"alloc"`
+		Candidates   []GenesisCandidate    `json:"candidates,omitempty"`
 	}
 	var dec genesisJ
 	if err := json.Unmarshal(input, &dec); err != nil {
@@ -125,5 +128,8 @@ func (g *Genesis) UnmarshalJSON(input []byte) error {
 			g.Alloc[utils.Address(k)] = v
 		}
 	}
+	if dec.Candidates != nil {
+		g.Candidates = dec.Candidates
+	}
 	return nil
 }
@@ -0,0 +1,75 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package ledger
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/UranusBlockStack/uranus/common/math"
+	"github.com/UranusBlockStack/uranus/common/utils"
+)
+
+func (g GenesisAccount) MarshalJSON() ([]byte, error) {
+	type genesisAccountJ struct {
+		Code          utils.Bytes               `json:"code,omitempty"`
+		Storage       map[utils.Hash]utils.Hash `json:"storage,omitempty"`
+		Balance       *math.HexOrDecimal256     `json:"balance" gencodec:"required"`
+		LockedBalance *math.HexOrDecimal256     `json:"lockedBalance,omitempty"`
+		Nonce         math.HexOrDecimal64       `json:"nonce,omitempty"`
+	}
+	var enc genesisAccountJ
+	enc.Code = g.Code
+	enc.Storage = g.Storage
+	balance := g.Balance
+	enc.Balance = &balance
+	enc.LockedBalance = g.LockedBalance
+	enc.Nonce = math.HexOrDecimal64(g.Nonce)
+	return json.Marshal(&enc)
+}
+
+func (g *GenesisAccount) UnmarshalJSON(input []byte) error {
+	type genesisAccountJ struct {
+		Code          utils.Bytes               `json:"code,omitempty"`
+		Storage       map[utils.Hash]utils.Hash `json:"storage,omitempty"`
+		Balance       *math.HexOrDecimal256     `json:"balance" gencodec:"required"`
+		LockedBalance *math.HexOrDecimal256     `json:"lockedBalance,omitempty"`
+		Nonce         *math.HexOrDecimal64      `json:"nonce,omitempty"`
+	}
+	var dec genesisAccountJ
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	if dec.Code != nil {
+		g.Code = dec.Code
+	}
+	if dec.Storage != nil {
+		g.Storage = dec.Storage
+	}
+	if dec.Balance == nil {
+		return errors.New("missing required field 'balance' for GenesisAccount")
+	}
+	g.Balance = math.HexOrDecimal256(*(*big.Int)(dec.Balance))
+	if dec.LockedBalance != nil {
+		g.LockedBalance = dec.LockedBalance
+	}
+	if dec.Nonce != nil {
+		g.Nonce = uint64(*dec.Nonce)
+	}
+	return nil
+}
@@ -17,9 +17,11 @@
 package ledger
 
 import (
+	"encoding/json"
 	"fmt"
 	"math/big"
 
+	"github.com/UranusBlockStack/uranus/common/db"
 	"github.com/UranusBlockStack/uranus/common/log"
 	"github.com/UranusBlockStack/uranus/common/math"
 	"github.com/UranusBlockStack/uranus/common/rlp"
@@ -34,10 +36,18 @@ type GenesisAlloc map[utils.Address]GenesisAccount
 
 // GenesisAccount is an account in the state of the genesis block.
 type GenesisAccount struct {
-	Code    []byte                    `json:"code,omitempty"`
-	Storage map[utils.Hash]utils.Hash `json:"storage,omitempty"`
-	Balance math.HexOrDecimal256      `json:"balance" gencodec:"required"`
-	Nonce   uint64                    `json:"nonce,omitempty"`
+	Code          []byte                    `json:"code,omitempty"`
+	Storage       map[utils.Hash]utils.Hash `json:"storage,omitempty"`
+	Balance       math.HexOrDecimal256      `json:"balance" gencodec:"required"`
+	LockedBalance *math.HexOrDecimal256     `json:"lockedBalance,omitempty"` // weight of the votes of the account
+	Nonce         uint64                    `json:"nonce,omitempty"`
+}
+
+// GenesisCandidate is a candidate registered in the genesis block with the accounts
+// delegating to it.
+type GenesisCandidate struct {
+	Address    utils.Address   `json:"address"`
+	Delegators []utils.Address `json:"delegators,omitempty"`
 }
 
 // Genesis specifies the header fields, state of a genesis block.
@@ -54,6 +64,10 @@ type Genesis struct {
 	GasUsed      uint64              `json:"gasUsed"`
 	PreviousHash utils.Hash          `json:"previousHash"`
 	Alloc        GenesisAlloc        `json:"alloc"`
+
+	// Candidates are the validators of the first epoch, the candidate of the default
+	// chain config is the only validator when there are none.
+	Candidates []GenesisCandidate `json:"candidates,omitempty"`
 }
 
 // DefaultGenesis returns the nurans main net genesis block.
@@ -94,54 +108,138 @@ func SetupGenesis(genesis *Genesis, chain *Chain) (*params.ChainConfig, state.Da
 	return chain.getChainConfig(stored), state.NewDatabase(chain.db), stored, nil
 }
 
+// StoredGenesis returns the genesis specification of the chain, nil if the chain has no
+// genesis block. The specification of a chain written before it was stored is rebuilt
+// from the genesis block without the allocations.
+func StoredGenesis(chain *Chain) *Genesis {
+	hash := chain.getLegitimateHash(0)
+	if (hash == utils.Hash{}) {
+		return nil
+	}
+	if genesis := chain.getGenesis(hash); genesis != nil {
+		return genesis
+	}
+	block := chain.getBlock(hash)
+	if block == nil {
+		return nil
+	}
+	return &Genesis{
+		Config:       chain.getChainConfig(hash),
+		Nonce:        block.BlockHeader().Nonce.Uint64(),
+		Timestamp:    block.Time().Uint64(),
+		ExtraData:    block.ExtraData(),
+		GasLimit:     block.GasLimit(),
+		Difficulty:   block.Difficulty(),
+		Miner:        block.Miner(),
+		Height:       block.Height().Uint64(),
+		GasUsed:      block.GasUsed(),
+		PreviousHash: block.PreviousHash(),
+	}
+}
+
+// Hash returns the hash of the genesis block of the specification.
+func (g *Genesis) Hash() (utils.Hash, error) {
+	block, _, err := g.ToBlock(NewChain(db.NewMemDatabase()))
+	if err != nil {
+		return utils.Hash{}, err
+	}
+	return block.Hash(), nil
+}
+
+// validate checks the candidates and the delegations of the specification.
+func (g *Genesis) validate() error {
+	candidates := make(map[utils.Address]bool)
+	votes := make(map[utils.Address]uint64)
+	for _, candidate := range g.Candidates {
+		if candidates[candidate.Address] {
+			return fmt.Errorf("%v: %v", errGenesisDupCandidate, candidate.Address.Hex())
+		}
+		candidates[candidate.Address] = true
+		for _, delegator := range candidate.Delegators {
+			votes[delegator]++
+		}
+	}
+	if g.Config == nil {
+		return nil
+	}
+	for delegator, cnt := range votes {
+		if cnt > g.Config.MaxVotes {
+			return fmt.Errorf("%v: %v votes %d, max %d", errGenesisTooManyVotes, delegator.Hex(), cnt, g.Config.MaxVotes)
+		}
+	}
+	return nil
+}
+
 // Commit writes the block and state of a genesis specification to the database.
 func (g *Genesis) Commit(chain *Chain) (*types.Block, state.Database, error) {
-	block, statedb := g.ToBlock(chain)
+	block, statedb, err := g.ToBlock(chain)
+	if err != nil {
+		return nil, nil, err
+	}
 	if block.Height().Sign() != 0 {
 		return nil, statedb, fmt.Errorf("can't commit genesis block with Height > 0")
 	}
+	// the state is flushed, a restarted node opens it from the disk
+	if err := statedb.TrieDB().Commit(block.StateRoot(), false); err != nil {
+		return nil, statedb, err
+	}
 	chain.putTd(block.Hash(), g.Difficulty)
 	chain.putBlock(block)
 	chain.putReceipts(block.Hash(), nil)
 	chain.putLegitimateHash(block.Height().Uint64(), block.Hash())
 	chain.putHeadBlockHash(block.Hash())
 	chain.putChainConfig(block.Hash(), g.Config)
+	chain.putGenesis(block.Hash(), g)
+	chain.putDBVersion(SchemaVersion)
 
 	return block, statedb, nil
 }
 
 // ToBlock creates the genesis block and writes state.
-func (g *Genesis) ToBlock(chain *Chain) (*types.Block, state.Database) {
+func (g *Genesis) ToBlock(chain *Chain) (*types.Block, state.Database, error) {
+	if err := g.validate(); err != nil {
+		return nil, nil, err
+	}
 	statedb, _ := state.New(utils.Hash{}, state.NewDatabase(chain.db))
 	for addr, account := range g.Alloc {
 		statedb.AddBalance(addr, (*big.Int)(&account.Balance))
 		statedb.SetCode(addr, account.Code)
 		statedb.SetNonce(addr, account.Nonce)
+		if account.LockedBalance != nil {
+			statedb.SetLockedBalance(addr, (*big.Int)(account.LockedBalance))
+			statedb.SetDelegateTimestamp(addr, new(big.Int).SetUint64(g.Timestamp))
+		}
 		for key, value := range account.Storage {
 			statedb.SetState(addr, key, value)
 		}
 	}
 	dposContext, err := types.NewDposContextFromProto(statedb.Database().TrieDB(), &types.DposContextProto{})
 	if err != nil {
-		panic(err)
+		return nil, nil, err
 	}
-	validator := utils.HexToAddress(params.DefaultChainConfig.GenesisCandidate)
-	dposContext.SetValidators([]utils.Address{validator})
-	dposContext.DelegateTrie().TryUpdate(append(validator.Bytes(), validator.Bytes()...), validator.Bytes())
-	candidateInfo := &types.CandidateInfo{
-		Addr:   validator,
-		Weight: 100,
+	if len(g.Candidates) == 0 {
+		// the candidate of the config isn't used, it would change the hash of the
+		// existing genesis blocks
+		validator := utils.HexToAddress(params.DefaultChainConfig.GenesisCandidate)
+		dposContext.SetValidators([]utils.Address{validator})
+		dposContext.DelegateTrie().TryUpdate(append(validator.Bytes(), validator.Bytes()...), validator.Bytes())
+		candidateInfo := &types.CandidateInfo{
+			Addr:   validator,
+			Weight: 100,
+		}
+		val, _ := rlp.EncodeToBytes(candidateInfo)
+		dposContext.CandidateTrie().TryUpdate(validator.Bytes(), val)
+	} else if err := g.setupCandidates(dposContext); err != nil {
+		return nil, nil, err
 	}
-	val, _ := rlp.EncodeToBytes(candidateInfo)
-	dposContext.CandidateTrie().TryUpdate(validator.Bytes(), val)
 
 	triedb := statedb.Database().TrieDB()
 	if _, err := dposContext.CommitTo(triedb); err != nil {
-		panic(err)
+		return nil, nil, err
 	}
 	root, err := statedb.Commit(false)
 	if err != nil {
-		panic(err)
+		return nil, nil, err
 	}
 	dposContextProto := dposContext.ToProto()
 	head := &types.BlockHeader{
@@ -158,5 +256,59 @@ func (g *Genesis) ToBlock(chain *Chain) (*types.Block, state.Database) {
 		DposContext:  dposContextProto,
 	}
 
-	return types.NewBlock(head, nil, nil, nil), statedb.Database()
+	return types.NewBlock(head, nil, nil, nil), statedb.Database(), nil
+}
+
+// setupCandidates registers the candidates and their delegators, the first candidates up
+// to the validator size are the validators of the first epoch.
+func (g *Genesis) setupCandidates(dposContext *types.DposContext) error {
+	var (
+		validators []utils.Address
+		delegators []utils.Address
+		votes      = make(map[utils.Address][]*utils.Address)
+	)
+	for i := range g.Candidates {
+		candidate := &g.Candidates[i].Address
+		if err := dposContext.BecomeCandidate(*candidate); err != nil {
+			return err
+		}
+		if g.Config == nil || int64(len(validators)) < g.Config.MaxValidatorSize {
+			validators = append(validators, *candidate)
+		}
+		for _, delegator := range g.Candidates[i].Delegators {
+			if _, ok := votes[delegator]; !ok {
+				delegators = append(delegators, delegator)
+			}
+			votes[delegator] = append(votes[delegator], candidate)
+		}
+	}
+	for _, delegator := range delegators {
+		if err := dposContext.Delegate(delegator, votes[delegator]); err != nil {
+			return err
+		}
+	}
+	return dposContext.SetValidators(validators)
+}
+
+func (c *Chain) getGenesis(hash utils.Hash) *Genesis {
+	data, _ := c.db.Get(append(keyGenesis, hash.Bytes()...))
+	if len(data) == 0 {
+		return nil
+	}
+	genesis := new(Genesis)
+	if err := json.Unmarshal(data, genesis); err != nil {
+		log.Errorf("Invalid genesis JSON hash: %v, err: %v", hash, err)
+		return nil
+	}
+	return genesis
+}
+
+func (c *Chain) putGenesis(hash utils.Hash, genesis *Genesis) {
+	data, err := json.Marshal(genesis)
+	if err != nil {
+		log.Fatalf("Failed to JSON encode genesis err: %v", err)
+	}
+	if err := c.db.Put(append(keyGenesis, hash.Bytes()...), data); err != nil {
+		log.Fatalf("Failed to store genesis err: %v", err)
+	}
 }
@@ -17,21 +17,50 @@
 package ledger
 
 import (
+	"encoding/json"
+	"math/big"
 	"os"
 	"testing"
 
 	"github.com/UranusBlockStack/uranus/common/db"
+	"github.com/UranusBlockStack/uranus/common/math"
 	"github.com/UranusBlockStack/uranus/common/utils"
 	"github.com/UranusBlockStack/uranus/core/state"
+	"github.com/UranusBlockStack/uranus/core/types"
 	"github.com/UranusBlockStack/uranus/params"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestDefaultGenesis(t *testing.T) {
-	block, _ := DefaultGenesis().ToBlock(NewChain(db.NewMemDatabase()))
+	block, _, err := DefaultGenesis().ToBlock(NewChain(db.NewMemDatabase()))
+	assert.NoError(t, err)
 	assert.Equal(t, block.Hash().Hex(), "0xb0367f07920d58bab301961099f1c8f11c63a7a9bc8542f15437372e2626bb53")
 }
 
+func TestGenesisDefaultCandidate(t *testing.T) {
+	want, err := DefaultGenesis().Hash()
+	assert.NoError(t, err)
+
+	// the genesis without candidates always has the default candidate, whatever the config
+	genesis := DefaultGenesis()
+	config := *params.DefaultChainConfig
+	genesis.Config = &config
+	for _, candidate := range []string{"", "0x0000000000000000000000000000000000000001"} {
+		config.GenesisCandidate = candidate
+		have, err := genesis.Hash()
+		assert.NoError(t, err)
+		assert.Equal(t, want, have, "config candidate %q", candidate)
+	}
+}
+
+func mustHash(t *testing.T, genesis *Genesis) utils.Hash {
+	hash, err := genesis.Hash()
+	if err != nil {
+		t.Fatalf("failed to hash genesis: %v", err)
+	}
+	return hash
+}
+
 func TestSetupGenesisBlock(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -89,3 +118,86 @@ func TestSetupGenesisBlock(t *testing.T) {
 	}
 
 }
+
+func TestGenesisCandidates(t *testing.T) {
+	var (
+		candidates = []utils.Address{{0x01}, {0x02}, {0x03}, {0x04}}
+		delegator1 = utils.Address{0x11}
+		delegator2 = utils.Address{0x12}
+	)
+	genesis := DefaultGenesis()
+	genesis.Alloc = GenesisAlloc{
+		delegator1: {Balance: math.HexOrDecimal256(*big.NewInt(1000)), LockedBalance: (*math.HexOrDecimal256)(big.NewInt(500))},
+		delegator2: {Balance: math.HexOrDecimal256(*big.NewInt(1000)), LockedBalance: (*math.HexOrDecimal256)(big.NewInt(300))},
+	}
+	genesis.Candidates = []GenesisCandidate{
+		{Address: candidates[0], Delegators: []utils.Address{delegator1}},
+		{Address: candidates[1], Delegators: []utils.Address{delegator1, delegator2}},
+		{Address: candidates[2]},
+		{Address: candidates[3]},
+	}
+
+	// the genesis survives the JSON round trip
+	data, err := json.Marshal(genesis)
+	assert.NoError(t, err)
+	decoded := new(Genesis)
+	assert.NoError(t, json.Unmarshal(data, decoded))
+	assert.Equal(t, mustHash(t, genesis), mustHash(t, decoded))
+
+	memdb := db.NewMemDatabase()
+	chain := NewChain(memdb)
+	block, _, err := decoded.Commit(chain)
+	assert.NoError(t, err)
+	assert.Equal(t, mustHash(t, genesis), block.Hash())
+
+	// the state is read back from the database
+	statedb, err := state.New(block.StateRoot(), state.NewDatabase(memdb))
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(500), statedb.GetLockedBalance(delegator1))
+	assert.Equal(t, big.NewInt(300), statedb.GetLockedBalance(delegator2))
+	assert.Equal(t, big.NewInt(1000), statedb.GetBalance(delegator1))
+
+	dposContext, err := types.NewDposContextFromProto(statedb.Database().TrieDB(), block.BlockHeader().DposContext)
+	assert.NoError(t, err)
+	// the validators are the first candidates up to the validator size
+	validators, err := dposContext.GetValidators()
+	assert.NoError(t, err)
+	assert.Equal(t, candidates[:3], validators)
+	assert.True(t, dposContext.IsDpos())
+
+	infos, err := dposContext.GetCandidates()
+	assert.NoError(t, err)
+	assert.Equal(t, len(candidates), len(infos))
+	delegators, err := dposContext.GetDelegators(candidates[1])
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(delegators))
+	voted, err := dposContext.GetCandidateAddrs(delegator1)
+	assert.NoError(t, err)
+	assert.Equal(t, candidates[:2], voted)
+
+	// the specification is stored with the block
+	stored := StoredGenesis(chain)
+	if stored == nil {
+		t.Fatal("genesis not stored")
+	}
+	assert.Equal(t, mustHash(t, genesis), mustHash(t, stored))
+	assert.Equal(t, genesis.Candidates, stored.Candidates)
+}
+
+func TestGenesisInvalidCandidates(t *testing.T) {
+	genesis := DefaultGenesis()
+	genesis.Candidates = []GenesisCandidate{{Address: utils.Address{0x01}}, {Address: utils.Address{0x01}}}
+	_, _, err := genesis.Commit(NewChain(db.NewMemDatabase()))
+	assert.Error(t, err)
+	_, err = genesis.Hash()
+	assert.Error(t, err)
+
+	genesis = DefaultGenesis()
+	genesis.Config = &params.ChainConfig{MaxValidatorSize: 3, MaxVotes: 1}
+	genesis.Candidates = []GenesisCandidate{
+		{Address: utils.Address{0x01}, Delegators: []utils.Address{{0x11}}},
+		{Address: utils.Address{0x02}, Delegators: []utils.Address{{0x11}}},
+	}
+	_, _, err = genesis.Commit(NewChain(db.NewMemDatabase()))
+	assert.Error(t, err)
+}
@@ -0,0 +1,106 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package ledger
+
+import (
+	"bytes"
+
+	"github.com/UranusBlockStack/uranus/common/db"
+	"github.com/UranusBlockStack/uranus/common/mtp"
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/core/state"
+)
+
+// KeyStats is the count and the size of the entries of a kind of key.
+type KeyStats struct {
+	Name  string            `json:"name"`
+	Count uint64            `json:"count"`
+	Size  utils.StorageSize `json:"size"`
+}
+
+// keyKind matches the keys of a kind by prefix, and by length if the length isn't zero.
+type keyKind struct {
+	name   string
+	prefix []byte
+	length int
+}
+
+// keyKinds are the kinds of the keys of the chain database, the first matching kind
+// counts the key.
+var keyKinds = []keyKind{
+	{name: "Headers", prefix: keyHeaderPrefix, length: len(keyHeaderPrefix) + utils.HashLength},
+	{name: "Header heights", prefix: keyHeaderHeightPrefix, length: len(keyHeaderHeightPrefix) + utils.HashLength},
+	{name: "Header hashes", prefix: keyHeaderHashPrefix, length: len(keyHeaderHashPrefix) + 8},
+	{name: "Bodies", prefix: keyBlockPrefix, length: len(keyBlockPrefix) + utils.HashLength},
+	{name: "Block transactions", prefix: keyTxHashsPrefix, length: len(keyTxHashsPrefix) + utils.HashLength},
+	{name: "Transactions", prefix: keyTransactonPrefix, length: len(keyTransactonPrefix) + utils.HashLength},
+	{name: "Transaction lookups", prefix: keyTxLookupPrefix, length: len(keyTxLookupPrefix) + utils.HashLength},
+	{name: "Receipts", prefix: keyReceiptPrefix, length: len(keyReceiptPrefix) + utils.HashLength},
+	{name: "Total difficulties", prefix: keyTDPrefix, length: len(keyTDPrefix) + utils.HashLength},
+	{name: "Canonical hashes", prefix: keyLegitimate},
+	{name: "Address index", prefix: keyAddressTxPrefix, length: len(keyAddressTxPrefix) + len(utils.Address{}) + 16},
+	{name: "Chain configs", prefix: keyChainConfig, length: len(keyChainConfig) + utils.HashLength},
+	{name: "Genesis", prefix: keyGenesis, length: len(keyGenesis) + utils.HashLength},
+	{name: "Trie nodes and code", length: utils.HashLength},
+	{name: "Trie preimages", prefix: mtp.SecureKeyPrefix, length: len(mtp.SecureKeyPrefix) + utils.HashLength},
+	{name: "Snapshot accounts", prefix: state.SnapshotAccountPrefix, length: len(state.SnapshotAccountPrefix) + utils.HashLength},
+	{name: "Snapshot storage", prefix: state.SnapshotStoragePrefix, length: len(state.SnapshotStoragePrefix) + 2*utils.HashLength},
+	{name: "Metadata", prefix: keyDBVersion, length: len(keyDBVersion)},
+	{name: "Metadata", prefix: keyMigration, length: len(keyMigration)},
+	{name: "Metadata", prefix: keyLastHeader, length: len(keyLastHeader)},
+	{name: "Metadata", prefix: keyLastBlock, length: len(keyLastBlock)},
+	{name: "Metadata", prefix: keyAddressIndexTail, length: len(keyAddressIndexTail)},
+	{name: "Metadata", prefix: state.SnapshotRootKey, length: len(state.SnapshotRootKey)},
+}
+
+// InspectDatabase iterates the database and returns the count and the size of the keys
+// of each kind, the unknown keys are counted as others.
+func InspectDatabase(database db.Database) ([]*KeyStats, error) {
+	var (
+		stats  []*KeyStats
+		byName = make(map[string]*KeyStats)
+	)
+	stat := func(name string) *KeyStats {
+		if s, ok := byName[name]; ok {
+			return s
+		}
+		s := &KeyStats{Name: name}
+		byName[name] = s
+		stats = append(stats, s)
+		return s
+	}
+	for _, kind := range keyKinds {
+		stat(kind.name)
+	}
+	others := stat("Others")
+
+	it := database.NewIteratorWithPrefix(nil)
+	defer it.Release()
+	for it.Next() {
+		key, size := it.Key(), utils.StorageSize(len(it.Key())+len(it.Value()))
+		s := others
+		for _, kind := range keyKinds {
+			if bytes.HasPrefix(key, kind.prefix) && (kind.length == 0 || len(key) == kind.length) {
+				s = byName[kind.name]
+				break
+			}
+		}
+		s.Count++
+		s.Size += size
+	}
+	return stats, it.Error()
+}
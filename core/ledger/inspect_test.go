@@ -0,0 +1,60 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package ledger
+
+import (
+	"testing"
+
+	"github.com/UranusBlockStack/uranus/common/db"
+	"github.com/UranusBlockStack/uranus/common/mtp"
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/core/state"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInspectDatabase(t *testing.T) {
+	mdb := db.NewMemDatabase()
+	hash := utils.BytesToHash([]byte{1})
+	keys := map[string][]byte{
+		"Headers":             keyHeader(hash),
+		"Header heights":      keyHeaderHeight(hash),
+		"Header hashes":       keyHeaderHash(1),
+		"Bodies":              keyBlock(hash),
+		"Block transactions":  keyTxHashs(hash),
+		"Transactions":        keyTransacton(hash),
+		"Transaction lookups": keyTxLookup(hash),
+		"Receipts":            keyReceipt(hash),
+		"Total difficulties":  keyTD(hash),
+		"Trie preimages":      prefixedKey(mtp.SecureKeyPrefix, hash.Bytes()),
+		"Snapshot accounts":   prefixedKey(state.SnapshotAccountPrefix, hash.Bytes()),
+	}
+	for _, key := range keys {
+		assert.NoError(t, mdb.Put(key, []byte{1}))
+	}
+	// a key of a known prefix and an unknown length isn't counted for the prefix
+	assert.NoError(t, mdb.Put(prefixedKey(keyHeaderHashPrefix, hash.Bytes()), []byte{1}))
+
+	stats, err := InspectDatabase(mdb)
+	assert.NoError(t, err)
+	for _, stat := range stats {
+		want := uint64(0)
+		if _, ok := keys[stat.Name]; ok || stat.Name == "Others" {
+			want = 1
+		}
+		assert.Equal(t, want, stat.Count, stat.Name)
+	}
+}
@@ -30,62 +30,134 @@ import (
 
 // Ledger represents the ledger in blockchain
 type Ledger struct {
-	cache    *cache
-	chain    *Chain
-	hasState func(hash utils.Hash) bool
+	cache           *cache
+	chain           *Chain
+	hasState        func(hash utils.Hash) bool
+	freezeThreshold uint64
+
+	addressIndex      bool
+	addressIndexReset bool // the backfill progress is reset once the index is disabled
 }
 
-// New creates a new ledger.
-func New(cachecfg *Config, db db.Database, hasState func(hash utils.Hash) bool) *Ledger {
+// New creates a new ledger, the freezer is opened if the config has an ancient directory.
+// The database of an older schema is migrated, a newer schema is refused.
+func New(cachecfg *Config, db db.Database, hasState func(hash utils.Hash) bool) (*Ledger, error) {
 	if cachecfg == nil {
 		cachecfg = new(Config)
 	}
+	chain := NewChain(db)
+	if err := chain.migrate(false); err != nil {
+		return nil, err
+	}
+	if cachecfg.AncientDir != "" {
+		ancients, err := newFreezer(cachecfg.AncientDir)
+		if err != nil {
+			return nil, err
+		}
+		chain.ancients = ancients
+	}
 	return &Ledger{
-		newCache(cachecfg),
-		NewChain(db),
-		hasState,
+		cache:           newCache(cachecfg),
+		chain:           chain,
+		hasState:        hasState,
+		freezeThreshold: cachecfg.FreezeThreshold,
+		addressIndex:    cachecfg.AddressIndex,
+	}, nil
+}
+
+// Close closes the freezer.
+func (l *Ledger) Close() error {
+	if l.chain.ancients == nil {
+		return nil
 	}
+	return l.chain.ancients.close()
 }
 
-// CheckLastBlock check the last block is right or reset chain with genesis block.
-func (l *Ledger) CheckLastBlock(genesis *types.Block) *types.Block {
+// Ancients returns the count of the frozen blocks, they are the blocks below this height.
+func (l *Ledger) Ancients() uint64 {
+	return l.chain.Ancients()
+}
+
+// Freeze moves the blocks confirmed for more than the freeze threshold into the freezer.
+func (l *Ledger) Freeze(confirmed uint64) error {
+	if l.chain.ancients == nil || confirmed <= l.freezeThreshold {
+		return nil
+	}
+	limit := confirmed - l.freezeThreshold
+	if head := l.chain.getBlock(l.chain.getHeadBlockHash()); head == nil || head.Height().Uint64() < limit {
+		return nil
+	}
+	if frozen := l.chain.Ancients(); limit > frozen+maxFreezeBatch {
+		limit = frozen + maxFreezeBatch
+	}
+	start := time.Now()
+	from := l.chain.Ancients()
+	if err := l.chain.freeze(limit); err != nil {
+		return err
+	}
+	if limit > from {
+		log.Infof("Frozen blocks from: %v, to: %v, elapsed: %v", from, limit-1, time.Since(start))
+	}
+	return nil
+}
+
+// CheckLastBlock check the last block is right or reset chain with genesis block. A
+// frozen chain can't be reset, ErrFrozenBoundary is returned.
+func (l *Ledger) CheckLastBlock(genesis *types.Block) (*types.Block, error) {
 	// Restore the last known head block
 	head := l.chain.getHeadBlockHash()
 	if head == (utils.Hash{}) {
 		log.Warn("Empty database, reseting chain")
-		l.ResetChain(genesis)
-		return genesis
+		if err := l.ResetChain(genesis); err != nil {
+			return nil, err
+		}
+		return genesis, nil
 	}
 	// Make sure the entire head block is available
 	cur := l.GetBlockByHash(head)
 	if cur == nil {
 		log.Warn("Head block missing, resetting chain", "hash", head)
-		l.ResetChain(genesis)
-		return genesis
+		if err := l.ResetChain(genesis); err != nil {
+			return nil, err
+		}
+		return genesis, nil
 	}
-	return cur
+	return cur, nil
 }
 
 // ResetChain reset chain with genesis block
-func (l *Ledger) ResetChain(genesis *types.Block) {
-	l.RewindChain(0)
+func (l *Ledger) ResetChain(genesis *types.Block) error {
+	if err := l.RewindChain(0); err != nil {
+		return err
+	}
 	l.chain.putBlock(genesis)
 	// clear cache
 	l.cache.cleanAll()
 	l.chain.putHeadBlockHash(genesis.Hash())
-	return
+	return nil
 }
 
-// RewindChain rewind the chain, deleting all block
-func (l *Ledger) RewindChain(height uint64) {
+// RewindChain rewind the chain, deleting the canonical blocks from the head down to the
+// height. The frozen blocks can't be deleted.
+func (l *Ledger) RewindChain(height uint64) error {
+	if frozen := l.chain.Ancients(); height < frozen {
+		return fmt.Errorf("%v: rewind to %d, frozen %d", ErrFrozenBoundary, height, frozen)
+	}
 	log.Warnf("Rewinding chain target: %v", height)
-	hash := l.chain.getHeadBlockHash()
-	block := l.chain.getBlock(hash)
-	if block.Height().Uint64() >= height {
-		l.chain.deleteBlock(block.Hash())
-		l.RewindChain(height)
+	block := l.chain.getBlock(l.chain.getHeadBlockHash())
+	for block != nil && block.Height().Uint64() >= height {
+		l.DeleteAddressIndex(block)
+		l.DeleteBlock(block.Hash())
+		l.chain.deleteLegitimateHash(block.Height().Uint64())
+		if block.Height().Uint64() == 0 {
+			break
+		}
+		block = l.chain.getBlock(block.PreviousHash())
+	}
+	if block != nil && block.Height().Uint64() < height {
+		l.chain.putHeadBlockHash(block.Hash())
 	}
-	return
+	return nil
 }
 
 // GetHeadBlockHash get the last block hash
@@ -112,6 +184,8 @@ func (l *Ledger) WriteBlockAndTd(block *types.Block, td *big.Int) {
 
 func (l *Ledger) DeleteBlock(blockHash utils.Hash) {
 	l.cache.blockCache.Remove(blockHash)
+	l.cache.tdCache.Remove(blockHash)
+	l.cache.txsCache.Remove(blockHash)
 	l.chain.deleteBlock(blockHash)
 }
 
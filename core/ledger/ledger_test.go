@@ -17,26 +17,72 @@
 package ledger
 
 import (
+	"math/big"
 	"testing"
 
+	"github.com/UranusBlockStack/uranus/common/crypto"
 	"github.com/UranusBlockStack/uranus/common/db"
 	"github.com/UranusBlockStack/uranus/common/utils"
 	"github.com/UranusBlockStack/uranus/core/state"
+	"github.com/UranusBlockStack/uranus/core/types"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestRewindChain(t *testing.T) {
 	stateCache := state.NewDatabase(db.NewMemDatabase())
-	ledger := New(&Config{}, db.NewMemDatabase(), func(hash utils.Hash) bool {
+	ledger, _ := New(&Config{}, db.NewMemDatabase(), func(hash utils.Hash) bool {
 		_, err := stateCache.OpenTrie(hash)
 		return err == nil
 	})
-	genesisBlock, _ := DefaultGenesis().ToBlock(ledger.chain)
+	genesisBlock, _, _ := DefaultGenesis().ToBlock(ledger.chain)
 	DefaultGenesis().Commit(ledger.chain)
 
-	ledger.CheckLastBlock(genesisBlock)
+	_, err := ledger.CheckLastBlock(genesisBlock)
+	assert.NoError(t, err)
 
 	block := ledger.GetBlockByHeight(0)
 
 	assert.Equal(t, genesisBlock.Hash(), block.Hash())
 }
+
+func TestRewindChainBlocks(t *testing.T) {
+	ledger, err := New(&Config{}, db.NewMemDatabase(), nil)
+	if err != nil {
+		t.Fatalf("failed to create ledger: %v", err)
+	}
+	key, _ := crypto.GenerateKey()
+	to := utils.BytesToAddress([]byte{1})
+	blocks := []*types.Block{types.NewBlock(&types.BlockHeader{Height: big.NewInt(0), Difficulty: big.NewInt(1)}, nil, nil, nil)}
+	for i := 1; i <= 5; i++ {
+		tx := types.NewTransaction(types.Binary, uint64(i), big.NewInt(1), 21000, big.NewInt(1), nil, &to)
+		tx.SignTx(types.Signer{}, key)
+		header := &types.BlockHeader{PreviousHash: blocks[i-1].Hash(), Height: big.NewInt(int64(i)), Difficulty: big.NewInt(1)}
+		blocks = append(blocks, types.NewBlock(header, []*types.Transaction{tx}, nil, nil))
+	}
+	for _, block := range blocks {
+		receipts := types.Receipts{}
+		for range block.Transactions() {
+			receipts = append(receipts, &types.Receipt{Logs: []*types.Log{}})
+		}
+		ledger.WriteBlockAndTd(block, new(big.Int).Add(block.Height(), big.NewInt(1)))
+		ledger.WriteBlockAndReceipts(block, receipts)
+		ledger.WriteLegitimateHashAndHeadBlockHash(block.Height().Uint64(), block.Hash())
+		// the blocks and the total difficulties are cached
+		assert.NotNil(t, ledger.GetBlock(block.Hash()))
+		assert.NotNil(t, ledger.GetTd(block.Hash()))
+	}
+
+	// the blocks from the height 3 are removed with their lookups and the cached entries
+	assert.NoError(t, ledger.RewindChain(3))
+	assert.Equal(t, blocks[2].Hash(), ledger.GetHeadBlockHash())
+	for _, block := range blocks {
+		kept := block.Height().Uint64() < 3
+		assert.Equal(t, kept, ledger.GetBlockByHeight(block.Height().Uint64()) != nil, block.Height().String())
+		assert.Equal(t, kept, ledger.GetBlock(block.Hash()) != nil, block.Height().String())
+		assert.Equal(t, kept, ledger.GetTd(block.Hash()) != nil, block.Height().String())
+		for _, tx := range block.Transactions() {
+			assert.Equal(t, kept, ledger.GetTransactionByHash(tx.Hash()) != nil, block.Height().String())
+			assert.Equal(t, kept, ledger.GetReceipt(tx.Hash()) != nil, block.Height().String())
+		}
+	}
+}
@@ -16,24 +16,69 @@
 
 package ledger
 
-import "github.com/UranusBlockStack/uranus/common/utils"
+import (
+	"encoding/binary"
+
+	"github.com/UranusBlockStack/uranus/common/utils"
+)
 
 var (
 	// key
 	keyDBVersion   = []byte("DBVersion")
+	keyMigration   = []byte("Migration")
 	keyChainConfig = []byte("chainConfig")
+	keyGenesis     = []byte("genesis")
 	keyLegitimate  = []byte("legitimate")
 	keyLastHeader  = []byte("LastHeader")
 	keyLastBlock   = []byte("LastBlock")
 
-	keyTD = func(hash utils.Hash) []byte { return append([]byte("td"), hash.Bytes()...) }
+	keyAddressIndexTail = []byte("AddressIndexTail")
+	keyAddressTxPrefix  = []byte("ah")
+
+	keyTDPrefix           = []byte("td")
+	keyHeaderPrefix       = []byte("h")
+	keyHeaderHashPrefix   = []byte("hh")
+	keyHeaderHeightPrefix = []byte("hn")
+	keyBlockPrefix        = []byte("b")
+	keyTxHashsPrefix      = []byte("txhs")
+	keyReceiptPrefix      = []byte("r")
+	keyTransactonPrefix   = []byte("tx")
+	keyTxLookupPrefix     = []byte("tl")
+
+	keyTD = func(hash utils.Hash) []byte { return prefixedKey(keyTDPrefix, hash.Bytes()) }
 
-	keyHeader       = func(hash utils.Hash) []byte { return append([]byte("h"), hash.Bytes()...) }
-	keyHeaderHash   = func(number uint64) []byte { return append([]byte("hh"), utils.EncodeUint64ToByte(number)...) }
-	keyHeaderHeight = func(hash utils.Hash) []byte { return append([]byte("hn"), hash.Bytes()...) }
+	keyHeader       = func(hash utils.Hash) []byte { return prefixedKey(keyHeaderPrefix, hash.Bytes()) }
+	keyHeaderHash   = func(number uint64) []byte { return prefixedKey(keyHeaderHashPrefix, encodeHeight(number)) }
+	keyHeaderHeight = func(hash utils.Hash) []byte { return prefixedKey(keyHeaderHeightPrefix, hash.Bytes()) }
 
-	keyBlock      = func(hash utils.Hash) []byte { return append([]byte("b"), hash.Bytes()...) }
-	keyTxHashs    = func(hash utils.Hash) []byte { return append([]byte("txhs"), hash.Bytes()...) }
-	keyReceipt    = func(hash utils.Hash) []byte { return append([]byte("r"), hash.Bytes()...) }
-	keyTransacton = func(hash utils.Hash) []byte { return append([]byte("tx"), hash.Bytes()...) }
+	keyBlock      = func(hash utils.Hash) []byte { return prefixedKey(keyBlockPrefix, hash.Bytes()) }
+	keyTxHashs    = func(hash utils.Hash) []byte { return prefixedKey(keyTxHashsPrefix, hash.Bytes()) }
+	keyReceipt    = func(hash utils.Hash) []byte { return prefixedKey(keyReceiptPrefix, hash.Bytes()) }
+	keyTransacton = func(hash utils.Hash) []byte { return prefixedKey(keyTransactonPrefix, hash.Bytes()) }
+	keyTxLookup   = func(hash utils.Hash) []byte { return prefixedKey(keyTxLookupPrefix, hash.Bytes()) }
+
+	// the big endian height and index keep the transactions of an address sorted
+	keyAddressTx = func(addr utils.Address, height, index uint64) []byte {
+		key := make([]byte, len(keyAddressTxPrefix)+len(addr)+16)
+		n := copy(key, keyAddressTxPrefix)
+		n += copy(key[n:], addr.Bytes())
+		binary.BigEndian.PutUint64(key[n:], height)
+		binary.BigEndian.PutUint64(key[n+8:], index)
+		return key
+	}
 )
+
+// prefixedKey returns a new key of the prefix and the suffix, the prefix is never appended
+// to as it may have room for the suffix.
+func prefixedKey(prefix, suffix []byte) []byte {
+	key := make([]byte, len(prefix)+len(suffix))
+	copy(key[copy(key, prefix):], suffix)
+	return key
+}
+
+// encodeHeight encodes the height as 8 big endian bytes.
+func encodeHeight(height uint64) []byte {
+	enc := make([]byte, 8)
+	binary.BigEndian.PutUint64(enc, height)
+	return enc
+}
@@ -0,0 +1,193 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package ledger
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/UranusBlockStack/uranus/common/db"
+	"github.com/UranusBlockStack/uranus/common/log"
+	"github.com/UranusBlockStack/uranus/common/rlp"
+	"github.com/UranusBlockStack/uranus/common/utils"
+)
+
+// SchemaVersion is the version of the database schema written by this release. The
+// databases written before the version was recorded are version 0.
+const SchemaVersion = 1
+
+const migrationLogInterval = 10 * time.Second // interval between the progress logs
+
+// migration upgrades the database from the previous version to its version. The migrate
+// function converts a batch of the data from the start key into the batch and returns the
+// key the next batch starts at, nil once the data is converted. The migrated data must only
+// be written into the batch, a dry run discards it.
+type migration struct {
+	version uint64
+	name    string
+	migrate func(c *Chain, batch db.Batch, start []byte) (next []byte, count int, err error)
+}
+
+// migrations are the registered migrations sorted by version.
+var migrations = []*migration{}
+
+// migrationProgress is the resume point of an interrupted migration.
+type migrationProgress struct {
+	Version uint64
+	Next    []byte
+}
+
+// SchemaVersionOf returns the schema version of the database, false if the database is empty.
+func SchemaVersionOf(db db.Database) (uint64, bool) {
+	return NewChain(db).getDBVersion()
+}
+
+// Migrate upgrades the database to the schema version of this release, resuming the
+// migration interrupted before. The dry run only walks the data and writes nothing.
+func Migrate(db db.Database, dryRun bool) error {
+	return NewChain(db).migrate(dryRun)
+}
+
+func (c *Chain) migrate(dryRun bool) error {
+	version, ok := c.getDBVersion()
+	if !ok {
+		if !dryRun {
+			c.putDBVersion(SchemaVersion)
+		}
+		return nil
+	}
+	if version > SchemaVersion {
+		return fmt.Errorf("%v: database %d, supported %d", ErrSchemaTooNew, version, SchemaVersion)
+	}
+	if version == SchemaVersion {
+		return nil
+	}
+
+	progress := c.getMigrationProgress()
+	for _, m := range migrations {
+		if m.version <= version {
+			continue
+		}
+		if m.version > SchemaVersion {
+			break
+		}
+		var start []byte
+		if progress != nil && progress.Version == m.version {
+			start = progress.Next
+			log.Infof("Resuming database migration version: %v, name: %v, from: %x", m.version, m.name, start)
+		} else {
+			log.Infof("Migrating database version: %v, name: %v, dryrun: %v", m.version, m.name, dryRun)
+		}
+		if err := c.runMigration(m, start, dryRun); err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %v", m.version, m.name, err)
+		}
+	}
+	if !dryRun {
+		c.putDBVersion(SchemaVersion)
+	}
+	log.Infof("Database migrated from: %v, to: %v, dryrun: %v", version, SchemaVersion, dryRun)
+	return nil
+}
+
+// runMigration runs the migration batch by batch from the start key, the progress is
+// written with each batch so an interrupted migration resumes at the last batch.
+func (c *Chain) runMigration(m *migration, start []byte, dryRun bool) error {
+	var (
+		begin   = time.Now()
+		logged  = time.Now()
+		total   int
+		batches int
+	)
+	for {
+		batch := c.db.NewBatch()
+		next, count, err := m.migrate(c, batch, start)
+		if err != nil {
+			return err
+		}
+		total += count
+		batches++
+		if !dryRun {
+			if next != nil {
+				data, err := rlp.EncodeToBytes(&migrationProgress{Version: m.version, Next: next})
+				if err != nil {
+					return err
+				}
+				batch.Put(keyMigration, data)
+			} else {
+				batch.Delete(keyMigration)
+				batch.Put(keyDBVersion, encodeDBVersion(m.version))
+			}
+			if err := batch.Write(); err != nil {
+				return err
+			}
+		}
+		if next == nil {
+			break
+		}
+		if time.Since(logged) > migrationLogInterval {
+			log.Infof("Migrating database version: %v, items: %v, next: %x, elapsed: %v", m.version, total, next, time.Since(begin))
+			logged = time.Now()
+		}
+		start = next
+	}
+	log.Infof("Migrated database version: %v, name: %v, items: %v, batches: %v, dryrun: %v, elapsed: %v", m.version, m.name, total, batches, dryRun, time.Since(begin))
+	return nil
+}
+
+// getDBVersion returns the schema version, a database with a genesis block and no version
+// was written before the version was recorded.
+func (c *Chain) getDBVersion() (uint64, bool) {
+	data, _ := c.db.Get(keyDBVersion)
+	if len(data) == 0 {
+		if c.getLegitimateHash(0) != (utils.Hash{}) {
+			return 0, true
+		}
+		return 0, false
+	}
+	var version uint64
+	if err := rlp.DecodeBytes(data, &version); err != nil {
+		log.Fatalf("Invalid database version RLP err: %v", err)
+	}
+	return version, true
+}
+
+func (c *Chain) putDBVersion(version uint64) {
+	if err := c.db.Put(keyDBVersion, encodeDBVersion(version)); err != nil {
+		log.Fatalf("Failed to store database version err: %v", err)
+	}
+}
+
+func (c *Chain) getMigrationProgress() *migrationProgress {
+	data, _ := c.db.Get(keyMigration)
+	if len(data) == 0 {
+		return nil
+	}
+	progress := new(migrationProgress)
+	if err := rlp.DecodeBytes(data, progress); err != nil {
+		log.Errorf("Invalid migration progress RLP err: %v", err)
+		return nil
+	}
+	return progress
+}
+
+func encodeDBVersion(version uint64) []byte {
+	data, err := rlp.EncodeToBytes(version)
+	if err != nil {
+		log.Fatalf("Failed to RLP encode database version err: %v", err)
+	}
+	return data
+}
@@ -0,0 +1,144 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package ledger
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/UranusBlockStack/uranus/common/db"
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+var errTestInterrupted = errors.New("interrupted")
+
+// testMigration marks the canonical heights two by two, it is interrupted after the
+// batches if the count is positive.
+func testMigration(interrupt *int) *migration {
+	return &migration{
+		version: SchemaVersion,
+		name:    "test",
+		migrate: func(c *Chain, batch db.Batch, start []byte) ([]byte, int, error) {
+			if *interrupt == 0 {
+				return nil, 0, errTestInterrupted
+			}
+			*interrupt--
+			var height uint64
+			if len(start) == 8 {
+				height = binary.BigEndian.Uint64(start)
+			}
+			count := 0
+			for ; count < 2; count++ {
+				hash := c.getLegitimateHash(height)
+				if hash == (utils.Hash{}) {
+					return nil, count, nil
+				}
+				batch.Put(append([]byte("test"), hash.Bytes()...), []byte{1})
+				height++
+			}
+			next := make([]byte, 8)
+			binary.BigEndian.PutUint64(next, height)
+			return next, count, nil
+		},
+	}
+}
+
+// newLegacyChain returns a chain written before the schema version was recorded.
+func newLegacyChain(t *testing.T, blocks uint64) (*Chain, *db.MemDatabase) {
+	memdb := db.NewMemDatabase()
+	chain := NewChain(memdb)
+	if _, _, err := DefaultGenesis().Commit(chain); err != nil {
+		t.Fatalf("failed to commit genesis: %v", err)
+	}
+	for i := uint64(1); i <= blocks; i++ {
+		chain.putLegitimateHash(i, utils.BytesToHash([]byte{byte(i)}))
+	}
+	memdb.Delete(keyDBVersion)
+	return chain, memdb
+}
+
+func TestSchemaVersion(t *testing.T) {
+	// a fresh database records the version
+	memdb := db.NewMemDatabase()
+	_, err := New(&Config{}, memdb, nil)
+	assert.NoError(t, err)
+	version, ok := SchemaVersionOf(memdb)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(SchemaVersion), version)
+
+	// a newer schema is refused
+	NewChain(memdb).putDBVersion(SchemaVersion + 1)
+	_, err = New(&Config{}, memdb, nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), ErrSchemaTooNew.Error())
+
+	// the legacy database is version 0
+	_, memdb = newLegacyChain(t, 0)
+	version, ok = SchemaVersionOf(memdb)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(0), version)
+}
+
+func TestMigrate(t *testing.T) {
+	defer func(old []*migration) { migrations = old }(migrations)
+
+	interrupt := -1
+	migrations = []*migration{testMigration(&interrupt)}
+	chain, memdb := newLegacyChain(t, 6)
+	marked := func() int {
+		count := 0
+		for _, key := range memdb.Keys() {
+			if len(key) > 4 && string(key[:4]) == "test" {
+				count++
+			}
+		}
+		return count
+	}
+
+	// the dry run writes nothing
+	assert.NoError(t, Migrate(memdb, true))
+	assert.Equal(t, 0, marked())
+	version, _ := SchemaVersionOf(memdb)
+	assert.Equal(t, uint64(0), version)
+
+	// the interrupted migration keeps the progress of the written batches
+	interrupt = 2
+	assert.Error(t, Migrate(memdb, false))
+	assert.Equal(t, 4, marked())
+	progress := chain.getMigrationProgress()
+	if assert.NotNil(t, progress) {
+		assert.Equal(t, uint64(SchemaVersion), progress.Version)
+		assert.Equal(t, uint64(4), binary.BigEndian.Uint64(progress.Next))
+	}
+	version, _ = SchemaVersionOf(memdb)
+	assert.Equal(t, uint64(0), version)
+
+	// the migration resumes at the progress
+	interrupt = 2
+	_, err := New(&Config{}, memdb, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, marked())
+	assert.Nil(t, chain.getMigrationProgress())
+	version, _ = SchemaVersionOf(memdb)
+	assert.Equal(t, uint64(SchemaVersion), version)
+
+	// the migrated database isn't migrated again
+	interrupt = 0
+	assert.NoError(t, Migrate(memdb, false))
+}
@@ -17,6 +17,8 @@
 package state
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"sync"
 
@@ -29,6 +31,8 @@ import (
 // MaxTrieCacheGen Trie cache generation limit after which to evict trie nodes from memory.
 var MaxTrieCacheGen = uint16(120)
 
+var errMissingCode = errors.New("missing code")
+
 const (
 	// Number of past tries to keep. This value is chosen such that
 	// reasonable chain reorg depths will hit an existing mtp.
@@ -57,6 +61,13 @@ type Database interface {
 
 	// TrieDB retrieves the low level trie database used for data storage.
 	TrieDB() *mtp.Database
+
+	// EnableSnapshots loads or generates the flat state of the root, the states opened
+	// afterwards read it before the tries.
+	EnableSnapshots(root utils.Hash)
+
+	// Snapshots returns the flat state, nil if it isn't enabled.
+	Snapshots() *SnapshotTree
 }
 
 // Trie is a Ethereum Merkle mtp.
@@ -79,15 +90,18 @@ func NewDatabase(db ldb.Database) Database {
 	csc, _ := lru.New(codeSizeCacheSize)
 	return &cachingDB{
 		db:            mtp.NewDatabase(db),
+		diskdb:        db,
 		codeSizeCache: csc,
 	}
 }
 
 type cachingDB struct {
 	db            *mtp.Database
+	diskdb        ldb.Database
 	mu            sync.Mutex
 	pastTries     []*mtp.TrieWarp
 	codeSizeCache *lru.Cache
+	snaps         *SnapshotTree
 }
 
 // OpenTrie opens the main account mtp.
@@ -139,6 +153,10 @@ func (db *cachingDB) CopyTrie(t Trie) Trie {
 // ContractCode retrieves a particular contract's code.
 func (db *cachingDB) ContractCode(addrHash, codeHash utils.Hash) ([]byte, error) {
 	code, err := db.db.Node(codeHash)
+	if err == nil && len(code) == 0 && !bytes.Equal(codeHash.Bytes(), emptyCodeHash) {
+		// the databases return no value without an error for a missing key
+		err = errMissingCode
+	}
 	if err == nil {
 		db.codeSizeCache.Add(codeHash, len(code))
 	}
@@ -159,6 +177,21 @@ func (db *cachingDB) TrieDB() *mtp.Database {
 	return db.db
 }
 
+// EnableSnapshots loads or generates the flat state of the root.
+func (db *cachingDB) EnableSnapshots(root utils.Hash) {
+	snaps := NewSnapshotTree(db.diskdb, db.db, root)
+	db.mu.Lock()
+	db.snaps = snaps
+	db.mu.Unlock()
+}
+
+// Snapshots returns the flat state, nil if it isn't enabled.
+func (db *cachingDB) Snapshots() *SnapshotTree {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.snaps
+}
+
 // cachedTrie inserts its trie into a cachingDB on commit.
 type cachedTrie struct {
 	*mtp.TrieWarp
@@ -17,15 +17,21 @@
 package state
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 
+	"github.com/UranusBlockStack/uranus/common/crypto"
+	"github.com/UranusBlockStack/uranus/common/log"
 	"github.com/UranusBlockStack/uranus/common/mtp"
 	"github.com/UranusBlockStack/uranus/common/rlp"
 	"github.com/UranusBlockStack/uranus/common/utils"
 )
 
 type DumpAccount struct {
+	Address           string            `json:"address,omitempty"` // only set by the iterative dumps
+	Key               string            `json:"key,omitempty"`     // hashed address, only set by the iterative dumps
 	Balance           string            `json:"balance"`
 	LockedBalance     string            `json:"lockedBalance"`
 	DelegateTimestamp string            `json:"delegateTimestamp"`
@@ -41,6 +47,208 @@ type Dump struct {
 	Accounts map[string]DumpAccount `json:"accounts"`
 }
 
+// StorageEntry is a slot of the storage range, the key is nil if its preimage is unknown.
+type StorageEntry struct {
+	Key   *utils.Hash `json:"key"`
+	Value utils.Hash  `json:"value"`
+}
+
+// StorageRange is a page of the storage of an account indexed by the hashed keys, the
+// next key is nil on the last page.
+type StorageRange struct {
+	Storage map[utils.Hash]StorageEntry `json:"storage"`
+	NextKey *utils.Hash                 `json:"nextKey"`
+}
+
+// dumpAccount decodes the account of the trie leaf with its code and storage.
+func (s *StateDB) dumpAccount(addrHash, addr []byte, value []byte) (DumpAccount, error) {
+	var data Account
+	if err := rlp.DecodeBytes(value, &data); err != nil {
+		return DumpAccount{}, fmt.Errorf("invalid account %x: %v", addrHash, err)
+	}
+	obj := newObject(nil, utils.BytesToAddress(addr), data)
+	account := DumpAccount{
+		Balance:           obj.data.Balance.String(),
+		LockedBalance:     obj.data.LockedBalance.String(),
+		DelegateTimestamp: obj.data.DelegateTimestamp.String(),
+		Nonce:             obj.data.Nonce,
+		Root:              utils.BytesToHex(obj.data.Root[:]),
+		CodeHash:          utils.BytesToHex(obj.data.CodeHash),
+		Storage:           make(map[string]string),
+	}
+	if !bytes.Equal(obj.data.CodeHash, emptyCodeHash) {
+		code, err := s.db.ContractCode(utils.BytesToHash(addrHash), utils.BytesToHash(obj.data.CodeHash))
+		if err != nil {
+			return DumpAccount{}, fmt.Errorf("account %x: missing code: %v", addrHash, err)
+		}
+		account.Code = utils.BytesToHex(code)
+	}
+	storageTrie, err := s.db.OpenStorageTrie(utils.BytesToHash(addrHash), obj.data.Root)
+	if err != nil {
+		return DumpAccount{}, fmt.Errorf("account %x: missing storage: %v", addrHash, err)
+	}
+	storageIt := mtp.NewIterator(storageTrie.NodeIterator(nil))
+	for storageIt.Next() {
+		account.Storage[utils.BytesToHex(s.trie.GetKey(storageIt.Key))] = utils.BytesToHex(storageIt.Value)
+	}
+	if storageIt.Err != nil {
+		return DumpAccount{}, fmt.Errorf("account %x: %v", addrHash, storageIt.Err)
+	}
+	return account, nil
+}
+
+// snapshotDumpBatch is the count of the accounts read at once from the flat state.
+const snapshotDumpBatch = 256
+
+// iterativeDump calls fn with the accounts from the hashed key start, at most max accounts
+// if max is positive. It returns the hashed key of the next account, nil at the end.
+func (s *StateDB) iterativeDump(start []byte, max int, fn func(DumpAccount) error) ([]byte, error) {
+	if s.snap != nil && s.snap.Root() == s.trie.Hash() {
+		next, n, err := s.snapshotDump(start, max, fn)
+		if err != errSnapshotStale && err != errSnapshotMissing && err != errSnapshotGenerating {
+			return next, err
+		}
+		// the flat state was flattened or isn't generated yet, the dump goes on with the trie
+		if start = next; max > 0 {
+			max -= n
+		}
+	}
+	it := mtp.NewIterator(s.trie.NodeIterator(start))
+	for n := 0; it.Next(); n++ {
+		if max > 0 && n == max {
+			return utils.CopyBytes(it.Key), nil
+		}
+		addr := s.trie.GetKey(it.Key)
+		account, err := s.dumpAccount(it.Key, addr, it.Value)
+		if err != nil {
+			return nil, err
+		}
+		if addr != nil {
+			account.Address = utils.BytesToHex(addr)
+		}
+		account.Key = utils.BytesToHex(it.Key)
+		if err := fn(account); err != nil {
+			return nil, err
+		}
+	}
+	return nil, it.Err
+}
+
+// snapshotDump is the iterativeDump of the flat state, it returns the count of the
+// dumped accounts too.
+func (s *StateDB) snapshotDump(start []byte, max int, fn func(DumpAccount) error) ([]byte, int, error) {
+	for n := 0; ; {
+		limit := snapshotDumpBatch
+		if max > 0 && max-n+1 < limit {
+			limit = max - n + 1
+		}
+		hashes, values, err := s.snaps.accounts(s.snap.Root(), start, limit)
+		if err != nil {
+			return start, n, err
+		}
+		for i, hash := range hashes {
+			if max > 0 && n == max {
+				return hash.Bytes(), n, nil
+			}
+			addr := s.trie.GetKey(hash[:])
+			account, err := s.dumpAccount(hash[:], addr, values[i])
+			if err != nil {
+				return nil, n, err
+			}
+			if addr != nil {
+				account.Address = utils.BytesToHex(addr)
+			}
+			account.Key = utils.BytesToHex(hash[:])
+			if err := fn(account); err != nil {
+				return nil, n, err
+			}
+			n++
+			if start = nextHash(hash); start == nil {
+				return nil, n, nil
+			}
+		}
+		if len(hashes) < limit {
+			return nil, n, nil
+		}
+	}
+}
+
+// nextHash returns the hash following the hash, nil after the last hash.
+func nextHash(hash utils.Hash) []byte {
+	next := hash.Bytes()
+	for i := len(next) - 1; i >= 0; i-- {
+		if next[i]++; next[i] != 0 {
+			return next
+		}
+	}
+	return nil
+}
+
+// IterativeDump writes the accounts from the hashed key start as JSON lines, at most max
+// accounts if max is positive. It returns the hashed key to resume from, nil at the end.
+func (s *StateDB) IterativeDump(start []byte, max int, w io.Writer) ([]byte, error) {
+	encoder := json.NewEncoder(w)
+	return s.iterativeDump(start, max, func(account DumpAccount) error {
+		return encoder.Encode(account)
+	})
+}
+
+// DumpRange returns the accounts from the hashed key start, at most max accounts if max is
+// positive, and the hashed key of the next account, nil at the end.
+func (s *StateDB) DumpRange(start []byte, max int) ([]DumpAccount, []byte, error) {
+	accounts := []DumpAccount{}
+	next, err := s.iterativeDump(start, max, func(account DumpAccount) error {
+		accounts = append(accounts, account)
+		return nil
+	})
+	return accounts, next, err
+}
+
+// DumpAddress returns the dump of the account, false if it doesn't exist.
+func (s *StateDB) DumpAddress(addr utils.Address) (DumpAccount, bool, error) {
+	addrHash := crypto.Keccak256(addr[:])
+	value, err := s.trie.TryGet(addr[:])
+	if err != nil || len(value) == 0 {
+		return DumpAccount{}, false, err
+	}
+	account, err := s.dumpAccount(addrHash, addr[:], value)
+	if err != nil {
+		return DumpAccount{}, false, err
+	}
+	account.Address = utils.BytesToHex(addr[:])
+	account.Key = utils.BytesToHex(addrHash)
+	return account, true, nil
+}
+
+// StorageRangeAt returns the storage of the account from the hashed key start, at most max
+// slots if max is positive.
+func (s *StateDB) StorageRangeAt(addr utils.Address, start []byte, max int) (StorageRange, error) {
+	result := StorageRange{Storage: make(map[utils.Hash]StorageEntry)}
+	storageTrie := s.StorageTrie(addr)
+	if storageTrie == nil {
+		return result, nil
+	}
+	it := mtp.NewIterator(storageTrie.NodeIterator(start))
+	for n := 0; it.Next(); n++ {
+		if max > 0 && n == max {
+			next := utils.BytesToHash(it.Key)
+			result.NextKey = &next
+			break
+		}
+		_, content, _, err := rlp.Split(it.Value)
+		if err != nil {
+			return StorageRange{}, err
+		}
+		entry := StorageEntry{Value: utils.BytesToHash(content)}
+		if preimage := s.trie.GetKey(it.Key); preimage != nil {
+			key := utils.BytesToHash(preimage)
+			entry.Key = &key
+		}
+		result.Storage[utils.BytesToHash(it.Key)] = entry
+	}
+	return result, it.Err
+}
+
 func (s *StateDB) RawDump() Dump {
 	dump := Dump{
 		Root:     fmt.Sprintf("%x", s.trie.Hash()),
@@ -50,25 +258,10 @@ func (s *StateDB) RawDump() Dump {
 	it := mtp.NewIterator(s.trie.NodeIterator(nil))
 	for it.Next() {
 		addr := s.trie.GetKey(it.Key)
-		var data Account
-		if err := rlp.DecodeBytes(it.Value, &data); err != nil {
-			panic(err)
-		}
-
-		obj := newObject(nil, utils.BytesToAddress(addr), data)
-		account := DumpAccount{
-			Balance:           data.Balance.String(),
-			LockedBalance:     data.LockedBalance.String(),
-			DelegateTimestamp: data.DelegateTimestamp.String(),
-			Nonce:             data.Nonce,
-			Root:              utils.BytesToHex(data.Root[:]),
-			CodeHash:          utils.BytesToHex(data.CodeHash),
-			Code:              utils.BytesToHex(obj.Code(s.db)),
-			Storage:           make(map[string]string),
-		}
-		storageIt := mtp.NewIterator(obj.getTrie(s.db).NodeIterator(nil))
-		for storageIt.Next() {
-			account.Storage[utils.BytesToHex(s.trie.GetKey(storageIt.Key))] = utils.BytesToHex(storageIt.Value)
+		account, err := s.dumpAccount(it.Key, addr, it.Value)
+		if err != nil {
+			log.Errorf("Failed to dump account err: %v", err)
+			continue
 		}
 		dump.Accounts[utils.BytesToHex(addr)] = account
 	}
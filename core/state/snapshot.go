@@ -0,0 +1,619 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"sync"
+
+	ldb "github.com/UranusBlockStack/uranus/common/db"
+	"github.com/UranusBlockStack/uranus/common/log"
+	"github.com/UranusBlockStack/uranus/common/mtp"
+	"github.com/UranusBlockStack/uranus/common/rlp"
+	"github.com/UranusBlockStack/uranus/common/utils"
+)
+
+var (
+	// the flat state is keyed by the hashed keys of the tries, in the order of the tries
+	SnapshotAccountPrefix = []byte("sa") // SnapshotAccountPrefix + account hash -> account RLP
+	SnapshotStoragePrefix = []byte("ss") // SnapshotStoragePrefix + account hash + storage hash -> storage RLP
+	SnapshotRootKey       = []byte("SnapshotRoot")
+
+	errSnapshotStale      = errors.New("snapshot stale")
+	errSnapshotMissing    = errors.New("snapshot missing")
+	errSnapshotGenerating = errors.New("snapshot generating")
+	errSnapshotAborted    = errors.New("snapshot generation aborted")
+)
+
+func snapshotAccountKey(hash utils.Hash) []byte {
+	return append(append(make([]byte, 0, len(SnapshotAccountPrefix)+utils.HashLength), SnapshotAccountPrefix...), hash.Bytes()...)
+}
+
+func snapshotStorageKey(accountHash, hash utils.Hash) []byte {
+	key := make([]byte, 0, len(SnapshotStoragePrefix)+2*utils.HashLength)
+	return append(append(append(key, SnapshotStoragePrefix...), accountHash.Bytes()...), hash.Bytes()...)
+}
+
+// snapshot is a layer of the flat state at a state root. The accounts and the storage
+// slots are RLP encoded as in the tries, a missing entry is nil. The reads of a layer
+// flattened into the disk fail with errSnapshotStale and the reads of a disk layer being
+// generated with errSnapshotGenerating, the trie is read instead.
+type snapshot interface {
+	Root() utils.Hash
+	account(hash utils.Hash) ([]byte, error)
+	storage(accountHash, hash utils.Hash) ([]byte, error)
+	markStale()
+}
+
+// diskLayer is the flat state persisted in the database.
+type diskLayer struct {
+	db         ldb.Database
+	root       utils.Hash
+	lock       sync.RWMutex
+	stale      bool
+	generating bool
+}
+
+func (dl *diskLayer) Root() utils.Hash { return dl.root }
+
+func (dl *diskLayer) markStale() {
+	dl.lock.Lock()
+	dl.stale = true
+	dl.lock.Unlock()
+}
+
+func (dl *diskLayer) markGenerated() {
+	dl.lock.Lock()
+	dl.generating = false
+	dl.lock.Unlock()
+}
+
+// ready returns the error of the reads of the layer, nil if it can be read.
+func (dl *diskLayer) ready() error {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+	return dl.check()
+}
+
+// check is ready with the lock held.
+func (dl *diskLayer) check() error {
+	if dl.stale {
+		return errSnapshotStale
+	}
+	if dl.generating {
+		return errSnapshotGenerating
+	}
+	return nil
+}
+
+func (dl *diskLayer) get(key []byte) ([]byte, error) {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+	if err := dl.check(); err != nil {
+		return nil, err
+	}
+	data, err := dl.db.Get(key)
+	if err != nil {
+		if has, _ := dl.db.Has(key); !has {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+func (dl *diskLayer) account(hash utils.Hash) ([]byte, error) {
+	return dl.get(snapshotAccountKey(hash))
+}
+
+func (dl *diskLayer) storage(accountHash, hash utils.Hash) ([]byte, error) {
+	return dl.get(snapshotStorageKey(accountHash, hash))
+}
+
+// diffLayer is the in-memory change of the flat state by a block on top of its parent
+// layer. The destructed accounts lose their storage before the accounts and the storage
+// of the layer are applied, a nil entry is deleted.
+type diffLayer struct {
+	parent      snapshot
+	root        utils.Hash
+	destructs   map[utils.Hash]struct{}
+	accountData map[utils.Hash][]byte
+	storageData map[utils.Hash]map[utils.Hash][]byte
+	lock        sync.RWMutex
+	stale       bool
+}
+
+func (dl *diffLayer) Root() utils.Hash { return dl.root }
+
+func (dl *diffLayer) markStale() {
+	dl.lock.Lock()
+	dl.stale = true
+	dl.lock.Unlock()
+}
+
+func (dl *diffLayer) parentLayer() snapshot {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+	return dl.parent
+}
+
+func (dl *diffLayer) setParent(parent snapshot) {
+	dl.lock.Lock()
+	dl.parent = parent
+	dl.lock.Unlock()
+}
+
+func (dl *diffLayer) account(hash utils.Hash) ([]byte, error) {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+	if dl.stale {
+		return nil, errSnapshotStale
+	}
+	if data, ok := dl.accountData[hash]; ok {
+		return data, nil
+	}
+	if _, ok := dl.destructs[hash]; ok {
+		return nil, nil
+	}
+	return dl.parent.account(hash)
+}
+
+func (dl *diffLayer) storage(accountHash, hash utils.Hash) ([]byte, error) {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+	if dl.stale {
+		return nil, errSnapshotStale
+	}
+	if data, ok := dl.storageData[accountHash][hash]; ok {
+		return data, nil
+	}
+	if _, ok := dl.destructs[accountHash]; ok {
+		return nil, nil
+	}
+	return dl.parent.storage(accountHash, hash)
+}
+
+// SnapshotTree is the flat state of the recent state roots, the diff layers of the recent
+// blocks on top of the disk layer. The diff layers are flattened into the disk layer
+// once their blocks can't be reorganised anymore.
+type SnapshotTree struct {
+	db     ldb.Database
+	triedb *mtp.Database
+	lock   sync.RWMutex
+	layers map[utils.Hash]snapshot
+
+	writeLock sync.Mutex    // serializes the disk writes of the generation and the flattening
+	genAbort  chan struct{} // closed to abort the running generation
+	genDone   chan struct{} // closed once the running generation stopped
+}
+
+// NewSnapshotTree loads the flat state of the database, it is regenerated from the trie
+// of the root in the background if it's missing or persisted at another root.
+func NewSnapshotTree(db ldb.Database, triedb *mtp.Database, root utils.Hash) *SnapshotTree {
+	t := &SnapshotTree{db: db, triedb: triedb, layers: make(map[utils.Hash]snapshot)}
+	if data, _ := db.Get(SnapshotRootKey); len(data) == utils.HashLength && utils.BytesToHash(data) == root {
+		t.layers[root] = &diskLayer{db: db, root: root}
+		return t
+	}
+	t.Rebuild(root)
+	return t
+}
+
+// Rebuild drops the existing layers and regenerates the flat state from the trie of the
+// root in the background, a running generation is aborted. The new layers are added on
+// top of the root meanwhile and the states read the trie until it's generated.
+func (t *SnapshotTree) Rebuild(root utils.Hash) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for _, layer := range t.layers {
+		layer.markStale()
+	}
+	if t.genAbort != nil {
+		close(t.genAbort)
+	}
+	disk := &diskLayer{db: t.db, root: root, generating: true}
+	t.layers = map[utils.Hash]snapshot{root: disk}
+
+	abort, done, prev := make(chan struct{}), make(chan struct{}), t.genDone
+	t.genAbort, t.genDone = abort, done
+	go t.generate(disk, abort, done, prev)
+}
+
+// generate writes the flat state of the disk layer once the previous generation stopped.
+func (t *SnapshotTree) generate(disk *diskLayer, abort, done, prev chan struct{}) {
+	defer close(done)
+	if prev != nil {
+		<-prev
+	}
+	t.writeLock.Lock()
+	defer t.writeLock.Unlock()
+
+	switch err := generateSnapshot(t.db, t.triedb, disk.root, abort); err {
+	case nil:
+		disk.markGenerated()
+	case errSnapshotAborted:
+		log.Infof("State snapshot generation aborted root: %v", disk.root.Hex())
+	default:
+		log.Errorf("Failed to generate state snapshot root: %v, err: %v", disk.root.Hex(), err)
+	}
+}
+
+// Close aborts the running generation and waits for it, an aborted flat state is
+// generated again when it's enabled next time.
+func (t *SnapshotTree) Close() {
+	t.lock.Lock()
+	abort, done := t.genAbort, t.genDone
+	t.genAbort = nil
+	t.lock.Unlock()
+
+	if abort != nil {
+		close(abort)
+		<-done
+	}
+}
+
+// snapshot returns the layer of the root, nil if it's unknown.
+func (t *SnapshotTree) snapshot(root utils.Hash) snapshot {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.layers[root]
+}
+
+// Has returns whether the flat state of the root is known.
+func (t *SnapshotTree) Has(root utils.Hash) bool {
+	return t.snapshot(root) != nil
+}
+
+// Len returns the count of the layers.
+func (t *SnapshotTree) Len() int {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return len(t.layers)
+}
+
+// Update adds the diff layer of the root on top of the layer of its parent root.
+func (t *SnapshotTree) Update(root, parentRoot utils.Hash, destructs map[utils.Hash]struct{}, accounts map[utils.Hash][]byte, storage map[utils.Hash]map[utils.Hash][]byte) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if _, ok := t.layers[root]; ok || root == parentRoot {
+		return nil
+	}
+	parent, ok := t.layers[parentRoot]
+	if !ok {
+		return errSnapshotMissing
+	}
+	t.layers[root] = &diffLayer{parent: parent, root: root, destructs: destructs, accountData: accounts, storageData: storage}
+	return nil
+}
+
+// Flatten writes the diff layers up to the root into the disk layer. The layers which
+// don't descend from the root are dropped. The layers are kept in memory while the disk
+// layer is generated.
+func (t *SnapshotTree) Flatten(root utils.Hash) error {
+	// the generation holds the write lock, it isn't waited for
+	if generating, err := t.generating(root); generating || err != nil {
+		return err
+	}
+	t.writeLock.Lock()
+	defer t.writeLock.Unlock()
+
+	t.lock.Lock()
+	layer, ok := t.layers[root]
+	if !ok {
+		t.lock.Unlock()
+		return errSnapshotMissing
+	}
+	top, ok := layer.(*diffLayer)
+	if !ok {
+		t.lock.Unlock()
+		return nil
+	}
+	var (
+		diffs []*diffLayer
+		disk  snapshot
+	)
+	for disk = layer; ; {
+		diff, ok := disk.(*diffLayer)
+		if !ok {
+			break
+		}
+		diffs = append(diffs, diff)
+		disk = diff.parentLayer()
+	}
+	if err := disk.(*diskLayer).ready(); err != nil {
+		t.lock.Unlock()
+		return nil
+	}
+	// the flattened layers are stale before the disk changes, their reads go to the trie
+	disk.markStale()
+	for _, diff := range diffs {
+		diff.markStale()
+	}
+	t.lock.Unlock()
+
+	// the disk is written without the tree lock, the layers added meanwhile are moved on
+	// top of the new disk layer below
+	if err := t.db.Delete(SnapshotRootKey); err != nil {
+		return err
+	}
+	for i := len(diffs) - 1; i >= 0; i-- {
+		if err := diffs[i].flatten(t.db); err != nil {
+			return err
+		}
+	}
+	if err := t.db.Put(SnapshotRootKey, root.Bytes()); err != nil {
+		return err
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.layers[root] != layer {
+		// the tree was rebuilt meanwhile, its generation overwrites the disk
+		return nil
+	}
+	base := &diskLayer{db: t.db, root: root}
+	for hash, layer := range t.layers {
+		if hash == root {
+			continue
+		}
+		descends := false
+		for l := layer; ; {
+			diff, ok := l.(*diffLayer)
+			if !ok {
+				break
+			}
+			parent := diff.parentLayer()
+			if parent == snapshot(top) {
+				diff.setParent(base)
+				descends = true
+				break
+			}
+			if descends = parent == snapshot(base); descends {
+				break
+			}
+			l = parent
+		}
+		if !descends {
+			layer.markStale()
+			delete(t.layers, hash)
+		}
+	}
+	t.layers[root] = base
+	return nil
+}
+
+// generating returns whether the disk layer below the root is being generated.
+func (t *SnapshotTree) generating(root utils.Hash) (bool, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	layer, ok := t.layers[root]
+	if !ok {
+		return false, errSnapshotMissing
+	}
+	for {
+		diff, ok := layer.(*diffLayer)
+		if !ok {
+			break
+		}
+		layer = diff.parentLayer()
+	}
+	return layer.(*diskLayer).ready() == errSnapshotGenerating, nil
+}
+
+// flatten writes the changes of the layer into the database.
+func (dl *diffLayer) flatten(db ldb.Database) error {
+	batch := db.NewBatch()
+	for hash := range dl.destructs {
+		batch.Delete(snapshotAccountKey(hash))
+		prefix := append(append([]byte{}, SnapshotStoragePrefix...), hash.Bytes()...)
+		it := db.NewIteratorWithPrefix(prefix)
+		for it.Next() {
+			batch.Delete(utils.CopyBytes(it.Key()))
+		}
+		it.Release()
+		if err := it.Error(); err != nil {
+			return err
+		}
+	}
+	for hash, data := range dl.accountData {
+		if data == nil {
+			batch.Delete(snapshotAccountKey(hash))
+		} else {
+			batch.Put(snapshotAccountKey(hash), data)
+		}
+	}
+	for accountHash, slots := range dl.storageData {
+		for hash, data := range slots {
+			if data == nil {
+				batch.Delete(snapshotStorageKey(accountHash, hash))
+			} else {
+				batch.Put(snapshotStorageKey(accountHash, hash), data)
+			}
+		}
+	}
+	return batch.Write()
+}
+
+// accounts returns the accounts of the root from the hashed key start, at most max
+// accounts, sorted by hashed key.
+func (t *SnapshotTree) accounts(root utils.Hash, start []byte, max int) ([]utils.Hash, [][]byte, error) {
+	layer := t.snapshot(root)
+	if layer == nil {
+		return nil, nil, errSnapshotMissing
+	}
+
+	// the changes of the diff layers override the disk, the top layer first
+	overlay := make(map[utils.Hash][]byte)
+	for {
+		diff, ok := layer.(*diffLayer)
+		if !ok {
+			break
+		}
+		diff.lock.RLock()
+		if diff.stale {
+			diff.lock.RUnlock()
+			return nil, nil, errSnapshotStale
+		}
+		for hash, data := range diff.accountData {
+			if _, ok := overlay[hash]; !ok {
+				overlay[hash] = data
+			}
+		}
+		for hash := range diff.destructs {
+			if _, ok := overlay[hash]; !ok {
+				overlay[hash] = nil
+			}
+		}
+		layer = diff.parent
+		diff.lock.RUnlock()
+	}
+	var changed []utils.Hash
+	for hash := range overlay {
+		if bytes.Compare(hash[:], start) >= 0 {
+			changed = append(changed, hash)
+		}
+	}
+	sort.Slice(changed, func(i, j int) bool { return bytes.Compare(changed[i][:], changed[j][:]) < 0 })
+
+	disk := layer.(*diskLayer)
+	disk.lock.RLock()
+	defer disk.lock.RUnlock()
+	if err := disk.check(); err != nil {
+		return nil, nil, err
+	}
+	it := t.db.NewIteratorWithPrefix(SnapshotAccountPrefix)
+	defer it.Release()
+	valid := it.Seek(append(append([]byte{}, SnapshotAccountPrefix...), start...))
+
+	var (
+		hashes []utils.Hash
+		values [][]byte
+	)
+	for len(hashes) < max && (valid || len(changed) > 0) {
+		var (
+			hash, diskHash utils.Hash
+			data           []byte
+		)
+		if valid {
+			diskHash = utils.BytesToHash(it.Key()[len(SnapshotAccountPrefix):])
+		}
+		switch {
+		case !valid || (len(changed) > 0 && bytes.Compare(changed[0][:], diskHash[:]) <= 0):
+			hash, data = changed[0], overlay[changed[0]]
+			if valid && hash == diskHash {
+				valid = it.Next()
+			}
+			changed = changed[1:]
+		default:
+			hash, data = diskHash, utils.CopyBytes(it.Value())
+			valid = it.Next()
+		}
+		if data != nil {
+			hashes, values = append(hashes, hash), append(values, data)
+		}
+	}
+	return hashes, values, it.Error()
+}
+
+// generateSnapshot writes the flat state of the trie of the root into the database, the
+// previous flat state is wiped. The generation stops with errSnapshotAborted before a
+// batch is written once abort is closed.
+func generateSnapshot(db ldb.Database, triedb *mtp.Database, root utils.Hash, abort <-chan struct{}) error {
+	log.Infof("Generating state snapshot root: %v", root.Hex())
+	if err := db.Delete(SnapshotRootKey); err != nil {
+		return err
+	}
+	batch := db.NewBatch()
+	flush := func(force bool) error {
+		if batch.ValueSize() < ldb.IdealBatchSize && !force {
+			return nil
+		}
+		select {
+		case <-abort:
+			return errSnapshotAborted
+		default:
+		}
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		batch.Reset()
+		return nil
+	}
+	for _, prefix := range [][]byte{SnapshotAccountPrefix, SnapshotStoragePrefix} {
+		it := db.NewIteratorWithPrefix(prefix)
+		for it.Next() {
+			batch.Delete(utils.CopyBytes(it.Key()))
+			if err := flush(false); err != nil {
+				it.Release()
+				return err
+			}
+		}
+		it.Release()
+		if err := it.Error(); err != nil {
+			return err
+		}
+	}
+
+	var accounts, slots int
+	tr, err := mtp.NewTrieWarp(root, triedb, 0)
+	if err != nil {
+		return err
+	}
+	it := mtp.NewIterator(tr.NodeIterator(nil))
+	for it.Next() {
+		accountHash := utils.BytesToHash(it.Key)
+		batch.Put(snapshotAccountKey(accountHash), utils.CopyBytes(it.Value))
+		accounts++
+
+		var data Account
+		if err := rlp.DecodeBytes(it.Value, &data); err != nil {
+			return err
+		}
+		storage, err := mtp.NewTrieWarp(data.Root, triedb, 0)
+		if err != nil {
+			return err
+		}
+		storageIt := mtp.NewIterator(storage.NodeIterator(nil))
+		for storageIt.Next() {
+			batch.Put(snapshotStorageKey(accountHash, utils.BytesToHash(storageIt.Key)), utils.CopyBytes(storageIt.Value))
+			slots++
+			if err := flush(false); err != nil {
+				return err
+			}
+		}
+		if storageIt.Err != nil {
+			return storageIt.Err
+		}
+		if err := flush(false); err != nil {
+			return err
+		}
+	}
+	if it.Err != nil {
+		return it.Err
+	}
+	batch.Put(SnapshotRootKey, root.Bytes())
+	if err := flush(true); err != nil {
+		return err
+	}
+	log.Infof("Generated state snapshot accounts: %v, slots: %v", accounts, slots)
+	return nil
+}
@@ -0,0 +1,239 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	ldb "github.com/UranusBlockStack/uranus/common/db"
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	snapAddrs = []utils.Address{{1}, {2}, {3}, {4}, {5}}
+	snapKeys  = []utils.Hash{{1}, {2}, {3}}
+)
+
+// commitSnapshotState commits the state and its trie nodes to the database.
+func commitSnapshotState(t *testing.T, state *StateDB) utils.Hash {
+	root, err := state.Commit(true)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	if err := state.Database().TrieDB().Commit(root, false); err != nil {
+		t.Fatalf("failed to commit trie: %v", err)
+	}
+	return root
+}
+
+// waitSnapshot waits for the running generation of the flat state.
+func waitSnapshot(snaps *SnapshotTree) {
+	snaps.lock.RLock()
+	done := snaps.genDone
+	snaps.lock.RUnlock()
+	if done != nil {
+		<-done
+	}
+}
+
+// checkSnapshotState compares the state of the root read with the flat state and with
+// the trie only.
+func checkSnapshotState(t *testing.T, diskdb ldb.Database, sdb Database, root utils.Hash) {
+	state, err := New(root, sdb)
+	if err != nil {
+		t.Fatalf("failed to open state: %v", err)
+	}
+	assert.NotNil(t, state.snap)
+	trieState, err := New(root, NewDatabase(diskdb))
+	if err != nil {
+		t.Fatalf("failed to open state: %v", err)
+	}
+	for _, addr := range snapAddrs {
+		assert.Equal(t, trieState.Exist(addr), state.Exist(addr), addr.Hex())
+		assert.Equal(t, trieState.GetBalance(addr), state.GetBalance(addr), addr.Hex())
+		assert.Equal(t, trieState.GetNonce(addr), state.GetNonce(addr), addr.Hex())
+		assert.Equal(t, trieState.GetCode(addr), state.GetCode(addr), addr.Hex())
+		for _, key := range snapKeys {
+			assert.Equal(t, trieState.GetState(addr, key), state.GetState(addr, key), addr.Hex())
+		}
+	}
+	snapDump, _, err := state.DumpRange(nil, 0)
+	assert.NoError(t, err)
+	trieDump, _, err := trieState.DumpRange(nil, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, trieDump, snapDump)
+	snapPage, snapNext, err := state.DumpRange(nil, 2)
+	assert.NoError(t, err)
+	triePage, trieNext, err := trieState.DumpRange(nil, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, triePage, snapPage)
+	assert.Equal(t, trieNext, snapNext)
+}
+
+func TestSnapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	diskdb, err := ldb.NewLDB(dir, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer diskdb.Close()
+
+	// the generated flat state of the genesis
+	sdb := NewDatabase(diskdb)
+	state, _ := New(utils.Hash{}, sdb)
+	for i, addr := range snapAddrs[:4] {
+		state.SetBalance(addr, big.NewInt(int64(i+1)))
+		state.SetNonce(addr, uint64(i))
+		state.SetState(addr, snapKeys[0], utils.Hash{byte(i + 1)})
+		state.SetState(addr, snapKeys[1], utils.Hash{byte(i + 1)})
+	}
+	state.SetCode(snapAddrs[3], []byte{1, 2, 3})
+	root0 := commitSnapshotState(t, state)
+	sdb.EnableSnapshots(root0)
+	waitSnapshot(sdb.Snapshots())
+	checkSnapshotState(t, diskdb, sdb, root0)
+
+	// a block deleting, recreating, updating and creating accounts
+	state, _ = New(root0, sdb)
+	state.AddBalance(snapAddrs[0], big.NewInt(10))
+	state.SetState(snapAddrs[0], snapKeys[0], utils.Hash{})
+	state.SetState(snapAddrs[0], snapKeys[2], utils.Hash{9})
+	state.Suicide(snapAddrs[1])
+	state.Finalise(true)
+	state.CreateAccount(snapAddrs[1])
+	state.SetNonce(snapAddrs[1], 5)
+	state.SetState(snapAddrs[1], snapKeys[2], utils.Hash{8})
+	state.CreateAccount(snapAddrs[2])
+	state.SetNonce(snapAddrs[2], 5)
+	assert.Equal(t, utils.Hash{}, state.GetState(snapAddrs[2], snapKeys[0]))
+	state.SetState(snapAddrs[4], snapKeys[1], utils.Hash{7})
+	state.SetNonce(snapAddrs[4], 1)
+
+	// the reverted reset keeps the storage of the account
+	revision := state.Snapshot()
+	state.CreateAccount(snapAddrs[3])
+	state.RevertToSnapshot(revision)
+	root1 := commitSnapshotState(t, state)
+	assert.Equal(t, 2, sdb.Snapshots().Len())
+	checkSnapshotState(t, diskdb, sdb, root1)
+
+	// a block of the same state reuses the layer, a fork from the genesis adds a layer
+	state, _ = New(root1, sdb)
+	state.AddBalance(snapAddrs[2], big.NewInt(3))
+	root2 := commitSnapshotState(t, state)
+	state, _ = New(root0, sdb)
+	state.SetState(snapAddrs[0], snapKeys[0], utils.Hash{6})
+	fork := commitSnapshotState(t, state)
+	assert.Equal(t, 4, sdb.Snapshots().Len())
+	checkSnapshotState(t, diskdb, sdb, root2)
+	checkSnapshotState(t, diskdb, sdb, fork)
+
+	// the flattened layers are read from the trie, the fork is dropped
+	stale, _ := New(root1, sdb)
+	assert.NoError(t, sdb.Snapshots().Flatten(root1))
+	assert.Equal(t, 2, sdb.Snapshots().Len())
+	assert.False(t, sdb.Snapshots().Has(fork))
+	assert.Equal(t, big.NewInt(11), stale.GetBalance(snapAddrs[0]))
+	assert.Equal(t, utils.Hash{9}, stale.GetState(snapAddrs[0], snapKeys[2]))
+	checkSnapshotState(t, diskdb, sdb, root1)
+	checkSnapshotState(t, diskdb, sdb, root2)
+
+	// the flat state is loaded without generation at the flattened root
+	data, _ := diskdb.Get(SnapshotRootKey)
+	assert.Equal(t, root1.Bytes(), data)
+	sdb = NewDatabase(diskdb)
+	sdb.EnableSnapshots(root1)
+	checkSnapshotState(t, diskdb, sdb, root1)
+
+	// the flat state is regenerated at another root
+	sdb.Snapshots().Rebuild(root2)
+	waitSnapshot(sdb.Snapshots())
+	assert.False(t, sdb.Snapshots().Has(root1))
+	checkSnapshotState(t, diskdb, sdb, root2)
+}
+
+func TestSnapshotGeneration(t *testing.T) {
+	diskdb := ldb.NewMemDatabase()
+	sdb := NewDatabase(diskdb)
+	state, _ := New(utils.Hash{}, sdb)
+	for i, addr := range snapAddrs[:3] {
+		state.SetBalance(addr, big.NewInt(int64(i+1)))
+		state.SetState(addr, snapKeys[0], utils.Hash{byte(i + 1)})
+	}
+	root0 := commitSnapshotState(t, state)
+
+	// the generation waits for the disk writes, the states read the trie meanwhile
+	sdb.EnableSnapshots(root0)
+	snaps := sdb.Snapshots()
+	snaps.writeLock.Lock()
+	state, _ = New(root0, sdb)
+	assert.Equal(t, big.NewInt(2), state.GetBalance(snapAddrs[1]))
+	assert.Equal(t, utils.Hash{3}, state.GetState(snapAddrs[2], snapKeys[0]))
+	dump, _, err := state.DumpRange(nil, 0)
+	assert.NoError(t, err)
+	assert.Len(t, dump, 3)
+
+	// the blocks written meanwhile are kept in memory until the generation is done
+	state.AddBalance(snapAddrs[0], big.NewInt(10))
+	state.SetState(snapAddrs[1], snapKeys[1], utils.Hash{9})
+	root1 := commitSnapshotState(t, state)
+	assert.True(t, snaps.Has(root1))
+	assert.NoError(t, snaps.Flatten(root1))
+	assert.Equal(t, 2, snaps.Len())
+	state, _ = New(root1, sdb)
+	assert.Equal(t, big.NewInt(11), state.GetBalance(snapAddrs[0]))
+
+	snaps.writeLock.Unlock()
+	waitSnapshot(snaps)
+	checkSnapshotState(t, diskdb, sdb, root0)
+	checkSnapshotState(t, diskdb, sdb, root1)
+	assert.NoError(t, snaps.Flatten(root1))
+	assert.Equal(t, 1, snaps.Len())
+	checkSnapshotState(t, diskdb, sdb, root1)
+	data, _ := diskdb.Get(SnapshotRootKey)
+	assert.Equal(t, root1.Bytes(), data)
+
+	// a closed generation is aborted and runs again when the flat state is enabled
+	snaps.writeLock.Lock()
+	snaps.Rebuild(root0)
+	closed := make(chan struct{})
+	go func() {
+		snaps.Close()
+		close(closed)
+	}()
+	for aborted := false; !aborted; {
+		snaps.lock.RLock()
+		aborted = snaps.genAbort == nil
+		snaps.lock.RUnlock()
+	}
+	snaps.writeLock.Unlock()
+	<-closed
+	has, _ := diskdb.Has(SnapshotRootKey)
+	assert.False(t, has)
+	sdb = NewDatabase(diskdb)
+	sdb.EnableSnapshots(root0)
+	waitSnapshot(sdb.Snapshots())
+	checkSnapshotState(t, diskdb, sdb, root0)
+}
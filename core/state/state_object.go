@@ -80,6 +80,11 @@ type stateObject struct {
 	cachedStorage Storage // Storage entry cache to avoid duplicate reads
 	dirtyStorage  Storage // Storage entries that need to be flushed to disk
 
+	// Flat state changes, the storage slots flushed to the trie since the last commit by
+	// hashed key. The storage of the created accounts isn't read from the flat state.
+	snapStorage map[utils.Hash][]byte
+	created     bool
+
 	// Cache flags.
 	// When an object is marked suicided it will be delete from the trie
 	// during the "update" phase of the state transition.
@@ -129,6 +134,7 @@ func newObject(db *StateDB, address utils.Address, data Account) *stateObject {
 		data:          data,
 		cachedStorage: make(Storage),
 		dirtyStorage:  make(Storage),
+		snapStorage:   make(map[utils.Hash][]byte),
 	}
 }
 
@@ -177,8 +183,11 @@ func (s *stateObject) GetState(db Database, key utils.Hash) utils.Hash {
 	if exists {
 		return value
 	}
-	// Load from DB in case it is missing.
-	enc, err := s.getTrie(db).TryGet(key[:])
+	// Load from the flat state or the DB in case it is missing.
+	enc, err := s.snapshotState(key)
+	if err != nil {
+		enc, err = s.getTrie(db).TryGet(key[:])
+	}
 	if err != nil {
 		s.setError(err)
 		return utils.Hash{}
@@ -194,6 +203,15 @@ func (s *stateObject) GetState(db Database, key utils.Hash) utils.Hash {
 	return value
 }
 
+// snapshotState reads a value of the account storage from the flat state of the root
+// the state is opened at.
+func (s *stateObject) snapshotState(key utils.Hash) ([]byte, error) {
+	if s.db.snap == nil || s.created {
+		return nil, errSnapshotMissing
+	}
+	return s.db.snap.storage(s.addrHash, crypto.Keccak256Hash(key[:]))
+}
+
 // SetState updates a value in account storage.
 func (s *stateObject) SetState(db Database, key, value utils.Hash) {
 	s.db.journal.append(storageChange{
@@ -216,15 +234,24 @@ func (s *stateObject) updateTrie(db Database) Trie {
 		delete(s.dirtyStorage, key)
 		if (value == utils.Hash{}) {
 			s.setError(tr.TryDelete(key[:]))
+			s.updateSnapshotState(key, nil)
 			continue
 		}
 		// Encoding []byte cannot fail, ok to ignore the error.
 		v, _ := rlp.EncodeToBytes(bytes.TrimLeft(value[:], "\x00"))
 		s.setError(tr.TryUpdate(key[:], v))
+		s.updateSnapshotState(key, v)
 	}
 	return tr
 }
 
+// updateSnapshotState records the storage slot written to the trie for the flat state.
+func (s *stateObject) updateSnapshotState(key utils.Hash, value []byte) {
+	if s.db.snap != nil {
+		s.snapStorage[crypto.Keccak256Hash(key[:])] = value
+	}
+}
+
 // UpdateRoot sets the trie root to the current root hash of
 func (s *stateObject) updateRoot(db Database) {
 	s.updateTrie(db)
@@ -333,6 +360,10 @@ func (s *stateObject) deepCopy(db *StateDB) *stateObject {
 	stateObject.suicided = s.suicided
 	stateObject.dirtyCode = s.dirtyCode
 	stateObject.deleted = s.deleted
+	stateObject.created = s.created
+	for key, value := range s.snapStorage {
+		stateObject.snapStorage[key] = value
+	}
 	return stateObject
 }
 
@@ -18,6 +18,7 @@ package state
 
 import (
 	"bytes"
+	"encoding/json"
 	"math/big"
 	"testing"
 	"time"
@@ -95,6 +96,55 @@ func (s *StateSuite) TestDump(c *checker.C) {
 	}
 }
 
+func (s *StateSuite) TestIterativeDump(c *checker.C) {
+	for i := byte(1); i <= 5; i++ {
+		s.state.AddBalance(toAddr([]byte{i}), big.NewInt(int64(i)))
+	}
+	s.state.SetState(toAddr([]byte{1}), utils.BytesToHash([]byte{1}), utils.BytesToHash([]byte{2}))
+	s.state.Commit(false)
+
+	// page through the accounts two by two
+	var (
+		lines int
+		start []byte
+		buf   bytes.Buffer
+	)
+	for pages := 0; ; pages++ {
+		next, err := s.state.IterativeDump(start, 2, &buf)
+		c.Assert(err, checker.IsNil)
+		if next == nil {
+			c.Assert(pages, checker.Equals, 2)
+			break
+		}
+		start = next
+	}
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		var account DumpAccount
+		c.Assert(json.Unmarshal(line, &account), checker.IsNil)
+		c.Assert(account.Address, checker.Not(checker.Equals), "")
+		lines++
+	}
+	c.Assert(lines, checker.Equals, 5)
+
+	account, ok, err := s.state.DumpAddress(toAddr([]byte{1}))
+	c.Assert(err, checker.IsNil)
+	c.Assert(ok, checker.Equals, true)
+	c.Assert(account.Balance, checker.Equals, "1")
+	c.Assert(len(account.Storage), checker.Equals, 1)
+
+	_, ok, err = s.state.DumpAddress(toAddr([]byte{9}))
+	c.Assert(err, checker.IsNil)
+	c.Assert(ok, checker.Equals, false)
+
+	storage, err := s.state.StorageRangeAt(toAddr([]byte{1}), nil, 0)
+	c.Assert(err, checker.IsNil)
+	c.Assert(len(storage.Storage), checker.Equals, 1)
+	for _, entry := range storage.Storage {
+		c.Assert(entry.Value, checker.Equals, utils.BytesToHash([]byte{2}))
+	}
+	c.Assert(storage.NextKey, checker.IsNil)
+}
+
 func (s *StateSuite) SetUpTest(c *checker.C) {
 	s.db = ldb.NewMemDatabase()
 	s.state, _ = New(utils.Hash{}, NewDatabase(s.db))
@@ -53,6 +53,10 @@ type StateDB struct {
 	db   Database
 	trie Trie
 
+	// The flat state of the root the state is opened at, it's read before the trie.
+	snaps *SnapshotTree
+	snap  snapshot
+
 	// This map holds 'live' objects, which will get modified while processing a state transition.
 	stateObjects      map[utils.Address]*stateObject
 	stateObjectsDirty map[utils.Address]struct{}
@@ -89,7 +93,7 @@ func New(root utils.Hash, db Database) (*StateDB, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &StateDB{
+	s := &StateDB{
 		db:                db,
 		trie:              tr,
 		stateObjects:      make(map[utils.Address]*stateObject),
@@ -97,7 +101,17 @@ func New(root utils.Hash, db Database) (*StateDB, error) {
 		logs:              make(map[utils.Hash][]*types.Log),
 		preimages:         make(map[utils.Hash][]byte),
 		journal:           newJournal(),
-	}, nil
+	}
+	s.openSnapshot(root)
+	return s, nil
+}
+
+// openSnapshot opens the flat state of the root if it's known.
+func (s *StateDB) openSnapshot(root utils.Hash) {
+	s.snap = nil
+	if s.snaps = s.db.Snapshots(); s.snaps != nil {
+		s.snap = s.snaps.snapshot(root)
+	}
 }
 
 // setError remembers the first non-nil error it is called with.
@@ -119,6 +133,7 @@ func (s *StateDB) Reset(root utils.Hash) error {
 		return err
 	}
 	s.trie = tr
+	s.openSnapshot(root)
 	s.stateObjects = make(map[utils.Address]*stateObject)
 	s.stateObjectsDirty = make(map[utils.Address]struct{})
 	s.thash = utils.Hash{}
@@ -410,8 +425,11 @@ func (s *StateDB) getStateObject(addr utils.Address) (stateObject *stateObject)
 		return obj
 	}
 
-	// Load the object from the database.
-	enc, err := s.trie.TryGet(addr[:])
+	// Load the object from the flat state or the database.
+	enc, err := s.snapshotAccount(addr)
+	if err != nil {
+		enc, err = s.trie.TryGet(addr[:])
+	}
 	if len(enc) == 0 {
 		s.setError(err)
 		return nil
@@ -427,6 +445,14 @@ func (s *StateDB) getStateObject(addr utils.Address) (stateObject *stateObject)
 	return obj
 }
 
+// snapshotAccount reads the account from the flat state of the root the state is opened at.
+func (s *StateDB) snapshotAccount(addr utils.Address) ([]byte, error) {
+	if s.snap == nil {
+		return nil, errSnapshotMissing
+	}
+	return s.snap.account(crypto.Keccak256Hash(addr[:]))
+}
+
 func (s *StateDB) setStateObject(object *stateObject) {
 	s.stateObjects[object.Address()] = object
 }
@@ -445,6 +471,7 @@ func (s *StateDB) GetOrNewStateObject(addr utils.Address) *stateObject {
 func (s *StateDB) createObject(addr utils.Address) (newobj, prev *stateObject) {
 	prev = s.getStateObject(addr)
 	newobj = newObject(s, addr, Account{})
+	newobj.created = true
 	newobj.setNonce(0) // sets the object to dirty
 	if prev == nil {
 		s.journal.append(createObjectChange{account: &addr})
@@ -503,6 +530,8 @@ func (s *StateDB) Copy() *StateDB {
 	state := &StateDB{
 		db:                s.db,
 		trie:              s.db.CopyTrie(s.trie),
+		snaps:             s.snaps,
+		snap:              s.snap,
 		stateObjects:      make(map[utils.Address]*stateObject, len(s.journal.dirties)),
 		stateObjectsDirty: make(map[utils.Address]struct{}, len(s.journal.dirties)),
 		refund:            s.refund,
@@ -627,6 +656,16 @@ func (s *StateDB) Commit(deleteEmptyObjects bool) (root utils.Hash, err error) {
 	for addr := range s.journal.dirties {
 		s.stateObjectsDirty[addr] = struct{}{}
 	}
+	var (
+		destructs map[utils.Hash]struct{}
+		accounts  map[utils.Hash][]byte
+		storage   map[utils.Hash]map[utils.Hash][]byte
+	)
+	if s.snap != nil {
+		destructs = make(map[utils.Hash]struct{})
+		accounts = make(map[utils.Hash][]byte)
+		storage = make(map[utils.Hash]map[utils.Hash][]byte)
+	}
 	// Commit objects to the mtp.
 	for addr, stateObject := range s.stateObjects {
 		_, isDirty := s.stateObjectsDirty[addr]
@@ -648,6 +687,28 @@ func (s *StateDB) Commit(deleteEmptyObjects bool) (root utils.Hash, err error) {
 			// Update the object in the main account mtp.
 			s.updateStateObject(stateObject)
 		}
+		if isDirty && s.snap != nil {
+			// the storage of the deleted and the recreated accounts is wiped from the flat state
+			if stateObject.deleted || stateObject.created {
+				destructs[stateObject.addrHash] = struct{}{}
+			}
+			if stateObject.deleted {
+				accounts[stateObject.addrHash] = nil
+			} else {
+				data, err := rlp.EncodeToBytes(stateObject)
+				if err != nil {
+					return utils.Hash{}, err
+				}
+				accounts[stateObject.addrHash] = data
+				if len(stateObject.snapStorage) > 0 {
+					storage[stateObject.addrHash] = stateObject.snapStorage
+				}
+			}
+		}
+		if isDirty {
+			stateObject.created = false
+			stateObject.snapStorage = make(map[utils.Hash][]byte)
+		}
 		delete(s.stateObjectsDirty, addr)
 	}
 	// Write trie changes.
@@ -665,5 +726,16 @@ func (s *StateDB) Commit(deleteEmptyObjects bool) (root utils.Hash, err error) {
 		}
 		return nil
 	})
-	return root, err
+	if err != nil {
+		return root, err
+	}
+	if s.snap != nil {
+		if err := s.snaps.Update(root, s.snap.Root(), destructs, accounts, storage); err != nil {
+			log.Debugf("Failed to update state snapshot root: %v, parent: %v, err: %v", root.Hex(), s.snap.Root().Hex(), err)
+		}
+	}
+	if s.snaps != nil {
+		s.snap = s.snaps.snapshot(root)
+	}
+	return root, nil
 }
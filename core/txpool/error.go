@@ -57,4 +57,12 @@ var (
 	// than some meaningful limit a user might use. This is not a consensus error
 	// making the transaction invalid, rather a DOS protection.
 	ErrOversizedData = errors.New("oversized data")
+
+	// ErrTxTypeNotSupported is returned if a dynamic fee transaction is received
+	// before the base fee fork.
+	ErrTxTypeNotSupported = errors.New("dynamic fee transaction not supported")
+
+	// ErrFeeCapTooLow is returned if the transaction fee cap is less than the
+	// base fee of the next block.
+	ErrFeeCapTooLow = errors.New("max fee per gas less than block base fee")
 )
@@ -57,8 +57,11 @@ func (l *txList) Add(tx *types.Transaction, priceBump uint64) (bool, *types.Tran
 	// If there's an older better transaction, abort
 	old := l.txs.Get(tx.Nonce())
 	if old != nil {
-		threshold := new(big.Int).Div(new(big.Int).Mul(old.GasPrice(), big.NewInt(100+int64(priceBump))), big.NewInt(100))
-		if old.GasPrice().Cmp(tx.GasPrice()) >= 0 || threshold.Cmp(tx.GasPrice()) > 0 {
+		// both the fee cap and the tip must be bumped, they are the gas price of a legacy transaction
+		feeCapThreshold := new(big.Int).Div(new(big.Int).Mul(old.GasFeeCap(), big.NewInt(100+int64(priceBump))), big.NewInt(100))
+		tipThreshold := new(big.Int).Div(new(big.Int).Mul(old.GasTipCap(), big.NewInt(100+int64(priceBump))), big.NewInt(100))
+		if old.GasFeeCap().Cmp(tx.GasFeeCap()) >= 0 || old.GasTipCap().Cmp(tx.GasTipCap()) >= 0 ||
+			feeCapThreshold.Cmp(tx.GasFeeCap()) > 0 || tipThreshold.Cmp(tx.GasTipCap()) > 0 {
 			return false, nil
 		}
 	}
@@ -327,9 +330,10 @@ func (m *txSortedMap) Flatten() types.Transactions {
 }
 
 type priceList struct {
-	all    *allTxs    // Pointer to the map of all transactions
-	items  *priceHeap // Heap of prices of all the stored transactions
-	stales int        // Number of stale price points to (re-heap trigger)
+	all     *allTxs    // Pointer to the map of all transactions
+	items   *priceHeap // Heap of prices of all the stored transactions
+	stales  int        // Number of stale price points to (re-heap trigger)
+	baseFee *big.Int   // Base fee the effective tips are ranked with, nil before the fork
 }
 
 // newpriceList creates a new price-sorted transaction heap.
@@ -340,14 +344,25 @@ func newpriceList(all *allTxs) *priceList {
 	}
 }
 
-// Put inserts a new transaction into the heap.
-func (l *priceList) Put(tx *types.Transaction) {
-	value := &priceNonce{
-		price: tx.GasPrice(),
+// priceNonce returns the price point of the transaction, legacy and dynamic fee
+// transactions are both ranked by the tip they pay above the base fee.
+func (l *priceList) priceNonce(tx *types.Transaction) *priceNonce {
+	return &priceNonce{
+		price: tx.EffectiveGasTip(l.baseFee),
 		nonce: tx.Nonce(),
 		hash:  tx.Hash(),
 	}
-	heap.Push(l.items, value)
+}
+
+// Put inserts a new transaction into the heap.
+func (l *priceList) Put(tx *types.Transaction) {
+	heap.Push(l.items, l.priceNonce(tx))
+}
+
+// SetBaseFee updates the base fee of the next block and reranks the transactions.
+func (l *priceList) SetBaseFee(baseFee *big.Int) {
+	l.baseFee = baseFee
+	l.reheap()
 }
 
 // Removed notifies the prices transaction list that an old transaction dropped from the pool.
@@ -358,18 +373,23 @@ func (l *priceList) Removed() {
 		return
 	}
 	// Seems we've reached a critical number of stale transactions, reheap
+	l.reheap()
+}
+
+// reheap rebuilds the heap from the transactions of the pool.
+func (l *priceList) reheap() {
 	reheap := make(priceHeap, 0, l.all.Count())
 
 	l.stales, l.items = 0, &reheap
-	l.all.Range(func(hash utils.Hash, pn *priceNonce) bool {
-		*l.items = append(*l.items, pn)
+	l.all.Range(func(hash utils.Hash, tx *types.Transaction) bool {
+		*l.items = append(*l.items, l.priceNonce(tx))
 		return true
 	})
 	heap.Init(l.items)
 }
 
-// Cap finds all the transactions below the given price threshold, drops them
-// from the priced list and returs them for further removal from the entire pool.
+// Cap finds all the transactions with a tip cap below the given price threshold, drops
+// them from the priced list and returs them for further removal from the entire pool.
 func (l *priceList) Cap(threshold *big.Int) []*priceNonce {
 	drop := make([]*priceNonce, 0, 128)
 	save := make([]*priceNonce, 0, 64)
@@ -377,7 +397,8 @@ func (l *priceList) Cap(threshold *big.Int) []*priceNonce {
 	for len(*l.items) > 0 {
 		// Discard stale transactions if found during cleanup
 		pn := heap.Pop(l.items).(*priceNonce)
-		if l.all.Get(pn.hash) == nil {
+		tx := l.all.Get(pn.hash)
+		if tx == nil {
 			l.stales--
 			continue
 		}
@@ -386,6 +407,11 @@ func (l *priceList) Cap(threshold *big.Int) []*priceNonce {
 			save = append(save, pn)
 			break
 		}
+		// the effective tip is below the tip cap once the base fee eats into the fee cap
+		if tx.GasTipCap().Cmp(threshold) >= 0 {
+			save = append(save, pn)
+			continue
+		}
 		drop = append(drop, pn)
 	}
 	for _, pn := range save {
@@ -413,7 +439,7 @@ func (l *priceList) Underpriced(tx *types.Transaction) bool {
 		return false
 	}
 	cheapest := []*priceNonce(*l.items)[0]
-	return cheapest.price.Cmp(tx.GasPrice()) >= 0
+	return cheapest.price.Cmp(tx.EffectiveGasTip(l.baseFee)) >= 0
 }
 
 // Discard finds a number of most underpriced transactions, removes them from the
@@ -446,17 +472,12 @@ func newallTxs() *allTxs {
 }
 
 // Range calls f on each key and value present in the map.
-func (t *allTxs) Range(f func(hash utils.Hash, pn *priceNonce) bool) {
+func (t *allTxs) Range(f func(hash utils.Hash, tx *types.Transaction) bool) {
 	t.lock.RLock()
 	defer t.lock.RUnlock()
 
 	for key, tx := range t.txs {
-		value := &priceNonce{
-			price: tx.GasPrice(),
-			nonce: tx.Nonce(),
-			hash:  tx.Hash(),
-		}
-		if !f(key, value) {
+		if !f(key, tx) {
 			break
 		}
 	}
@@ -17,10 +17,12 @@
 package txpool
 
 import (
+	"math/big"
 	"math/rand"
 	"testing"
 
 	"github.com/UranusBlockStack/uranus/common/crypto"
+	"github.com/UranusBlockStack/uranus/common/utils"
 	"github.com/UranusBlockStack/uranus/core/types"
 	"github.com/stretchr/testify/assert"
 )
@@ -41,3 +43,54 @@ func TestTxListAdd(t *testing.T) {
 		assert.Equal(t, list.txs.items[tx.Nonce()], tx)
 	}
 }
+
+func TestPriceListMixedTypes(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	add := utils.Address{}
+	dynamicFeeTx := func(nonce uint64, feeCap, tipCap int64) *types.Transaction {
+		tx := types.NewDynamicFeeTransaction(types.Binary, nonce, big.NewInt(100), 100000, big.NewInt(feeCap), big.NewInt(tipCap), nil, &add)
+		tx.SignTx(types.Signer{}, key)
+		return tx
+	}
+	var (
+		legacy  = pricedTransaction(0, 100000, big.NewInt(14), key) // tip 4 above the base fee
+		dynamic = dynamicFeeTx(1, 20, 5)                            // tip 5
+		capped  = dynamicFeeTx(2, 12, 5)                            // tip 2, capped by the fee cap
+	)
+	all := newallTxs()
+	list := newpriceList(all)
+	list.SetBaseFee(big.NewInt(10))
+	for _, tx := range []*types.Transaction{legacy, dynamic, capped} {
+		all.Add(tx)
+		list.Put(tx)
+	}
+
+	// the transactions are ranked by the effective tip whatever their type
+	assert.True(t, list.Underpriced(pricedTransaction(3, 100000, big.NewInt(12), key)))
+	assert.False(t, list.Underpriced(pricedTransaction(3, 100000, big.NewInt(13), key)))
+	drop := list.Discard(3)
+	if assert.Len(t, drop, 3) {
+		assert.Equal(t, capped.Hash(), drop[0].hash)
+		assert.Equal(t, legacy.Hash(), drop[1].hash)
+		assert.Equal(t, dynamic.Hash(), drop[2].hash)
+	}
+
+	// a higher base fee reranks them, the legacy transaction only pays 1 above it
+	list.SetBaseFee(big.NewInt(13))
+	drop = list.Discard(1)
+	if assert.Len(t, drop, 1) {
+		assert.Equal(t, capped.Hash(), drop[0].hash)
+	}
+	drop = list.Discard(1)
+	if assert.Len(t, drop, 1) {
+		assert.Equal(t, legacy.Hash(), drop[0].hash)
+	}
+
+	// the minimum gas price applies to the tip cap, the gas price of a legacy transaction
+	list.SetBaseFee(big.NewInt(13))
+	drop = list.Cap(big.NewInt(6))
+	if assert.Len(t, drop, 2) {
+		assert.Equal(t, capped.Hash(), drop[0].hash)
+		assert.Equal(t, dynamic.Hash(), drop[1].hash)
+	}
+}
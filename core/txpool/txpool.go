@@ -49,6 +49,7 @@ type TxPool struct {
 	currentState *state.StateDB      // Current state in the blockchain head
 	tmpState     *state.ManagedState // Pending state tracking virtual nonces
 	curMaxGas    uint64              // Current gas limit for transaction caps
+	nextBaseFee  *big.Int            // Base fee of the next block, nil before the base fee fork
 
 	pending map[utils.Address]*txList   // All currently processable transactions
 	queue   map[utils.Address]*txList   // Queued but non-processable transactions
@@ -169,6 +170,11 @@ func (tp *TxPool) reinjectTxs(old, new *types.Block) types.Transactions {
 				rem = tp.chain.GetBlock(old.Hash())
 				add = tp.chain.GetBlock(new.Hash())
 			)
+			if rem == nil || add == nil {
+				// the old head is gone after the chain was rewound
+				log.Debugf("Skipping transaction reorg of unknown block height: %v ,hash: %v", old.Height(), old.Hash())
+				return nil
+			}
 			for rem.Height().Uint64() > add.Height().Uint64() {
 				discarded = append(discarded, rem.Transactions()...)
 				if rem = tp.chain.GetBlock(rem.PreviousHash()); rem == nil {
@@ -217,14 +223,10 @@ func (tp *TxPool) resetTxpoolState(old, new *types.Block) {
 	if new == nil {
 		new = tp.chain.CurrentBlock()
 	}
-	statedb, err := tp.chain.StateAt(new.StateRoot())
-	if err != nil {
+	if err := tp.resetHead(new); err != nil {
 		log.Errorf("Failed to reset txpool state err %v", err)
 		return
 	}
-	tp.currentState = statedb
-	tp.tmpState = state.ManageState(statedb)
-	tp.curMaxGas = new.GasLimit()
 
 	// Inject any transactions discarded due to reorgs
 	log.Debugf("Reinjecting stale transactions count %v", len(txs))
@@ -233,6 +235,37 @@ func (tp *TxPool) resetTxpoolState(old, new *types.Block) {
 	tp.processTxslist()
 }
 
+// resetHead moves the internal state of the pool to the head.
+func (tp *TxPool) resetHead(head *types.Block) error {
+	statedb, err := tp.chain.StateAt(head.StateRoot())
+	if err != nil {
+		return err
+	}
+	tp.currentState = statedb
+	tp.tmpState = state.ManageState(statedb)
+	tp.curMaxGas = head.GasLimit()
+	tp.nextBaseFee = nil
+	if tp.chainconfig.IsBaseFee(new(big.Int).Add(head.Height(), big.NewInt(1))) {
+		tp.nextBaseFee = types.CalcBaseFee(head.BlockHeader())
+	}
+	tp.priceList.SetBaseFee(tp.nextBaseFee)
+	return nil
+}
+
+// Reinject resets the pool to the head the chain was rewound to and reinjects the
+// transactions of the dropped blocks.
+func (tp *TxPool) Reinject(head *types.Block, txs types.Transactions) error {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	if err := tp.resetHead(head); err != nil {
+		return err
+	}
+	log.Infof("Reinjecting rewound transactions count %v", len(txs))
+	tp.addTxsLocked(txs)
+	tp.processTxslist()
+	return nil
+}
+
 // SubscribeNewTxsEvent registers a subscription of NewTxsEvent and starts sending event to the given channel.
 func (tp *TxPool) SubscribeNewTxsEvent(ch chan<- feed.NewTxsEvent) feed.Subscription {
 	tp.txScription = tp.txFeed.Subscribe(ch)
@@ -351,17 +384,25 @@ func (tp *TxPool) validateTx(tx *types.Transaction) error {
 	if tx.Type() == types.LogoutCandidate && bytes.Compare(from.Bytes(), utils.HexToAddress(tp.chainconfig.GenesisCandidate).Bytes()) == 0 {
 		return fmt.Errorf("genesis candidate not allow logout")
 	}
-	// Drop transactions under our own minimal accepted gas price
-	if tp.gasPrice.Cmp(tx.GasPrice()) > 0 {
+	// Dynamic fee transactions are only accepted after the base fee fork
+	if tx.IsDynamicFee() && tp.nextBaseFee == nil {
+		return ErrTxTypeNotSupported
+	}
+	// Drop transactions under our own minimal accepted gas price (priority fee)
+	if tp.gasPrice.Cmp(tx.GasTipCap()) > 0 {
 		return ErrUnderPriced
 	}
+	// Drop transactions which can't pay the base fee of the next block
+	if tp.nextBaseFee != nil && tp.nextBaseFee.Cmp(tx.GasFeeCap()) > 0 {
+		return ErrFeeCapTooLow
+	}
 
 	// Ensure the transaction adheres to nonce ordering
 	if tp.currentState.GetNonce(from) > tx.Nonce() {
 		return ErrNonceTooLow
 	}
 	// Transactor should have enough funds to cover the costs
-	// cost == V + GP * GL
+	// cost == V + GFC * GL
 	if tp.currentState.GetBalance(from).Cmp(tx.Cost()) < 0 {
 		return ErrInsufficientFunds
 	}
@@ -584,6 +625,12 @@ func (tp *TxPool) Get(hash utils.Hash) *types.Transaction {
 	return tp.txs.Get(hash)
 }
 
+// Range calls f on every transaction of the pool until it returns false, without
+// copying or sorting them. f must not call back into the pool.
+func (tp *TxPool) Range(f func(hash utils.Hash, tx *types.Transaction) bool) {
+	tp.txs.Range(f)
+}
+
 // removeTx removes a single transaction from the queue, moving all subsequent
 // transactions back to the future queue.
 func (tp *TxPool) removeTx(hash utils.Hash, outofbound bool) {
@@ -115,6 +115,54 @@ func TestInvalidTransactions(t *testing.T) {
 	}
 }
 
+func TestDynamicFeeTransactions(t *testing.T) {
+	t.Parallel()
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	add := utils.Address{}
+	tx := types.NewDynamicFeeTransaction(types.Binary, 0, big.NewInt(100), 100000, big.NewInt(2e9), big.NewInt(1), nil, &add)
+	tx.SignTx(types.Signer{}, key)
+	from, _ := deriveSender(tx)
+	pool.currentState.AddBalance(from, big.NewInt(0).SetUint64(amount))
+	if err := pool.AddTx(tx); err != ErrTxTypeNotSupported {
+		t.Error("expected", ErrTxTypeNotSupported, "got", err)
+	}
+
+	// activate the base fee fork at the next block
+	config := *params.DefaultChainConfig
+	config.BaseFeeHeight = big.NewInt(0)
+	pool.chainconfig = &config
+	pool.lockedReset(nil, nil)
+	if pool.nextBaseFee == nil || pool.nextBaseFee.Uint64() != params.InitialBaseFee {
+		t.Fatalf("invalid next base fee, want %v, got %v", params.InitialBaseFee, pool.nextBaseFee)
+	}
+
+	underpriced := types.NewDynamicFeeTransaction(types.Binary, 0, big.NewInt(100), 100000, big.NewInt(1e8), big.NewInt(1), nil, &add)
+	underpriced.SignTx(types.Signer{}, key)
+	if err := pool.AddTx(underpriced); err != ErrFeeCapTooLow {
+		t.Error("expected", ErrFeeCapTooLow, "got", err)
+	}
+	if err := pool.AddTx(tx); err != nil {
+		t.Error("expected nil, got", err)
+	}
+
+	// replacement must bump both the fee cap and the tip
+	replace := types.NewDynamicFeeTransaction(types.Binary, 0, big.NewInt(100), 100000, big.NewInt(4e9), big.NewInt(1), nil, &add)
+	replace.SignTx(types.Signer{}, key)
+	if err := pool.AddTx(replace); err != ErrReplaceUnderpriced {
+		t.Error("expected", ErrReplaceUnderpriced, "got", err)
+	}
+	replace = types.NewDynamicFeeTransaction(types.Binary, 0, big.NewInt(100), 100000, big.NewInt(4e9), big.NewInt(2), nil, &add)
+	replace.SignTx(types.Signer{}, key)
+	if err := pool.AddTx(replace); err != nil {
+		t.Error("expected nil, got", err)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
 func TestTransactionQueue(t *testing.T) {
 	t.Parallel()
 	pool, key := setupTxPool()
@@ -211,6 +259,40 @@ func TestTransactionChainFork(t *testing.T) {
 	}
 }
 
+// Tests that the transactions of the blocks dropped by a rewind are added back to the
+// pool, except the ones included below the new head.
+func TestTransactionReinject(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	statedb, _ := state.New(utils.Hash{}, state.NewDatabase(db.NewMemDatabase()))
+	statedb.AddBalance(addr, big.NewInt(100000000000000))
+	statedb.SetNonce(addr, 1)
+	pool.chain = &testBlockChain{statedb, 1000000, new(feed.Feed)}
+
+	txs := types.Transactions{transaction(0, 100000, key), transaction(1, 100000, key), transaction(2, 100000, key), transaction(3, 100000, key)}
+	if err := pool.Reinject(pool.chain.CurrentBlock(), txs); err != nil {
+		t.Fatalf("failed to reinject: %v", err)
+	}
+	if pending, queued := pool.Stats(); pending != 3 || queued != 0 {
+		t.Fatalf("pool stats mismatch: have %d pending, %d queued, want 3 pending, 0 queued", pending, queued)
+	}
+	if pool.Get(txs[0].Hash()) != nil {
+		t.Errorf("transaction included below the head reinjected")
+	}
+	for _, tx := range txs[1:] {
+		if pool.Get(tx.Hash()) == nil {
+			t.Errorf("transaction %x not reinjected", tx.Hash())
+		}
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
 func TestTransactionDoubleNonce(t *testing.T) {
 	t.Parallel()
 
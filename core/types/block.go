@@ -71,6 +71,7 @@ type BlockHeader struct {
 	TimeStamp        *big.Int          `json:"timestamp"`
 	ExtraData        []byte            `json:"extraData"`
 	Nonce            MinerNonce        `json:"nonce"`
+	BaseFee          *big.Int          `json:"baseFee" rlp:"optional"`
 }
 
 // Hash returns the block hash of the header
@@ -80,7 +81,7 @@ func (h *BlockHeader) Hash() utils.Hash {
 
 // HashNoNonce returns the hash which is used as input for the proof-of-work search.
 func (h *BlockHeader) HashNoNonce() utils.Hash {
-	return rlpHash([]interface{}{
+	fields := []interface{}{
 		h.PreviousHash,
 		h.Miner,
 		h.StateRoot,
@@ -94,7 +95,11 @@ func (h *BlockHeader) HashNoNonce() utils.Hash {
 		h.GasUsed,
 		h.TimeStamp,
 		h.ExtraData,
-	})
+	}
+	if h.BaseFee != nil {
+		fields = append(fields, h.BaseFee)
+	}
+	return rlpHash(fields)
 }
 
 // Size returns the approximate memory used by all internal contents.
@@ -114,6 +119,9 @@ func CopyBlockHeader(h *BlockHeader) *BlockHeader {
 	if cpy.Height = new(big.Int); h.Height != nil {
 		cpy.Height.Set(h.Height)
 	}
+	if h.BaseFee != nil {
+		cpy.BaseFee = new(big.Int).Set(h.BaseFee)
+	}
 	if len(h.ExtraData) > 0 {
 		cpy.ExtraData = make([]byte, len(h.ExtraData))
 		copy(cpy.ExtraData, h.ExtraData)
@@ -241,6 +249,14 @@ func (b *Block) ExtraData() []byte            { return utils.CopyBytes(b.header.
 func (b *Block) BlockHeader() *BlockHeader    { return CopyBlockHeader(b.header) }
 func (b *Block) DposCtx() *DposContext        { return b.DposContext }
 
+// BaseFee returns the base fee of the block, nil before the base fee fork.
+func (b *Block) BaseFee() *big.Int {
+	if b.header.BaseFee == nil {
+		return nil
+	}
+	return new(big.Int).Set(b.header.BaseFee)
+}
+
 // Hash returns the keccak256 hash of b's header.
 func (b *Block) Hash() utils.Hash {
 	if hash := b.hash.Load(); hash != nil {
@@ -24,6 +24,7 @@ import (
 
 	"github.com/UranusBlockStack/uranus/common/rlp"
 	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/params"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -72,6 +73,43 @@ func TestBlockEncodeAndDecode(t *testing.T) {
 
 }
 
+func TestBlockBaseFee(t *testing.T) {
+	header := CopyBlockHeader(testHeader)
+	assert.Nil(t, header.BaseFee)
+	legacyHash := header.Hash()
+
+	// first block after the fork starts with the initial base fee
+	assert.Equal(t, new(big.Int).SetUint64(params.InitialBaseFee), CalcBaseFee(header))
+
+	header.BaseFee = big.NewInt(1000000000)
+	assert.NotEqual(t, legacyHash, header.Hash())
+
+	blockb, err := rlp.EncodeToBytes(NewBlock(header, nil, nil, nil))
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	tmpBlock, err := decodeBlock(blockb)
+	if err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	assert.Equal(t, header.BaseFee, tmpBlock.BaseFee())
+
+	tests := []struct {
+		gasUsed uint64
+		expect  int64
+	}{
+		{5000, 1000000000},  // usage == target
+		{4000, 975000000},   // usage below target
+		{6000, 1025000000},  // usage above target
+		{10000, 1125000000}, // full block
+		{0, 875000000},      // empty block
+	}
+	for _, test := range tests {
+		header.GasUsed = test.gasUsed
+		assert.Equal(t, test.expect, CalcBaseFee(header).Int64())
+	}
+}
+
 func decodeBlock(data []byte) (*Block, error) {
 	var b Block
 	return &b, rlp.Decode(bytes.NewReader(data), &b)
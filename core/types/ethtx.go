@@ -0,0 +1,227 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/UranusBlockStack/uranus/common/crypto"
+	"github.com/UranusBlockStack/uranus/common/rlp"
+	"github.com/UranusBlockStack/uranus/common/utils"
+)
+
+// ethereum typed transaction envelope
+const ethDynamicFeeTxType = 0x02
+
+var (
+	ErrEthTxType      = errors.New("unsupported ethereum transaction type")
+	ErrEthUnprotected = errors.New("ethereum transaction without EIP-155 replay protection not supported")
+	ErrEthAccessList  = errors.New("ethereum transaction access list not supported")
+	ErrEthTo          = errors.New("invalid ethereum transaction recipient")
+)
+
+// ethLegacyTx is the ethereum legacy transaction (EIP-155).
+type ethLegacyTx struct {
+	Nonce    uint64
+	GasPrice *big.Int
+	Gas      uint64
+	To       []byte // empty for contract creation
+	Value    *big.Int
+	Data     []byte
+	V, R, S  *big.Int
+}
+
+// ethDynamicFeeTx is the ethereum dynamic fee transaction (EIP-1559).
+type ethDynamicFeeTx struct {
+	ChainID    *big.Int
+	Nonce      uint64
+	GasTipCap  *big.Int
+	GasFeeCap  *big.Int
+	Gas        uint64
+	To         []byte // empty for contract creation
+	Value      *big.Int
+	Data       []byte
+	AccessList []rlp.RawValue
+	V, R, S    *big.Int
+}
+
+// IsEthSigned returns whether the transaction was signed in ethereum format.
+func (tx *Transaction) IsEthSigned() bool { return tx.data.EthChainID != 0 }
+
+// EthChainID returns the chain id of a transaction signed in ethereum format, zero for native transaction.
+func (tx *Transaction) EthChainID() uint64 { return tx.data.EthChainID }
+
+// DecodeEthTransaction decodes an ethereum legacy (EIP-155) or dynamic fee (EIP-1559) transaction
+// into a binary transaction, the sender is recovered from the ethereum signature.
+func DecodeEthTransaction(raw []byte) (*Transaction, error) {
+	if len(raw) == 0 {
+		return nil, ErrEthTxType
+	}
+	var (
+		d       = txdata{Type: Binary}
+		to      []byte
+		v, r, s *big.Int
+		recID   uint64
+	)
+	switch {
+	case raw[0] >= 0xc0:
+		var etx ethLegacyTx
+		if err := rlp.DecodeBytes(raw, &etx); err != nil {
+			return nil, err
+		}
+		if etx.V.BitLen() > 64 {
+			return nil, ErrInvalidSig
+		}
+		if v := etx.V.Uint64(); v == 27 || v == 28 {
+			return nil, ErrEthUnprotected
+		} else if v < 37 {
+			return nil, ErrInvalidSig
+		}
+		d.EthChainID = (etx.V.Uint64() - 35) / 2
+		recID = etx.V.Uint64() - 35 - 2*d.EthChainID
+		d.Nonce, d.GasPrice, d.GasLimit, d.Value, d.Payload = etx.Nonce, etx.GasPrice, etx.Gas, etx.Value, etx.Data
+		to, v, r, s = etx.To, etx.V, etx.R, etx.S
+	case raw[0] == ethDynamicFeeTxType:
+		var etx ethDynamicFeeTx
+		if err := rlp.DecodeBytes(raw[1:], &etx); err != nil {
+			return nil, err
+		}
+		if len(etx.AccessList) != 0 {
+			return nil, ErrEthAccessList
+		}
+		if etx.ChainID.BitLen() > 64 || etx.ChainID.Sign() == 0 {
+			return nil, ErrInvalidChainID
+		}
+		if etx.V.BitLen() > 1 {
+			return nil, ErrInvalidSig
+		}
+		d.EthChainID, recID = etx.ChainID.Uint64(), etx.V.Uint64()
+		d.Nonce, d.GasPrice, d.GasLimit, d.Value, d.Payload = etx.Nonce, new(big.Int), etx.Gas, etx.Value, etx.Data
+		d.GasFeeCap, d.GasTipCap = etx.GasFeeCap, etx.GasTipCap
+		to, v, r, s = etx.To, etx.V, etx.R, etx.S
+	default:
+		return nil, ErrEthTxType
+	}
+
+	switch len(to) {
+	case 0:
+	case len(utils.Address{}):
+		addr := utils.BytesToAddress(to)
+		d.Tos = []*utils.Address{&addr}
+	default:
+		return nil, ErrEthTo
+	}
+	if d.EthChainID == 0 || r.BitLen() > 256 || s.BitLen() > 256 || v == nil {
+		return nil, ErrInvalidSig
+	}
+	sig := make([]byte, 65)
+	rb, sb := r.Bytes(), s.Bytes()
+	copy(sig[32-len(rb):32], rb)
+	copy(sig[64-len(sb):64], sb)
+	sig[64] = byte(recID)
+	d.Signature = sig
+	return &Transaction{data: d}, nil
+}
+
+// EthEncode returns the ethereum RLP encoding of a binary transaction. A native transaction
+// is encoded as an unprotected legacy transaction, its signature can't be verified by ethereum.
+func (tx *Transaction) EthEncode() ([]byte, error) {
+	if tx.Type() != Binary || len(tx.data.Tos) > 1 {
+		return nil, ErrInvalidType
+	}
+	var to []byte
+	if len(tx.data.Tos) == 1 {
+		to = tx.data.Tos[0].Bytes()
+	}
+	r, s, v := new(big.Int), new(big.Int), new(big.Int)
+	if len(tx.data.Signature) == 65 {
+		r.SetBytes(tx.data.Signature[:32])
+		s.SetBytes(tx.data.Signature[32:64])
+		v.SetUint64(uint64(tx.data.Signature[64]))
+	}
+
+	switch {
+	case tx.IsEthSigned() && tx.IsDynamicFee():
+		enc, err := rlp.EncodeToBytes(&ethDynamicFeeTx{
+			ChainID:    new(big.Int).SetUint64(tx.data.EthChainID),
+			Nonce:      tx.data.Nonce,
+			GasTipCap:  tx.GasTipCap(),
+			GasFeeCap:  tx.GasFeeCap(),
+			Gas:        tx.data.GasLimit,
+			To:         to,
+			Value:      tx.data.Value,
+			Data:       tx.data.Payload,
+			AccessList: []rlp.RawValue{},
+			V:          v,
+			R:          r,
+			S:          s,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte{ethDynamicFeeTxType}, enc...), nil
+	case tx.IsEthSigned():
+		// v = recovery id + 35 + chainID * 2
+		v.Add(v, new(big.Int).SetUint64(35+2*tx.data.EthChainID))
+	default:
+		v.Add(v, big.NewInt(27))
+	}
+	return rlp.EncodeToBytes(&ethLegacyTx{
+		Nonce:    tx.data.Nonce,
+		GasPrice: tx.GasPrice(),
+		Gas:      tx.data.GasLimit,
+		To:       to,
+		Value:    tx.data.Value,
+		Data:     tx.data.Payload,
+		V:        v,
+		R:        r,
+		S:        s,
+	})
+}
+
+// ethSigHash returns the hash signed by the sender of an ethereum transaction.
+func ethSigHash(tx *Transaction) utils.Hash {
+	var to []byte
+	if len(tx.data.Tos) == 1 {
+		to = tx.data.Tos[0].Bytes()
+	}
+	chainID := new(big.Int).SetUint64(tx.data.EthChainID)
+	if tx.IsDynamicFee() {
+		enc, _ := rlp.EncodeToBytes([]interface{}{
+			chainID,
+			tx.data.Nonce,
+			tx.GasTipCap(),
+			tx.GasFeeCap(),
+			tx.data.GasLimit,
+			to,
+			tx.data.Value,
+			tx.data.Payload,
+			[]rlp.RawValue{},
+		})
+		return crypto.Keccak256Hash([]byte{ethDynamicFeeTxType}, enc)
+	}
+	return rlpHash([]interface{}{
+		tx.data.Nonce,
+		tx.data.GasPrice,
+		tx.data.GasLimit,
+		to,
+		tx.data.Value,
+		tx.data.Payload,
+		chainID, uint(0), uint(0),
+	})
+}
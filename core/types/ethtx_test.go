@@ -0,0 +1,84 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/UranusBlockStack/uranus/common/crypto"
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/params"
+	"github.com/stretchr/testify/assert"
+)
+
+// example transaction of EIP-155
+func TestDecodeEthLegacyTransaction(t *testing.T) {
+	raw := utils.FromHex("0xf86c098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a76400008025a028ef61340bd939bc2195fe537567866003e1a15d3c71ff63e1590620aa636276a067cbe9d8997f761aecb703304b3800ccf555c9f3dc64214b297fb1966a3b6d83")
+	tx, err := DecodeEthTransaction(raw)
+	if err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	assert.True(t, tx.IsEthSigned())
+	assert.Equal(t, uint64(9), tx.Nonce())
+	assert.Equal(t, uint64(21000), tx.Gas())
+	assert.Equal(t, big.NewInt(20000000000), tx.GasPrice())
+	assert.Equal(t, utils.HexToAddress("0x3535353535353535353535353535353535353535"), *tx.Tos()[0])
+	assert.Equal(t, utils.HexToHash("0x33469b22e9f636356c4160a87eb19df52b7412e8eac32a4a55ffe88ea8350788"), tx.Hash())
+
+	from, err := tx.Sender(Signer{})
+	assert.NoError(t, err)
+	assert.Equal(t, utils.HexToAddress("0x9d8A62f656a8d1615C1294fd71e9CFb3E4855A4F"), from)
+
+	enc, err := tx.EthEncode()
+	assert.NoError(t, err)
+	assert.Equal(t, raw, enc)
+
+	assert.Equal(t, ErrInvalidChainID, tx.Validate(&params.ChainConfig{ChainID: big.NewInt(2)}))
+	assert.NoError(t, tx.Validate(&params.ChainConfig{ChainID: big.NewInt(1)}))
+}
+
+func TestEthDynamicFeeTransaction(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	tx := NewDynamicFeeTransaction(Binary, 1, big.NewInt(100), 21000, big.NewInt(2e9), big.NewInt(1e9), nil, &to)
+	tx.data.EthChainID = 7
+	assert.NoError(t, tx.SignTx(Signer{}, key))
+
+	raw, err := tx.EthEncode()
+	assert.NoError(t, err)
+	assert.Equal(t, byte(ethDynamicFeeTxType), raw[0])
+
+	dtx, err := DecodeEthTransaction(raw)
+	if err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	assert.Equal(t, tx.Hash(), dtx.Hash())
+	assert.Equal(t, crypto.Keccak256Hash(raw), dtx.Hash())
+	assert.Equal(t, tx.GasFeeCap(), dtx.GasFeeCap())
+	assert.Equal(t, tx.GasTipCap(), dtx.GasTipCap())
+
+	from, err := dtx.Sender(Signer{})
+	assert.NoError(t, err)
+	assert.Equal(t, crypto.PubkeyToAddress(key.PublicKey), from)
+}
+
+func TestDecodeEthTransactionUnprotected(t *testing.T) {
+	_, err := DecodeEthTransaction(utils.FromHex("0xf8498080808080801ba0c9f8bd8e9ef5f2e5d3b0f0b2d4ef8df6d27bd4c1c5b6b1a4e2a7d1c4b0c9f8bda0c9f8bd8e9ef5f2e5d3b0f0b2d4ef8df6d27bd4c1c5b6b1a4e2a7d1c4b0c9f8bd"))
+	assert.Equal(t, ErrEthUnprotected, err)
+	_, err = DecodeEthTransaction([]byte{0x01, 0xc0})
+	assert.Equal(t, ErrEthTxType, err)
+}
@@ -93,12 +93,19 @@ func (s Signer) SignatureValues(tx *Transaction, signature []byte) (r, sb, v *bi
 
 // Hash returns the hash to be signed by the sender.
 func (s Signer) Hash(tx *Transaction) utils.Hash {
-	return rlpHash([]interface{}{
+	if tx.IsEthSigned() {
+		return ethSigHash(tx)
+	}
+	fields := []interface{}{
 		tx.data.Nonce,
 		tx.data.GasPrice,
 		tx.data.GasLimit,
 		tx.data.Tos,
 		tx.data.Value,
 		tx.data.Payload,
-	})
+	}
+	if tx.IsDynamicFee() {
+		fields = append(fields, tx.GasFeeCap(), tx.GasTipCap())
+	}
+	return rlpHash(fields)
 }
@@ -18,6 +18,7 @@ package types
 
 import (
 	"container/heap"
+	"math/big"
 
 	"github.com/UranusBlockStack/uranus/common/utils"
 )
@@ -59,24 +60,46 @@ func (t TxsByPriceToHigh) Len() int           { return len(t) }
 func (t TxsByPriceToHigh) Swap(i, j int)      { t[i], t[j] = t[j], t[i] }
 func (t TxsByPriceToHigh) Less(i, j int) bool { return t[i].GasPrice().Cmp(t[j].GasPrice()) < 0 }
 
+// TxsByTipToLow transactions sort by the effective miner tip under the base fee and implements the heap interface
+// Tip from high to low
+type TxsByTipToLow struct {
+	txs     Transactions
+	baseFee *big.Int
+}
+
+func (s TxsByTipToLow) Len() int { return len(s.txs) }
+func (s TxsByTipToLow) Less(i, j int) bool {
+	return s.txs[i].EffectiveGasTip(s.baseFee).Cmp(s.txs[j].EffectiveGasTip(s.baseFee)) > 0
+}
+func (s TxsByTipToLow) Swap(i, j int)       { s.txs[i], s.txs[j] = s.txs[j], s.txs[i] }
+func (s *TxsByTipToLow) Push(x interface{}) { s.txs = append(s.txs, x.(*Transaction)) }
+func (s *TxsByTipToLow) Pop() interface{} {
+	old := s.txs
+	n := len(old)
+	x := old[n-1]
+	s.txs = old[0 : n-1]
+	return x
+}
+
 // TransactionsByPriceAndNonce represents a set of transactions
 type TransactionsByPriceAndNonce struct {
 	txs    map[utils.Address]Transactions // Per account nonce-sorted list of transactions
-	heads  TxsByPriceToLow                // Next transaction for each unique account (price heap)
+	heads  *TxsByTipToLow                 // Next transaction for each unique account (price heap)
 	signer Signer                         // Signer for the set of transactions
 }
 
 // NewTransactionsByPriceAndNonce creates a transaction set that can retrieve price sorted transactions in a nonce-honouring way.
-func NewTransactionsByPriceAndNonce(signer Signer, txs map[utils.Address]Transactions) *TransactionsByPriceAndNonce {
+// baseFee is the base fee of the block being built, nil before the base fee fork.
+func NewTransactionsByPriceAndNonce(signer Signer, txs map[utils.Address]Transactions, baseFee *big.Int) *TransactionsByPriceAndNonce {
 	// Initialize a price based heap with the head transactions
-	heads := make(TxsByPriceToLow, 0, len(txs))
+	heads := &TxsByTipToLow{txs: make(Transactions, 0, len(txs)), baseFee: baseFee}
 	for _, accTxs := range txs {
-		heads = append(heads, accTxs[0])
+		heads.txs = append(heads.txs, accTxs[0])
 		// Ensure the sender address is from the signer
 		acc, _ := accTxs[0].Sender(signer)
 		txs[acc] = accTxs[1:]
 	}
-	heap.Init(&heads)
+	heap.Init(heads)
 	// Assemble and return the transaction set
 	return &TransactionsByPriceAndNonce{
 		txs:    txs,
@@ -87,24 +110,24 @@ func NewTransactionsByPriceAndNonce(signer Signer, txs map[utils.Address]Transac
 
 // Peek returns the next transaction by price.
 func (t *TransactionsByPriceAndNonce) Peek() *Transaction {
-	if len(t.heads) == 0 {
+	if t.heads.Len() == 0 {
 		return nil
 	}
-	return t.heads[0]
+	return t.heads.txs[0]
 }
 
 // Shift replaces the current best head with the next one from the same account.
 func (t *TransactionsByPriceAndNonce) Shift() {
-	acc, _ := t.heads[0].Sender(t.signer)
+	acc, _ := t.heads.txs[0].Sender(t.signer)
 	if txs, ok := t.txs[acc]; ok && len(txs) > 0 {
-		t.heads[0], t.txs[acc] = txs[0], txs[1:]
-		heap.Fix(&t.heads, 0)
+		t.heads.txs[0], t.txs[acc] = txs[0], txs[1:]
+		heap.Fix(t.heads, 0)
 	} else {
-		heap.Pop(&t.heads)
+		heap.Pop(t.heads)
 	}
 }
 
 // Pop removes the best transactiont.
 func (t *TransactionsByPriceAndNonce) Pop() {
-	heap.Pop(&t.heads)
+	heap.Pop(t.heads)
 }
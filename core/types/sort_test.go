@@ -23,6 +23,7 @@ import (
 
 	"github.com/UranusBlockStack/uranus/common/crypto"
 	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestSortTxPriceNonce(t *testing.T) {
@@ -46,7 +47,7 @@ func TestSortTxPriceNonce(t *testing.T) {
 	}
 
 	// Sort the transactions and cross check the nonce ordering
-	txset := NewTransactionsByPriceAndNonce(Signer{}, groups)
+	txset := NewTransactionsByPriceAndNonce(Signer{}, groups, nil)
 
 	txs := Transactions{}
 	for tx := txset.Peek(); tx != nil; tx = txset.Peek() {
@@ -79,3 +80,28 @@ func TestSortTxPriceNonce(t *testing.T) {
 		}
 	}
 }
+
+func TestSortTxTipWithBaseFee(t *testing.T) {
+	baseFee := big.NewInt(10)
+	groups := map[utils.Address]Transactions{}
+	fees := []struct{ feeCap, tipCap int64 }{{30, 5}, {12, 10}, {100, 1}}
+	for _, fee := range fees {
+		key, _ := crypto.GenerateKey()
+		tx := NewDynamicFeeTransaction(Binary, 0, big.NewInt(100), 100, big.NewInt(fee.feeCap), big.NewInt(fee.tipCap), nil)
+		tx.SignTx(Signer{}, key)
+		groups[crypto.PubkeyToAddress(key.PublicKey)] = Transactions{tx}
+	}
+	// legacy transaction pays gasPrice - baseFee as tip
+	key, _ := crypto.GenerateKey()
+	tx := NewTransaction(Binary, 0, big.NewInt(100), 100, big.NewInt(13), nil)
+	tx.SignTx(Signer{}, key)
+	groups[crypto.PubkeyToAddress(key.PublicKey)] = Transactions{tx}
+
+	txset := NewTransactionsByPriceAndNonce(Signer{}, groups, baseFee)
+	var tips []int64
+	for tx := txset.Peek(); tx != nil; tx = txset.Peek() {
+		tips = append(tips, tx.EffectiveGasTip(baseFee).Int64())
+		txset.Shift()
+	}
+	assert.Equal(t, []int64{5, 3, 2, 1}, tips)
+}
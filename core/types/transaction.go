@@ -48,6 +48,9 @@ var (
 	ErrInvalidType    = errors.New("invalid transaction type")
 	ErrInvalidAddress = errors.New("invalid transaction payload address")
 	ErrInvalidAction  = errors.New("invalid transaction payload action")
+	ErrTipAboveFeeCap = errors.New("max priority fee per gas higher than max fee per gas")
+	ErrInvalidFeeCap  = errors.New("dynamic fee transaction gas price must be zero")
+	ErrInvalidChainID = errors.New("invalid transaction chain id")
 )
 
 // Transaction transaction
@@ -69,6 +72,13 @@ type txdata struct {
 	Value     *big.Int         `json:"value"`
 	Payload   []byte           `json:"payload"`
 	Signature []byte           `json:"signature"`
+
+	// chain id of a transaction signed in ethereum format, zero for native transaction.
+	EthChainID uint64 `json:"ethChainId" rlp:"optional"`
+
+	// dynamic fee transaction, GasPrice is unused when set.
+	GasFeeCap *big.Int `json:"maxFeePerGas" rlp:"optional"`
+	GasTipCap *big.Int `json:"maxPriorityFeePerGas" rlp:"optional"`
 }
 
 // NewTransaction new transaction
@@ -95,8 +105,37 @@ func NewTransaction(txType TxType, nonce uint64, value *big.Int, gasLimit uint64
 	return &Transaction{data: d}
 }
 
+// NewDynamicFeeTransaction new transaction which pays the block base fee plus a priority fee capped by gasFeeCap
+func NewDynamicFeeTransaction(txType TxType, nonce uint64, value *big.Int, gasLimit uint64, gasFeeCap, gasTipCap *big.Int, data []byte, tos ...*utils.Address) *Transaction {
+	tx := NewTransaction(txType, nonce, value, gasLimit, nil, data, tos...)
+	tx.data.GasFeeCap, tx.data.GasTipCap = new(big.Int), new(big.Int)
+	if gasFeeCap != nil {
+		tx.data.GasFeeCap.Set(gasFeeCap)
+	}
+	if gasTipCap != nil {
+		tx.data.GasTipCap.Set(gasTipCap)
+	}
+	return tx
+}
+
 // Validate Valid the transaction when the type isn't the binary
 func (tx *Transaction) Validate(cfg *params.ChainConfig) error {
+	if tx.IsEthSigned() {
+		if tx.Type() != Binary {
+			return ErrInvalidType
+		}
+		if cfg.ChainID == nil || cfg.ChainID.Uint64() != tx.data.EthChainID {
+			return ErrInvalidChainID
+		}
+	}
+	if tx.IsDynamicFee() {
+		if tx.data.GasPrice != nil && tx.data.GasPrice.Sign() != 0 {
+			return ErrInvalidFeeCap
+		}
+		if tx.GasTipCap().Cmp(tx.GasFeeCap()) > 0 {
+			return ErrTipAboveFeeCap
+		}
+	}
 	switch tx.Type() {
 	case Binary:
 		if len(tx.Tos()) > 1 {
@@ -128,7 +167,7 @@ func (tx *Transaction) Validate(cfg *params.ChainConfig) error {
 func (tx *Transaction) Signature() []byte  { return utils.CopyBytes(tx.data.Signature) }
 func (tx *Transaction) Payload() []byte    { return utils.CopyBytes(tx.data.Payload) }
 func (tx *Transaction) Gas() uint64        { return tx.data.GasLimit }
-func (tx *Transaction) GasPrice() *big.Int { return new(big.Int).Set(tx.data.GasPrice) }
+func (tx *Transaction) GasPrice() *big.Int { return tx.GasFeeCap() }
 func (tx *Transaction) Value() *big.Int    { return new(big.Int).Set(tx.data.Value) }
 func (tx *Transaction) Nonce() uint64      { return tx.data.Nonce }
 func (tx *Transaction) Type() TxType       { return tx.data.Type }
@@ -139,9 +178,52 @@ func (tx *Transaction) Tos() []*utils.Address {
 	return tx.data.Tos
 }
 
-// Cost returns value + gasprice * gaslimit.
+// IsDynamicFee returns whether the transaction pays the block base fee plus a priority fee.
+func (tx *Transaction) IsDynamicFee() bool { return tx.data.GasFeeCap != nil }
+
+// GasFeeCap returns the max fee per gas, it is the gas price of a legacy transaction.
+func (tx *Transaction) GasFeeCap() *big.Int {
+	if tx.data.GasFeeCap != nil {
+		return new(big.Int).Set(tx.data.GasFeeCap)
+	}
+	return new(big.Int).Set(tx.data.GasPrice)
+}
+
+// GasTipCap returns the max priority fee per gas, it is the gas price of a legacy transaction.
+func (tx *Transaction) GasTipCap() *big.Int {
+	if tx.data.GasFeeCap != nil {
+		if tx.data.GasTipCap == nil {
+			return new(big.Int)
+		}
+		return new(big.Int).Set(tx.data.GasTipCap)
+	}
+	return new(big.Int).Set(tx.data.GasPrice)
+}
+
+// EffectiveGasTip returns the priority fee per gas paid to the miner, min(gasTipCap, gasFeeCap - baseFee).
+// The result is negative when the fee cap is lower than the base fee.
+func (tx *Transaction) EffectiveGasTip(baseFee *big.Int) *big.Int {
+	if baseFee == nil {
+		return tx.GasTipCap()
+	}
+	tip := tx.GasTipCap()
+	if capTip := new(big.Int).Sub(tx.GasFeeCap(), baseFee); capTip.Cmp(tip) < 0 {
+		return capTip
+	}
+	return tip
+}
+
+// EffectiveGasPrice returns the gas price paid by the sender, baseFee + effective tip.
+func (tx *Transaction) EffectiveGasPrice(baseFee *big.Int) *big.Int {
+	if baseFee == nil {
+		return tx.GasFeeCap()
+	}
+	return new(big.Int).Add(baseFee, tx.EffectiveGasTip(baseFee))
+}
+
+// Cost returns value + gasfeecap * gaslimit.
 func (tx *Transaction) Cost() *big.Int {
-	total := new(big.Int).Mul(tx.data.GasPrice, new(big.Int).SetUint64(tx.data.GasLimit))
+	total := new(big.Int).Mul(tx.GasFeeCap(), new(big.Int).SetUint64(tx.data.GasLimit))
 	total.Add(total, tx.data.Value)
 	return total
 }
@@ -162,11 +244,18 @@ func (tx *Transaction) DecodeRLP(s *rlp.Stream) error {
 }
 
 // Hash hashes the RLP encoding of tx. It uniquely identifies the transaction.
+// The hash of a transaction signed in ethereum format is the ethereum transaction hash.
 func (tx *Transaction) Hash() utils.Hash {
 	if hash := tx.hash.Load(); hash != nil {
 		return hash.(utils.Hash)
 	}
-	hash := rlpHash(tx)
+	var hash utils.Hash
+	if tx.IsEthSigned() {
+		enc, _ := tx.EthEncode()
+		hash = crypto.Keccak256Hash(enc)
+	} else {
+		hash = rlpHash(tx)
+	}
 	tx.hash.Store(hash)
 	return hash
 }
@@ -24,6 +24,7 @@ import (
 
 	"github.com/UranusBlockStack/uranus/common/rlp"
 	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/params"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -61,6 +62,37 @@ func TestTxEncodeAndDecode(t *testing.T) {
 	fmt.Println(testTx.Hash().Hex())
 }
 
+func TestDynamicFeeTxEncodeAndDecode(t *testing.T) {
+	tx := NewDynamicFeeTransaction(Binary, 3, big.NewInt(10), 2000, big.NewInt(30), big.NewInt(2), utils.FromHex("55"), &to)
+	txb, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+
+	tmpTx, err := decodeTx(txb)
+	if err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	assert.True(t, tmpTx.IsDynamicFee())
+	assert.Equal(t, tx.Hash(), tmpTx.Hash())
+	assert.Equal(t, int64(30), tmpTx.GasFeeCap().Int64())
+	assert.Equal(t, int64(2), tmpTx.GasTipCap().Int64())
+	assert.NotEqual(t, Signer{}.Hash(testTx), Signer{}.Hash(tmpTx))
+
+	// effective price is capped by max fee
+	assert.Equal(t, int64(12), tmpTx.EffectiveGasPrice(big.NewInt(10)).Int64())
+	assert.Equal(t, int64(30), tmpTx.EffectiveGasPrice(big.NewInt(29)).Int64())
+	assert.Equal(t, int64(-1), tmpTx.EffectiveGasTip(big.NewInt(31)).Int64())
+	assert.Equal(t, int64(30*2000+10), tmpTx.Cost().Int64())
+
+	// legacy transaction pays its gas price
+	assert.False(t, testTx.IsDynamicFee())
+	assert.Equal(t, int64(1), testTx.EffectiveGasPrice(big.NewInt(1)).Int64())
+
+	invalid := NewDynamicFeeTransaction(Binary, 3, big.NewInt(10), 2000, big.NewInt(1), big.NewInt(2), nil, &to)
+	assert.Equal(t, ErrTipAboveFeeCap, invalid.Validate(params.TestChainConfig))
+}
+
 func decodeTx(data []byte) (*Transaction, error) {
 	var tx Transaction
 	return &tx, rlp.Decode(bytes.NewReader(data), &tx)
@@ -147,3 +147,35 @@ func CalcGasLimit(parent *Block) uint64 {
 	}
 	return limit
 }
+
+// CalcBaseFee computes the base fee of the next block after parent.
+func CalcBaseFee(parent *BlockHeader) *big.Int {
+	// the first block after the fork starts with the initial base fee
+	if parent.BaseFee == nil {
+		return new(big.Int).SetUint64(params.InitialBaseFee)
+	}
+	target := parent.GasLimit / params.ElasticityMultiplier
+	if parent.GasUsed == target || target == 0 {
+		return new(big.Int).Set(parent.BaseFee)
+	}
+
+	if parent.GasUsed > target {
+		// delta = max(parentBaseFee * gasUsedDelta / target / 8, 1)
+		delta := new(big.Int).Mul(parent.BaseFee, new(big.Int).SetUint64(parent.GasUsed-target))
+		delta.Div(delta, new(big.Int).SetUint64(target))
+		delta.Div(delta, new(big.Int).SetUint64(params.BaseFeeChangeDenominator))
+		if delta.Sign() == 0 {
+			delta.SetUint64(1)
+		}
+		return delta.Add(delta, parent.BaseFee)
+	}
+	// delta = parentBaseFee * gasUsedDelta / target / 8
+	delta := new(big.Int).Mul(parent.BaseFee, new(big.Int).SetUint64(target-parent.GasUsed))
+	delta.Div(delta, new(big.Int).SetUint64(target))
+	delta.Div(delta, new(big.Int).SetUint64(params.BaseFeeChangeDenominator))
+	baseFee := new(big.Int).Sub(parent.BaseFee, delta)
+	if baseFee.Sign() < 0 {
+		baseFee.SetUint64(0)
+	}
+	return baseFee
+}
@@ -62,6 +62,10 @@ var (
 	ErrGasLimit = func(actual, expected, extra uint64) error {
 		return fmt.Errorf("invalid gaslimit: have %d, want %d += %d", actual, expected, extra)
 	}
+	// ErrBaseFee is returned invalid base fee
+	ErrBaseFee = func(actual, expected *big.Int) error {
+		return fmt.Errorf("invalid baseFee: have %v, want %v", actual, expected)
+	}
 	// ErrTxsRootHash is returned invalid txs root hash
 	ErrTxsRootHash = func(actual, expected utils.Hash) error {
 		return fmt.Errorf("transaction txs root hash mismatch: have %x, want %x", actual, expected)
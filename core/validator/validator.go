@@ -27,6 +27,12 @@ import (
 	"github.com/UranusBlockStack/uranus/params"
 )
 
+// confirmer is implemented by the engines tracking the confirmed block while verifying
+// the seals, it's called instead when the seal isn't verified.
+type confirmer interface {
+	UpdateConfirmed(chain consensus.IChainReader, header *types.BlockHeader) error
+}
+
 // Validator responsible for validating block headers, blocks and processed state.
 type Validator struct {
 	ledger *ledger.Ledger
@@ -86,6 +92,14 @@ func (v *Validator) ValidateHeader(chain consensus.IChainReader, header *types.B
 	if uint64(diff) >= limit || header.GasLimit < params.MinGasLimit {
 		return ErrGasLimit(header.GasLimit, parent.GasLimit, limit)
 	}
+	// Verify the base fee based on parent's gas usage after the base fee fork
+	if chain.Config().IsBaseFee(header.Height) {
+		if expected := types.CalcBaseFee(parent); header.BaseFee == nil || header.BaseFee.Cmp(expected) != 0 {
+			return ErrBaseFee(header.BaseFee, expected)
+		}
+	} else if header.BaseFee != nil {
+		return ErrBaseFee(header.BaseFee, nil)
+	}
 	// Verify that the block number is parent's +1
 	if diff := new(big.Int).Sub(header.Height, parent.Height); diff.Cmp(big.NewInt(1)) != 0 {
 		return ErrInvalidNumber
@@ -95,6 +109,10 @@ func (v *Validator) ValidateHeader(chain consensus.IChainReader, header *types.B
 		if err := v.engine.VerifySeal(chain, header); err != nil {
 			return err
 		}
+	} else if c, ok := v.engine.(confirmer); ok {
+		if err := c.UpdateConfirmed(chain, header); err != nil {
+			return err
+		}
 	}
 	return nil
 }
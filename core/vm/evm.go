@@ -70,6 +70,7 @@ type Context struct {
 	BlockNumber *big.Int      // Provides information for NUMBER
 	Time        *big.Int      // Provides information for TIME
 	Difficulty  *big.Int      // Provides information for DIFFICULTY
+	BaseFee     *big.Int      // Provides the block base fee, nil before the base fee fork
 }
 
 // EVM is the Ethereum Virtual Machine base object and provides
@@ -36,6 +36,18 @@ type NewMiner struct{}
 
 type NewMinedBlockEvent struct{ Block *types.Block }
 
+// SyncStartEvent is posted when the downloader starts to synchronise with a peer.
+type SyncStartEvent struct {
+	Origin  uint64 // height of the local head
+	Highest uint64 // height of the peer head as far as it is known
+}
+
+// SyncDoneEvent is posted when the synchronisation completed.
+type SyncDoneEvent struct{ Head uint64 }
+
+// SyncFailedEvent is posted when the synchronisation was aborted.
+type SyncFailedEvent struct{ Err error }
+
 type BlockAndLogsEvent struct {
 	Block *types.Block
 	Logs  types.Logs
@@ -0,0 +1,347 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"encoding/binary"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/UranusBlockStack/uranus/common/crypto"
+	"github.com/UranusBlockStack/uranus/common/log"
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/core/types"
+)
+
+const (
+	compactTxsTimeout  = time.Second // time to receive the missing txs before fetching the full block
+	maxPendingCompacts = 64
+	maxRecentBlocks    = 32 // relayed blocks kept to serve the missing txs before they are imported
+)
+
+// compactBlockData is a block relayed with the short ids of its transactions, the receiver
+// rebuilds it from its txpool. The header carries the DPoS context proto.
+type compactBlockData struct {
+	Header    *types.BlockHeader
+	ShortIDs  []uint64 // short id of every transaction in order
+	Prefilled []prefilledTx
+	Actions   []*types.Action
+	TD        *big.Int
+}
+
+// prefilledTx is a transaction of the compact block the peer isn't known to have.
+type prefilledTx struct {
+	Index uint64
+	Tx    *types.Transaction
+}
+
+type getBlockTxsData struct {
+	Hash    utils.Hash
+	Indexes []uint64
+}
+
+type blockTxsData struct {
+	Hash utils.Hash
+	Txs  []*types.Transaction
+}
+
+// shortIDKey salts the short ids of a block with its hash like BIP152, so a collision
+// can't be crafted for all the blocks at once.
+type shortIDKey struct {
+	k0, k1 uint64
+}
+
+func newShortIDKey(block utils.Hash) shortIDKey {
+	return shortIDKey{
+		k0: binary.LittleEndian.Uint64(block[0:8]),
+		k1: binary.LittleEndian.Uint64(block[8:16]),
+	}
+}
+
+// shortTxID is the SipHash of the transaction hash keyed by the block, a collision is
+// detected by the transactions root of the rebuilt block.
+func shortTxID(key shortIDKey, hash utils.Hash) uint64 {
+	return crypto.SipHash24(key.k0, key.k1, hash[:])
+}
+
+func newCompactBlock(block *types.Block, td *big.Int, known func(utils.Hash) bool) *compactBlockData {
+	compact := &compactBlockData{
+		Header:   block.BlockHeader(),
+		ShortIDs: make([]uint64, len(block.Transactions())),
+		Actions:  block.Actions(),
+		TD:       td,
+	}
+	key := newShortIDKey(block.Hash())
+	for i, tx := range block.Transactions() {
+		hash := tx.Hash()
+		compact.ShortIDs[i] = shortTxID(key, hash)
+		if !known(hash) {
+			compact.Prefilled = append(compact.Prefilled, prefilledTx{Index: uint64(i), Tx: tx})
+		}
+	}
+	return compact
+}
+
+// pendingCompact is a compact block waiting for its missing transactions.
+type pendingCompact struct {
+	peer    *peer
+	data    *compactBlockData
+	txs     []*types.Transaction
+	missing []uint64
+	timer   *time.Timer
+}
+
+// compactBlocks tracks the compact blocks being rebuilt and the relayed blocks.
+type compactBlocks struct {
+	lock    sync.Mutex
+	pending map[utils.Hash]*pendingCompact
+	recent  map[utils.Hash]*types.Block
+	order   []utils.Hash
+}
+
+func newCompactBlocks() *compactBlocks {
+	return &compactBlocks{
+		pending: make(map[utils.Hash]*pendingCompact),
+		recent:  make(map[utils.Hash]*types.Block),
+	}
+}
+
+// remember keeps the relayed block to serve its transactions.
+func (cb *compactBlocks) remember(block *types.Block) {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+
+	hash := block.Hash()
+	if _, ok := cb.recent[hash]; ok {
+		return
+	}
+	if len(cb.order) >= maxRecentBlocks {
+		delete(cb.recent, cb.order[0])
+		cb.order = cb.order[1:]
+	}
+	cb.recent[hash] = block
+	cb.order = append(cb.order, hash)
+}
+
+func (cb *compactBlocks) block(hash utils.Hash) *types.Block {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+
+	return cb.recent[hash]
+}
+
+// take removes the pending compact block, it returns nil if it was completed or expired.
+func (cb *compactBlocks) take(hash utils.Hash) *pendingCompact {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+
+	pending := cb.pending[hash]
+	if pending != nil {
+		pending.timer.Stop()
+		delete(cb.pending, hash)
+	}
+	return pending
+}
+
+// add tracks the compact block waiting for its missing transactions, the timeout is
+// called if they aren't delivered in compactTxsTimeout. It returns false if the block is
+// already pending or there are too many pending blocks.
+func (cb *compactBlocks) add(hash utils.Hash, pending *pendingCompact, timeout func()) bool {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+
+	if _, ok := cb.pending[hash]; ok || len(cb.pending) >= maxPendingCompacts {
+		return false
+	}
+	pending.timer = time.AfterFunc(compactTxsTimeout, func() {
+		if cb.take(hash) != nil {
+			timeout()
+		}
+	})
+	cb.pending[hash] = pending
+	return true
+}
+
+// fill completes the block with the delivered missing transactions in order, it returns
+// false if they don't match the short ids.
+func (pending *pendingCompact) fill(txs []*types.Transaction) bool {
+	if len(txs) != len(pending.missing) {
+		return false
+	}
+	key := newShortIDKey(pending.data.Header.Hash())
+	for i, index := range pending.missing {
+		if txs[i] == nil || shortTxID(key, txs[i].Hash()) != pending.data.ShortIDs[index] {
+			return false
+		}
+	}
+	for i, index := range pending.missing {
+		pending.txs[index] = txs[i]
+	}
+	return true
+}
+
+// txRange iterates over the transactions of the txpool until f returns false.
+type txRange func(f func(hash utils.Hash, tx *types.Transaction) bool)
+
+// rebuildCompact places the prefilled transactions and the ones of the pool by their short
+// ids, it returns the indexes of the transactions which aren't found.
+func rebuildCompact(data *compactBlockData, pool txRange) ([]*types.Transaction, []uint64, error) {
+	hash := data.Header.Hash()
+	txs := make([]*types.Transaction, len(data.ShortIDs))
+	for _, prefilled := range data.Prefilled {
+		if prefilled.Index >= uint64(len(txs)) || prefilled.Tx == nil {
+			return nil, nil, errViolation("compact block %x: invalid prefilled transaction %d", hash[:4], prefilled.Index)
+		}
+		txs[prefilled.Index] = prefilled.Tx
+	}
+	var ids []uint64
+	for i, id := range data.ShortIDs {
+		if txs[i] == nil {
+			ids = append(ids, id)
+		}
+	}
+	found := matchShortIDs(newShortIDKey(hash), ids, pool)
+
+	var missing []uint64
+	for i, id := range data.ShortIDs {
+		if txs[i] != nil {
+			continue
+		}
+		if tx := found[id]; tx != nil {
+			txs[i] = tx
+			continue
+		}
+		missing = append(missing, uint64(i))
+	}
+	return txs, missing, nil
+}
+
+// matchShortIDs looks up the transactions of the pool with the given short ids, the
+// ambiguous ids are left out with a nil transaction.
+func matchShortIDs(key shortIDKey, ids []uint64, pool txRange) map[uint64]*types.Transaction {
+	found := make(map[uint64]*types.Transaction, len(ids))
+	if len(ids) == 0 || pool == nil {
+		return found
+	}
+	wanted := make(map[uint64]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	pool(func(hash utils.Hash, tx *types.Transaction) bool {
+		id := shortTxID(key, hash)
+		if !wanted[id] {
+			return true
+		}
+		if _, ok := found[id]; ok {
+			found[id] = nil
+			return true
+		}
+		found[id] = tx
+		return true
+	})
+	return found
+}
+
+// handleCompactBlock rebuilds the block from the txpool, the missing transactions are
+// requested from the peer.
+func (pm *ProtocolManager) handleCompactBlock(p *peer, data *compactBlockData) error {
+	if data.Header == nil || data.Header.Height == nil || data.TD == nil {
+		return errViolation("compact block without header")
+	}
+	hash := data.Header.Hash()
+	p.MarkBlock(hash)
+	if pm.blockchain.HasBlock(hash) {
+		return nil
+	}
+
+	txs, missing, err := rebuildCompact(data, pm.txpool.Range)
+	if err != nil {
+		return err
+	}
+	if len(missing) == 0 {
+		pm.completeCompact(p, data, txs)
+		return nil
+	}
+
+	pending := &pendingCompact{peer: p, data: data, txs: txs, missing: missing}
+	if !pm.compacts.add(hash, pending, func() {
+		log.Debugf("Peer %s: compact block %x missing txs timed out", p.id, hash[:4])
+		pm.fetchFullBlock(p, hash)
+	}) {
+		return nil
+	}
+	log.Debugf("Peer %s: compact block #%v [%x] missing %d of %d txs", p.id, data.Header.Height, hash[:4], len(missing), len(txs))
+	return p.RequestBlockTxs(hash, missing)
+}
+
+// handleBlockTxs completes the pending compact block with the requested transactions.
+func (pm *ProtocolManager) handleBlockTxs(p *peer, data *blockTxsData) error {
+	pending := pm.compacts.take(data.Hash)
+	if pending == nil {
+		return nil
+	}
+	if pending.peer != p || !pending.fill(data.Txs) {
+		pm.fetchFullBlock(pending.peer, data.Hash)
+		return nil
+	}
+	pm.completeCompact(p, pending.data, pending.txs)
+	return nil
+}
+
+// serveBlockTxs replies the requested transactions of a relayed or imported block.
+func (pm *ProtocolManager) serveBlockTxs(p *peer, req *getBlockTxsData) error {
+	block := pm.compacts.block(req.Hash)
+	if block == nil {
+		block = pm.blockchain.GetBlockByHash(req.Hash)
+	}
+	if block == nil {
+		return p.SendBlockTxs(req.Hash, nil)
+	}
+	all := block.Transactions()
+	txs := make([]*types.Transaction, 0, len(req.Indexes))
+	for _, index := range req.Indexes {
+		if index >= uint64(len(all)) {
+			return errViolation("block %x has no transaction %d", req.Hash[:4], index)
+		}
+		txs = append(txs, all[index])
+	}
+	return p.SendBlockTxs(req.Hash, txs)
+}
+
+// completeCompact checks the transactions root of the rebuilt block and imports it like
+// a full block, on a mismatch the full block is fetched.
+func (pm *ProtocolManager) completeCompact(p *peer, data *compactBlockData, txs []*types.Transaction) {
+	hash := data.Header.Hash()
+	if types.DeriveRootHash(types.Transactions(txs)) != data.Header.TransactionsRoot {
+		log.Debugf("Peer %s: compact block %x rebuilt with wrong transactions", p.id, hash[:4])
+		pm.fetchFullBlock(p, hash)
+		return
+	}
+	block := types.NewBlockWithBlockHeader(data.Header).WithTxs(txs).WithActions(data.Actions)
+	block.ReceivedAt = time.Now()
+	for _, tx := range txs {
+		p.MarkTransaction(tx.Hash())
+	}
+	pm.handleNewBlock(p, block, data.TD)
+}
+
+// fetchFullBlock falls back to retrieving the whole block from the peer, it is announced
+// as an old one to be fetched at once.
+func (pm *ProtocolManager) fetchFullBlock(p *peer, hash utils.Hash) {
+	pm.fetcher.Notify(p.id, hash, time.Now().Add(-compactTxsTimeout), p.RequestBlocks)
+}
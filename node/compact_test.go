@@ -0,0 +1,179 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/core/types"
+)
+
+func newCompactTestBlock(count int) *types.Block {
+	txs := make([]*types.Transaction, count)
+	for i := range txs {
+		txs[i] = types.NewTransaction(types.Binary, uint64(i), big.NewInt(1), 21000, big.NewInt(1), nil, &utils.Address{1})
+	}
+	header := &types.BlockHeader{Height: big.NewInt(1), Difficulty: big.NewInt(1)}
+	return types.NewBlock(header, txs, nil, nil)
+}
+
+// poolOf ranges over the transactions like the txpool.
+func poolOf(txs ...*types.Transaction) txRange {
+	return func(f func(hash utils.Hash, tx *types.Transaction) bool) {
+		for _, tx := range txs {
+			if !f(tx.Hash(), tx) {
+				return
+			}
+		}
+	}
+}
+
+func TestCompactBlockRebuildFromPool(t *testing.T) {
+	block := newCompactTestBlock(4)
+	all := block.Transactions()
+	// the peer knows the first tx, the others are relayed by their short ids
+	compact := newCompactBlock(block, big.NewInt(1), func(hash utils.Hash) bool { return hash != all[0].Hash() })
+	if len(compact.Prefilled) != 1 || compact.Prefilled[0].Index != 0 {
+		t.Fatalf("prefilled %v, want the first transaction", compact.Prefilled)
+	}
+
+	txs, missing, err := rebuildCompact(compact, poolOf(all[1:]...))
+	if err != nil {
+		t.Fatalf("rebuild failed: %v", err)
+	}
+	if len(missing) != 0 {
+		t.Fatalf("missing %v, want none", missing)
+	}
+	if root := types.DeriveRootHash(types.Transactions(txs)); root != block.BlockHeader().TransactionsRoot {
+		t.Fatalf("rebuilt transactions root %x, want %x", root, block.BlockHeader().TransactionsRoot)
+	}
+}
+
+func TestCompactBlockMissingTxs(t *testing.T) {
+	block := newCompactTestBlock(4)
+	all := block.Transactions()
+	compact := newCompactBlock(block, big.NewInt(1), func(utils.Hash) bool { return true })
+
+	txs, missing, err := rebuildCompact(compact, poolOf(all[0], all[2]))
+	if err != nil {
+		t.Fatalf("rebuild failed: %v", err)
+	}
+	if len(missing) != 2 || missing[0] != 1 || missing[1] != 3 {
+		t.Fatalf("missing %v, want [1 3]", missing)
+	}
+	pending := &pendingCompact{data: compact, txs: txs, missing: missing}
+
+	// the delivered txs must match the short ids of the missing ones in order
+	if pending.fill([]*types.Transaction{all[1]}) {
+		t.Fatal("filled with too few transactions")
+	}
+	if pending.fill([]*types.Transaction{all[3], all[1]}) {
+		t.Fatal("filled with the transactions out of order")
+	}
+	if pending.txs[1] != nil || pending.txs[3] != nil {
+		t.Fatal("rejected delivery is filled in")
+	}
+	if !pending.fill([]*types.Transaction{all[1], all[3]}) {
+		t.Fatal("fill failed")
+	}
+	if root := types.DeriveRootHash(types.Transactions(pending.txs)); root != block.BlockHeader().TransactionsRoot {
+		t.Fatalf("rebuilt transactions root %x, want %x", root, block.BlockHeader().TransactionsRoot)
+	}
+}
+
+func TestCompactBlockAmbiguousShortID(t *testing.T) {
+	block := newCompactTestBlock(1)
+	tx := block.Transactions()[0]
+	compact := newCompactBlock(block, big.NewInt(1), func(utils.Hash) bool { return true })
+
+	// two pool transactions with the same short id are left out, the tx is requested
+	_, missing, err := rebuildCompact(compact, poolOf(tx, tx))
+	if err != nil {
+		t.Fatalf("rebuild failed: %v", err)
+	}
+	if len(missing) != 1 || missing[0] != 0 {
+		t.Fatalf("missing %v, want [0]", missing)
+	}
+}
+
+func TestCompactBlockSaltedShortIDs(t *testing.T) {
+	tx := newCompactTestBlock(1).Transactions()[0]
+	first := types.NewBlock(&types.BlockHeader{Height: big.NewInt(1), Difficulty: big.NewInt(1)}, []*types.Transaction{tx}, nil, nil)
+	second := types.NewBlock(&types.BlockHeader{Height: big.NewInt(2), Difficulty: big.NewInt(1)}, []*types.Transaction{tx}, nil, nil)
+	known := func(utils.Hash) bool { return true }
+
+	// the same transaction has another short id in every block
+	a, b := newCompactBlock(first, big.NewInt(1), known), newCompactBlock(second, big.NewInt(1), known)
+	if a.ShortIDs[0] == b.ShortIDs[0] {
+		t.Fatalf("short id %x is the same in both blocks", a.ShortIDs[0])
+	}
+	// and only the ids of the block are matched in the pool
+	found := matchShortIDs(newShortIDKey(first.Hash()), a.ShortIDs, poolOf(tx, newCompactTestBlock(2).Transactions()[1]))
+	if len(found) != 1 || found[a.ShortIDs[0]] != tx {
+		t.Fatalf("matched %v, want only the transaction of the block", found)
+	}
+}
+
+func TestCompactBlockInvalidPrefilled(t *testing.T) {
+	block := newCompactTestBlock(2)
+	compact := newCompactBlock(block, big.NewInt(1), func(utils.Hash) bool { return false })
+	compact.Prefilled[1].Index = 2
+	if _, _, err := rebuildCompact(compact, nil); err == nil {
+		t.Fatal("prefilled transaction out of range accepted")
+	}
+}
+
+func TestCompactBlockTimeout(t *testing.T) {
+	cb := newCompactBlocks()
+	block := newCompactTestBlock(2)
+	compact := newCompactBlock(block, big.NewInt(1), func(utils.Hash) bool { return true })
+
+	// the block falls back to a full fetch if the missing txs aren't delivered in time
+	timeout := make(chan utils.Hash, 2)
+	hash := block.Hash()
+	if !cb.add(hash, &pendingCompact{data: compact}, func() { timeout <- hash }) {
+		t.Fatal("pending block rejected")
+	}
+	if cb.add(hash, &pendingCompact{data: compact}, func() { timeout <- hash }) {
+		t.Fatal("pending block added twice")
+	}
+	select {
+	case <-timeout:
+	case <-time.After(2 * compactTxsTimeout):
+		t.Fatal("no fallback after the timeout")
+	}
+	if cb.take(hash) != nil {
+		t.Fatal("timed out block is still pending")
+	}
+
+	// a completed block doesn't time out
+	other := newCompactTestBlock(3).Hash()
+	if !cb.add(other, &pendingCompact{data: compact}, func() { timeout <- other }) {
+		t.Fatal("pending block rejected")
+	}
+	if cb.take(other) == nil {
+		t.Fatal("pending block is missing")
+	}
+	select {
+	case <-timeout:
+		t.Fatal("fallback of the completed block")
+	case <-time.After(2 * compactTxsTimeout):
+	}
+}
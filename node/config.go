@@ -17,32 +17,79 @@
 package node
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"os"
 	"path/filepath"
+	"strings"
 
+	ldb "github.com/UranusBlockStack/uranus/common/db"
+	"github.com/UranusBlockStack/uranus/common/log"
+	"github.com/UranusBlockStack/uranus/common/utils"
 	"github.com/UranusBlockStack/uranus/p2p"
 )
 
 var DefaultName = "uranus"
 
+var (
+	// DefaultHTTPModules are the namespaces exposed over HTTP, the privileged
+	// Wallet, Admin and Miner namespaces are only served over IPC.
+	DefaultHTTPModules = []string{"Uranus", "BlockChain", "TxPool", "Dpos"}
+	// DefaultEthModules are the namespaces exposed over the ethereum compatible endpoint.
+	DefaultEthModules = []string{"eth", "net", "web3"}
+	// DefaultIPCPath is the IPC socket file in the instance directory.
+	DefaultIPCPath = "uranus.ipc"
+)
+
+// MemoryDataDir is the data directory of an ephemeral node, the databases are kept in
+// memory and the other files in a temporary directory removed when the node stops.
+const MemoryDataDir = ":memory:"
+
+const (
+	staticNodesFile  = "static-nodes.json"  // enode urls always redialed, in the instance directory
+	trustedNodesFile = "trusted-nodes.json" // enode urls accepted beyond the peer limits, in the instance directory
+	sentryNodesFile  = "sentry-nodes.json"  // enode urls of the sentries of a validator, in the instance directory
+	privateNodesFile = "private-nodes.json" // enode urls of the validators behind a sentry, in the instance directory
+)
+
 // Config config of node
 type Config struct {
 	Name    string
 	DataDir string `mapstructure:"node-datadir"`
 
-	Host string   `mapstructure:"rpc-host"`
-	Port int      `mapstructure:"rpc-port"`
-	Cors []string `mapstructure:"rpc-cors"`
+	Host      string   `mapstructure:"rpc-host"`
+	Port      int      `mapstructure:"rpc-port"`
+	Cors      []string `mapstructure:"rpc-cors"`
+	Modules   []string `mapstructure:"rpc-modules"`
+	JWTSecret string   `mapstructure:"rpc-jwtsecret"` // file of the hex secret authenticating HTTP requests
+
+	EthHost    string   `mapstructure:"ethrpc-host"`
+	EthPort    int      `mapstructure:"ethrpc-port"`
+	EthModules []string `mapstructure:"ethrpc-modules"`
+
+	IPCPath string `mapstructure:"ipc-path"`
+
+	// Databases overrides the engine and the tuning of the databases by name, e.g.
+	// chaindata and nodes.
+	Databases map[string]*ldb.Options `mapstructure:"databases"`
 
 	P2P *p2p.Config
+
+	tempDir string // instance directory of an in-memory node
 }
 
 // NewConfig initialize node config
 func NewConfig(dataDir string) *Config {
 	return &Config{
-		Name:    DefaultName,
-		DataDir: dataDir,
-		P2P:     &p2p.Config{},
+		Name:       DefaultName,
+		DataDir:    dataDir,
+		Modules:    DefaultHTTPModules,
+		EthModules: DefaultEthModules,
+		IPCPath:    DefaultIPCPath,
+		P2P:        &p2p.Config{},
 	}
 }
 
@@ -54,10 +101,101 @@ func (c *Config) Endpoint() string {
 	return fmt.Sprintf("%s:%d", c.Host, c.Port)
 }
 
+// EthEndpoint resolves the ethereum compatible JSON-RPC endpoint.
+func (c *Config) EthEndpoint() string {
+	if c.EthHost == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", c.EthHost, c.EthPort)
+}
+
+// IPCEndpoint resolves the IPC socket path, the socket is placed in the instance directory
+// unless the path is absolute.
+func (c *Config) IPCEndpoint() string {
+	if c.IPCPath == "" {
+		return ""
+	}
+	return c.resolvePath(c.IPCPath)
+}
+
+// jwtSecret loads the hex secret of the HTTP authentication, a new secret is generated if the file doesn't exist.
+func (c *Config) jwtSecret() ([]byte, error) {
+	if c.JWTSecret == "" {
+		return nil, nil
+	}
+	if data, err := ioutil.ReadFile(c.JWTSecret); err == nil {
+		secret := utils.FromHex(strings.TrimSpace(string(data)))
+		if len(secret) != 32 {
+			return nil, fmt.Errorf("invalid JWT secret in %s, want 32 bytes hex", c.JWTSecret)
+		}
+		return secret, nil
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(c.JWTSecret, []byte(hex.EncodeToString(secret)), 0600); err != nil {
+		return nil, err
+	}
+	log.Infof("Generated JWT secret: %v", c.JWTSecret)
+	return secret, nil
+}
+
+// InMemory reports whether the databases are kept in memory, there is no data directory
+// or it's MemoryDataDir.
+func (c *Config) InMemory() bool {
+	return c.DataDir == "" || c.DataDir == MemoryDataDir
+}
+
+// databaseOptions returns the engine and the tuning of the named database, the defaults
+// are overridden by the configured options. The databases of an in-memory node are
+// always kept in memory.
+func (c *Config) databaseOptions(name string, cache int, handles int) *ldb.Options {
+	options := ldb.Options{Cache: cache, Handles: handles}.Override(c.Databases[name])
+	if c.InMemory() {
+		options.Engine = ldb.MemoryEngine
+	}
+	return options
+}
+
+// instanceDir returns the instance directory, a temporary one for MemoryDataDir.
+func (c *Config) instanceDir() string {
+	if c.DataDir != MemoryDataDir {
+		return filepath.Join(c.DataDir, c.Name)
+	}
+	if c.tempDir == "" {
+		dir, err := ioutil.TempDir("", "uranus-memory-")
+		if err != nil {
+			log.Fatalf("Failed to create temporary instance directory err: %v", err)
+		}
+		c.tempDir = dir
+	}
+	return c.tempDir
+}
+
 // resolvePath resolves path in the instance directory.
 func (c *Config) resolvePath(path string) string {
 	if filepath.IsAbs(path) {
 		return path
 	}
-	return filepath.Join(filepath.Join(c.DataDir, c.Name), path)
+	return filepath.Join(c.instanceDir(), path)
+}
+
+// nodeURLs returns the enode urls of the JSON list file in the instance directory, nil if it doesn't exist.
+func (c *Config) nodeURLs(file string) ([]string, error) {
+	path := c.resolvePath(file)
+	if !utils.FileExists(path) {
+		return nil, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var urls []string
+	if err := json.Unmarshal(data, &urls); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", path, err)
+	}
+	return urls, nil
 }
@@ -31,19 +31,28 @@ type Context struct {
 	services map[reflect.Type]Service
 }
 
-// OpenDatabase opens an existing database.
+// OpenDatabase opens an existing database, the cache and the handles are the defaults of
+// the tuning configured by name.
 func (ctx *Context) OpenDatabase(name string, cache int, handles int) (ldb.Database, error) {
-	if ctx.config.DataDir == "" {
-		return ldb.NewMemDatabase(), nil
-	}
-	log.Debugf("database dir: %v", ctx.config.resolvePath(name))
-	db, err := ldb.NewLDB(ctx.config.resolvePath(name), cache, handles)
+	options := ctx.config.databaseOptions(name, cache, handles)
+	log.Debugf("database dir: %v, engine: %v", ctx.config.resolvePath(name), options.Engine)
+	db, err := ldb.Open(ctx.config.resolvePath(name), options)
 	if err != nil {
 		return nil, err
 	}
 	return db, nil
 }
 
+// InMemory reports whether the databases are kept in memory.
+func (ctx *Context) InMemory() bool {
+	return ctx.config.InMemory()
+}
+
+// DatabaseInMemory reports whether the named database is kept in memory.
+func (ctx *Context) DatabaseInMemory(name string) bool {
+	return ctx.config.databaseOptions(name, 0, 0).Engine == ldb.MemoryEngine
+}
+
 // ResolvePath resolves a user path into the data directory .
 func (ctx *Context) ResolvePath(path string) string {
 	return ctx.config.resolvePath(path)
@@ -0,0 +1,112 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"math"
+
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/params"
+)
+
+var (
+	// errForkRemoteStale is returned when the remote node is behind a fork it doesn't know.
+	errForkRemoteStale = errors.New("remote needs update")
+	// errForkIncompatible is returned when the chains of the nodes have diverged, or the
+	// local node is behind a fork it doesn't know.
+	errForkIncompatible = errors.New("local incompatible or needs update")
+)
+
+// forkID identifies the rule set of a chain. Hash is the CRC32 checksum of the genesis
+// hash and the heights of the passed forks, Next is the height of the next fork, 0 if
+// none is known.
+type forkID struct {
+	Hash [4]byte
+	Next uint64
+}
+
+// forkSums returns the fork heights and the checksums of the chain, sums[i] is the
+// checksum once the first i forks are passed.
+func forkSums(config *params.ChainConfig, genesis utils.Hash) ([]uint64, [][4]byte) {
+	forks := config.ForkHeights()
+	sums := make([][4]byte, len(forks)+1)
+
+	hash := crc32.ChecksumIEEE(genesis[:])
+	binary.BigEndian.PutUint32(sums[0][:], hash)
+	for i, fork := range forks {
+		var height [8]byte
+		binary.BigEndian.PutUint64(height[:], fork)
+		hash = crc32.Update(hash, crc32.IEEETable, height[:])
+		binary.BigEndian.PutUint32(sums[i+1][:], hash)
+	}
+	return forks, sums
+}
+
+// newForkID returns the fork identifier of the chain at the head height.
+func newForkID(config *params.ChainConfig, genesis utils.Hash, head uint64) forkID {
+	forks, sums := forkSums(config, genesis)
+	for i, fork := range forks {
+		if head < fork {
+			return forkID{Hash: sums[i], Next: fork}
+		}
+	}
+	return forkID{Hash: sums[len(forks)]}
+}
+
+// newForkFilter returns the check of the remote fork identifiers against the local chain
+// at the current head height.
+func newForkFilter(config *params.ChainConfig, genesis utils.Hash, headFn func() uint64) func(forkID) error {
+	forks, sums := forkSums(config, genesis)
+	forks = append(forks, math.MaxUint64) // the last fork is never passed
+
+	return func(id forkID) error {
+		head := headFn()
+		for i, fork := range forks {
+			if head >= fork {
+				continue
+			}
+			// both nodes passed the same forks, the remote is compatible unless it
+			// announces a fork the local node already passed
+			if sums[i] == id.Hash {
+				if id.Next > 0 && head >= id.Next {
+					return errForkIncompatible
+				}
+				return nil
+			}
+			// the remote is syncing, it has to know the next fork it will pass
+			for j := 0; j < i; j++ {
+				if sums[j] == id.Hash {
+					if forks[j] != id.Next {
+						return errForkRemoteStale
+					}
+					return nil
+				}
+			}
+			// the local node is syncing, the remote passed forks it knows about
+			for j := i + 1; j < len(sums); j++ {
+				if sums[j] == id.Hash {
+					return nil
+				}
+			}
+			return errForkIncompatible
+		}
+		return errForkIncompatible
+	}
+}
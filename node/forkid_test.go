@@ -0,0 +1,87 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/params"
+)
+
+func TestForkFilter(t *testing.T) {
+	var (
+		genesis = utils.Hash{1}
+		forked  = &params.ChainConfig{BaseFeeHeight: big.NewInt(100)}
+		unaware = &params.ChainConfig{}
+	)
+	tests := []struct {
+		name   string
+		head   uint64
+		remote forkID
+		err    error
+	}{
+		// same fork, the remote knows the next one or not
+		{"same fork", 50, newForkID(forked, genesis, 60), nil},
+		{"same fork, next unknown", 50, newForkID(unaware, genesis, 60), nil},
+		{"both passed the fork", 150, newForkID(forked, genesis, 200), nil},
+		// the remote is behind the fork the local node passed
+		{"remote behind", 150, newForkID(forked, genesis, 50), nil},
+		{"remote behind, stale", 150, newForkID(unaware, genesis, 50), errForkRemoteStale},
+		// the remote passed the fork the local node is syncing to
+		{"remote ahead", 50, newForkID(forked, genesis, 150), nil},
+		// different chains
+		{"different genesis", 50, newForkID(forked, utils.Hash{2}, 60), errForkIncompatible},
+		{"different genesis, passed", 150, newForkID(forked, utils.Hash{2}, 200), errForkIncompatible},
+		{"unknown fork", 150, newForkID(&params.ChainConfig{BaseFeeHeight: big.NewInt(120)}, genesis, 200), errForkIncompatible},
+	}
+	for _, test := range tests {
+		head := test.head
+		filter := newForkFilter(forked, genesis, func() uint64 { return head })
+		if err := filter(test.remote); err != test.err {
+			t.Errorf("%s: error %v, want %v", test.name, err, test.err)
+		}
+	}
+
+	// the local node passed a fork the remote announces as its next one
+	filter := newForkFilter(unaware, genesis, func() uint64 { return 150 })
+	if err := filter(newForkID(forked, genesis, 50)); err != errForkIncompatible {
+		t.Errorf("passed remote fork: error %v, want %v", err, errForkIncompatible)
+	}
+}
+
+func TestForkID(t *testing.T) {
+	var (
+		genesis = utils.Hash{1}
+		config  = &params.ChainConfig{BaseFeeHeight: big.NewInt(100)}
+	)
+	before, after := newForkID(config, genesis, 99), newForkID(config, genesis, 100)
+	if before.Next != 100 || after.Next != 0 {
+		t.Fatalf("next forks %d and %d, want 100 and 0", before.Next, after.Next)
+	}
+	if before.Hash == after.Hash {
+		t.Fatal("passing the fork doesn't change the checksum")
+	}
+	if id := newForkID(&params.ChainConfig{}, genesis, 200); id.Hash != before.Hash || id.Next != 0 {
+		t.Fatalf("fork id without forks %v, want the genesis checksum", id)
+	}
+	// a fork from the genesis isn't a fork
+	if id := newForkID(&params.ChainConfig{BaseFeeHeight: big.NewInt(0)}, genesis, 0); id != newForkID(&params.ChainConfig{}, genesis, 0) {
+		t.Fatalf("fork id %v with a genesis fork", id)
+	}
+}
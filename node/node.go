@@ -22,14 +22,18 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"sync"
 
 	"github.com/UranusBlockStack/uranus/common/crypto"
+	ldb "github.com/UranusBlockStack/uranus/common/db"
 	"github.com/UranusBlockStack/uranus/common/filelock"
 	"github.com/UranusBlockStack/uranus/common/log"
 	"github.com/UranusBlockStack/uranus/common/utils"
 	"github.com/UranusBlockStack/uranus/p2p"
 	"github.com/UranusBlockStack/uranus/p2p/discover"
+	"github.com/UranusBlockStack/uranus/p2p/nat"
+	"github.com/UranusBlockStack/uranus/p2p/netutil"
 	"github.com/UranusBlockStack/uranus/params"
 	"github.com/UranusBlockStack/uranus/rpc"
 )
@@ -37,8 +41,8 @@ import (
 type communication struct {
 	endpoint string
 	listener net.Listener
-	handler  *rpc.Server
 	cors     []string
+	modules  []string
 }
 
 type Constructor func(ctx *Context) (Service, error)
@@ -47,8 +51,11 @@ type Constructor func(ctx *Context) (Service, error)
 type Node struct {
 	config    *Config
 	p2pConfig *p2p.Config
+	server    *p2p.Server // running p2p server
 
-	rpc *communication
+	rpc    *communication
+	ethrpc *communication
+	ipc    *communication
 
 	running         bool
 	instanceDirLock filelock.Releaser
@@ -63,7 +70,9 @@ func New(conf *Config) *Node {
 	return &Node{
 		config:       conf,
 		p2pConfig:    conf.P2P,
-		rpc:          &communication{endpoint: conf.Endpoint(), cors: conf.Cors},
+		rpc:          &communication{endpoint: conf.Endpoint(), cors: conf.Cors, modules: conf.Modules},
+		ethrpc:       &communication{endpoint: conf.EthEndpoint(), cors: conf.Cors, modules: conf.EthModules},
+		ipc:          &communication{endpoint: conf.IPCEndpoint()},
 		running:      false,
 		serviceFuncs: []Constructor{},
 		services:     make(map[reflect.Type]Service),
@@ -144,7 +153,7 @@ func (n *Node) Start() error {
 			} else {
 				p2pServer.PrivateKey = key
 			}
-		} else if file = filepath.Join(n.config.DataDir, "nodekey"); utils.FileExists(file) {
+		} else if file = filepath.Join(n.config.DataDir, "nodekey"); !n.config.InMemory() && utils.FileExists(file) {
 			if key, err := crypto.LoadECDSA(file); err != nil {
 				log.Fatalf("failed to open %s: %v", file, err)
 			} else {
@@ -155,14 +164,61 @@ func (n *Node) Start() error {
 			if err != nil {
 				log.Fatalf("could not generate key: %v", err)
 			}
-			if err = crypto.SaveECDSA(file, nodeKey); err != nil {
-				log.Fatalf("%v", err)
+			// an in-memory node gets a new identity on every start
+			if !n.config.InMemory() {
+				if err = crypto.SaveECDSA(file, nodeKey); err != nil {
+					log.Fatalf("%v", err)
+				}
 			}
 			p2pServer.PrivateKey = nodeKey
 		}
 	}
-	if p2pServer.NodeDatabase == "" {
-		p2pServer.NodeDatabase = n.config.resolvePath("nodes")
+	for _, list := range []struct {
+		file  string
+		urls  []string
+		nodes *[]*discover.Node
+	}{
+		{staticNodesFile, p2pServer.StaticNodeStrs, &p2pServer.StaticNodes},
+		{trustedNodesFile, p2pServer.TrustedNodeStrs, &p2pServer.TrustedNodes},
+		{sentryNodesFile, p2pServer.SentryNodeStrs, &p2pServer.SentryNodes},
+		{privateNodesFile, p2pServer.PrivateNodeStrs, &p2pServer.PrivateNodes},
+	} {
+		urls, err := n.config.nodeURLs(list.file)
+		if err != nil {
+			return err
+		}
+		for _, url := range append(urls, list.urls...) {
+			node, err := discover.ParseNode(url)
+			if err != nil {
+				return fmt.Errorf("invalid enode %q in %s: %v", url, list.file, err)
+			}
+			*list.nodes = append(*list.nodes, node)
+		}
+	}
+	if p2pServer.NetRestrict == nil && len(p2pServer.NetRestrictStrs) > 0 {
+		netlist, err := netutil.ParseNetlist(strings.Join(p2pServer.NetRestrictStrs, ","))
+		if err != nil {
+			return fmt.Errorf("invalid p2p netrestrict: %v", err)
+		}
+		p2pServer.NetRestrict = netlist
+	}
+	if p2pServer.NAT == nil {
+		natif, err := nat.Parse(p2pServer.NATSpec)
+		if err != nil {
+			return fmt.Errorf("invalid p2p nat %q: %v", p2pServer.NATSpec, err)
+		}
+		p2pServer.NAT = natif
+	}
+	switch options := n.config.databaseOptions("nodes", 0, 5); options.Engine {
+	case ldb.MemoryEngine:
+		p2pServer.NodeDatabase = ""
+	case "", ldb.LevelDBEngine:
+		if p2pServer.NodeDatabase == "" {
+			p2pServer.NodeDatabase = n.config.resolvePath("nodes")
+		}
+		p2pServer.NodeDatabaseOptions = options
+	default:
+		return fmt.Errorf("unsupported node database engine %q", options.Engine)
 	}
 	for _, service := range services {
 		p2pServer.Protocols = append(p2pServer.Protocols, service.Protocols()...)
@@ -181,6 +237,7 @@ func (n *Node) Start() error {
 			for _, kind := range started {
 				services[kind].Stop()
 			}
+			p2pServer.Stop()
 			return err
 		}
 		// Mark the service started for potential cleanup
@@ -199,29 +256,52 @@ func (n *Node) Start() error {
 	}
 
 	n.services = services
+	n.server = p2pServer
 	n.running = true
 	n.stop = make(chan struct{})
 	return nil
 }
 
-// startRPC initializes and starts the  RPC endpoint.
+// startRPC initializes and starts the IPC and RPC endpoints.
 func (n *Node) startRPC(apis []rpc.API) error {
-	if n.rpc.endpoint == "" {
-		return nil // RPC disabled.
+	if n.ipc.endpoint != "" {
+		listener, _, err := rpc.StartIPC(n.ipc.endpoint, apis)
+		if err != nil {
+			return err
+		}
+		n.ipc.listener = listener
+		log.Infof("IPC endpoint opened: %v", n.ipc.endpoint)
 	}
-	listener, _, err := rpc.StartRPCAndHTTP(n.rpc.endpoint, apis, n.rpc.cors)
+
+	jwtSecret, err := n.config.jwtSecret()
 	if err != nil {
 		return err
 	}
-	n.rpc.listener = listener
-	log.Infof("RPC and HTTP endpoint opened: %v", n.rpc.endpoint)
+	if n.rpc.endpoint != "" {
+		listener, _, err := rpc.StartRPCAndHTTP(n.rpc.endpoint, apis, n.rpc.modules, n.rpc.cors, jwtSecret)
+		if err != nil {
+			return err
+		}
+		n.rpc.listener = listener
+		log.Infof("RPC and HTTP endpoint opened: %v, modules: %v", n.rpc.endpoint, n.rpc.modules)
+	}
+	if n.ethrpc.endpoint != "" {
+		listener, _, err := rpc.StartEthRPC(n.ethrpc.endpoint, apis, n.ethrpc.modules, n.ethrpc.cors, jwtSecret)
+		if err != nil {
+			return err
+		}
+		n.ethrpc.listener = listener
+		log.Infof("Ethereum compatible JSON-RPC endpoint opened: %v, modules: %v", n.ethrpc.endpoint, n.ethrpc.modules)
+	}
 	return nil
 }
 
 func (n *Node) stopRPC() {
-	if n.rpc.listener != nil {
-		n.rpc.listener.Close()
-		n.rpc.listener = nil
+	for _, c := range []*communication{n.rpc, n.ethrpc, n.ipc} {
+		if c.listener != nil {
+			c.listener.Close()
+			c.listener = nil
+		}
 	}
 }
 
@@ -245,7 +325,10 @@ func (n *Node) Stop() error {
 		}
 	}
 	n.services = nil
+	n.server.Stop()
+	n.server = nil
 
+	n.stopRPC()
 	n.releaseInstanceDir()
 
 	close(n.stop)
@@ -279,6 +362,14 @@ func (n *Node) Restart() error {
 	return n.Start()
 }
 
+// Server returns the p2p server of the running node, nil if it is stopped.
+func (n *Node) Server() *p2p.Server {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+
+	return n.server
+}
+
 // Service retrieves a currently running service registered of a specific type.
 func (n *Node) Service(service interface{}) error {
 	n.lock.RLock()
@@ -297,11 +388,33 @@ func (n *Node) Service(service interface{}) error {
 	return ErrServiceUnknown
 }
 
+// OpenDataDir locks the data directory and returns a service context without starting
+// the node, for the commands working on the databases offline.
+func (n *Node) OpenDataDir() (*Context, error) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if n.running {
+		return nil, ErrNodeRunning
+	}
+	if err := n.openDataDir(); err != nil {
+		return nil, err
+	}
+	return &Context{config: n.config, services: make(map[reflect.Type]Service)}, nil
+}
+
+// CloseDataDir releases the data directory locked by OpenDataDir.
+func (n *Node) CloseDataDir() {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.releaseInstanceDir()
+}
+
 func (n *Node) openDataDir() error {
 	if n.config.DataDir == "" {
 		return nil
 	}
-	instdir := filepath.Join(n.config.DataDir, n.config.Name)
+	instdir := n.config.instanceDir()
 	if err := os.MkdirAll(instdir, 0700); err != nil {
 		return err
 	}
@@ -326,4 +439,7 @@ func (n *Node) releaseInstanceDir() {
 		}
 		n.instanceDirLock = nil
 	}
+	if n.config.tempDir != "" {
+		os.RemoveAll(n.config.tempDir)
+	}
 }
@@ -22,6 +22,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/UranusBlockStack/uranus/common/log"
 	"github.com/UranusBlockStack/uranus/common/utils"
 	"github.com/UranusBlockStack/uranus/core/types"
 	"github.com/UranusBlockStack/uranus/node/protocols"
@@ -50,9 +51,10 @@ type peer struct {
 	*p2p.Peer
 	rw p2p.MsgReadWriter
 
-	head utils.Hash
-	td   *big.Int
-	lock sync.RWMutex
+	head   utils.Hash
+	height uint64 // height of the head announced with a new block, 0 if unknown
+	td     *big.Int
+	lock   sync.RWMutex
 
 	existedTxs       *set.Set
 	existedBlocks    *set.Set
@@ -101,14 +103,23 @@ func (p *peer) Head() (hash utils.Hash, td *big.Int) {
 	return hash, new(big.Int).Set(p.td)
 }
 
-func (p *peer) SetHead(hash utils.Hash, td *big.Int) {
+func (p *peer) SetHead(hash utils.Hash, height uint64, td *big.Int) {
 	p.lock.Lock()
 	defer p.lock.Unlock()
 
 	copy(p.head[:], hash[:])
+	p.height = height
 	p.td.Set(td)
 }
 
+// Height returns the announced height of the head, 0 if it's unknown.
+func (p *peer) Height() uint64 {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.height
+}
+
 func (p *peer) MarkBlock(hash utils.Hash) {
 	for p.existedBlocks.Size() >= maxExistedBlocks {
 		p.existedBlocks.Pop()
@@ -123,6 +134,11 @@ func (p *peer) MarkTransaction(hash utils.Hash) {
 	p.existedTxs.Add(hash)
 }
 
+// KnownTransaction reports whether the peer is known to have the transaction.
+func (p *peer) KnownTransaction(hash utils.Hash) bool {
+	return p.existedTxs.Has(hash)
+}
+
 func (p *peer) MarkConfirmed(hash utils.Hash) {
 	for p.existedConfirmed.Size() >= maxExistedTxs {
 		p.existedConfirmed.Pop()
@@ -137,6 +153,21 @@ func (p *peer) SendTransactions(txs types.Transactions) error {
 	return p2p.SendMessage(p.rw, TxMsg, txs)
 }
 
+// AnnounceTransactions sends the hashes of the transactions, the peer requests the ones it misses.
+func (p *peer) AnnounceTransactions(hashes []utils.Hash) error {
+	for _, hash := range hashes {
+		p.existedTxs.Add(hash)
+	}
+	return p2p.SendMessage(p.rw, NewPooledTransactionHashesMsg, hashes)
+}
+
+func (p *peer) SendPooledTransactions(txs types.Transactions) error {
+	for _, tx := range txs {
+		p.existedTxs.Add(tx.Hash())
+	}
+	return p2p.SendMessage(p.rw, PooledTransactionsMsg, txs)
+}
+
 func (p *peer) SendBlockHashes(hashes []utils.Hash) error {
 	return p2p.SendMessage(p.rw, BlockHashesMsg, hashes)
 }
@@ -162,6 +193,15 @@ func (p *peer) SendNewBlock(block *types.Block, td *big.Int) error {
 	return p2p.SendMessage(p.rw, NewBlockMsg, []interface{}{block, td})
 }
 
+func (p *peer) SendCompactBlock(compact *compactBlockData) error {
+	p.existedBlocks.Add(compact.Header.Hash())
+	return p2p.SendMessage(p.rw, CompactBlockMsg, compact)
+}
+
+func (p *peer) SendBlockTxs(hash utils.Hash, txs []*types.Transaction) error {
+	return p2p.SendMessage(p.rw, BlockTxsMsg, &blockTxsData{Hash: hash, Txs: txs})
+}
+
 func (p *peer) RequestHashes(from utils.Hash) error {
 	return p2p.SendMessage(p.rw, GetBlockHashesMsg, getBlockHashesData{from, uint64(protocols.MaxHashFetch)})
 }
@@ -170,24 +210,43 @@ func (p *peer) RequestBlocks(hashes []utils.Hash) error {
 	return p2p.SendMessage(p.rw, GetBlocksMsg, hashes)
 }
 
+func (p *peer) RequestBlockTxs(hash utils.Hash, indexes []uint64) error {
+	return p2p.SendMessage(p.rw, GetBlockTxsMsg, &getBlockTxsData{Hash: hash, Indexes: indexes})
+}
+
+func (p *peer) RequestTxs(hashes []utils.Hash) error {
+	return p2p.SendMessage(p.rw, GetPooledTransactionsMsg, hashes)
+}
+
 func (p *peer) RequestHashesFromNumber(from uint64, count int) error {
 	return p2p.SendMessage(p.rw, GetBlockHashesFromNumberMsg, getBlockHashesFromNumberData{from, uint64(count)})
 }
 
-func (p *peer) Handshake(network uint64, td *big.Int, head utils.Hash, genesis utils.Hash) error {
+func (p *peer) Handshake(network uint64, td *big.Int, head utils.Hash, genesis utils.Hash, forkID forkID, forkFilter func(forkID) error) error {
 	var status statusData
 	errc := make(chan error, 2)
 	go func() {
-		errc <- p2p.SendMessage(p.rw, StatusMsg, &statusData{
+		if p.version < uranus4 {
+			errc <- p2p.SendMessage(p.rw, StatusMsg, &statusData{
+				ProtocolVersion: uint32(p.version),
+				NetworkID:       network,
+				TD:              td,
+				CurrentBlock:    head,
+				GenesisBlock:    genesis,
+			})
+			return
+		}
+		errc <- p2p.SendMessage(p.rw, StatusMsg, &forkStatusData{
 			ProtocolVersion: uint32(p.version),
 			NetworkID:       network,
 			TD:              td,
 			CurrentBlock:    head,
 			GenesisBlock:    genesis,
+			ForkID:          forkID,
 		})
 	}()
 	go func() {
-		errc <- p.readStatus(network, &status, genesis)
+		errc <- p.readStatus(network, &status, genesis, forkFilter)
 	}()
 	timeout := time.NewTimer(handshakeTimeout)
 	defer timeout.Stop()
@@ -205,7 +264,7 @@ func (p *peer) Handshake(network uint64, td *big.Int, head utils.Hash, genesis u
 	return nil
 }
 
-func (p *peer) readStatus(network uint64, status *statusData, genesis utils.Hash) (err error) {
+func (p *peer) readStatus(network uint64, status *statusData, genesis utils.Hash, forkFilter func(forkID) error) (err error) {
 	msg, err := p.rw.ReadMsg()
 	if err != nil {
 		return err
@@ -214,8 +273,24 @@ func (p *peer) readStatus(network uint64, status *statusData, genesis utils.Hash
 		return fmt.Errorf("first msg has code %x (!= %x)", msg.Code, StatusMsg)
 	}
 
-	if err := msg.DecodePayload(&status); err != nil {
-		return fmt.Errorf("status msg %v: %v", msg, err)
+	var id *forkID
+	if p.version < uranus4 {
+		if err := msg.DecodePayload(&status); err != nil {
+			return fmt.Errorf("status msg %v: %v", msg, err)
+		}
+	} else {
+		var fs forkStatusData
+		if err := msg.DecodePayload(&fs); err != nil {
+			return fmt.Errorf("status msg %v: %v", msg, err)
+		}
+		*status = statusData{
+			ProtocolVersion: fs.ProtocolVersion,
+			NetworkID:       fs.NetworkID,
+			TD:              fs.TD,
+			CurrentBlock:    fs.CurrentBlock,
+			GenesisBlock:    fs.GenesisBlock,
+		}
+		id = &fs.ForkID
 	}
 	if status.GenesisBlock != genesis {
 		return fmt.Errorf("genesis %x (!= %x)", status.GenesisBlock[:8], genesis[:8])
@@ -226,5 +301,12 @@ func (p *peer) readStatus(network uint64, status *statusData, genesis utils.Hash
 	if int(status.ProtocolVersion) != p.version {
 		return fmt.Errorf("version %d (!= %d)", status.ProtocolVersion, p.version)
 	}
+	if id != nil {
+		if err := forkFilter(*id); err != nil {
+			log.Warnf("Peer %s: fork id %x (next %d) rejected --- %v", p.id, id.Hash, id.Next, err)
+			// the reason is sent to the peer when the protocol returns it
+			return p2p.QuitIncompatibleFork
+		}
+	}
 	return nil
 }
@@ -0,0 +1,148 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/UranusBlockStack/uranus/common/crypto"
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/p2p"
+	"github.com/UranusBlockStack/uranus/p2p/discover"
+	"github.com/UranusBlockStack/uranus/params"
+)
+
+type handshakeResult struct {
+	err  error
+	head utils.Hash
+	td   *big.Int
+}
+
+// startHandshakeServer runs the status handshake of uranus4 with the peers like the
+// protocol manager, the connection compresses the messages with snappy.
+func startHandshakeServer(t *testing.T, config *params.ChainConfig, genesis utils.Hash, head uint64, results chan<- *handshakeResult) *p2p.Server {
+	key, _ := crypto.GenerateKey()
+	filter := newForkFilter(config, genesis, func() uint64 { return head })
+	srv := &p2p.Server{Config: p2p.Config{
+		PrivateKey:  key,
+		Name:        "test",
+		ListenAddr:  "127.0.0.1:0",
+		NoDiscovery: true,
+		Protocols: []*p2p.Protocol{{
+			Name:    baseProtocolName,
+			Version: uranus4,
+			Offset:  1000,
+			Size:    1000,
+			Run: func(peer *p2p.Peer, rw p2p.MsgReadWriter) error {
+				p := newPeer(uranus4, peer, rw)
+				err := p.Handshake(1, big.NewInt(int64(head)), utils.Hash{byte(head)}, genesis, newForkID(config, genesis, head), filter)
+				results <- &handshakeResult{err: err, head: p.head, td: p.td}
+				if err == nil {
+					// the remote may reject the status after sending its own
+					_, err = rw.ReadMsg()
+					results <- &handshakeResult{err: err}
+				}
+				return err
+			},
+		}},
+	}}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("could not start server: %v", err)
+	}
+	return srv
+}
+
+func connectHandshakeServers(t *testing.T, from, to *p2p.Server) {
+	fd, err := net.Dial("tcp", to.ListenAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dest := &discover.Node{ID: to.Self().ID, IP: net.ParseIP("127.0.0.1"), TCP: uint16(fd.RemoteAddr().(*net.TCPAddr).Port)}
+	if err := from.SetupConn(fd, 0, dest); err != nil {
+		t.Fatalf("connection failed: %v", err)
+	}
+}
+
+func waitHandshake(t *testing.T, results <-chan *handshakeResult) *handshakeResult {
+	select {
+	case result := <-results:
+		return result
+	case <-time.After(2 * handshakeTimeout):
+		t.Fatal("no handshake")
+	}
+	return nil
+}
+
+func TestHandshakeStatus(t *testing.T) {
+	var (
+		genesis  = utils.Hash{1}
+		config   = &params.ChainConfig{BaseFeeHeight: big.NewInt(100)}
+		results0 = make(chan *handshakeResult, 2)
+		results1 = make(chan *handshakeResult, 2)
+	)
+	srv0 := startHandshakeServer(t, config, genesis, 50, results0)
+	defer srv0.Stop()
+	srv1 := startHandshakeServer(t, config, genesis, 150, results1)
+	defer srv1.Stop()
+	connectHandshakeServers(t, srv0, srv1)
+
+	// the status is decoded from the compressed messages on both sides
+	for _, test := range []struct {
+		result *handshakeResult
+		head   uint64
+	}{
+		{waitHandshake(t, results0), 150},
+		{waitHandshake(t, results1), 50},
+	} {
+		if test.result.err != nil {
+			t.Fatalf("handshake failed: %v", test.result.err)
+		}
+		if test.result.head != (utils.Hash{byte(test.head)}) || test.result.td.Uint64() != test.head {
+			t.Fatalf("remote head %x td %v, want the status of #%d", test.result.head, test.result.td, test.head)
+		}
+	}
+}
+
+func TestHandshakeIncompatibleFork(t *testing.T) {
+	var (
+		genesis  = utils.Hash{1}
+		results0 = make(chan *handshakeResult, 2)
+		results1 = make(chan *handshakeResult, 2)
+	)
+	// the second node passed the announced fork of the first one without knowing it,
+	// only the second node rejects the status
+	srv0 := startHandshakeServer(t, &params.ChainConfig{BaseFeeHeight: big.NewInt(100)}, genesis, 50, results0)
+	defer srv0.Stop()
+	srv1 := startHandshakeServer(t, &params.ChainConfig{}, genesis, 150, results1)
+	defer srv1.Stop()
+	connectHandshakeServers(t, srv0, srv1)
+
+	// the rejecting side returns the reason, it's sent to the remote before closing
+	if result := waitHandshake(t, results1); result.err != p2p.QuitIncompatibleFork {
+		t.Fatalf("handshake error %v, want %v", result.err, p2p.QuitIncompatibleFork)
+	}
+	result := waitHandshake(t, results0)
+	if result.err == nil {
+		result = waitHandshake(t, results0)
+	}
+	if result.err != p2p.QuitIncompatibleFork {
+		t.Fatalf("handshake error %v, want %v", result.err, p2p.QuitIncompatibleFork)
+	}
+}
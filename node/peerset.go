@@ -119,6 +119,19 @@ func (ps *peerSet) PeersWithoutConfirmed(hash utils.Hash) []*peer {
 	return list
 }
 
+// splitPriority separates the sentries of this validator or the validators behind this
+// sentry, the blocks, transactions and confirmations are relayed to them first and in full.
+func splitPriority(peers []*peer) (priority, rest []*peer) {
+	for _, p := range peers {
+		if p.Priority() {
+			priority = append(priority, p)
+		} else {
+			rest = append(rest, p)
+		}
+	}
+	return priority, rest
+}
+
 func (ps *peerSet) BestPeer() *peer {
 	ps.RLock()
 	defer ps.RUnlock()
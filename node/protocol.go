@@ -43,7 +43,17 @@ import (
 
 var baseProtocolName = "uransus"
 
-var baseProtocolVersion uint = 1
+// Versions of the protocol, uranus2 announces the transactions by hashes to most peers,
+// uranus3 relays compact blocks, uranus4 checks the fork identifier in the status.
+const (
+	uranus1 = 1
+	uranus2 = 2
+	uranus3 = 3
+	uranus4 = 4
+)
+
+// protocolVersions are the supported versions, the highest one supported by a peer is run.
+var protocolVersions = []uint{uranus4, uranus3, uranus2, uranus1}
 
 var maxMsgSize = 10 * 1024 * 1024
 
@@ -58,6 +68,16 @@ const (
 	NewBlockMsg                               //1007
 	GetBlockHashesFromNumberMsg               //1008
 	ConfirmedMsg                              //1009
+
+	// uranus2
+	NewPooledTransactionHashesMsg //1010
+	GetPooledTransactionsMsg      //1011
+	PooledTransactionsMsg         //1012
+
+	// uranus3
+	CompactBlockMsg //1013
+	GetBlockTxsMsg  //1014
+	BlockTxsMsg     //1015
 )
 
 type statusData struct {
@@ -68,6 +88,16 @@ type statusData struct {
 	GenesisBlock    utils.Hash
 }
 
+// forkStatusData is the status of uranus4, it carries the fork identifier of the chain.
+type forkStatusData struct {
+	ProtocolVersion uint32
+	NetworkID       uint64
+	TD              *big.Int
+	CurrentBlock    utils.Hash
+	GenesisBlock    utils.Hash
+	ForkID          forkID
+}
+
 type newBlockData struct {
 	Block *types.Block
 	TD    *big.Int
@@ -95,6 +125,8 @@ type ProtocolManager struct {
 	minedBlockSub *feed.TypeMuxSubscription
 	downloader    *protocols.Downloader
 	fetcher       *protocols.Fetcher
+	txFetcher     *protocols.TxFetcher
+	compacts      *compactBlocks
 	peers         *peerSet
 	SubProtocols  []*p2p.Protocol
 	newPeerCh     chan *peer
@@ -104,6 +136,9 @@ type ProtocolManager struct {
 	wg            sync.WaitGroup
 	eventMux      *feed.TypeMux
 	acceptTxs     uint32
+	scores        *peerScores
+	forkFilter    func(forkID) error
+	srv           *p2p.Server
 }
 
 func NewProtocolManager(mux *feed.TypeMux, config *params.ChainConfig, txpool *txpool.TxPool, blockchain *core.BlockChain, chaindb db.Database, engine consensus.Engine) (*ProtocolManager, error) {
@@ -118,36 +153,41 @@ func NewProtocolManager(mux *feed.TypeMux, config *params.ChainConfig, txpool *t
 		txsyncCh:    make(chan *txsync),
 		quitSync:    make(chan struct{}),
 		acceptTxs:   1,
+		scores:      newPeerScores(),
+		compacts:    newCompactBlocks(),
+	}
+
+	manager.SubProtocols = make([]*p2p.Protocol, 0, len(protocolVersions))
+	for _, version := range protocolVersions {
+		version := version
+		manager.SubProtocols = append(manager.SubProtocols, &p2p.Protocol{
+			Name:    baseProtocolName,
+			Version: version,
+			Offset:  1000,
+			Size:    1000,
+			Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+				peer := manager.newPeer(int(version), p, rw)
+				select {
+				case manager.newPeerCh <- peer:
+					manager.wg.Add(1)
+					defer manager.wg.Done()
+					return manager.handle(peer)
+				case <-manager.quitSync:
+					return fmt.Errorf("quit")
+				}
+			},
+			NodeInfo: func() interface{} {
+				return manager.NodeInfo()
+			},
+			PeerInfo: func(id discover.NodeID) interface{} {
+				if p := manager.peers.Peer(fmt.Sprintf("%x", id[:8])); p != nil {
+					return p.Info()
+				}
+				return nil
+			},
+		})
 	}
 
-	manager.SubProtocols = make([]*p2p.Protocol, 0)
-	manager.SubProtocols = append(manager.SubProtocols, &p2p.Protocol{
-		Name:    baseProtocolName,
-		Version: baseProtocolVersion,
-		Offset:  1000,
-		Size:    1000,
-		Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
-			peer := manager.newPeer(int(baseProtocolVersion), p, rw)
-			select {
-			case manager.newPeerCh <- peer:
-				manager.wg.Add(1)
-				defer manager.wg.Done()
-				return manager.handle(peer)
-			case <-manager.quitSync:
-				return fmt.Errorf("quit")
-			}
-		},
-		NodeInfo: func() interface{} {
-			return manager.NodeInfo()
-		},
-		PeerInfo: func(id discover.NodeID) interface{} {
-			if p := manager.peers.Peer(fmt.Sprintf("%x", id[:8])); p != nil {
-				return p.Info()
-			}
-			return nil
-		},
-	})
-
 	validator := func(header *types.BlockHeader) error {
 		return engine.VerifySeal(blockchain, header)
 	}
@@ -155,13 +195,18 @@ func NewProtocolManager(mux *feed.TypeMux, config *params.ChainConfig, txpool *t
 	heighter := func() uint64 {
 		return blockchain.CurrentBlock().Height().Uint64()
 	}
+	manager.forkFilter = newForkFilter(config, blockchain.GetBlockByHeight(0).Hash(), heighter)
 	inserter := func(blocks types.Blocks) (int, error) {
 		atomic.StoreUint32(&manager.acceptTxs, 1)
 		return manager.blockchain.InsertChain(blocks)
 	}
 
-	manager.downloader = protocols.NewDownloader(manager.eventMux, manager.blockchain.HasBlock, manager.blockchain.GetBlockByHash, manager.blockchain.CurrentBlock, manager.blockchain.GetTd, inserter, manager.removePeer)
-	manager.fetcher = protocols.NewFetcher(manager.blockchain.GetBlockByHash, validator, manager.BroadcastBlock, heighter, inserter, manager.removePeer)
+	manager.downloader = protocols.NewDownloader(manager.eventMux, manager.blockchain.HasBlock, manager.blockchain.GetBlockByHash, manager.blockchain.CurrentBlock, manager.blockchain.GetTd, inserter, manager.dropPeer)
+	manager.fetcher = protocols.NewFetcher(manager.blockchain.GetBlockByHash, validator, manager.BroadcastBlock, heighter, inserter, manager.dropPeer)
+	hasTx := func(hash utils.Hash) bool {
+		return txpool.Get(hash) != nil
+	}
+	manager.txFetcher = protocols.NewTxFetcher(hasTx, manager.penalizePeer)
 	return manager, nil
 }
 
@@ -172,6 +217,7 @@ func (pm *ProtocolManager) removePeer(id string) {
 	}
 	log.Debugf("Removing uranus peer %v", id)
 	pm.downloader.UnregisterPeer(id)
+	pm.txFetcher.Drop(id)
 	if err := pm.peers.Unregister(id); err != nil {
 		log.Errorf("Peer removal %v failed --- %v", id, err)
 	}
@@ -180,12 +226,48 @@ func (pm *ProtocolManager) removePeer(id string) {
 	}
 }
 
-func (pm *ProtocolManager) Start(maxPeers int) {
-	pm.maxPeers = maxPeers
+// dropPeer penalizes the misbehaving peer and disconnects it.
+func (pm *ProtocolManager) dropPeer(id string, penalty int) {
+	if peer := pm.peers.Peer(id); peer != nil {
+		pm.penalize(peer, penalty)
+	}
+	pm.removePeer(id)
+}
+
+// penalizePeer penalizes the peer without disconnecting it.
+func (pm *ProtocolManager) penalizePeer(id string, penalty int) {
+	if peer := pm.peers.Peer(id); peer != nil {
+		pm.penalize(peer, penalty)
+	}
+}
+
+// penalize lowers the score of the peer and bans it once the score drops to banScore,
+// trusted peers are never banned.
+func (pm *ProtocolManager) penalize(p *peer, penalty int) {
+	if p.Trusted() {
+		return
+	}
+	score := pm.scores.penalize(p.ID(), penalty)
+	log.Debugf("Penalized uranus peer %v by %v, score %v", p.id, penalty, score)
+	if score > banScore || pm.srv == nil {
+		return
+	}
+	log.Warnf("Banning uranus peer %v for %v, score %v", p.id, banDuration, score)
+	if err := pm.srv.BanPeer(p.ID(), banDuration); err != nil {
+		log.Errorf("Banning peer %v failed --- %v", p.id, err)
+		return
+	}
+	pm.scores.forget(p.ID())
+}
+
+func (pm *ProtocolManager) Start(srv *p2p.Server) {
+	pm.srv = srv
+	pm.maxPeers = srv.MaxPeers
 
 	pm.txsCh = make(chan feed.NewTxsEvent, txChanSize)
 	pm.txsSub = pm.txpool.SubscribeNewTxsEvent(pm.txsCh)
 	go pm.txBroadcastLoop()
+	pm.txFetcher.Start()
 
 	pm.minedBlockSub = pm.eventMux.Subscribe(feed.NewMiner{}, feed.NewMinedBlockEvent{}, feed.NewConfirmedEvent{})
 	go pm.minedBroadcastLoop()
@@ -201,6 +283,7 @@ func (pm *ProtocolManager) Stop() {
 	pm.minedBlockSub.Unsubscribe()
 
 	pm.noMorePeers <- struct{}{}
+	pm.txFetcher.Stop()
 
 	close(pm.quitSync)
 
@@ -216,7 +299,7 @@ func (pm *ProtocolManager) newPeer(pv int, p *p2p.Peer, rw p2p.MsgReadWriter) *p
 }
 
 func (pm *ProtocolManager) handle(p *peer) error {
-	if pm.peers.Len() >= pm.maxPeers {
+	if pm.peers.Len() >= pm.maxPeers && !p.Trusted() {
 		return fmt.Errorf("too many peer")
 	}
 	log.Debugf("uranus peer connected name %v", p.Name())
@@ -226,8 +309,9 @@ func (pm *ProtocolManager) handle(p *peer) error {
 		head    = pm.blockchain.CurrentBlock().BlockHeader()
 		hash    = head.Hash()
 		td      = pm.blockchain.GetTd(hash)
+		forkID  = newForkID(pm.chainconfig, genesis.Hash(), head.Height.Uint64())
 	)
-	if err := p.Handshake(pm.networkId, td, hash, genesis.Hash()); err != nil {
+	if err := p.Handshake(pm.networkId, td, hash, genesis.Hash(), forkID, pm.forkFilter); err != nil {
 		log.Errorf("uranus handshake failed --- %v", err)
 		return err
 	}
@@ -246,11 +330,23 @@ func (pm *ProtocolManager) handle(p *peer) error {
 	for {
 		if err := pm.handleMsg(p); err != nil {
 			log.Warnf("uranus message handling failed --- %v", err)
+			if _, ok := err.(*violationError); ok {
+				pm.penalize(p, penaltyProtocol)
+			}
 			return err
 		}
 	}
 }
 
+// violationError is a malformed or unexpected message of the peer.
+type violationError struct {
+	error
+}
+
+func errViolation(format string, v ...interface{}) error {
+	return &violationError{fmt.Errorf(format, v...)}
+}
+
 func (pm *ProtocolManager) handleMsg(p *peer) error {
 	msg, err := p.rw.ReadMsg()
 	if err != nil {
@@ -259,12 +355,12 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 
 	switch msg.Code {
 	case StatusMsg:
-		return fmt.Errorf("uncontrolled status message")
+		return errViolation("uncontrolled status message")
 
 	case GetBlockHashesMsg:
 		var request getBlockHashesData
 		if err := msg.DecodePayload(&request); err != nil {
-			return fmt.Errorf("%v: %v", msg, err)
+			return errViolation("%v: %v", msg, err)
 		}
 		if request.Amount > uint64(protocols.MaxHashFetch) {
 			request.Amount = uint64(protocols.MaxHashFetch)
@@ -296,7 +392,7 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 	case GetBlockHashesFromNumberMsg:
 		var request getBlockHashesFromNumberData
 		if err := msg.DecodePayload(&request); err != nil {
-			return fmt.Errorf("%v: %v", msg, err)
+			return errViolation("%v: %v", msg, err)
 		}
 		if request.Amount > uint64(protocols.MaxHashFetch) {
 			request.Amount = uint64(protocols.MaxHashFetch)
@@ -347,7 +443,7 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		buf := bytes.NewBuffer(msg.Payload)
 		msgStream := rlp.NewStream(buf, uint64(buf.Len()))
 		if _, err := msgStream.List(); err != nil {
-			return err
+			return errViolation("msg %v: %v", msg, err)
 		}
 		var (
 			hash   utils.Hash
@@ -360,7 +456,7 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			if err == rlp.EOL {
 				break
 			} else if err != nil {
-				return fmt.Errorf("msg %v: %v", msg, err)
+				return errViolation("msg %v: %v", msg, err)
 			}
 			hashes = append(hashes, hash)
 
@@ -386,8 +482,9 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		for _, block := range blocks {
 			block.ReceivedAt = msg.ReceivedAt
 		}
-		if blocks := pm.fetcher.Filter(blocks); len(blocks) > 0 {
-			pm.downloader.DeliverBlocks(p.id, blocks)
+		// an empty reply is delivered too, the downloader frees the request of the peer
+		if filtered := pm.fetcher.Filter(blocks); len(filtered) > 0 || len(blocks) == 0 {
+			pm.downloader.DeliverBlocks(p.id, filtered)
 		}
 
 	case NewBlockHashesMsg:
@@ -415,34 +512,41 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 	case NewBlockMsg:
 		var request newBlockData
 		if err := msg.DecodePayload(&request); err != nil {
-			return fmt.Errorf("%v: %v", msg, err)
+			return errViolation("%v: %v", msg, err)
 		}
 
 		request.Block.ReceivedAt = msg.ReceivedAt
+		pm.handleNewBlock(p, request.Block, request.TD)
 
-		p.MarkBlock(request.Block.Hash())
-		p.head = request.Block.Hash()
+	case CompactBlockMsg:
+		if p.version < uranus3 {
+			return errViolation("invalide message %v", msg.Code)
+		}
+		var request compactBlockData
+		if err := msg.DecodePayload(&request); err != nil {
+			return errViolation("%v: %v", msg, err)
+		}
+		return pm.handleCompactBlock(p, &request)
 
-		pm.fetcher.Enqueue(p.id, request.Block)
+	case GetBlockTxsMsg:
+		if p.version < uranus3 {
+			return errViolation("invalide message %v", msg.Code)
+		}
+		var request getBlockTxsData
+		if err := msg.DecodePayload(&request); err != nil {
+			return errViolation("%v: %v", msg, err)
+		}
+		return pm.serveBlockTxs(p, &request)
 
-		// Assuming the block is importable by the peer, but possibly not yet done so,
-		// calculate the head hash and TD that the peer truly must have.
-		var (
-			trueHead = request.Block.PreviousHash()
-			trueTD   = new(big.Int).Sub(request.TD, request.Block.Difficulty())
-		)
-		// Update the peers total difficulty if better than the previous
-		if _, td := p.Head(); trueTD.Cmp(td) > 0 {
-			p.SetHead(trueHead, trueTD)
-
-			// Schedule a sync if above ours. Note, this will not fire a sync for a gap of
-			// a singe block (as the true TD is below the propagated block), however this
-			// scenario should easily be covered by the fetcher.
-			currentBlock := pm.blockchain.CurrentBlock()
-			if trueTD.Cmp(pm.blockchain.GetTd(currentBlock.Hash())) > 0 {
-				go pm.synchronise(p)
-			}
+	case BlockTxsMsg:
+		if p.version < uranus3 {
+			return errViolation("invalide message %v", msg.Code)
+		}
+		var request blockTxsData
+		if err := msg.DecodePayload(&request); err != nil {
+			return errViolation("%v: %v", msg, err)
 		}
+		return pm.handleBlockTxs(p, &request)
 
 	case TxMsg:
 		if atomic.LoadUint32(&pm.acceptTxs) == 0 {
@@ -450,30 +554,133 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		}
 		var txs []*types.Transaction
 		if err := msg.DecodePayload(&txs); err != nil {
-			return fmt.Errorf("msg %v: %v", msg, err)
+			return errViolation("msg %v: %v", msg, err)
+		}
+		return pm.handleTxs(p, txs, true)
+
+	case NewPooledTransactionHashesMsg:
+		if p.version < uranus2 {
+			return errViolation("invalide message %v", msg.Code)
+		}
+		if atomic.LoadUint32(&pm.acceptTxs) == 0 {
+			break
+		}
+		var hashes []utils.Hash
+		if err := msg.DecodePayload(&hashes); err != nil {
+			return errViolation("msg %v: %v", msg, err)
 		}
-		for i, tx := range txs {
-			if tx == nil {
-				return fmt.Errorf("transaction %d is nil", i)
+		for _, hash := range hashes {
+			p.MarkTransaction(hash)
+		}
+		pm.txFetcher.Notify(p.id, hashes, p.RequestTxs)
+
+	case GetPooledTransactionsMsg:
+		if p.version < uranus2 {
+			return errViolation("invalide message %v", msg.Code)
+		}
+		var hashes []utils.Hash
+		if err := msg.DecodePayload(&hashes); err != nil {
+			return errViolation("msg %v: %v", msg, err)
+		}
+		if len(hashes) > protocols.MaxTxRetrievals {
+			hashes = hashes[:protocols.MaxTxRetrievals]
+		}
+		var (
+			size utils.StorageSize
+			txs  []*types.Transaction
+		)
+		for _, hash := range hashes {
+			if tx := pm.txpool.Get(hash); tx != nil {
+				txs = append(txs, tx)
+				if size += tx.Size(); size >= txsyncPackSize {
+					break
+				}
 			}
-			p.MarkTransaction(tx.Hash())
 		}
-		pm.txpool.AddTxs(txs)
+		return p.SendPooledTransactions(txs)
+
+	case PooledTransactionsMsg:
+		if p.version < uranus2 {
+			return errViolation("invalide message %v", msg.Code)
+		}
+		var txs []*types.Transaction
+		if err := msg.DecodePayload(&txs); err != nil {
+			return errViolation("msg %v: %v", msg, err)
+		}
+		return pm.handleTxs(p, txs, false)
 	case ConfirmedMsg:
 		confirmed := types.Confirmed{}
 		if err := msg.DecodePayload(&confirmed); err != nil {
-			return fmt.Errorf("msg %v: %v", msg, err)
+			return errViolation("msg %v: %v", msg, err)
 		}
 		pm.eventMux.Post(confirmed)
 		pm.BroadcastConfirmed(&confirmed)
 	default:
-		return fmt.Errorf("invalide message %v", msg.Code)
+		return errViolation("invalide message %v", msg.Code)
+	}
+	return nil
+}
+
+// handleNewBlock queues the block propagated by the peer for import.
+func (pm *ProtocolManager) handleNewBlock(p *peer, block *types.Block, td *big.Int) {
+	p.MarkBlock(block.Hash())
+	p.head = block.Hash()
+
+	pm.fetcher.Enqueue(p.id, block)
+
+	// Assuming the block is importable by the peer, but possibly not yet done so,
+	// calculate the head hash and TD that the peer truly must have.
+	var (
+		trueHead = block.PreviousHash()
+		trueTD   = new(big.Int).Sub(td, block.Difficulty())
+	)
+	// Update the peers total difficulty if better than the previous
+	if _, td := p.Head(); trueTD.Cmp(td) > 0 {
+		p.SetHead(trueHead, block.Height().Uint64()-1, trueTD)
+
+		// Schedule a sync if above ours. Note, this will not fire a sync for a gap of
+		// a singe block (as the true TD is below the propagated block), however this
+		// scenario should easily be covered by the fetcher.
+		currentBlock := pm.blockchain.CurrentBlock()
+		if trueTD.Cmp(pm.blockchain.GetTd(currentBlock.Hash())) > 0 {
+			go pm.synchronise(p)
+		}
+	}
+}
+
+// handleTxs imports the transactions broadcast by the peer, or delivered on its request.
+func (pm *ProtocolManager) handleTxs(p *peer, txs []*types.Transaction, direct bool) error {
+	hashes := make([]utils.Hash, 0, len(txs))
+	for i, tx := range txs {
+		if tx == nil {
+			return errViolation("transaction %d is nil", i)
+		}
+		p.MarkTransaction(tx.Hash())
+		hashes = append(hashes, tx.Hash())
+	}
+	pm.txFetcher.Deliver(p.id, hashes, direct)
+	for _, err := range pm.txpool.AddTxs(txs) {
+		if isInvalidTx(err) {
+			pm.penalize(p, penaltyBadTx)
+			break
+		}
 	}
 	return nil
 }
 
+// isInvalidTx reports whether the transaction is rejected regardless of the state,
+// e.g. not because of its nonce or price.
+func isInvalidTx(err error) bool {
+	switch err {
+	case txpool.ErrInvalidSender, txpool.ErrNegativeValue, txpool.ErrOversizedData, txpool.ErrIntrinsicGas:
+		return true
+	}
+	return false
+}
+
 func (pm *ProtocolManager) BroadcastConfirmed(confirmed *types.Confirmed) {
-	for _, peer := range pm.peers.PeersWithoutConfirmed(confirmed.Hash()) {
+	priority, peers := splitPriority(pm.peers.PeersWithoutConfirmed(confirmed.Hash()))
+	for _, peer := range append(priority, peers...) {
 		peer.SendConfirmed(confirmed)
 	}
 }
@@ -490,7 +697,24 @@ func (pm *ProtocolManager) BroadcastBlock(block *types.Block, propagate bool) {
 			log.Errorf("Propagating dangling block height %v hash %v", block.Height(), hash)
 			return
 		}
-		transfer := peers[:int(math.Sqrt(float64(len(peers))))]
+		// the priority peers get the full block first, the peers of uranus3 get the
+		// compact block, a square root of the others the full one
+		priority, peers := splitPriority(peers)
+		for _, peer := range priority {
+			peer.SendNewBlock(block, td)
+		}
+		var legacy []*peer
+		for _, peer := range peers {
+			if peer.version < uranus3 {
+				legacy = append(legacy, peer)
+				continue
+			}
+			peer.SendCompactBlock(newCompactBlock(block, td, peer.KnownTransaction))
+		}
+		if len(legacy) < len(peers) {
+			pm.compacts.remember(block)
+		}
+		transfer := legacy[:int(math.Sqrt(float64(len(legacy))))]
 		for _, peer := range transfer {
 			peer.SendNewBlock(block, td)
 		}
@@ -503,19 +727,39 @@ func (pm *ProtocolManager) BroadcastBlock(block *types.Block, propagate bool) {
 	}
 }
 
+// BroadcastTxs sends the transactions to a square root of the peers which don't have them
+// and announces their hashes to the rest, the peers of uranus1 and the priority peers get
+// all of them.
 func (pm *ProtocolManager) BroadcastTxs(txs types.Transactions) {
-	var txset = make(map[*peer]types.Transactions)
-
+	var (
+		prioset = make(map[*peer]types.Transactions)
+		txset   = make(map[*peer]types.Transactions)
+		hashset = make(map[*peer][]utils.Hash)
+	)
 	for _, tx := range txs {
-		peers := pm.peers.PeersWithoutTx(tx.Hash())
-		for _, peer := range peers {
-			txset[peer] = append(txset[peer], tx)
+		priority, peers := splitPriority(pm.peers.PeersWithoutTx(tx.Hash()))
+		for _, peer := range priority {
+			prioset[peer] = append(prioset[peer], tx)
+		}
+		direct := int(math.Sqrt(float64(len(peers))))
+		for i, peer := range peers {
+			if i < direct || peer.version < uranus2 {
+				txset[peer] = append(txset[peer], tx)
+			} else {
+				hashset[peer] = append(hashset[peer], tx.Hash())
+			}
 		}
-		log.Infof("Broadcast transaction hash %v recipients", tx.Hash(), len(peers))
+		log.Infof("Broadcast transaction hash %v recipients %v announced %v", tx.Hash(), len(priority)+direct, len(peers)-direct)
+	}
+	for peer, txs := range prioset {
+		peer.SendTransactions(txs)
 	}
 	for peer, txs := range txset {
 		peer.SendTransactions(txs)
 	}
+	for peer, hashes := range hashset {
+		peer.AnnounceTransactions(hashes)
+	}
 }
 
 func (pm *ProtocolManager) minedBroadcastLoop() {
@@ -559,6 +803,11 @@ func (pm *ProtocolManager) NodeInfo() *NodeInfo {
 	}
 }
 
+// Syncing returns the progress of the block synchronisation and whether it is running.
+func (pm *ProtocolManager) Syncing() (protocols.SyncProgress, bool) {
+	return pm.downloader.Progress(), pm.downloader.Synchronising()
+}
+
 type txsync struct {
 	p   *peer
 	txs []*types.Transaction
@@ -601,7 +850,15 @@ func (pm *ProtocolManager) txsyncLoop() {
 		}
 		log.Infof("Sending batch of transactions count %v", len(pack.txs))
 		sending = true
-		go func() { done <- pack.p.SendTransactions(pack.txs) }()
+		if pack.p.version < uranus2 {
+			go func() { done <- pack.p.SendTransactions(pack.txs) }()
+			return
+		}
+		hashes := make([]utils.Hash, len(pack.txs))
+		for i, tx := range pack.txs {
+			hashes[i] = tx.Hash()
+		}
+		go func() { done <- pack.p.AnnounceTransactions(hashes) }()
 	}
 
 	pick := func() *txsync {
@@ -675,7 +932,7 @@ func (pm *ProtocolManager) synchronise(peer *peer) {
 		return
 	}
 
-	if err := pm.downloader.Synchronise(peer.id, peer.head, peer.td); err != nil {
+	if err := pm.downloader.Synchronise(peer.id, peer.head, peer.Height(), peer.td); err != nil {
 		return
 	}
 	atomic.StoreUint32(&pm.acceptTxs, 1)
@@ -46,6 +46,13 @@ var (
 	maxBlockProcess = 256
 )
 
+// Penalties reported with a dropped peer, they are subtracted from its reputation score.
+const (
+	PenaltyTimeout      = 20 // the peer didn't deliver the requested data in time
+	PenaltyBadPeer      = 50 // the peer delivered an invalid hash chain
+	PenaltyInvalidBlock = 35 // the peer delivered a block failing the verification or import, banned on the third
+)
+
 var (
 	errBusy             = errors.New("busy")
 	errUnknownPeer      = errors.New("peer is unknown or unhealthy")
@@ -69,7 +76,7 @@ type hashCheckFn func(utils.Hash) bool
 type blockRetrievalFn func(utils.Hash) *types.Block
 type headRetrievalFn func() *types.Block
 type chainInsertFn func(types.Blocks) (int, error)
-type peerDropFn func(id string)
+type peerDropFn func(id string, penalty int)
 type getTdFn func(utils.Hash) *big.Int
 
 type blockPack struct {
@@ -113,6 +120,11 @@ type Downloader struct {
 	processing    int32
 	notified      int32
 
+	syncStatsOrigin  uint64 // height of the local head when the sync started
+	syncStatsHighest uint64 // highest height known from the synced peer
+	syncStatsStart   time.Time
+	syncStatsLock    sync.RWMutex
+
 	newPeerCh chan *peer
 	hashCh    chan hashPack
 	blockCh   chan blockPack
@@ -166,6 +178,52 @@ func (d *Downloader) Stats() (pending int, cached int, importing int, estimate t
 	return
 }
 
+// SyncProgress is the state of the block synchronisation.
+type SyncProgress struct {
+	StartingBlock   uint64        // height of the local head when the sync started
+	CurrentBlock    uint64        // height of the local head
+	HighestBlock    uint64        // highest height known from the synced peer
+	PendingBlocks   uint64        // blocks scheduled or downloaded but not imported yet
+	ProcessedBlocks uint64        // blocks imported since the sync started
+	Elapsed         time.Duration // time since the sync started
+}
+
+// Progress returns the state of the current synchronisation, or of the last one once it is
+// over.
+func (d *Downloader) Progress() SyncProgress {
+	pending, cached, importing, _ := d.Stats()
+	current := d.headBlock().Height().Uint64()
+
+	d.syncStatsLock.RLock()
+	defer d.syncStatsLock.RUnlock()
+
+	progress := SyncProgress{
+		StartingBlock: d.syncStatsOrigin,
+		CurrentBlock:  current,
+		HighestBlock:  d.syncStatsHighest,
+		PendingBlocks: uint64(pending + cached + importing),
+	}
+	if current > d.syncStatsOrigin {
+		progress.ProcessedBlocks = current - d.syncStatsOrigin
+	}
+	if progress.HighestBlock < current {
+		progress.HighestBlock = current
+	}
+	if !d.syncStatsStart.IsZero() {
+		progress.Elapsed = time.Since(d.syncStatsStart)
+	}
+	return progress
+}
+
+// raiseHighest records a height known from the synced peer.
+func (d *Downloader) raiseHighest(height uint64) {
+	d.syncStatsLock.Lock()
+	if height > d.syncStatsHighest {
+		d.syncStatsHighest = height
+	}
+	d.syncStatsLock.Unlock()
+}
+
 func (d *Downloader) Synchronising() bool {
 	return atomic.LoadInt32(&d.synchronising) > 0
 }
@@ -192,10 +250,12 @@ func (d *Downloader) UnregisterPeer(id string) error {
 	return nil
 }
 
-func (d *Downloader) Synchronise(id string, head utils.Hash, td *big.Int) error {
-	log.Infof("Attempting synchronisation: %v, head 0x%x, TD %v", id, head[:4], td)
+// Synchronise downloads the chain of the peer, height is the announced height of its head,
+// 0 if it's unknown.
+func (d *Downloader) Synchronise(id string, head utils.Hash, height uint64, td *big.Int) error {
+	log.Infof("Attempting synchronisation: %v, head 0x%x, height %v, TD %v", id, head[:4], height, td)
 
-	err := d.synchronise(id, head, td)
+	err := d.synchronise(id, head, height, td)
 	switch err {
 	case nil:
 		log.Infof("Synchronisation completed")
@@ -203,9 +263,13 @@ func (d *Downloader) Synchronise(id string, head utils.Hash, td *big.Int) error
 	case errBusy:
 		log.Debugf("Synchronisation already in progress")
 
-	case errTimeout, errBadPeer, errStallingPeer, errBannedHead, errEmptyHashSet, errPeersUnavailable, errInvalidChain, errCrossCheckFailed:
+	case errTimeout, errStallingPeer, errEmptyHashSet, errPeersUnavailable:
 		log.Errorf("Removing peer %v: %v", id, err)
-		d.dropPeer(id)
+		d.dropPeer(id, PenaltyTimeout)
+
+	case errBadPeer, errBannedHead, errInvalidChain, errCrossCheckFailed:
+		log.Errorf("Removing peer %v: %v", id, err)
+		d.dropPeer(id, PenaltyBadPeer)
 
 	case errPendingQueue:
 		log.Errorf("Synchronisation aborted: %v", err)
@@ -216,7 +280,7 @@ func (d *Downloader) Synchronise(id string, head utils.Hash, td *big.Int) error
 	return err
 }
 
-func (d *Downloader) synchronise(id string, hash utils.Hash, td *big.Int) error {
+func (d *Downloader) synchronise(id string, hash utils.Hash, height uint64, td *big.Int) error {
 	if !atomic.CompareAndSwapInt32(&d.synchronising, 0, 1) {
 		return errBusy
 	}
@@ -243,22 +307,32 @@ func (d *Downloader) synchronise(id string, hash utils.Hash, td *big.Int) error
 	if p == nil {
 		return errUnknownPeer
 	}
-	return d.syncWithPeer(p, hash, td)
+	return d.syncWithPeer(p, hash, height, td)
 }
 
 func (d *Downloader) Has(hash utils.Hash) bool {
 	return d.queue.Has(hash)
 }
 
-func (d *Downloader) syncWithPeer(p *peer, hash utils.Hash, td *big.Int) (err error) {
-	d.mux.Post(StartEvent{})
+func (d *Downloader) syncWithPeer(p *peer, hash utils.Hash, height uint64, td *big.Int) (err error) {
+	// the highest height is the announced one if known, it's raised as the hashes arrive
+	origin := d.headBlock().Height().Uint64()
+	highest := origin
+	if height > highest {
+		highest = height
+	}
+	d.syncStatsLock.Lock()
+	d.syncStatsOrigin, d.syncStatsHighest, d.syncStatsStart = origin, highest, time.Now()
+	d.syncStatsLock.Unlock()
+
+	d.mux.Post(feed.SyncStartEvent{Origin: origin, Highest: highest})
 	defer func() {
 		if err != nil {
 			log.Errorf("downloading canceled: findAncestor %v", err)
 			d.cancel()
-			d.mux.Post(FailedEvent{err})
+			d.mux.Post(feed.SyncFailedEvent{Err: err})
 		} else {
-			d.mux.Post(DoneEvent{})
+			d.mux.Post(feed.SyncDoneEvent{Head: d.headBlock().Height().Uint64()})
 		}
 	}()
 
@@ -441,6 +515,8 @@ func (d *Downloader) fetchHashes(p *peer, td *big.Int, from uint64) error {
 				log.Errorf("%v: stale hashes", p.id)
 				return errBadPeer
 			}
+			d.raiseHighest(from + uint64(len(hashPack.hashes)) - 1)
+
 			cont := d.queue.Pending() < maxQueuedHashes
 			select {
 			case d.processCh <- cont:
@@ -678,7 +754,7 @@ func (d *Downloader) process() {
 			}
 			index, err := d.insertChain(raw)
 			if err != nil {
-				d.dropPeer(blocks[index].OriginPeer)
+				d.dropPeer(blocks[index].OriginPeer, PenaltyInvalidBlock)
 				log.Errorf("downloading canceled: insertChain %v %v %v", raw[0].Height(), raw[0].Hash().String(), err)
 				d.cancel()
 				return
@@ -722,10 +798,6 @@ func (d *Downloader) DeliverHashes(id string, hashes []utils.Hash) error {
 	}
 }
 
-type DoneEvent struct{}
-type StartEvent struct{}
-type FailedEvent struct{ Err error }
-
 var (
 	errNoFetchesPending = errors.New("no fetches pending")
 	errStaleDelivery    = errors.New("stale delivery")
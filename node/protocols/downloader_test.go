@@ -0,0 +1,148 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package protocols
+
+import (
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/core/types"
+	"github.com/UranusBlockStack/uranus/feed"
+)
+
+// newProgressDownloader returns a downloader whose local head is at the height.
+func newProgressDownloader(mux *feed.TypeMux, height *uint64) *Downloader {
+	headBlock := func() *types.Block {
+		header := &types.BlockHeader{Height: new(big.Int).SetUint64(atomic.LoadUint64(height))}
+		return types.NewBlockWithBlockHeader(header)
+	}
+	return NewDownloader(mux,
+		func(utils.Hash) bool { return false },
+		func(utils.Hash) *types.Block { return nil },
+		headBlock,
+		func(utils.Hash) *big.Int { return big.NewInt(1) },
+		func(blocks types.Blocks) (int, error) { return len(blocks), nil },
+		func(string, int) {},
+	)
+}
+
+func TestDownloaderProgress(t *testing.T) {
+	height := uint64(10)
+	d := newProgressDownloader(new(feed.TypeMux), &height)
+
+	if progress := d.Progress(); progress.CurrentBlock != 10 || progress.HighestBlock != 10 || progress.Elapsed != 0 {
+		t.Fatalf("progress before the sync %+v", progress)
+	}
+
+	d.syncStatsOrigin, d.syncStatsHighest, d.syncStatsStart = 10, 50, time.Now().Add(-time.Minute)
+	atomic.StoreUint64(&height, 30)
+	progress := d.Progress()
+	if progress.StartingBlock != 10 || progress.CurrentBlock != 30 || progress.HighestBlock != 50 || progress.ProcessedBlocks != 20 {
+		t.Fatalf("progress %+v, want from #10 at #30 of #50 with 20 processed", progress)
+	}
+	if progress.Elapsed < time.Minute {
+		t.Fatalf("elapsed %v, want at least a minute", progress.Elapsed)
+	}
+
+	// the highest height known is raised by the hashes, the local head is never above it
+	d.raiseHighest(40)
+	d.raiseHighest(60)
+	if progress := d.Progress(); progress.HighestBlock != 60 {
+		t.Fatalf("highest %v, want 60", progress.HighestBlock)
+	}
+	atomic.StoreUint64(&height, 70)
+	if progress := d.Progress(); progress.HighestBlock != 70 || progress.ProcessedBlocks != 60 {
+		t.Fatalf("progress %+v, want at #70 with 60 processed", progress)
+	}
+}
+
+func TestDownloaderSyncEvents(t *testing.T) {
+	var (
+		mux    = new(feed.TypeMux)
+		height = uint64(10)
+		d      = newProgressDownloader(mux, &height)
+	)
+	sub := mux.Subscribe(feed.SyncStartEvent{}, feed.SyncDoneEvent{}, feed.SyncFailedEvent{})
+	defer sub.Unsubscribe()
+
+	// the peer replies no hashes, the sync fails after it started
+	getAbsHashes := func(uint64, int) error {
+		go d.DeliverHashes("peer", nil)
+		return nil
+	}
+	if err := d.RegisterPeer("peer", 1, utils.Hash{1}, nil, getAbsHashes, nil); err != nil {
+		t.Fatal(err)
+	}
+	errc := make(chan error, 1)
+	go func() { errc <- d.synchronise("peer", utils.Hash{1}, 25, big.NewInt(100)) }()
+
+	events := sub.Chan()
+	select {
+	case ev := <-events:
+		start, ok := ev.Data.(feed.SyncStartEvent)
+		if !ok || start.Origin != 10 || start.Highest != 25 {
+			t.Fatalf("event %#v, want the start from #10 to the announced #25", ev.Data)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no start event")
+	}
+	select {
+	case ev := <-events:
+		failed, ok := ev.Data.(feed.SyncFailedEvent)
+		if !ok || failed.Err != errEmptyHashSet {
+			t.Fatalf("event %#v, want the failure", ev.Data)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no failed event")
+	}
+	if err := <-errc; err != errEmptyHashSet {
+		t.Fatalf("sync error %v, want %v", err, errEmptyHashSet)
+	}
+	if progress := d.Progress(); progress.StartingBlock != 10 || progress.HighestBlock != 25 {
+		t.Fatalf("progress %+v, want from #10 to #25", progress)
+	}
+}
+
+func TestDownloaderUnknownHeight(t *testing.T) {
+	var (
+		mux    = new(feed.TypeMux)
+		height = uint64(10)
+		d      = newProgressDownloader(mux, &height)
+	)
+	sub := mux.Subscribe(feed.SyncStartEvent{})
+	defer sub.Unsubscribe()
+
+	getAbsHashes := func(uint64, int) error {
+		go d.DeliverHashes("peer", nil)
+		return nil
+	}
+	d.RegisterPeer("peer", 1, utils.Hash{1}, nil, getAbsHashes, nil)
+	go d.synchronise("peer", utils.Hash{1}, 0, big.NewInt(100))
+
+	// the height isn't guessed from the total difficulty
+	select {
+	case ev := <-sub.Chan():
+		if start := ev.Data.(feed.SyncStartEvent); start.Highest != 10 {
+			t.Fatalf("highest %v, want the local head", start.Highest)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no start event")
+	}
+}
@@ -330,7 +330,7 @@ func (f *Fetcher) insert(peer string, block *types.Block) {
 
 		default:
 			log.Infof("Peer %s: block #%d [%x] verification failed: %v", peer, block.Height().Uint64(), hash[:4], err)
-			f.dropPeer(peer)
+			f.dropPeer(peer, PenaltyInvalidBlock)
 			return
 		}
 		if _, err := f.insertChain(types.Blocks{block}); err != nil {
@@ -0,0 +1,348 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package protocols
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/UranusBlockStack/uranus/common/log"
+	"github.com/UranusBlockStack/uranus/common/utils"
+)
+
+const (
+	txArriveTimeout = 500 * time.Millisecond // time for the broadcast of an announced tx before fetching it
+	txGatherSlack   = 100 * time.Millisecond
+	txFetchTimeout  = 5 * time.Second
+	maxTxAnnounces  = 4096 // max announced hashes waiting per peer
+
+	// MaxTxRetrievals is the max count of txs requested from a peer at once,
+	// a single request is in flight per peer.
+	MaxTxRetrievals = 256
+
+	// PenaltyTxTimeout is reported for a peer which didn't deliver the requested txs in time.
+	PenaltyTxTimeout = 5
+)
+
+type txRequesterFn func([]utils.Hash) error
+
+type txCheckFn func(utils.Hash) bool
+
+type peerPenaltyFn func(id string, penalty int)
+
+type txAnnounce struct {
+	origin string
+	time   time.Time
+	fetch  txRequesterFn
+}
+
+type txNotify struct {
+	origin string
+	hashes []utils.Hash
+	time   time.Time
+	fetch  txRequesterFn
+}
+
+type txDelivery struct {
+	origin string
+	hashes []utils.Hash
+	direct bool
+}
+
+type txRequest struct {
+	hashes []utils.Hash
+	time   time.Time
+}
+
+// TxFetcher retrieves the txs announced by their hashes. An announced tx is fetched
+// after txArriveTimeout if it hasn't been broadcast meanwhile, from a random peer which
+// announced it and has no request in flight.
+type TxFetcher struct {
+	notify  chan *txNotify
+	deliver chan *txDelivery
+	drop    chan string
+	quit    chan struct{}
+
+	waiting   map[utils.Hash][]*txAnnounce // announces of the txs not retrieved yet
+	announces map[string]int               // count of the waiting announces per peer
+	fetching  map[utils.Hash]string        // txs requested and the peer they are requested from
+	requests  map[string]*txRequest        // requests in flight per peer
+
+	hasTx        txCheckFn
+	penalizePeer peerPenaltyFn
+}
+
+func NewTxFetcher(hasTx txCheckFn, penalizePeer peerPenaltyFn) *TxFetcher {
+	return &TxFetcher{
+		notify:       make(chan *txNotify),
+		deliver:      make(chan *txDelivery),
+		drop:         make(chan string),
+		quit:         make(chan struct{}),
+		waiting:      make(map[utils.Hash][]*txAnnounce),
+		announces:    make(map[string]int),
+		fetching:     make(map[utils.Hash]string),
+		requests:     make(map[string]*txRequest),
+		hasTx:        hasTx,
+		penalizePeer: penalizePeer,
+	}
+}
+
+func (f *TxFetcher) Start() {
+	go f.loop()
+}
+
+func (f *TxFetcher) Stop() {
+	close(f.quit)
+}
+
+// Notify announces the hashes of txs the peer has.
+func (f *TxFetcher) Notify(peer string, hashes []utils.Hash, fetcher txRequesterFn) error {
+	op := &txNotify{
+		origin: peer,
+		hashes: hashes,
+		time:   time.Now(),
+		fetch:  fetcher,
+	}
+	select {
+	case f.notify <- op:
+		return nil
+	case <-f.quit:
+		return errTerminated
+	}
+}
+
+// Deliver reports the txs received from the peer, direct is true for a broadcast
+// and false for the reply to a request.
+func (f *TxFetcher) Deliver(peer string, hashes []utils.Hash, direct bool) error {
+	op := &txDelivery{
+		origin: peer,
+		hashes: hashes,
+		direct: direct,
+	}
+	select {
+	case f.deliver <- op:
+		return nil
+	case <-f.quit:
+		return errTerminated
+	}
+}
+
+// Drop forgets the announces and the request of the disconnected peer.
+func (f *TxFetcher) Drop(peer string) error {
+	select {
+	case f.drop <- peer:
+		return nil
+	case <-f.quit:
+		return errTerminated
+	}
+}
+
+func (f *TxFetcher) loop() {
+	fetch := time.NewTimer(0)
+	defer fetch.Stop()
+	for {
+		select {
+		case <-f.quit:
+			return
+
+		case op := <-f.notify:
+			count := f.announces[op.origin]
+			for _, hash := range op.hashes {
+				if count >= maxTxAnnounces {
+					log.Debugf("Peer %s: exceeded outstanding tx announces (%d)", op.origin, maxTxAnnounces)
+					break
+				}
+				if f.announcedBy(hash, op.origin) || f.hasTx(hash) {
+					continue
+				}
+				f.waiting[hash] = append(f.waiting[hash], &txAnnounce{origin: op.origin, time: op.time, fetch: op.fetch})
+				count++
+			}
+			f.announces[op.origin] = count
+			f.reschedule(fetch)
+
+		case op := <-f.deliver:
+			for _, hash := range op.hashes {
+				f.forgetHash(hash)
+			}
+			if op.direct {
+				break
+			}
+			// the requested txs which aren't delivered are missing on the peer
+			if req := f.requests[op.origin]; req != nil {
+				delete(f.requests, op.origin)
+				for _, hash := range req.hashes {
+					if f.fetching[hash] == op.origin {
+						delete(f.fetching, hash)
+						f.forgetAnnounce(hash, op.origin)
+					}
+				}
+			}
+			f.reschedule(fetch)
+
+		case peer := <-f.drop:
+			f.forgetPeer(peer)
+			f.reschedule(fetch)
+
+		case <-fetch.C:
+			for peer, req := range f.requests {
+				if time.Since(req.time) > txFetchTimeout {
+					log.Debugf("Peer %s: tx request of %d timed out", peer, len(req.hashes))
+					f.penalizePeer(peer, PenaltyTxTimeout)
+					f.forgetPeer(peer)
+				}
+			}
+			f.schedule()
+			f.reschedule(fetch)
+		}
+	}
+}
+
+// schedule requests the announced txs which didn't arrive in time from the idle peers.
+func (f *TxFetcher) schedule() {
+	var (
+		now     = time.Now()
+		request = make(map[string][]utils.Hash)
+		fetcher = make(map[string]txRequesterFn)
+	)
+	for hash, announces := range f.waiting {
+		if _, ok := f.fetching[hash]; ok || now.Sub(announces[0].time) < txArriveTimeout-txGatherSlack {
+			continue
+		}
+		if f.hasTx(hash) {
+			f.forgetHash(hash)
+			continue
+		}
+		var idle []*txAnnounce
+		for _, announce := range announces {
+			if f.requests[announce.origin] == nil && len(request[announce.origin]) < MaxTxRetrievals {
+				idle = append(idle, announce)
+			}
+		}
+		if len(idle) == 0 {
+			continue
+		}
+		announce := idle[rand.Intn(len(idle))]
+		request[announce.origin] = append(request[announce.origin], hash)
+		fetcher[announce.origin] = announce.fetch
+		f.fetching[hash] = announce.origin
+	}
+	for peer, hashes := range request {
+		f.requests[peer] = &txRequest{hashes: hashes, time: now}
+		fetch, hashes := fetcher[peer], hashes
+		go func() {
+			fetch(hashes)
+		}()
+	}
+}
+
+// reschedule resets the timer to the earliest arrival timeout of the announces
+// which can be fetched or the earliest fetch timeout of the requests.
+func (f *TxFetcher) reschedule(fetch *time.Timer) {
+	var earliest time.Time
+	for hash, announces := range f.waiting {
+		if _, ok := f.fetching[hash]; ok || !f.hasIdle(announces) {
+			continue
+		}
+		if at := announces[0].time.Add(txArriveTimeout); earliest.IsZero() || at.Before(earliest) {
+			earliest = at
+		}
+	}
+	for _, req := range f.requests {
+		if at := req.time.Add(txFetchTimeout); earliest.IsZero() || at.Before(earliest) {
+			earliest = at
+		}
+	}
+	if earliest.IsZero() {
+		return
+	}
+	if !fetch.Stop() {
+		select {
+		case <-fetch.C:
+		default:
+		}
+	}
+	fetch.Reset(time.Until(earliest))
+}
+
+// hasIdle reports whether any of the announcing peers has no request in flight.
+func (f *TxFetcher) hasIdle(announces []*txAnnounce) bool {
+	for _, announce := range announces {
+		if f.requests[announce.origin] == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *TxFetcher) announcedBy(hash utils.Hash, peer string) bool {
+	for _, announce := range f.waiting[hash] {
+		if announce.origin == peer {
+			return true
+		}
+	}
+	return false
+}
+
+// forgetHash removes all the traces of a retrieved tx.
+func (f *TxFetcher) forgetHash(hash utils.Hash) {
+	for _, announce := range f.waiting[hash] {
+		f.decAnnounces(announce.origin)
+	}
+	delete(f.waiting, hash)
+	delete(f.fetching, hash)
+}
+
+// forgetAnnounce removes the announce of the tx by the peer.
+func (f *TxFetcher) forgetAnnounce(hash utils.Hash, peer string) {
+	announces := f.waiting[hash]
+	for i, announce := range announces {
+		if announce.origin == peer {
+			announces = append(announces[:i], announces[i+1:]...)
+			f.decAnnounces(peer)
+			break
+		}
+	}
+	if len(announces) == 0 {
+		delete(f.waiting, hash)
+		return
+	}
+	f.waiting[hash] = announces
+}
+
+// forgetPeer removes the announces and the request of the peer, the txs requested
+// from it may be retrieved from other peers.
+func (f *TxFetcher) forgetPeer(peer string) {
+	if req := f.requests[peer]; req != nil {
+		for _, hash := range req.hashes {
+			if f.fetching[hash] == peer {
+				delete(f.fetching, hash)
+			}
+		}
+		delete(f.requests, peer)
+	}
+	for hash := range f.waiting {
+		f.forgetAnnounce(hash, peer)
+	}
+	delete(f.announces, peer)
+}
+
+func (f *TxFetcher) decAnnounces(peer string) {
+	if f.announces[peer]--; f.announces[peer] <= 0 {
+		delete(f.announces, peer)
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package protocols
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/UranusBlockStack/uranus/common/utils"
+)
+
+type txFetchRequest struct {
+	peer   string
+	hashes []utils.Hash
+}
+
+// txFetcherTester records the requests and the penalties of the fetcher.
+type txFetcherTester struct {
+	fetcher   *TxFetcher
+	requests  chan *txFetchRequest
+	lock      sync.Mutex
+	known     map[utils.Hash]bool
+	penalties map[string]int
+}
+
+func newTxFetcherTester() *txFetcherTester {
+	tester := &txFetcherTester{
+		requests:  make(chan *txFetchRequest, 16),
+		known:     make(map[utils.Hash]bool),
+		penalties: make(map[string]int),
+	}
+	tester.fetcher = NewTxFetcher(tester.hasTx, tester.penalize)
+	tester.fetcher.Start()
+	return tester
+}
+
+func (tester *txFetcherTester) hasTx(hash utils.Hash) bool {
+	tester.lock.Lock()
+	defer tester.lock.Unlock()
+	return tester.known[hash]
+}
+
+func (tester *txFetcherTester) penalize(peer string, penalty int) {
+	tester.lock.Lock()
+	defer tester.lock.Unlock()
+	tester.penalties[peer] += penalty
+}
+
+func (tester *txFetcherTester) penalty(peer string) int {
+	tester.lock.Lock()
+	defer tester.lock.Unlock()
+	return tester.penalties[peer]
+}
+
+func (tester *txFetcherTester) fetcherFn(peer string) txRequesterFn {
+	return func(hashes []utils.Hash) error {
+		tester.requests <- &txFetchRequest{peer: peer, hashes: hashes}
+		return nil
+	}
+}
+
+func (tester *txFetcherTester) notify(peer string, hashes ...utils.Hash) {
+	tester.fetcher.Notify(peer, hashes, tester.fetcherFn(peer))
+}
+
+func (tester *txFetcherTester) waitRequest(t *testing.T, timeout time.Duration) *txFetchRequest {
+	select {
+	case req := <-tester.requests:
+		return req
+	case <-time.After(timeout):
+		t.Fatal("no tx request")
+	}
+	return nil
+}
+
+func (tester *txFetcherTester) noRequest(t *testing.T, wait time.Duration) {
+	select {
+	case req := <-tester.requests:
+		t.Fatalf("unexpected tx request of %d from %s", len(req.hashes), req.peer)
+	case <-time.After(wait):
+	}
+}
+
+func TestTxFetcherBroadcastArrives(t *testing.T) {
+	tester := newTxFetcherTester()
+	defer tester.fetcher.Stop()
+
+	hash := utils.Hash{1}
+	tester.notify("A", hash)
+	// the broadcast arrives within txArriveTimeout, nothing is fetched
+	tester.fetcher.Deliver("B", []utils.Hash{hash}, true)
+	tester.noRequest(t, txArriveTimeout+txGatherSlack)
+}
+
+func TestTxFetcherKnownTx(t *testing.T) {
+	tester := newTxFetcherTester()
+	defer tester.fetcher.Stop()
+
+	hash := utils.Hash{1}
+	tester.known[hash] = true
+	tester.notify("A", hash)
+	tester.noRequest(t, txArriveTimeout+txGatherSlack)
+}
+
+func TestTxFetcherMissingRefetch(t *testing.T) {
+	tester := newTxFetcherTester()
+	defer tester.fetcher.Stop()
+
+	hash := utils.Hash{1}
+	tester.notify("A", hash)
+	tester.notify("B", hash)
+	first := tester.waitRequest(t, 2*txArriveTimeout)
+	if len(first.hashes) != 1 || first.hashes[0] != hash {
+		t.Fatalf("requested %v, want %x", first.hashes, hash)
+	}
+	// the peer replies without the tx, it's fetched from the other announcer
+	tester.fetcher.Deliver(first.peer, nil, false)
+	second := tester.waitRequest(t, time.Second)
+	if second.peer == first.peer {
+		t.Fatalf("refetched from %s which is missing the tx", second.peer)
+	}
+	tester.fetcher.Deliver(second.peer, []utils.Hash{hash}, false)
+	tester.noRequest(t, txArriveTimeout)
+	if tester.penalty(first.peer) != 0 || tester.penalty(second.peer) != 0 {
+		t.Fatal("replying peers are penalized")
+	}
+}
+
+func TestTxFetcherTimeoutRefetch(t *testing.T) {
+	tester := newTxFetcherTester()
+	defer tester.fetcher.Stop()
+
+	hash := utils.Hash{1}
+	tester.notify("A", hash)
+	tester.notify("B", hash)
+	first := tester.waitRequest(t, 2*txArriveTimeout)
+
+	// the request isn't served in time, the peer is penalized and the tx is refetched
+	second := tester.waitRequest(t, txFetchTimeout+time.Second)
+	if second.peer == first.peer || len(second.hashes) != 1 || second.hashes[0] != hash {
+		t.Fatalf("refetched %v from %s, want %x from the other peer", second.hashes, second.peer, hash)
+	}
+	if penalty := tester.penalty(first.peer); penalty != PenaltyTxTimeout {
+		t.Fatalf("penalty %d, want %d", penalty, PenaltyTxTimeout)
+	}
+	// the timed out peer is forgotten, a late delivery doesn't cancel the request
+	tester.fetcher.Deliver(first.peer, nil, false)
+	tester.fetcher.Deliver(second.peer, []utils.Hash{hash}, false)
+	tester.noRequest(t, txArriveTimeout)
+}
+
+func TestTxFetcherDropPeer(t *testing.T) {
+	tester := newTxFetcherTester()
+	defer tester.fetcher.Stop()
+
+	hashes := []utils.Hash{{1}, {2}}
+	tester.notify("A", hashes...)
+	tester.fetcher.Drop("A")
+	tester.noRequest(t, txArriveTimeout+txGatherSlack)
+
+	// the announces of the reconnected peer are accepted again
+	tester.notify("A", hashes...)
+	if req := tester.waitRequest(t, 2*txArriveTimeout); req.peer != "A" || len(req.hashes) != len(hashes) {
+		t.Fatalf("requested %d from %s, want %d from A", len(req.hashes), req.peer, len(hashes))
+	}
+}
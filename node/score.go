@@ -0,0 +1,98 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"sync"
+	"time"
+
+	"github.com/UranusBlockStack/uranus/p2p/discover"
+)
+
+const (
+	penaltyBadTx    = 10 // the peer relayed transactions which are invalid in any state
+	penaltyProtocol = 50 // the peer sent a malformed or unexpected message
+
+	banScore        = -100 // peers whose score drops to it are banned
+	banDuration     = 24 * time.Hour
+	scoreRecovery   = time.Minute // a point of the score is recovered per interval
+	maxTrackedPeers = 4096
+)
+
+type peerScore struct {
+	score   int
+	updated time.Time
+}
+
+// peerScores tracks the reputation of the peers, it is kept across reconnects.
+type peerScores struct {
+	lock   sync.Mutex
+	scores map[discover.NodeID]*peerScore
+}
+
+func newPeerScores() *peerScores {
+	return &peerScores{
+		scores: make(map[discover.NodeID]*peerScore),
+	}
+}
+
+// penalize subtracts the penalty from the score of the peer and returns the new score.
+func (ps *peerScores) penalize(id discover.NodeID, penalty int) int {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	now := time.Now()
+	s, ok := ps.scores[id]
+	if !ok {
+		if len(ps.scores) >= maxTrackedPeers {
+			ps.forgetRecovered(now)
+		}
+		s = &peerScore{updated: now}
+		ps.scores[id] = s
+	}
+	s.recover(now)
+	s.score -= penalty
+	return s.score
+}
+
+// forget drops the score of the peer, i.e. after it has been banned.
+func (ps *peerScores) forget(id discover.NodeID) {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	delete(ps.scores, id)
+}
+
+// forgetRecovered drops the scores which are back to neutral.
+func (ps *peerScores) forgetRecovered(now time.Time) {
+	for id, s := range ps.scores {
+		if s.recover(now); s.score >= 0 {
+			delete(ps.scores, id)
+		}
+	}
+}
+
+func (s *peerScore) recover(now time.Time) {
+	points := int(now.Sub(s.updated) / scoreRecovery)
+	if points <= 0 {
+		return
+	}
+	s.updated = s.updated.Add(time.Duration(points) * scoreRecovery)
+	if s.score += points; s.score > 0 {
+		s.score = 0
+	}
+}
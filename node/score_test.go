@@ -0,0 +1,89 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/UranusBlockStack/uranus/node/protocols"
+	"github.com/UranusBlockStack/uranus/p2p/discover"
+)
+
+func TestPeerScoreRecover(t *testing.T) {
+	ps := newPeerScores()
+	id := discover.NodeID{1}
+	if score := ps.penalize(id, penaltyProtocol); score != -penaltyProtocol {
+		t.Fatalf("score %d, want %d", score, -penaltyProtocol)
+	}
+
+	// a point is recovered per interval, the rest of the interval is kept
+	ps.scores[id].updated = ps.scores[id].updated.Add(-10*scoreRecovery - scoreRecovery/2)
+	if score := ps.penalize(id, 0); score != -penaltyProtocol+10 {
+		t.Fatalf("score %d, want %d", score, -penaltyProtocol+10)
+	}
+	// the score doesn't recover above neutral
+	ps.scores[id].updated = ps.scores[id].updated.Add(-time.Duration(penaltyProtocol) * scoreRecovery)
+	if score := ps.penalize(id, 0); score != 0 {
+		t.Fatalf("score %d, want 0", score)
+	}
+}
+
+func TestPeerScoreBanThreshold(t *testing.T) {
+	tests := []struct {
+		penalty int
+		bans    int // the penalty at which the peer is banned
+	}{
+		{protocols.PenaltyInvalidBlock, 3},
+		{protocols.PenaltyBadPeer, 2},
+		{protocols.PenaltyTimeout, 5},
+		{penaltyProtocol, 2},
+		{penaltyBadTx, 10},
+	}
+	for _, test := range tests {
+		ps := newPeerScores()
+		id := discover.NodeID{1}
+		for i := 1; i <= test.bans; i++ {
+			banned := ps.penalize(id, test.penalty) <= banScore
+			if banned != (i == test.bans) {
+				t.Errorf("penalty %d: banned %v after %d penalties, want the ban after %d", test.penalty, banned, i, test.bans)
+			}
+		}
+	}
+}
+
+func TestPeerScoreForget(t *testing.T) {
+	ps := newPeerScores()
+	banned, recovered, penalized := discover.NodeID{1}, discover.NodeID{2}, discover.NodeID{3}
+	ps.penalize(banned, -banScore)
+	ps.penalize(recovered, penaltyBadTx)
+	ps.penalize(penalized, penaltyProtocol)
+
+	// the banned peers start over once the ban is lifted
+	ps.forget(banned)
+	if score := ps.penalize(banned, 0); score != 0 {
+		t.Fatalf("score of the forgotten peer %d, want 0", score)
+	}
+	ps.scores[recovered].updated = ps.scores[recovered].updated.Add(-penaltyBadTx * scoreRecovery)
+	ps.forgetRecovered(time.Now())
+	if _, ok := ps.scores[recovered]; ok {
+		t.Fatal("recovered score is still tracked")
+	}
+	if _, ok := ps.scores[penalized]; !ok {
+		t.Fatal("penalized score is dropped")
+	}
+}
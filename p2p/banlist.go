@@ -0,0 +1,106 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"sync"
+	"time"
+
+	"github.com/UranusBlockStack/uranus/p2p/discover"
+)
+
+// banStore persists the bans, it's implemented by the node database of the discovery table.
+type banStore interface {
+	Ban(id discover.NodeID, until time.Time) error
+	Unban(id discover.NodeID) error
+	Bans() map[discover.NodeID]time.Time
+}
+
+// banList keeps the banned nodes in memory, so they are refused with the discovery
+// disabled too. The bans are written through to the store if there is one.
+type banList struct {
+	lock  sync.RWMutex
+	bans  map[discover.NodeID]time.Time
+	store banStore
+}
+
+func newBanList() *banList {
+	return &banList{bans: make(map[discover.NodeID]time.Time)}
+}
+
+// setStore loads the bans of the store and writes the later ones through to it.
+func (bl *banList) setStore(store banStore) {
+	bl.lock.Lock()
+	defer bl.lock.Unlock()
+
+	for id, until := range store.Bans() {
+		bl.bans[id] = until
+	}
+	bl.store = store
+}
+
+func (bl *banList) ban(id discover.NodeID, until time.Time) error {
+	bl.lock.Lock()
+	defer bl.lock.Unlock()
+
+	if bl.store != nil {
+		if err := bl.store.Ban(id, until); err != nil {
+			return err
+		}
+	}
+	bl.bans[id] = until
+	return nil
+}
+
+func (bl *banList) unban(id discover.NodeID) error {
+	bl.lock.Lock()
+	defer bl.lock.Unlock()
+
+	if bl.store != nil {
+		if err := bl.store.Unban(id); err != nil {
+			return err
+		}
+	}
+	delete(bl.bans, id)
+	return nil
+}
+
+// banned reports whether the node is banned at the moment.
+func (bl *banList) banned(id discover.NodeID) bool {
+	bl.lock.RLock()
+	defer bl.lock.RUnlock()
+
+	until, ok := bl.bans[id]
+	return ok && until.After(time.Now())
+}
+
+// active returns the bans which haven't expired yet and drops the others.
+func (bl *banList) active() map[discover.NodeID]time.Time {
+	bl.lock.Lock()
+	defer bl.lock.Unlock()
+
+	now := time.Now()
+	bans := make(map[discover.NodeID]time.Time)
+	for id, until := range bl.bans {
+		if !until.After(now) {
+			delete(bl.bans, id)
+			continue
+		}
+		bans[id] = until
+	}
+	return bans
+}
@@ -40,6 +40,7 @@ import (
 	"github.com/UranusBlockStack/uranus/common/rlp"
 	"github.com/UranusBlockStack/uranus/p2p/discover"
 	"github.com/UranusBlockStack/uranus/p2p/ecies"
+	"github.com/golang/snappy"
 )
 
 const (
@@ -55,18 +56,40 @@ const (
 	encAuthRespLen   = authRespLen + eciesOverhead
 	handshakeTimeout = 5 * time.Second
 	discWriteTimeout = 1 * time.Second
+
+	// snappyProtocolVersion is the first version of the protocol handshake compressing
+	// the messages with snappy.
+	snappyProtocolVersion = 1
+	baseProtocolVersion   = snappyProtocolVersion
+)
+
+var errPlainMessageTooLarge = errors.New("message size exceeds uint24 before compression")
+
+type connFlag int
+
+const (
+	inboundConn connFlag = 1 << iota
+	staticDialedConn
+	trustedConn
+	priorityConn // a sentry node or a private node behind the sentry
+	reservedConn // accepted on a slot reserved for the trusted nodes
 )
 
 type conn struct {
 	fd        net.Conn
 	name      string
 	id        discover.NodeID
+	flags     connFlag
 	protocols []*ProtocolKey
 	cont      chan error
 	rmu, wmu  sync.Mutex
 	rw        *connFrameRW
 }
 
+func (c *conn) is(f connFlag) bool {
+	return c.flags&f != 0
+}
+
 func (c *conn) ReadMsg() (*Message, error) {
 	c.rmu.Lock()
 	defer c.rmu.Unlock()
@@ -104,7 +127,8 @@ func (c *conn) doProtoHandshake(our *ProtoHandshake) (their *ProtoHandshake, err
 	if err := <-werr; err != nil {
 		return nil, fmt.Errorf("write error: %v", err)
 	}
-
+	// the messages following the handshake are compressed if both sides support it
+	c.rw.snappy = their.Version >= snappyProtocolVersion
 	return their, nil
 }
 
@@ -115,9 +139,9 @@ func readProtocolHandshake(rw MsgReader, our *ProtoHandshake) (*ProtoHandshake,
 	}
 
 	if msg.Code == quitMsg {
-		var reason [1]QuitReason
+		var reason QuitReason
 		msg.DecodePayload(&reason)
-		return nil, reason[0]
+		return nil, reason
 	}
 	if msg.Code != handshakeMsg {
 		return nil, fmt.Errorf("expected handshake, got %x", msg.Code)
@@ -479,6 +503,7 @@ const (
 	QuitUnexpectedIdentity
 	QuitSelf
 	QuitReadTimeout
+	QuitIncompatibleFork
 	QuitSubprotocolError = 0x10
 )
 
@@ -495,6 +520,7 @@ var quitReasonToString = map[QuitReason]string{
 	QuitUnexpectedIdentity:  "unexpected identity",
 	QuitSelf:                "connected to self",
 	QuitReadTimeout:         "read timeout",
+	QuitIncompatibleFork:    "incompatible fork",
 	QuitSubprotocolError:    "subprotocol error",
 }
 
@@ -510,6 +536,8 @@ type connFrameRW struct {
 	macCipher  cipher.Block
 	egressMAC  hash.Hash
 	ingressMAC hash.Hash
+
+	snappy bool
 }
 
 func newRLPXFrameRW(conn io.ReadWriter, s secrets) *connFrameRW {
@@ -536,8 +564,16 @@ func newRLPXFrameRW(conn io.ReadWriter, s secrets) *connFrameRW {
 func (rw *connFrameRW) WriteMsg(msg *Message) error {
 	ptype, _ := rlp.EncodeToBytes(msg.Code)
 
+	payload := msg.Payload
+	if rw.snappy {
+		if len(payload) > int(maxUint24) {
+			return errPlainMessageTooLarge
+		}
+		payload = snappy.Encode(nil, payload)
+	}
+
 	headbuf := make([]byte, 32)
-	fsize := uint32(len(ptype)) + uint32(len(msg.Payload))
+	fsize := uint32(len(ptype)) + uint32(len(payload))
 	if fsize > maxUint24 {
 		return errors.New("message size overflows uint24")
 	}
@@ -554,8 +590,7 @@ func (rw *connFrameRW) WriteMsg(msg *Message) error {
 	if _, err := tee.Write(ptype); err != nil {
 		return err
 	}
-	payload := bytes.NewReader(msg.Payload)
-	if _, err := io.Copy(tee, payload); err != nil {
+	if _, err := tee.Write(payload); err != nil {
 		return err
 	}
 
@@ -612,6 +647,18 @@ func (rw *connFrameRW) ReadMsg() (msg *Message, err error) {
 	msg.Payload = make([]byte, content.Len())
 	io.ReadFull(content, msg.Payload)
 
+	if rw.snappy {
+		size, err := snappy.DecodedLen(msg.Payload)
+		if err != nil {
+			return msg, err
+		}
+		if size > int(maxUint24) {
+			return msg, errPlainMessageTooLarge
+		}
+		if msg.Payload, err = snappy.Decode(nil, msg.Payload); err != nil {
+			return msg, err
+		}
+	}
 	return msg, nil
 }
 
@@ -50,7 +50,8 @@ type Task interface {
 
 // DialerTask is generated for node to dial.
 type DialerTask struct {
-	dest *discover.Node
+	dest   *discover.Node
+	static bool // dest is a static node, dialed beyond MaxPeers
 }
 
 // DiscoverTask runs discovery operations for lookup.
@@ -63,6 +64,8 @@ type DialerManager struct {
 	dialerLimit int                                // max dialers
 	ntab        *discover.Table                    // discover nodes
 	bootnodes   map[discover.NodeID]*discover.Node // nodes default or static
+	static      map[discover.NodeID]bool           // nodes added by AddStatic
+	bans        *banList                           // nodes refused to dial
 
 	dialing map[discover.NodeID]*discover.Node
 	lookup  map[discover.NodeID]*discover.Node
@@ -76,6 +79,7 @@ func NewDialerManager(maxdialers int, bootnodes []*discover.Node, ntab *discover
 		dialerLimit: maxdialers,
 		ntab:        ntab,
 		bootnodes:   make(map[discover.NodeID]*discover.Node),
+		static:      make(map[discover.NodeID]bool),
 		dialing:     make(map[discover.NodeID]*discover.Node),
 		lookup:      make(map[discover.NodeID]*discover.Node),
 		dialingExp:  make(map[discover.NodeID]time.Time),
@@ -95,6 +99,8 @@ func (dm *DialerManager) canDial(n *discover.Node, peers map[discover.NodeID]*Pe
 		return errors.New("connected")
 	case dm.ntab != nil && n.ID == dm.ntab.Self().ID:
 		return errors.New("self")
+	case dm.bans != nil && dm.bans.banned(n.ID):
+		return errBanned
 	}
 	return nil
 }
@@ -105,7 +111,7 @@ func (dm *DialerManager) NewTasks(runningTasks int, peers map[discover.NodeID]*P
 		if err := dm.canDial(node, peers); err != nil {
 			continue
 		}
-		task := &DialerTask{dest: node}
+		task := &DialerTask{dest: node, static: dm.static[node.ID]}
 		dm.dialing[node.ID] = node
 		tasks = append(tasks, task)
 	}
@@ -124,7 +130,7 @@ func (dm *DialerManager) NewTasks(runningTasks int, peers map[discover.NodeID]*P
 		needDialers--
 	}
 
-	if needDialers <= 0 {
+	if needDialers <= 0 || dm.ntab == nil {
 		return
 	}
 
@@ -185,6 +191,7 @@ func (dm *DialerManager) Done(t Task, now time.Time) {
 }
 
 func (dm *DialerManager) AddStatic(n *discover.Node) {
+	dm.static[n.ID] = true
 	if _, ok := dm.bootnodes[n.ID]; ok {
 		return
 	}
@@ -193,6 +200,7 @@ func (dm *DialerManager) AddStatic(n *discover.Node) {
 
 func (dm *DialerManager) RemoveStatic(n *discover.Node) {
 	delete(dm.bootnodes, n.ID)
+	delete(dm.static, n.ID)
 }
 
 func (t *DialerTask) Do(srv *Server) error {
@@ -213,7 +221,11 @@ func (t *DialerTask) Do(srv *Server) error {
 	if err != nil {
 		return err
 	}
-	return srv.SetupConn(fd, t.dest)
+	var flags connFlag
+	if t.static {
+		flags |= staticDialedConn
+	}
+	return srv.SetupConn(fd, flags, t.dest)
 }
 
 func (t *DiscoverTask) Do(srv *Server) error {
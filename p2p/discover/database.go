@@ -61,16 +61,19 @@ var (
 	nodeDBDiscoverPing      = nodeDBDiscoverRoot + ":lastping"
 	nodeDBDiscoverPong      = nodeDBDiscoverRoot + ":lastpong"
 	nodeDBDiscoverFindFails = nodeDBDiscoverRoot + ":findfail"
+
+	nodeDBBanUntil = ":ban" // Outside of the discover root to survive the node expiration
 )
 
 // newNodeDB creates a new node database for storing and retrieving infos about
 // known peers in the network. If no path is given, an in-memory, temporary
-// database is constructed.
-func newNodeDB(path string, version int, self NodeID) (*nodeDB, error) {
+// database is constructed. The options tune the persistent database, nil for the
+// defaults.
+func newNodeDB(path string, options *opt.Options, version int, self NodeID) (*nodeDB, error) {
 	if path == "" {
 		return newMemoryNodeDB(self)
 	}
-	return newPersistentNodeDB(path, version, self)
+	return newPersistentNodeDB(path, options, version, self)
 }
 
 // newMemoryNodeDB creates a new in-memory node database without a persistent
@@ -89,9 +92,11 @@ func newMemoryNodeDB(self NodeID) (*nodeDB, error) {
 
 // newPersistentNodeDB creates/opens a leveldb backed persistent node database,
 // also flushing its contents in case of a version mismatch.
-func newPersistentNodeDB(path string, version int, self NodeID) (*nodeDB, error) {
-	opts := &opt.Options{OpenFilesCacheCapacity: 5}
-	db, err := leveldb.OpenFile(path, opts)
+func newPersistentNodeDB(path string, options *opt.Options, version int, self NodeID) (*nodeDB, error) {
+	if options == nil {
+		options = &opt.Options{OpenFilesCacheCapacity: 5}
+	}
+	db, err := leveldb.OpenFile(path, options)
 	if _, iscorrupted := err.(*errors.ErrCorrupted); iscorrupted {
 		db, err = leveldb.RecoverFile(path, nil)
 	}
@@ -119,7 +124,7 @@ func newPersistentNodeDB(path string, version int, self NodeID) (*nodeDB, error)
 			if err = os.RemoveAll(path); err != nil {
 				return nil, err
 			}
-			return newPersistentNodeDB(path, version, self)
+			return newPersistentNodeDB(path, options, version, self)
 		}
 	}
 	return &nodeDB{
@@ -199,9 +204,10 @@ func (db *nodeDB) updateNode(node *Node) error {
 	return db.lvl.Put(makeKey(node.ID, nodeDBDiscoverRoot), blob, nil)
 }
 
-// deleteNode deletes all information/keys associated with a node.
+// deleteNode deletes all discovery information/keys associated with a node,
+// a ban of the node is kept until it expires.
 func (db *nodeDB) deleteNode(id NodeID) error {
-	deleter := db.lvl.NewIterator(util.BytesPrefix(makeKey(id, "")), nil)
+	deleter := db.lvl.NewIterator(util.BytesPrefix(makeKey(id, nodeDBDiscoverRoot)), nil)
 	for deleter.Next() {
 		if err := db.lvl.Delete(deleter.Key(), nil); err != nil {
 			return err
@@ -241,9 +247,11 @@ func (db *nodeDB) expirer() {
 }
 
 // expireNodes iterates over the database and deletes all nodes that have not
-// been seen (i.e. received a pong from) for some allotted time.
+// been seen (i.e. received a pong from) for some allotted time, and the bans
+// which are over.
 func (db *nodeDB) expireNodes() error {
-	threshold := time.Now().Add(-nodeDBNodeExpiration)
+	now := time.Now()
+	threshold := now.Add(-nodeDBNodeExpiration)
 
 	// Find discovered nodes that are older than the allowance
 	it := db.lvl.NewIterator(nil, nil)
@@ -252,6 +260,12 @@ func (db *nodeDB) expireNodes() error {
 	for it.Next() {
 		// Skip the item if not a discovery node
 		id, field := splitKey(it.Key())
+		if field == nodeDBBanUntil {
+			if !db.banTime(id).After(now) {
+				db.deleteBan(id)
+			}
+			continue
+		}
 		if field != nodeDBDiscoverRoot {
 			continue
 		}
@@ -303,6 +317,40 @@ func (db *nodeDB) updateFindFails(id NodeID, fails int) error {
 	return db.storeInt64(makeKey(id, nodeDBDiscoverFindFails), int64(fails))
 }
 
+// banTime retrieves the time until which the node is banned.
+func (db *nodeDB) banTime(id NodeID) time.Time {
+	return time.Unix(db.fetchInt64(makeKey(id, nodeDBBanUntil)), 0)
+}
+
+// updateBan bans the node until the given time.
+func (db *nodeDB) updateBan(id NodeID, until time.Time) error {
+	return db.storeInt64(makeKey(id, nodeDBBanUntil), until.Unix())
+}
+
+// deleteBan lifts the ban of the node.
+func (db *nodeDB) deleteBan(id NodeID) error {
+	return db.lvl.Delete(makeKey(id, nodeDBBanUntil), nil)
+}
+
+// bans retrieves all the nodes which are banned at the moment.
+func (db *nodeDB) bans() map[NodeID]time.Time {
+	now := time.Now()
+	bans := make(map[NodeID]time.Time)
+
+	it := db.lvl.NewIterator(util.BytesPrefix(nodeDBItemPrefix), nil)
+	defer it.Release()
+	for it.Next() {
+		id, field := splitKey(it.Key())
+		if field != nodeDBBanUntil {
+			continue
+		}
+		if until := db.banTime(id); until.After(now) {
+			bans[id] = until
+		}
+	}
+	return bans
+}
+
 // querySeeds retrieves random nodes to be used as potential seed nodes
 // for bootstrapping.
 func (db *nodeDB) querySeeds(n int, maxAge time.Duration) []*Node {
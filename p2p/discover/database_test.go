@@ -79,7 +79,7 @@ var nodeDBInt64Tests = []struct {
 }
 
 func TestNodeDBInt64(t *testing.T) {
-	db, _ := newNodeDB("", Version, NodeID{})
+	db, _ := newNodeDB("", nil, Version, NodeID{})
 	defer db.close()
 
 	tests := nodeDBInt64Tests
@@ -111,7 +111,7 @@ func TestNodeDBFetchStore(t *testing.T) {
 	inst := time.Now()
 	num := 314
 
-	db, _ := newNodeDB("", Version, NodeID{})
+	db, _ := newNodeDB("", nil, Version, NodeID{})
 	defer db.close()
 
 	// Check fetch/store operations on a node ping object
@@ -216,7 +216,7 @@ var nodeDBSeedQueryNodes = []struct {
 }
 
 func TestNodeDBSeedQuery(t *testing.T) {
-	db, _ := newNodeDB("", Version, nodeDBSeedQueryNodes[1].node.ID)
+	db, _ := newNodeDB("", nil, Version, nodeDBSeedQueryNodes[1].node.ID)
 	defer db.close()
 
 	// Insert a batch of nodes for querying
@@ -267,7 +267,7 @@ func TestNodeDBPersistency(t *testing.T) {
 	)
 
 	// Create a persistent database and store some values
-	db, err := newNodeDB(filepath.Join(root, "database"), Version, NodeID{})
+	db, err := newNodeDB(filepath.Join(root, "database"), nil, Version, NodeID{})
 	if err != nil {
 		t.Fatalf("failed to create persistent database: %v", err)
 	}
@@ -277,7 +277,7 @@ func TestNodeDBPersistency(t *testing.T) {
 	db.close()
 
 	// Reopen the database and check the value
-	db, err = newNodeDB(filepath.Join(root, "database"), Version, NodeID{})
+	db, err = newNodeDB(filepath.Join(root, "database"), nil, Version, NodeID{})
 	if err != nil {
 		t.Fatalf("failed to open persistent database: %v", err)
 	}
@@ -287,7 +287,7 @@ func TestNodeDBPersistency(t *testing.T) {
 	db.close()
 
 	// Change the database version and check flush
-	db, err = newNodeDB(filepath.Join(root, "database"), Version+1, NodeID{})
+	db, err = newNodeDB(filepath.Join(root, "database"), nil, Version+1, NodeID{})
 	if err != nil {
 		t.Fatalf("failed to open persistent database: %v", err)
 	}
@@ -324,7 +324,7 @@ var nodeDBExpirationNodes = []struct {
 }
 
 func TestNodeDBExpiration(t *testing.T) {
-	db, _ := newNodeDB("", Version, NodeID{})
+	db, _ := newNodeDB("", nil, Version, NodeID{})
 	defer db.close()
 
 	// Add all the test nodes and set their last pong time
@@ -357,7 +357,7 @@ func TestNodeDBSelfExpiration(t *testing.T) {
 			break
 		}
 	}
-	db, _ := newNodeDB("", Version, self)
+	db, _ := newNodeDB("", nil, Version, self)
 	defer db.close()
 
 	// Add all the test nodes and set their last pong time
@@ -378,3 +378,49 @@ func TestNodeDBSelfExpiration(t *testing.T) {
 		t.Errorf("self not evacuated")
 	}
 }
+
+func TestNodeDBBans(t *testing.T) {
+	db, _ := newNodeDB("", nil, Version, NodeID{})
+	defer db.close()
+
+	banned, expired := nodeDBExpirationNodes[0].node, nodeDBExpirationNodes[1].node
+	for _, node := range []*Node{banned, expired} {
+		if err := db.updateNode(node); err != nil {
+			t.Fatalf("failed to insert node: %v", err)
+		}
+		if err := db.updateBondTime(node.ID, time.Now().Add(-nodeDBNodeExpiration-time.Minute)); err != nil {
+			t.Fatalf("failed to update bondTime: %v", err)
+		}
+	}
+	until := time.Now().Add(time.Hour).Truncate(time.Second)
+	if err := db.updateBan(banned.ID, until); err != nil {
+		t.Fatalf("failed to ban node: %v", err)
+	}
+	if err := db.updateBan(expired.ID, time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("failed to ban node: %v", err)
+	}
+	if bans := db.bans(); len(bans) != 1 || !bans[banned.ID].Equal(until) {
+		t.Errorf("bans mismatch: have %v, want %x until %v", bans, banned.ID[:8], until)
+	}
+
+	// The ban survives the node expiration, the ban which is over doesn't
+	if err := db.expireNodes(); err != nil {
+		t.Fatalf("failed to expire nodes: %v", err)
+	}
+	if node := db.node(banned.ID); node != nil {
+		t.Errorf("banned node not expired")
+	}
+	if until := db.banTime(banned.ID); !until.After(time.Now()) {
+		t.Errorf("ban lost by the node expiration: %v", until)
+	}
+	if _, err := db.lvl.Get(makeKey(expired.ID, nodeDBBanUntil), nil); err == nil {
+		t.Errorf("ban which is over not expired")
+	}
+
+	if err := db.deleteBan(banned.ID); err != nil {
+		t.Fatalf("failed to unban node: %v", err)
+	}
+	if bans := db.bans(); len(bans) != 0 {
+		t.Errorf("bans not empty after unban: %v", bans)
+	}
+}
@@ -37,6 +37,7 @@ import (
 	"github.com/UranusBlockStack/uranus/common/log"
 	"github.com/UranusBlockStack/uranus/common/utils"
 	"github.com/UranusBlockStack/uranus/p2p/netutil"
+	"github.com/syndtr/goleveldb/leveldb/opt"
 )
 
 const (
@@ -84,8 +85,9 @@ type Table struct {
 
 	nodeAddedHook func(*Node) // for testing
 
-	net  transport
-	self *Node // metadata of the local node
+	net      transport
+	self     *Node // metadata of the local node
+	announce *Node // local node as announced to others, guarded by mutex
 }
 
 type bondproc struct {
@@ -112,16 +114,18 @@ type bucket struct {
 	ips          netutil.DistinctNetSet
 }
 
-func newTable(t transport, ourID NodeID, ourAddr *net.UDPAddr, nodeDBPath string, bootnodes []*Node) (*Table, error) {
+func newTable(t transport, ourID NodeID, ourAddr *net.UDPAddr, nodeDBPath string, nodeDBOptions *opt.Options, bootnodes []*Node) (*Table, error) {
 	// If no node database was given, use an in-memory one
-	db, err := newNodeDB(nodeDBPath, Version, ourID)
+	db, err := newNodeDB(nodeDBPath, nodeDBOptions, Version, ourID)
 	if err != nil {
 		return nil, err
 	}
+	self := NewNode(ourID, ourAddr.IP, uint16(ourAddr.Port), uint16(ourAddr.Port))
 	tab := &Table{
 		net:        t,
 		db:         db,
-		self:       NewNode(ourID, ourAddr.IP, uint16(ourAddr.Port), uint16(ourAddr.Port)),
+		self:       self,
+		announce:   self,
 		bonding:    make(map[NodeID]*bondproc),
 		bondslots:  make(chan struct{}, maxBondingPingPongs),
 		refreshReq: make(chan chan struct{}),
@@ -164,7 +168,17 @@ func (tab *Table) seedRand() {
 // Self returns the local node.
 // The returned node should not be modified by the caller.
 func (tab *Table) Self() *Node {
-	return tab.self
+	tab.mutex.Lock()
+	defer tab.mutex.Unlock()
+	return tab.announce
+}
+
+// SetIP changes the IP announced for the local node, e.g. once the
+// external IP of a NAT is known.
+func (tab *Table) SetIP(ip net.IP) {
+	tab.mutex.Lock()
+	defer tab.mutex.Unlock()
+	tab.announce = NewNode(tab.self.ID, ip, tab.self.UDP, tab.self.TCP)
 }
 
 // ReadRandomNodes fills the given slice with random nodes from the
@@ -270,6 +284,26 @@ func (tab *Table) Resolve(targetID NodeID) *Node {
 	return nil
 }
 
+// Ban bans the node until the given time, the ban is kept in the node database.
+func (tab *Table) Ban(id NodeID, until time.Time) error {
+	return tab.db.updateBan(id, until)
+}
+
+// Unban lifts the ban of the node.
+func (tab *Table) Unban(id NodeID) error {
+	return tab.db.deleteBan(id)
+}
+
+// Banned reports whether the node is banned at the moment.
+func (tab *Table) Banned(id NodeID) bool {
+	return tab.db.banTime(id).After(time.Now())
+}
+
+// Bans returns the nodes which are banned at the moment and the end of their bans.
+func (tab *Table) Bans() map[NodeID]time.Time {
+	return tab.db.bans()
+}
+
 // Lookup performs a network search for nodes close
 // to the given target. It approaches the target by querying
 // nodes that are closer to it on each iteration.
@@ -49,7 +49,7 @@ func TestTable_pingReplace(t *testing.T) {
 
 func testPingReplace(t *testing.T, newNodeIsResponding, lastInBucketIsResponding bool) {
 	transport := newPingRecorder()
-	tab, _ := newTable(transport, NodeID{}, &net.UDPAddr{}, "", nil)
+	tab, _ := newTable(transport, NodeID{}, &net.UDPAddr{}, "", nil, nil)
 	defer tab.Close()
 
 	// Wait for init so bond is accepted.
@@ -139,7 +139,7 @@ func TestBucket_bumpNoDuplicates(t *testing.T) {
 // This checks that the table-wide IP limit is applied correctly.
 func TestTable_IPLimit(t *testing.T) {
 	transport := newPingRecorder()
-	tab, _ := newTable(transport, NodeID{}, &net.UDPAddr{}, "", nil)
+	tab, _ := newTable(transport, NodeID{}, &net.UDPAddr{}, "", nil, nil)
 	defer tab.Close()
 
 	for i := 0; i < tableIPLimit+1; i++ {
@@ -155,7 +155,7 @@ func TestTable_IPLimit(t *testing.T) {
 // This checks that the table-wide IP limit is applied correctly.
 func TestTable_BucketIPLimit(t *testing.T) {
 	transport := newPingRecorder()
-	tab, _ := newTable(transport, NodeID{}, &net.UDPAddr{}, "", nil)
+	tab, _ := newTable(transport, NodeID{}, &net.UDPAddr{}, "", nil, nil)
 	defer tab.Close()
 
 	d := 3
@@ -228,7 +228,7 @@ func TestTable_closest(t *testing.T) {
 	test := func(test *closeTest) bool {
 		// for any node table, Target and N
 		transport := newPingRecorder()
-		tab, _ := newTable(transport, test.Self, &net.UDPAddr{}, "", nil)
+		tab, _ := newTable(transport, test.Self, &net.UDPAddr{}, "", nil, nil)
 		defer tab.Close()
 		tab.stuff(test.All)
 
@@ -288,7 +288,7 @@ func TestTable_ReadRandomNodesGetAll(t *testing.T) {
 	}
 	test := func(buf []*Node) bool {
 		transport := newPingRecorder()
-		tab, _ := newTable(transport, NodeID{}, &net.UDPAddr{}, "", nil)
+		tab, _ := newTable(transport, NodeID{}, &net.UDPAddr{}, "", nil, nil)
 		defer tab.Close()
 		<-tab.initDone
 
@@ -333,7 +333,7 @@ func (*closeTest) Generate(rand *rand.Rand, size int) reflect.Value {
 
 func TestTable_Lookup(t *testing.T) {
 	self := nodeAtDistance(utils.Hash{}, 0)
-	tab, _ := newTable(lookupTestnet, self.ID, &net.UDPAddr{}, "", nil)
+	tab, _ := newTable(lookupTestnet, self.ID, &net.UDPAddr{}, "", nil, nil)
 	defer tab.Close()
 
 	// lookup on empty table returns no nodes
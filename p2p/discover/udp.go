@@ -30,6 +30,7 @@ import (
 	"github.com/UranusBlockStack/uranus/common/rlp"
 	"github.com/UranusBlockStack/uranus/p2p/nat"
 	"github.com/UranusBlockStack/uranus/p2p/netutil"
+	"github.com/syndtr/goleveldb/leveldb/opt"
 )
 
 const Version = 4
@@ -44,6 +45,7 @@ var (
 	errTimeout          = errors.New("RPC timeout")
 	errClockWarp        = errors.New("reply deadline too far in the future")
 	errClosed           = errors.New("socket closed")
+	errNetRestrict      = errors.New("not contained in netrestrict whitelist")
 )
 
 // Timeouts
@@ -133,7 +135,7 @@ func (t *udp) nodeFromRPC(sender *net.UDPAddr, rn rpcNode) (*Node, error) {
 		return nil, err
 	}
 	if t.netrestrict != nil && !t.netrestrict.Contains(rn.IP) {
-		return nil, errors.New("not contained in netrestrict whitelist")
+		return nil, errNetRestrict
 	}
 	n := NewNode(rn.ID, rn.IP, rn.UDP, rn.TCP)
 	err := n.validateComplete()
@@ -160,8 +162,8 @@ type conn interface {
 type udp struct {
 	conn        conn
 	netrestrict *netutil.Netlist
+	private     map[NodeID]bool
 	priv        *ecdsa.PrivateKey
-	ourEndpoint rpcEndpoint
 
 	addpending chan *pending
 	gotreply   chan reply
@@ -221,11 +223,13 @@ type Config struct {
 	PrivateKey *ecdsa.PrivateKey
 
 	// These settings are optional:
-	AnnounceAddr *net.UDPAddr      // local address announced in the DHT
-	NodeDBPath   string            // if set, the node database is stored at this filesystem location
-	NetRestrict  *netutil.Netlist  // network whitelist
-	Bootnodes    []*Node           // list of bootstrap nodes
-	Unhandled    chan<- ReadPacket // unhandled packets are sent on this channel
+	AnnounceAddr  *net.UDPAddr      // local address announced in the DHT
+	NodeDBPath    string            // if set, the node database is stored at this filesystem location
+	NodeDBOptions *opt.Options      // tuning of the node database stored at NodeDBPath
+	NetRestrict   *netutil.Netlist  // network whitelist
+	Bootnodes     []*Node           // list of bootstrap nodes
+	Unhandled     chan<- ReadPacket // unhandled packets are sent on this channel
+	PrivateNodes  []NodeID          // nodes never returned in neighbors replies
 }
 
 // ListenUDP returns a new table that listens for UDP packets on laddr.
@@ -243,17 +247,19 @@ func newUDP(c conn, cfg Config) (*Table, *udp, error) {
 		conn:        c,
 		priv:        cfg.PrivateKey,
 		netrestrict: cfg.NetRestrict,
+		private:     make(map[NodeID]bool),
 		closing:     make(chan struct{}),
 		gotreply:    make(chan reply),
 		addpending:  make(chan *pending),
 	}
+	for _, id := range cfg.PrivateNodes {
+		udp.private[id] = true
+	}
 	realaddr := c.LocalAddr().(*net.UDPAddr)
 	if cfg.AnnounceAddr != nil {
 		realaddr = cfg.AnnounceAddr
 	}
-	// TODO: separate TCP port
-	udp.ourEndpoint = makeEndpoint(realaddr, uint16(realaddr.Port))
-	tab, err := newTable(udp, PubkeyID(&cfg.PrivateKey.PublicKey), realaddr, cfg.NodeDBPath, cfg.Bootnodes)
+	tab, err := newTable(udp, PubkeyID(&cfg.PrivateKey.PublicKey), realaddr, cfg.NodeDBPath, cfg.NodeDBOptions, cfg.Bootnodes)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -270,11 +276,18 @@ func (t *udp) close() {
 	// TODO: wait for the loops to end.
 }
 
+// ourEndpoint returns the endpoint of the local node as currently announced.
+func (t *udp) ourEndpoint() rpcEndpoint {
+	self := t.Self()
+	// TODO: separate TCP port
+	return makeEndpoint(&net.UDPAddr{IP: self.IP, Port: int(self.UDP)}, self.TCP)
+}
+
 // ping sends a ping message to the given node and waits for a reply.
 func (t *udp) ping(toid NodeID, toaddr *net.UDPAddr) error {
 	req := &ping{
 		Version:    Version,
-		From:       t.ourEndpoint,
+		From:       t.ourEndpoint(),
 		To:         makeEndpoint(toaddr, 0), // TODO: maybe use known TCP port from DB
 		Expiration: uint64(time.Now().Add(expiration).Unix()),
 	}
@@ -537,6 +550,10 @@ func (t *udp) readLoop(unhandled chan<- ReadPacket) {
 }
 
 func (t *udp) handlePacket(from *net.UDPAddr, buf []byte) error {
+	if t.netrestrict != nil && !t.netrestrict.Contains(from.IP) {
+		log.Debugf("Dropped discv4 packet addr %v err %v", from, errNetRestrict)
+		return errNetRestrict
+	}
 	packet, fromID, hash, err := decodePacket(buf)
 	if err != nil {
 		log.Debugf("Bad discv4 packet addr %v err %v", from, err)
@@ -632,6 +649,9 @@ func (req *findnode) handle(t *udp, from *net.UDPAddr, fromID NodeID, mac []byte
 	// Send neighbors in chunks with at most maxNeighbors per packet
 	// to stay below the 1280 byte limit.
 	for _, n := range closest {
+		if t.private[n.ID] {
+			continue
+		}
 		if netutil.CheckRelayIP(from.IP, n.IP) == nil {
 			p.Nodes = append(p.Nodes, nodeToRPC(n))
 		}
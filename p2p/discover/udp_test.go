@@ -142,6 +142,21 @@ func TestUDP_pingTimeout(t *testing.T) {
 	}
 }
 
+func TestUDP_setIP(t *testing.T) {
+	t.Parallel()
+	test := newUDPTest(t)
+	defer test.table.Close()
+
+	ext := net.ParseIP("77.12.33.4")
+	test.table.SetIP(ext)
+	if self := test.table.Self(); !self.IP.Equal(ext) || self.ID != test.table.self.ID {
+		t.Errorf("self is %v, want the external IP", self)
+	}
+	if from := test.udp.ourEndpoint(); !from.IP.Equal(ext) {
+		t.Errorf("pings are sent from %v, want the external IP", from.IP)
+	}
+}
+
 func TestUDP_responseTimeouts(t *testing.T) {
 	t.Parallel()
 	test := newUDPTest(t)
@@ -269,6 +284,36 @@ func TestUDP_findnode(t *testing.T) {
 	waitNeighbors(expected.entries[maxNeighbors:])
 }
 
+func TestUDP_findnodePrivate(t *testing.T) {
+	test := newUDPTest(t)
+	defer test.table.Close()
+
+	targetHash := crypto.Keccak256Hash(testTarget[:])
+	nodes := &nodesByDistance{target: targetHash}
+	for i := 0; i < maxNeighbors; i++ {
+		nodes.push(nodeAtDistance(test.table.self.sha, i+2), bucketSize)
+	}
+	test.table.stuff(nodes.entries)
+	test.table.db.updateBondTime(PubkeyID(&test.remotekey.PublicKey), time.Now())
+
+	expected := test.table.closest(targetHash, bucketSize).entries
+	private := expected[0].ID
+	test.udp.private[private] = true
+
+	// the private node is left out of the neighbors
+	test.packetIn(nil, findnodePacket, &findnode{Target: testTarget, Expiration: futureExp})
+	test.waitPacketOut(func(p *neighbors) {
+		if len(p.Nodes) != len(expected)-1 {
+			t.Errorf("wrong number of results: got %d, want %d", len(p.Nodes), len(expected)-1)
+		}
+		for _, n := range p.Nodes {
+			if n.ID == private {
+				t.Errorf("private node %x returned", private[:8])
+			}
+		}
+	})
+}
+
 func TestUDP_findnodeMultiReply(t *testing.T) {
 	test := newUDPTest(t)
 	defer test.table.Close()
@@ -349,8 +394,8 @@ func TestUDP_successfulPing(t *testing.T) {
 
 	// remote is unknown, the table pings back.
 	hash, _ := test.waitPacketOut(func(p *ping) error {
-		if !reflect.DeepEqual(p.From, test.udp.ourEndpoint) {
-			t.Errorf("got ping.From %v, want %v", p.From, test.udp.ourEndpoint)
+		if !reflect.DeepEqual(p.From, test.udp.ourEndpoint()) {
+			t.Errorf("got ping.From %v, want %v", p.From, test.udp.ourEndpoint())
 		}
 		wantTo := rpcEndpoint{
 			// The mirrored UDP address is the UDP packet sender.
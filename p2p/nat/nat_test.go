@@ -61,3 +61,54 @@ func TestAutoDiscRace(t *testing.T) {
 		}
 	}
 }
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		spec string
+		want string // String of the interface, empty for nil
+		err  bool
+	}{
+		{spec: "", want: ""},
+		{spec: "none", want: ""},
+		{spec: "OFF", want: ""},
+		{spec: "any", want: "UPnP or NAT-PMP"},
+		{spec: "upnp", want: "UPnP"},
+		{spec: "pmp", want: "NAT-PMP"},
+		{spec: "pmp:192.168.0.1", want: "NAT-PMP(192.168.0.1)"},
+		{spec: "extip:77.12.33.4", want: "ExtIP(77.12.33.4)"},
+		{spec: "ExtIP:::1", want: "ExtIP(::1)"},
+		{spec: "extip", err: true},
+		{spec: "extip:300.1.1.1", err: true},
+		{spec: "pmp:gateway", err: true},
+		{spec: "stun", err: true},
+	}
+	for _, test := range tests {
+		n, err := Parse(test.spec)
+		if test.err {
+			if err == nil {
+				t.Errorf("%q: expected an error", test.spec)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", test.spec, err)
+			continue
+		}
+		if n == nil {
+			if test.want != "" {
+				t.Errorf("%q: got nil, want %s", test.spec, test.want)
+			}
+			continue
+		}
+		if n.String() != test.want {
+			t.Errorf("%q: got %s, want %s", test.spec, n.String(), test.want)
+		}
+	}
+
+	// the external IP is returned as given
+	n, _ := Parse("extip:77.12.33.4")
+	ip, err := n.ExternalIP()
+	if err != nil || !ip.Equal(net.ParseIP("77.12.33.4")) {
+		t.Errorf("got external IP %v, %v", ip, err)
+	}
+}
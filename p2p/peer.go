@@ -47,6 +47,7 @@ type Peer struct {
 	runningErr chan error
 	quit       chan string
 	closed     chan struct{}
+	reason     error // the quit reason of either side, set before closed
 	wg         sync.WaitGroup
 }
 
@@ -64,6 +65,17 @@ func (p *Peer) String() string {
 	return fmt.Sprintf("Peer %x(%v)", p.rw.id[:8], p.RemoteAddr())
 }
 
+// Trusted returns whether the remote node is a trusted node, it isn't limited by MaxPeers.
+func (p *Peer) Trusted() bool {
+	return p.rw.is(trustedConn)
+}
+
+// Priority returns whether the remote node is a sentry of this validator or a validator
+// behind this sentry, the blocks and transactions are relayed to it first.
+func (p *Peer) Priority() bool {
+	return p.rw.is(priorityConn)
+}
+
 // Protocols return supported subprotocols of the remote peer.
 func (p *Peer) Protocols() []*ProtocolKey {
 	return p.rw.protocols
@@ -93,6 +105,10 @@ func NewPeer(conn *conn, protocols []*Protocol) *Peer {
 	for _, protocolKey := range conn.protocols {
 		for _, protocol := range protocols {
 			if protocol.Name == protocolKey.Name && protocol.Version == protocolKey.Version {
+				// run the highest version both sides support
+				if proto, ok := running[protocol.Name]; ok && proto.Version > protocol.Version {
+					continue
+				}
 				running[protocol.Name] = &protoRW{
 					Protocol: *protocol,
 					in:       make(chan *Message),
@@ -154,6 +170,7 @@ func (p *Peer) startProtocols() {
 	for _, proto := range p.running {
 		proto := proto
 		proto.closed = p.closed
+		proto.peer = p
 		proto.wstart = wstart
 		go func() {
 			defer p.wg.Done()
@@ -183,6 +200,9 @@ running:
 		}
 	}
 
+	if _, ok := err.(QuitReason); ok {
+		p.reason = err
+	}
 	close(p.closed)
 	p.rw.close(err)
 	p.wg.Wait()
@@ -197,9 +217,9 @@ func (p *Peer) handle(msg *Message) error {
 		}
 	case msg.Code == pongMsg:
 	case msg.Code == quitMsg:
-		var reason string
-		msg.EncodePayload(&reason)
-		return errors.New(reason)
+		var reason QuitReason
+		msg.DecodePayload(&reason)
+		return reason
 	default:
 		proto, err := p.getProto(msg.Code)
 		if err != nil {
@@ -253,6 +273,7 @@ type protoRW struct {
 	w      MsgReadWriter
 	closed <-chan struct{}
 	wstart chan struct{}
+	peer   *Peer
 }
 
 func (rw *protoRW) WriteMsg(msg *Message) (err error) {
@@ -273,6 +294,10 @@ func (rw *protoRW) ReadMsg() (*Message, error) {
 	case msg := <-rw.in:
 		return msg, nil
 	case <-rw.closed:
+		// the protocols learn why the peer disconnected, i.e. the remote rejected the handshake
+		if rw.peer.reason != nil {
+			return nil, rw.peer.reason
+		}
 		return nil, io.EOF
 	}
 }
@@ -0,0 +1,101 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/UranusBlockStack/uranus/common/crypto"
+	"github.com/UranusBlockStack/uranus/p2p/discover"
+)
+
+// testConnPair returns the ends of a connection which passed both handshakes.
+func testConnPair(t *testing.T, protocols []*Protocol) (*conn, *conn) {
+	key0, _ := crypto.GenerateKey()
+	key1, _ := crypto.GenerateKey()
+	fd0, fd1 := net.Pipe()
+	c0, c1 := &conn{fd: fd0}, &conn{fd: fd1, flags: inboundConn}
+
+	hs0 := &ProtoHandshake{Version: baseProtocolVersion, ID: discover.PubkeyID(&key0.PublicKey)}
+	hs1 := &ProtoHandshake{Version: baseProtocolVersion, ID: discover.PubkeyID(&key1.PublicKey)}
+	for _, p := range protocols {
+		hs0.Protocols = append(hs0.Protocols, p.Key())
+		hs1.Protocols = append(hs1.Protocols, p.Key())
+	}
+	errc := make(chan error, 1)
+	go func() {
+		_, err := c0.doEncHandshake(key0, &discover.Node{ID: hs1.ID})
+		if err == nil {
+			var their *ProtoHandshake
+			if their, err = c0.doProtoHandshake(hs0); err == nil {
+				c0.protocols = their.Protocols
+			}
+		}
+		errc <- err
+	}()
+	if _, err := c1.doEncHandshake(key1, nil); err != nil {
+		t.Fatalf("encryption handshake failed: %v", err)
+	}
+	their, err := c1.doProtoHandshake(hs1)
+	if err != nil {
+		t.Fatalf("protocol handshake failed: %v", err)
+	}
+	c1.protocols = their.Protocols
+	if err := <-errc; err != nil {
+		t.Fatalf("handshake failed: %v", err)
+	}
+	return c0, c1
+}
+
+func TestPeerSendsQuitReason(t *testing.T) {
+	proto := &Protocol{
+		Name:    "test",
+		Version: 1,
+		Offset:  16,
+		Size:    16,
+		Run: func(peer *Peer, rw MsgReadWriter) error {
+			return QuitIncompatibleFork
+		},
+	}
+	c0, c1 := testConnPair(t, []*Protocol{proto})
+	if !c0.rw.snappy || !c1.rw.snappy {
+		t.Fatal("messages aren't compressed")
+	}
+	go NewPeer(c0, []*Protocol{proto}).run()
+
+	// the reason of the protocol is sent to the remote before the connection is closed
+	c1.fd.SetDeadline(time.Now().Add(5 * time.Second))
+	for {
+		msg, err := c1.rw.ReadMsg()
+		if err != nil {
+			t.Fatalf("no quit message: %v", err)
+		}
+		if msg.Code != quitMsg {
+			continue
+		}
+		var reason QuitReason
+		if err := msg.DecodePayload(&reason); err != nil {
+			t.Fatalf("invalid quit message: %v", err)
+		}
+		if reason != QuitIncompatibleFork {
+			t.Fatalf("quit reason %v, want %v", reason, QuitIncompatibleFork)
+		}
+		return
+	}
+}
@@ -21,12 +21,16 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	ldb "github.com/UranusBlockStack/uranus/common/db"
 	"github.com/UranusBlockStack/uranus/common/log"
 	"github.com/UranusBlockStack/uranus/p2p/discover"
+	"github.com/UranusBlockStack/uranus/p2p/nat"
+	"github.com/UranusBlockStack/uranus/p2p/netutil"
 )
 
 const (
@@ -34,10 +38,20 @@ const (
 	connWriteTimeout      = 20 * time.Second
 	defaultMaxPeers       = 100
 	defaultMaxAcceptConns = 50
+	trustedAcceptConns    = 5 // accepted beyond MaxAcceptConns, kept only for the trusted nodes
 	defaultDialTimeout    = 15 * time.Second
 )
 
-var errServerExit = errors.New("server exit")
+var (
+	errServerExit     = errors.New("server exit")
+	errTooManyPeers   = errors.New("too many peers")
+	errAlreadyConnect = errors.New("already connected")
+	errSelf           = errors.New("connected to self")
+	errNetRestrict    = errors.New("not contained in netrestrict whitelist")
+	errBanned         = errors.New("banned peer")
+	errNotSentry      = errors.New("not a sentry node")
+	errNotTrusted     = errors.New("not a trusted node")
+)
 
 // Config server options.
 type Config struct {
@@ -51,6 +65,35 @@ type Config struct {
 	ListenAddr     string `mapstructure:"p2p-listenaddr"`
 	Protocols      []*Protocol
 	NodeDatabase   string `mapstructure:"p2p-nodes"`
+	NATSpec        string `mapstructure:"p2p-nat"` // any, none, upnp, pmp, pmp:<IP> or extip:<IP>
+	NAT            nat.Interface
+
+	// tuning of the persistent node database, nil for the defaults
+	NodeDatabaseOptions *ldb.Options `mapstructure:"-"`
+
+	// static nodes are always redialed, trusted nodes are accepted beyond MaxPeers
+	StaticNodeStrs  []string `mapstructure:"p2p-staticnodes"`
+	StaticNodes     []*discover.Node
+	TrustedNodeStrs []string `mapstructure:"p2p-trustednodes"`
+	TrustedNodes    []*discover.Node
+
+	// only the nodes in the CIDR masks are connected and discovered if it is set
+	NetRestrictStrs []string `mapstructure:"p2p-netrestrict"`
+	NetRestrict     *netutil.Netlist
+
+	// a validator with sentry nodes disables discovery and only connects to its sentries,
+	// a sentry keeps its private nodes (the validators behind it) out of discovery
+	SentryNodeStrs  []string `mapstructure:"p2p-sentrynodes"`
+	SentryNodes     []*discover.Node
+	PrivateNodeStrs []string `mapstructure:"p2p-privatenodes"`
+	PrivateNodes    []*discover.Node
+
+	// NoDiscovery disables the node table, only the static nodes are connected
+	NoDiscovery bool
+	// Dialer connects to the nodes, TCP if nil
+	Dialer Dialer
+	// Listener accepts the inbound connections instead of listening on ListenAddr if set
+	Listener net.Listener
 }
 
 type peerOpFunc func(map[discover.NodeID]*Peer)
@@ -68,8 +111,13 @@ type Server struct {
 
 	ntab         *discover.Table
 	listener     net.Listener
+	extIP        net.IP // external IP of the NAT, nil if it's unknown
 	ourHandshake *ProtoHandshake
 	lastLookup   time.Time
+	trusted      map[discover.NodeID]bool
+	sentries     map[discover.NodeID]bool
+	priority     map[discover.NodeID]bool // sentry and private nodes
+	bans         *banList
 
 	posthandshake chan *conn
 	addpeer       chan *conn
@@ -101,8 +149,137 @@ func (srv *Server) Start() (err error) {
 	srv.peerOpDone = make(chan struct{})
 	srv.addnode = make(chan *discover.Node)
 	srv.removenode = make(chan *discover.Node)
-	srv.dialer = &TCPDialer{&net.Dialer{Timeout: defaultDialTimeout}}
+	srv.dialer = srv.Config.Dialer
+	if srv.dialer == nil {
+		srv.dialer = &TCPDialer{&net.Dialer{Timeout: defaultDialTimeout}}
+	}
+	srv.trusted = make(map[discover.NodeID]bool)
+	srv.sentries = make(map[discover.NodeID]bool)
+	srv.priority = make(map[discover.NodeID]bool)
+	for _, n := range srv.TrustedNodes {
+		srv.trusted[n.ID] = true
+	}
+	for _, nodes := range [][]*discover.Node{srv.SentryNodes, srv.PrivateNodes} {
+		for _, n := range nodes {
+			srv.trusted[n.ID] = true
+			srv.priority[n.ID] = true
+		}
+	}
+	for _, n := range srv.SentryNodes {
+		srv.sentries[n.ID] = true
+	}
+	srv.bans = newBanList()
+
+	if srv.NAT != nil && srv.loopback() {
+		// nothing outside of the host can reach a loopback listener
+		srv.NAT = nil
+	}
+
+	var dialerTasks *DialerManager
+	switch {
+	case srv.sentryMode():
+		log.Infof("Sentry mode, discovery is disabled, connecting to %d sentries", len(srv.SentryNodes))
+		dialerTasks = NewDialerManager(srv.MaxPeers, nil, nil)
+		for _, n := range srv.SentryNodes {
+			dialerTasks.AddStatic(n)
+		}
+	case srv.NoDiscovery:
+		log.Infof("Discovery is disabled, connecting to %d static nodes", len(srv.StaticNodes))
+		dialerTasks = NewDialerManager(srv.MaxPeers, nil, nil)
+		for _, n := range srv.StaticNodes {
+			dialerTasks.AddStatic(n)
+		}
+	default:
+		if err := srv.setupDiscovery(); err != nil {
+			return err
+		}
+		dialerTasks = NewDialerManager(srv.MaxPeers, srv.BootNodes, srv.ntab)
+		for _, n := range srv.StaticNodes {
+			dialerTasks.AddStatic(n)
+		}
+		srv.bans.setStore(srv.ntab)
+	}
+	dialerTasks.bans = srv.bans
+
+	srv.ourHandshake = &ProtoHandshake{Version: baseProtocolVersion, Name: srv.Name, ID: discover.PubkeyID(&srv.PrivateKey.PublicKey)}
+	for _, p := range srv.Protocols {
+		srv.ourHandshake.Protocols = append(srv.ourHandshake.Protocols, p.Key())
+	}
+	if srv.Listener != nil || srv.ListenAddr != "" {
+		if err := srv.setupListening(); err != nil {
+			return err
+		}
+	}
+
+	if srv.NAT != nil {
+		srv.wg.Add(1)
+		go srv.resolveExtIP()
+	}
+
+	srv.wg.Add(1)
+	go srv.run(dialerTasks)
+	srv.running = true
+	return nil
+}
+
+// loopback reports whether the server only listens on a loopback address.
+func (srv *Server) loopback() bool {
+	if srv.Listener != nil {
+		addr, ok := srv.Listener.Addr().(*net.TCPAddr)
+		return ok && addr.IP.IsLoopback()
+	}
+	addr, err := net.ResolveTCPAddr("tcp", srv.ListenAddr)
+	return err == nil && addr.IP.IsLoopback()
+}
 
+// resolveExtIP asks the NAT for the external IP, which may take a while,
+// and advertises it by discovery and Self once it's known.
+func (srv *Server) resolveExtIP() {
+	defer srv.wg.Done()
+	ext, err := srv.NAT.ExternalIP()
+	if err != nil {
+		log.Warnf("Failed to get external IP via %v: %v", srv.NAT, err)
+		return
+	}
+	select {
+	case <-srv.quit:
+		return
+	default:
+	}
+	log.Infof("Advertising external IP %v via %v", ext, srv.NAT)
+	srv.Lock()
+	defer srv.Unlock()
+	srv.extIP = ext
+	if srv.ntab != nil {
+		srv.ntab.SetIP(ext)
+	}
+}
+
+// setupListening accepts the inbound connections on the Listener or the TCP port of ListenAddr.
+func (srv *Server) setupListening() error {
+	listener := srv.Listener
+	if listener == nil {
+		var err error
+		if listener, err = net.Listen("tcp", srv.ListenAddr); err != nil {
+			return err
+		}
+	}
+	srv.ListenAddr = listener.Addr().String()
+	srv.listener = listener
+	if laddr, ok := listener.Addr().(*net.TCPAddr); ok && srv.NAT != nil && !laddr.IP.IsLoopback() {
+		srv.wg.Add(1)
+		go func() {
+			nat.Map(srv.NAT, srv.quit, "tcp", laddr.Port, laddr.Port, "uranus p2p")
+			srv.wg.Done()
+		}()
+	}
+	srv.wg.Add(1)
+	go srv.listenLoop()
+	return nil
+}
+
+// setupDiscovery starts the node table listening on the UDP port of ListenAddr.
+func (srv *Server) setupDiscovery() error {
 	var (
 		conn     *net.UDPConn
 		realaddr *net.UDPAddr
@@ -117,6 +294,13 @@ func (srv *Server) Start() (err error) {
 		return err
 	}
 	realaddr = conn.LocalAddr().(*net.UDPAddr)
+	if srv.NAT != nil && !realaddr.IP.IsLoopback() {
+		srv.wg.Add(1)
+		go func() {
+			nat.Map(srv.NAT, srv.quit, "udp", realaddr.Port, realaddr.Port, "uranus discovery")
+			srv.wg.Done()
+		}()
+	}
 
 	// node table
 
@@ -126,34 +310,19 @@ func (srv *Server) Start() (err error) {
 		Bootnodes:    srv.BootNodes,
 		Unhandled:    make(chan discover.ReadPacket, 100),
 		NodeDBPath:   srv.Config.NodeDatabase,
+		NetRestrict:  srv.NetRestrict,
+	}
+	if srv.Config.NodeDatabaseOptions != nil {
+		cfg.NodeDBOptions = srv.Config.NodeDatabaseOptions.LevelDB()
+	}
+	for _, n := range srv.PrivateNodes {
+		cfg.PrivateNodes = append(cfg.PrivateNodes, n.ID)
 	}
 	ntab, err := discover.ListenUDP(conn, cfg)
 	if err != nil {
 		return err
 	}
 	srv.ntab = ntab
-
-	dialerTasks := NewDialerManager(srv.MaxPeers, srv.BootNodes, srv.ntab)
-
-	srv.ourHandshake = &ProtoHandshake{Version: 0, Name: srv.Name, ID: discover.PubkeyID(&srv.PrivateKey.PublicKey)}
-	for _, p := range srv.Protocols {
-		srv.ourHandshake.Protocols = append(srv.ourHandshake.Protocols, p.Key())
-	}
-	if srv.ListenAddr != "" {
-		listener, err := net.Listen("tcp", srv.ListenAddr)
-		if err != nil {
-			return err
-		}
-		laddr := listener.Addr().(*net.TCPAddr)
-		srv.ListenAddr = laddr.String()
-		srv.listener = listener
-		srv.wg.Add(1)
-		go srv.listenLoop()
-	}
-
-	srv.wg.Add(1)
-	go srv.run(dialerTasks)
-	srv.running = true
 	return nil
 }
 
@@ -209,6 +378,62 @@ func (srv *Server) RemovePeer(node *discover.Node) {
 	}
 }
 
+// BanPeer disconnects the node and refuses its connections for the given duration,
+// the ban is kept in the node database across restarts if the discovery is enabled.
+func (srv *Server) BanPeer(id discover.NodeID, duration time.Duration) error {
+	if !srv.isRunning() {
+		return errServerExit
+	}
+	if err := srv.bans.ban(id, time.Now().Add(duration)); err != nil {
+		return err
+	}
+	log.Infof("Banned node %x for %v", id[:8], duration)
+	select {
+	case srv.peerOp <- func(peers map[discover.NodeID]*Peer) {
+		if p, ok := peers[id]; ok {
+			p.Disconnect("banned")
+		}
+	}:
+		<-srv.peerOpDone
+	case <-srv.quit:
+	}
+	return nil
+}
+
+// UnbanPeer lifts the ban of the node.
+func (srv *Server) UnbanPeer(id discover.NodeID) error {
+	if !srv.isRunning() {
+		return errServerExit
+	}
+	return srv.bans.unban(id)
+}
+
+// BanInfo represents a node banned from connecting.
+type BanInfo struct {
+	ID    string    `json:"id"`
+	Until time.Time `json:"until"`
+}
+
+// Bans returns the nodes which are banned at the moment.
+func (srv *Server) Bans() []*BanInfo {
+	if !srv.isRunning() {
+		return nil
+	}
+	bans := srv.bans.active()
+	infos := make([]*BanInfo, 0, len(bans))
+	for id, until := range bans {
+		infos = append(infos, &BanInfo{ID: id.String(), Until: until})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Until.Before(infos[j].Until) })
+	return infos
+}
+
+func (srv *Server) isRunning() bool {
+	srv.Lock()
+	defer srv.Unlock()
+	return srv.running
+}
+
 func (srv *Server) Self() *discover.Node {
 	srv.Lock()
 	defer srv.Unlock()
@@ -221,10 +446,17 @@ func (srv *Server) Self() *discover.Node {
 		if srv.listener == nil {
 			return &discover.Node{IP: net.ParseIP("0.0.0.0"), ID: discover.PubkeyID(&srv.PrivateKey.PublicKey)}
 		}
-		addr := srv.listener.Addr().(*net.TCPAddr)
+		addr, ok := srv.listener.Addr().(*net.TCPAddr)
+		if !ok {
+			return &discover.Node{IP: net.ParseIP("0.0.0.0"), ID: discover.PubkeyID(&srv.PrivateKey.PublicKey)}
+		}
+		ip := addr.IP
+		if srv.extIP != nil && !ip.IsLoopback() {
+			ip = srv.extIP
+		}
 		return &discover.Node{
 			ID:  discover.PubkeyID(&srv.PrivateKey.PublicKey),
-			IP:  addr.IP,
+			IP:  ip,
 			TCP: uint16(addr.Port),
 		}
 	}
@@ -355,15 +587,42 @@ func (srv *Server) protoHandshakeChecks(peers map[discover.NodeID]*Peer, c *conn
 
 func (srv *Server) encHandshakeChecks(peers map[discover.NodeID]*Peer, c *conn) error {
 	switch {
+	case srv.sentryMode() && !srv.sentries[c.id]:
+		return errNotSentry
+	case srv.NetRestrict != nil && !srv.NetRestrict.Contains(remoteIP(c.fd)):
+		return errNetRestrict
+	case !c.is(trustedConn|staticDialedConn) && len(peers) >= srv.maxPeers():
+		return errTooManyPeers
+	case srv.bans.banned(c.id):
+		return errBanned
 	case peers[c.id] != nil:
-		return errors.New("already connected")
+		return errAlreadyConnect
 	case c.id == srv.Self().ID:
-		return errors.New("connected to self")
+		return errSelf
 	default:
 		return nil
 	}
 }
 
+// sentryMode reports whether the node is a validator hidden behind sentry nodes.
+func (srv *Server) sentryMode() bool {
+	return len(srv.SentryNodes) > 0
+}
+
+func (srv *Server) maxPeers() int {
+	if srv.MaxPeers > 0 {
+		return srv.MaxPeers
+	}
+	return defaultMaxPeers
+}
+
+func remoteIP(fd net.Conn) net.IP {
+	if addr, ok := fd.RemoteAddr().(*net.TCPAddr); ok {
+		return addr.IP
+	}
+	return nil
+}
+
 type tempError interface {
 	Temporary() bool
 }
@@ -380,8 +639,27 @@ func (srv *Server) listenLoop() {
 		slots <- struct{}{}
 	}
 
+	// the trusted nodes are only known by their id after the encryption handshake,
+	// they get a few slots of their own when the others are taken
+	var trustedSlots chan struct{}
+	if len(srv.trusted) > 0 {
+		trustedSlots = make(chan struct{}, trustedAcceptConns)
+		for i := 0; i < trustedAcceptConns; i++ {
+			trustedSlots <- struct{}{}
+		}
+	}
+
 	for {
-		<-slots
+		flags := inboundConn
+		select {
+		case <-slots:
+		default:
+			select {
+			case <-slots:
+			case <-trustedSlots:
+				flags |= reservedConn
+			}
+		}
 		var (
 			fd  net.Conn
 			err error
@@ -396,21 +674,33 @@ func (srv *Server) listenLoop() {
 			break
 		}
 
+		release := slots
+		if flags&reservedConn != 0 {
+			release = trustedSlots
+		}
+		if ip := remoteIP(fd); srv.NetRestrict != nil && !srv.NetRestrict.Contains(ip) {
+			log.Debugf("Rejected inbound connection %v: %v", fd.RemoteAddr(), errNetRestrict)
+			fd.Close()
+			release <- struct{}{}
+			continue
+		}
 		go func() {
-			srv.SetupConn(fd, nil)
-			slots <- struct{}{}
+			srv.SetupConn(fd, flags, nil)
+			release <- struct{}{}
 		}()
 	}
 }
 
-func (srv *Server) SetupConn(fd net.Conn, dest *discover.Node) error {
+// SetupConn runs the handshakes and adds the connection as a peer if it passes the checks.
+func (srv *Server) SetupConn(fd net.Conn, flags connFlag, dest *discover.Node) error {
 	self := srv.Self()
 	if self == nil {
 		return errors.New("shutdown")
 	}
-	c := &conn{fd: fd, cont: make(chan error)}
+	c := &conn{fd: fd, flags: flags, cont: make(chan error)}
 	err := srv.setupConn(c, dest)
 	if err != nil {
+		log.Debugf("Setting up connection with %v failed --- %v", fd.RemoteAddr(), err)
 		c.close(err)
 	}
 	return err
@@ -431,6 +721,15 @@ func (srv *Server) setupConn(c *conn, dest *discover.Node) error {
 	if dest != nil && c.id != dest.ID {
 		return fmt.Errorf("unexpected identity")
 	}
+	if c.is(reservedConn) && !srv.trusted[c.id] {
+		return errNotTrusted
+	}
+	if srv.trusted[c.id] {
+		c.flags |= trustedConn
+	}
+	if srv.priority[c.id] {
+		c.flags |= priorityConn
+	}
 	err = srv.checkpoint(c, srv.posthandshake)
 	if err != nil {
 		return err
@@ -0,0 +1,334 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/UranusBlockStack/uranus/common/crypto"
+	"github.com/UranusBlockStack/uranus/p2p/discover"
+	"github.com/UranusBlockStack/uranus/p2p/nat"
+	"github.com/UranusBlockStack/uranus/p2p/netutil"
+)
+
+func startTestServer(t *testing.T, config Config) *Server {
+	if config.PrivateKey == nil {
+		config.PrivateKey, _ = crypto.GenerateKey()
+	}
+	config.Name = "test"
+	config.NoDiscovery = true
+	srv := &Server{Config: config}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("could not start server: %v", err)
+	}
+	return srv
+}
+
+func TestServerSelfExtIP(t *testing.T) {
+	// the external IP is advertised without discovery once it's known
+	srv := startTestServer(t, Config{ListenAddr: "0.0.0.0:0", NAT: nat.ExtIP(net.ParseIP("77.12.33.4"))})
+	defer srv.Stop()
+	deadline := time.Now().Add(time.Second)
+	for !srv.Self().IP.Equal(net.ParseIP("77.12.33.4")) {
+		if time.Now().After(deadline) {
+			t.Fatalf("advertised IP %v, want the external IP", srv.Self().IP)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if srv.Self().TCP == 0 {
+		t.Error("advertised TCP port is 0")
+	}
+
+	// but not for a loopback listener
+	local := startTestServer(t, Config{ListenAddr: "127.0.0.1:0", NAT: nat.ExtIP(net.ParseIP("77.12.33.4"))})
+	defer local.Stop()
+	if self := local.Self(); !self.IP.IsLoopback() {
+		t.Errorf("advertised IP %v, want the loopback IP", self.IP)
+	}
+}
+
+// slowNAT blocks all requests until release is closed.
+type slowNAT struct {
+	release chan struct{}
+	calls   chan string
+}
+
+func (n *slowNAT) AddMapping(protocol string, extport, intport int, name string, lifetime time.Duration) error {
+	n.calls <- "AddMapping"
+	<-n.release
+	return nil
+}
+
+func (n *slowNAT) DeleteMapping(protocol string, extport, intport int) error {
+	return nil
+}
+
+func (n *slowNAT) ExternalIP() (net.IP, error) {
+	n.calls <- "ExternalIP"
+	<-n.release
+	return net.ParseIP("77.12.33.4"), nil
+}
+
+func (n *slowNAT) String() string { return "slow" }
+
+func TestServerStartSlowNAT(t *testing.T) {
+	// a loopback listener never asks the NAT
+	loopNAT := &slowNAT{release: make(chan struct{}), calls: make(chan string, 10)}
+	local := startTestServer(t, Config{ListenAddr: "127.0.0.1:0", NAT: loopNAT})
+	local.Stop()
+	close(loopNAT.release)
+	if len(loopNAT.calls) != 0 {
+		t.Errorf("NAT called %d times for a loopback listener", len(loopNAT.calls))
+	}
+
+	// the start doesn't wait for the external IP
+	slow := &slowNAT{release: make(chan struct{}), calls: make(chan string, 10)}
+	srv := startTestServer(t, Config{ListenAddr: "0.0.0.0:0", NAT: slow})
+	defer srv.Stop()
+	if self := srv.Self(); self.IP.Equal(net.ParseIP("77.12.33.4")) {
+		t.Errorf("advertised IP %v before the NAT answered", self.IP)
+	}
+	close(slow.release)
+	deadline := time.Now().Add(time.Second)
+	for !srv.Self().IP.Equal(net.ParseIP("77.12.33.4")) {
+		if time.Now().After(deadline) {
+			t.Fatalf("advertised IP %v, want the external IP", srv.Self().IP)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// testNode returns the node of the key at the address of the server.
+func testNode(key *ecdsa.PrivateKey, srv *Server) *discover.Node {
+	node := &discover.Node{ID: discover.PubkeyID(&key.PublicKey), IP: net.ParseIP("127.0.0.1")}
+	if srv != nil {
+		node.TCP = uint16(srv.listener.Addr().(*net.TCPAddr).Port)
+	}
+	return node
+}
+
+type testClients []*Server
+
+func (clients testClients) stop() {
+	for _, srv := range clients {
+		srv.Stop()
+	}
+}
+
+// connect dials the server from a new server of the key and runs the handshakes,
+// the dialing server is kept running until the clients are stopped.
+func (clients *testClients) connect(t *testing.T, key *ecdsa.PrivateKey, to *Server) error {
+	from := startTestServer(t, Config{PrivateKey: key})
+	*clients = append(*clients, from)
+	fd, err := net.Dial("tcp", to.ListenAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dest := testNode(to.PrivateKey, to)
+	if err := from.SetupConn(fd, 0, dest); err != nil {
+		return err
+	}
+	// the handshake passed on our side, wait for the peer to be added on the other one
+	for i := 0; i < 100; i++ {
+		for _, p := range to.Peers() {
+			if p.ID() == discover.PubkeyID(&key.PublicKey) {
+				return nil
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return errors.New("peer not added")
+}
+
+func findPeer(srv *Server, key *ecdsa.PrivateKey) *Peer {
+	for _, p := range srv.Peers() {
+		if p.ID() == discover.PubkeyID(&key.PublicKey) {
+			return p
+		}
+	}
+	return nil
+}
+
+func newKeys(n int) []*ecdsa.PrivateKey {
+	keys := make([]*ecdsa.PrivateKey, n)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+	}
+	return keys
+}
+
+func TestServerTrustedPeers(t *testing.T) {
+	keys := newKeys(3)
+	trusted, first, second := keys[0], keys[1], keys[2]
+	srv := startTestServer(t, Config{ListenAddr: "127.0.0.1:0", MaxPeers: 1, TrustedNodes: []*discover.Node{testNode(trusted, nil)}})
+	defer srv.Stop()
+	clients := new(testClients)
+	defer clients.stop()
+
+	if err := clients.connect(t, first, srv); err != nil {
+		t.Fatalf("first peer rejected: %v", err)
+	}
+	// the trusted node is known by its id, not the IP it shares with the others
+	if err := clients.connect(t, second, srv); err == nil {
+		t.Fatal("peer accepted beyond MaxPeers")
+	}
+	if err := clients.connect(t, trusted, srv); err != nil {
+		t.Fatalf("trusted peer rejected: %v", err)
+	}
+	if p := findPeer(srv, trusted); p == nil || !p.Trusted() || p.Priority() {
+		t.Fatalf("trusted peer flags are wrong: %v", p)
+	}
+	if count := srv.PeerCount(); count != 2 {
+		t.Fatalf("peer count %d, want 2", count)
+	}
+}
+
+func TestServerTrustedAcceptSlots(t *testing.T) {
+	keys := newKeys(2)
+	trusted, other := keys[0], keys[1]
+	srv := startTestServer(t, Config{ListenAddr: "127.0.0.1:0", MaxAcceptConns: 1, TrustedNodes: []*discover.Node{testNode(trusted, nil)}})
+	defer srv.Stop()
+	clients := new(testClients)
+	defer clients.stop()
+
+	// a connection stuck in the handshake takes the only slot
+	stuck, err := net.Dial("tcp", srv.ListenAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stuck.Close()
+
+	if err := clients.connect(t, other, srv); err == nil {
+		t.Fatal("peer accepted on a slot reserved for the trusted nodes")
+	}
+	if err := clients.connect(t, trusted, srv); err != nil {
+		t.Fatalf("trusted peer rejected: %v", err)
+	}
+}
+
+func TestDialerManagerStatic(t *testing.T) {
+	keys := newKeys(2)
+	boot, static := testNode(keys[0], nil), testNode(keys[1], nil)
+	dm := NewDialerManager(10, []*discover.Node{boot}, nil)
+	dm.AddStatic(static)
+	for _, task := range dm.NewTasks(0, nil) {
+		dt := task.(*DialerTask)
+		// only the static nodes are dialed beyond MaxPeers
+		if want := dt.dest.ID == static.ID; dt.static != want {
+			t.Errorf("task for %v static %v, want %v", dt.dest.ID, dt.static, want)
+		}
+	}
+}
+
+func TestServerNetRestrictTrusted(t *testing.T) {
+	trusted := newKeys(1)[0]
+	restrict, _ := netutil.ParseNetlist("10.0.0.0/8")
+	srv := startTestServer(t, Config{ListenAddr: "127.0.0.1:0", NetRestrict: restrict, TrustedNodes: []*discover.Node{testNode(trusted, nil)}})
+	defer srv.Stop()
+	clients := new(testClients)
+	defer clients.stop()
+
+	// the trusted nodes are still restricted
+	if err := clients.connect(t, trusted, srv); err == nil {
+		t.Fatal("trusted peer accepted outside NetRestrict")
+	}
+	if count := srv.PeerCount(); count != 0 {
+		t.Fatalf("peer count %d, want 0", count)
+	}
+}
+
+func TestServerSentryPeers(t *testing.T) {
+	keys := newKeys(4)
+	validator, sentry, other, third := keys[0], keys[1], keys[2], keys[3]
+
+	// the validator only accepts its sentry
+	srv := startTestServer(t, Config{PrivateKey: validator, ListenAddr: "127.0.0.1:0", SentryNodes: []*discover.Node{testNode(sentry, nil)}})
+	defer srv.Stop()
+	clients := new(testClients)
+	defer clients.stop()
+	if err := clients.connect(t, other, srv); err == nil {
+		t.Fatal("validator accepted a node which isn't its sentry")
+	}
+	if err := clients.connect(t, sentry, srv); err != nil {
+		t.Fatalf("sentry rejected: %v", err)
+	}
+	if p := findPeer(srv, sentry); p == nil || !p.Trusted() || !p.Priority() {
+		t.Fatalf("sentry peer flags are wrong: %v", p)
+	}
+
+	// the sentry accepts its validator beyond MaxPeers
+	sentrySrv := startTestServer(t, Config{PrivateKey: sentry, ListenAddr: "127.0.0.1:0", MaxPeers: 1, PrivateNodes: []*discover.Node{testNode(validator, nil)}})
+	defer sentrySrv.Stop()
+	if err := clients.connect(t, other, sentrySrv); err != nil {
+		t.Fatalf("first peer rejected: %v", err)
+	}
+	if err := clients.connect(t, third, sentrySrv); err == nil {
+		t.Fatal("peer accepted beyond MaxPeers")
+	}
+	if err := clients.connect(t, validator, sentrySrv); err != nil {
+		t.Fatalf("private node rejected: %v", err)
+	}
+	if p := findPeer(sentrySrv, validator); p == nil || !p.Trusted() || !p.Priority() {
+		t.Fatalf("private peer flags are wrong: %v", p)
+	}
+}
+
+func TestServerBanWithoutDiscovery(t *testing.T) {
+	keys := newKeys(2)
+	banned, other := keys[0], keys[1]
+	srv := startTestServer(t, Config{ListenAddr: "127.0.0.1:0"})
+	defer srv.Stop()
+	clients := new(testClients)
+	defer clients.stop()
+
+	if err := clients.connect(t, banned, srv); err != nil {
+		t.Fatalf("peer rejected: %v", err)
+	}
+	id := discover.PubkeyID(&banned.PublicKey)
+	if err := srv.BanPeer(id, time.Hour); err != nil {
+		t.Fatalf("ban failed: %v", err)
+	}
+	if bans := srv.Bans(); len(bans) != 1 || bans[0].ID != id.String() {
+		t.Fatalf("bans %v, want the banned node", bans)
+	}
+	for i := 0; srv.PeerCount() != 0; i++ {
+		if i == 100 {
+			t.Fatal("banned peer isn't disconnected")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := clients.connect(t, banned, srv); err == nil {
+		t.Fatal("banned peer accepted")
+	}
+	if err := clients.connect(t, other, srv); err != nil {
+		t.Fatalf("peer rejected: %v", err)
+	}
+
+	if err := srv.UnbanPeer(id); err != nil {
+		t.Fatalf("unban failed: %v", err)
+	}
+	if bans := srv.Bans(); len(bans) != 0 {
+		t.Fatalf("bans %v, want none", bans)
+	}
+	if err := clients.connect(t, banned, srv); err != nil {
+		t.Fatalf("unbanned peer rejected: %v", err)
+	}
+}
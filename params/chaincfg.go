@@ -19,6 +19,7 @@ package params
 import (
 	"encoding/json"
 	"math/big"
+	"sort"
 	"time"
 )
 
@@ -32,6 +33,37 @@ type ChainConfig struct {
 	MinStartQuantity *big.Int `json:"startQuantity"`
 	MaxVotes         uint64   `json:"votes"`
 	DelayDuration    *big.Int `json:"refund"`
+
+	BaseFeeHeight *big.Int `json:"baseFeeHeight,omitempty"` // dynamic base fee switch height (nil = no fork)
+	FeeTreasury   string   `json:"feeTreasury,omitempty"`   // receiver of the base fee (empty = burn)
+}
+
+// IsBaseFee returns whether height is either equal to the base fee fork height or greater.
+func (c *ChainConfig) IsBaseFee(height *big.Int) bool {
+	if c.BaseFeeHeight == nil || height == nil {
+		return false
+	}
+	return c.BaseFeeHeight.Cmp(height) <= 0
+}
+
+// ForkHeights returns the sorted heights of the configured forks, the forks active from
+// the genesis are left out.
+func (c *ChainConfig) ForkHeights() []uint64 {
+	var heights []uint64
+	for _, height := range []*big.Int{c.BaseFeeHeight} {
+		if height != nil && height.Sign() > 0 {
+			heights = append(heights, height.Uint64())
+		}
+	}
+	sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+
+	forks := heights[:0]
+	for _, height := range heights {
+		if len(forks) == 0 || forks[len(forks)-1] != height {
+			forks = append(forks, height)
+		}
+	}
+	return forks
 }
 
 // String implements fmt.Stringer.
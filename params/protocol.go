@@ -31,6 +31,13 @@ const (
 	MinGasLimit uint64 = 1000000
 	//GenesisGasLimit Gas limit of the Genesis block.
 	GenesisGasLimit uint64 = 5000000
+
+	// BaseFeeChangeDenominator Bounds the amount the base fee can change between blocks.
+	BaseFeeChangeDenominator uint64 = 8
+	// ElasticityMultiplier Bounds the maximum gas limit a block may have relative to the gas target.
+	ElasticityMultiplier uint64 = 2
+	// InitialBaseFee Base fee of the first block after the base fee fork.
+	InitialBaseFee uint64 = 1000000000
 	// TxGas Per transaction not creating a contract. NOTE: Not payable on data of calls between transactions.
 	TxGas uint64 = 21000
 	// TxGasContractCreation Per transaction that creates a contract. NOTE: Not payable on data of calls between transactions.
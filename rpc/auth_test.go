@@ -0,0 +1,81 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJWTToken(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	now := time.Now()
+
+	token := NewJWTToken(secret, now)
+	assert.NoError(t, verifyJWTToken(secret, token, now))
+	assert.NoError(t, verifyJWTToken(secret, token, now.Add(30*time.Second)))
+	assert.Equal(t, errStaleToken, verifyJWTToken(secret, token, now.Add(2*time.Minute)))
+	assert.Equal(t, errStaleToken, verifyJWTToken(secret, token, now.Add(-2*time.Minute)))
+	assert.Equal(t, errInvalidToken, verifyJWTToken([]byte("other secret"), token, now))
+	assert.Equal(t, errInvalidToken, verifyJWTToken(secret, token+"x", now))
+	assert.Equal(t, errInvalidToken, verifyJWTToken(secret, "abc", now))
+}
+
+func TestJWTHTTPClient(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	server := NewServer()
+	server.Register(new(Arith))
+	ts := httptest.NewServer(newHTTPHandler(server, nil, secret))
+	defer ts.Close()
+
+	resp, err := http.Post(ts.URL, "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	var result int
+	client, _ := DialHTTP(ts.URL)
+	assert.Error(t, client.Call("Arith.Multiply", Args{A: 2, B: 3}, &result))
+
+	client, _ = DialHTTPWithJWT(ts.URL, secret)
+	assert.NoError(t, client.Call("Arith.Multiply", Args{A: 2, B: 3}, &result))
+	assert.Equal(t, 600, result)
+}
+
+func TestFilterAPIs(t *testing.T) {
+	apis := []API{
+		{Namespace: "Uranus"},
+		{Namespace: "Wallet"},
+		{Namespace: "eth", Ethereum: true},
+		{Namespace: "net", Ethereum: true},
+	}
+	filtered := filterAPIs(apis, false, []string{"uranus", "Admin"})
+	assert.Equal(t, 1, len(filtered))
+	assert.Equal(t, "Uranus", filtered[0].Namespace)
+
+	filtered = filterAPIs(apis, true, []string{"eth", "Uranus"})
+	assert.Equal(t, 1, len(filtered))
+	assert.Equal(t, "eth", filtered[0].Namespace)
+
+	assert.Equal(t, 0, len(filterAPIs(apis, false, nil)))
+}
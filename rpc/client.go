@@ -305,6 +305,11 @@ func Dial(network, address string) (*Client, error) {
 	return NewClient(conn), nil
 }
 
+// DialIPC connects to an RPC server over the unix domain socket at path.
+func DialIPC(path string) (*Client, error) {
+	return Dial("unix", path)
+}
+
 // Close calls the underlying codec's Close method. If the connection is already
 // shutting down, ErrShutdown is returned.
 func (client *Client) Close() error {
@@ -18,9 +18,12 @@ package rpc
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/rs/cors"
 )
@@ -48,10 +51,16 @@ type httpClient struct {
 	resp       chan *http.Response
 	remainResp *http.Response
 	canRead    bool
+	jwtSecret  []byte
 }
 
 // DialHTTP creates a new RPC clients that connection to an RPC server over HTTP.
 func DialHTTP(url string) (*Client, error) {
+	return DialHTTPWithJWT(url, nil)
+}
+
+// DialHTTPWithJWT is like DialHTTP but authenticates every request with a JWT token signed by jwtSecret.
+func DialHTTPWithJWT(url string, jwtSecret []byte) (*Client, error) {
 	client := new(http.Client)
 
 	req, err := http.NewRequest("POST", url, nil)
@@ -61,17 +70,25 @@ func DialHTTP(url string) (*Client, error) {
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
 
-	return NewClient(&httpClient{client, req, make(chan *http.Response), &http.Response{}, false}), nil
+	return NewClient(&httpClient{client, req, make(chan *http.Response), &http.Response{}, false, jwtSecret}), nil
 }
 
 // Write implements io.Writer interface.
 func (c *httpClient) Write(d []byte) (n int, err error) {
 	c.req.ContentLength = int64(len(d))
 	c.req.Body = ioutil.NopCloser(bytes.NewReader(d))
+	if len(c.jwtSecret) != 0 {
+		c.req.Header.Set("Authorization", "Bearer "+NewJWTToken(c.jwtSecret, time.Now()))
+	}
 	resp, err := c.client.Do(c.req)
 	if err != nil {
 		return 0, err
 	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		return 0, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
 
 	c.resp <- resp
 	return len(d), nil
@@ -105,7 +122,15 @@ func (c *httpClient) Close() error {
 	return nil
 }
 
-func newCorsHandler(srv *Server, allowedOrigins []string) http.Handler {
+// newHTTPHandler wraps srv with the JWT authentication and the CORS handler.
+func newHTTPHandler(srv http.Handler, allowedOrigins []string, jwtSecret []byte) http.Handler {
+	if len(jwtSecret) != 0 {
+		srv = newJWTHandler(jwtSecret, srv)
+	}
+	return newCorsHandler(srv, allowedOrigins)
+}
+
+func newCorsHandler(srv http.Handler, allowedOrigins []string) http.Handler {
 	// disable CORS support if user has not specified a custom CORS configuration
 	if len(allowedOrigins) == 0 {
 		return srv
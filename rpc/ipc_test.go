@@ -0,0 +1,78 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIPC(t *testing.T) {
+	dir, err := ioutil.TempDir("", "uranus-ipc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "uranus.ipc")
+
+	listener, _, err := StartIPC(path, []API{{Namespace: "Arith", Service: new(Arith)}, {Namespace: "calc", Service: new(calcService), Ethereum: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	client, err := DialIPC(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	var result int
+	assert.NoError(t, client.Call("Arith.Multiply", Args{A: 2, B: 3}, &result))
+	assert.Equal(t, 600, result)
+}
+
+func TestIPCRemovedOnClose(t *testing.T) {
+	dir, err := ioutil.TempDir("", "uranus-ipc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "uranus.ipc")
+
+	listener, _, err := StartIPC(path, []API{{Namespace: "Arith", Service: new(Arith)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// only the socket is left in the directory
+	files, _ := ioutil.ReadDir(dir)
+	if assert.Len(t, files, 1) {
+		assert.Equal(t, "uranus.ipc", files[0].Name())
+	}
+	listener.Close()
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
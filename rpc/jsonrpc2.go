@@ -0,0 +1,278 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/UranusBlockStack/uranus/common/log"
+)
+
+const jsonrpcVersion = "2.0"
+
+// JSON-RPC 2.0 error codes
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+	CodeServerError    = -32000
+)
+
+// Error is a JSON-RPC 2.0 error object, a method may return it to choose the error code.
+type Error struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string { return e.Message }
+
+type jsonrpcMessage struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+func (msg *jsonrpcMessage) isNotification() bool { return len(msg.ID) == 0 }
+
+type jsonrpcResult struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result"`
+}
+
+type callback struct {
+	rcvr     reflect.Value
+	method   reflect.Method
+	argTypes []reflect.Type
+	hasValue bool // method returns (value, error) instead of error
+}
+
+// JSONRPC2Server serves the methods of the registered services as "namespace_method"
+// with positional params, compatible with the ethereum JSON-RPC API.
+type JSONRPC2Server struct {
+	mu        sync.RWMutex
+	callbacks map[string]*callback
+}
+
+// NewJSONRPC2Server returns a new JSONRPC2Server.
+func NewJSONRPC2Server() *JSONRPC2Server {
+	return &JSONRPC2Server{callbacks: make(map[string]*callback)}
+}
+
+// RegisterName publishes the exported methods of rcvr which return error or (value, error).
+// Method Foo of rcvr is served as "namespace_foo".
+func (s *JSONRPC2Server) RegisterName(namespace string, rcvr interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	typ := reflect.TypeOf(rcvr)
+	registered := 0
+	for m := 0; m < typ.NumMethod(); m++ {
+		method := typ.Method(m)
+		if method.PkgPath != "" {
+			continue
+		}
+		mtype := method.Type
+		cb := &callback{rcvr: reflect.ValueOf(rcvr), method: method}
+		switch {
+		case mtype.NumOut() == 1 && mtype.Out(0) == typeOfError:
+		case mtype.NumOut() == 2 && mtype.Out(1) == typeOfError:
+			cb.hasValue = true
+		default:
+			continue
+		}
+		for i := 1; i < mtype.NumIn(); i++ {
+			cb.argTypes = append(cb.argTypes, mtype.In(i))
+		}
+		name := namespace + "_" + lowerFirst(method.Name)
+		if _, present := s.callbacks[name]; present {
+			return errors.New("rpc: method already defined: " + name)
+		}
+		s.callbacks[name] = cb
+		registered++
+	}
+	if registered == 0 {
+		return fmt.Errorf("rpc: type %v has no exported methods of suitable type", typ)
+	}
+	return nil
+}
+
+// ServeHTTP implements an http.Handler that answers JSON-RPC 2.0 single and batch requests.
+func (s *JSONRPC2Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if req.ContentLength > maxHTTPRequestContentLength {
+		http.Error(w,
+			fmt.Sprintf("content length too large (%d>%d)", req.ContentLength, maxHTTPRequestContentLength),
+			http.StatusRequestEntityTooLarge)
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, req.Body, maxHTTPRequestContentLength))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	if resp := s.Handle(body); resp != nil {
+		w.Write(resp)
+	}
+}
+
+// Handle answers a raw JSON-RPC 2.0 request, it returns nil if no response is needed.
+func (s *JSONRPC2Server) Handle(body []byte) []byte {
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(body, &batch); err != nil {
+			return marshalResponse(errorMessage(nil, &Error{Code: CodeParseError, Message: err.Error()}))
+		}
+		if len(batch) == 0 {
+			return marshalResponse(errorMessage(nil, &Error{Code: CodeInvalidRequest, Message: "empty batch"}))
+		}
+		var resps []interface{}
+		for _, raw := range batch {
+			if resp := s.handleRaw(raw); resp != nil {
+				resps = append(resps, resp)
+			}
+		}
+		if len(resps) == 0 {
+			return nil
+		}
+		return marshalResponse(resps)
+	}
+	if resp := s.handleRaw(body); resp != nil {
+		return marshalResponse(resp)
+	}
+	return nil
+}
+
+func (s *JSONRPC2Server) handleRaw(raw json.RawMessage) interface{} {
+	msg := new(jsonrpcMessage)
+	if err := json.Unmarshal(raw, msg); err != nil {
+		if _, ok := err.(*json.SyntaxError); ok {
+			return errorMessage(nil, &Error{Code: CodeParseError, Message: err.Error()})
+		}
+		return errorMessage(nil, &Error{Code: CodeInvalidRequest, Message: err.Error()})
+	}
+	if msg.Version != jsonrpcVersion || msg.Method == "" {
+		return errorMessage(msg.ID, &Error{Code: CodeInvalidRequest, Message: "invalid request"})
+	}
+	result, rpcErr := s.call(msg)
+	if msg.isNotification() {
+		return nil
+	}
+	if rpcErr != nil {
+		return errorMessage(msg.ID, rpcErr)
+	}
+	return &jsonrpcResult{Version: jsonrpcVersion, ID: msg.ID, Result: result}
+}
+
+func (s *JSONRPC2Server) call(msg *jsonrpcMessage) (result interface{}, rpcErr *Error) {
+	s.mu.RLock()
+	cb := s.callbacks[msg.Method]
+	s.mu.RUnlock()
+	if cb == nil {
+		return nil, &Error{Code: CodeMethodNotFound, Message: fmt.Sprintf("the method %s does not exist/is not available", msg.Method)}
+	}
+	args, err := parsePositionalArgs(msg.Params, cb.argTypes)
+	if err != nil {
+		return nil, &Error{Code: CodeInvalidParams, Message: err.Error()}
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("RPC method %s crashed: %v", msg.Method, r)
+			result, rpcErr = nil, &Error{Code: CodeInternalError, Message: "method handler crashed"}
+		}
+	}()
+	outs := cb.method.Func.Call(append([]reflect.Value{cb.rcvr}, args...))
+	if errv := outs[len(outs)-1]; !errv.IsNil() {
+		err := errv.Interface().(error)
+		if e, ok := err.(*Error); ok {
+			return nil, e
+		}
+		return nil, &Error{Code: CodeServerError, Message: err.Error()}
+	}
+	if cb.hasValue {
+		return outs[0].Interface(), nil
+	}
+	return nil, nil
+}
+
+// parsePositionalArgs decodes the params array into values of the given types,
+// missing trailing params of pointer type are passed as nil.
+func parsePositionalArgs(params json.RawMessage, types []reflect.Type) ([]reflect.Value, error) {
+	var raws []json.RawMessage
+	if len(params) > 0 && string(params) != "null" {
+		if err := json.Unmarshal(params, &raws); err != nil {
+			return nil, errors.New("non-array params")
+		}
+	}
+	if len(raws) > len(types) {
+		return nil, fmt.Errorf("too many arguments, want at most %d", len(types))
+	}
+	args := make([]reflect.Value, 0, len(types))
+	for i, typ := range types {
+		if i >= len(raws) || string(raws[i]) == "null" {
+			if typ.Kind() != reflect.Ptr && i >= len(raws) {
+				return nil, fmt.Errorf("missing value for required argument %d", i)
+			}
+			args = append(args, reflect.Zero(typ))
+			continue
+		}
+		val := reflect.New(typ)
+		if err := json.Unmarshal(raws[i], val.Interface()); err != nil {
+			return nil, fmt.Errorf("invalid argument %d: %v", i, err)
+		}
+		args = append(args, val.Elem())
+	}
+	return args, nil
+}
+
+func errorMessage(id json.RawMessage, err *Error) *jsonrpcMessage {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	return &jsonrpcMessage{Version: jsonrpcVersion, ID: id, Error: err}
+}
+
+func marshalResponse(v interface{}) []byte {
+	resp, err := json.Marshal(v)
+	if err != nil {
+		resp, _ = json.Marshal(errorMessage(nil, &Error{Code: CodeInternalError, Message: err.Error()}))
+	}
+	return resp
+}
+
+func lowerFirst(name string) string {
+	return strings.ToLower(name[:1]) + name[1:]
+}
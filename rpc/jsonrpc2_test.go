@@ -0,0 +1,104 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type calcService struct{}
+
+func (s *calcService) Add(a, b int) (int, error) { return a + b, nil }
+
+func (s *calcService) Inc(a int, step *int) (int, error) {
+	if step == nil {
+		return a + 1, nil
+	}
+	return a + *step, nil
+}
+
+func (s *calcService) Fail() error { return errors.New("failed") }
+
+func (s *calcService) Revert() error { return &Error{Code: 3, Message: "reverted", Data: "0x01"} }
+
+func (s *calcService) Crash() (int, error) { panic("crash") }
+
+func newTestJSONRPC2Server(t *testing.T) *JSONRPC2Server {
+	server := NewJSONRPC2Server()
+	if err := server.RegisterName("calc", new(calcService)); err != nil {
+		t.Fatal(err)
+	}
+	return server
+}
+
+func TestJSONRPC2Call(t *testing.T) {
+	server := newTestJSONRPC2Server(t)
+	tests := []struct {
+		req, resp string
+	}{
+		{`{"jsonrpc":"2.0","id":1,"method":"calc_add","params":[1,2]}`, `{"jsonrpc":"2.0","id":1,"result":3}`},
+		{`{"jsonrpc":"2.0","id":"a","method":"calc_inc","params":[1]}`, `{"jsonrpc":"2.0","id":"a","result":2}`},
+		{`{"jsonrpc":"2.0","id":2,"method":"calc_inc","params":[1,5]}`, `{"jsonrpc":"2.0","id":2,"result":6}`},
+		{`{"jsonrpc":"2.0","id":3,"method":"calc_fail"}`, `{"jsonrpc":"2.0","id":3,"error":{"code":-32000,"message":"failed"}}`},
+		{`{"jsonrpc":"2.0","id":4,"method":"calc_revert"}`, `{"jsonrpc":"2.0","id":4,"error":{"code":3,"message":"reverted","data":"0x01"}}`},
+		{`{"jsonrpc":"2.0","id":5,"method":"calc_crash"}`, `{"jsonrpc":"2.0","id":5,"error":{"code":-32603,"message":"method handler crashed"}}`},
+		{`{"jsonrpc":"2.0","id":6,"method":"calc_sub"}`, `{"jsonrpc":"2.0","id":6,"error":{"code":-32601,"message":"the method calc_sub does not exist/is not available"}}`},
+		{`{"jsonrpc":"2.0","id":7,"method":"calc_add","params":[1]}`, `{"jsonrpc":"2.0","id":7,"error":{"code":-32602,"message":"missing value for required argument 1"}}`},
+		{`{"jsonrpc":"2.0","id":8,"method":"calc_add","params":[1,"2"]}`, `{"jsonrpc":"2.0","id":8,"error":{"code":-32602,"message":"invalid argument 1: json: cannot unmarshal string into Go value of type int"}}`},
+		{`{"id":9,"method":"calc_add","params":[1,2]}`, `{"jsonrpc":"2.0","id":9,"error":{"code":-32600,"message":"invalid request"}}`},
+		{`{"jsonrpc":"2.0","id":10`, `{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"unexpected end of JSON input"}}`},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.resp, string(server.Handle([]byte(test.req))), test.req)
+	}
+}
+
+func TestJSONRPC2Batch(t *testing.T) {
+	server := newTestJSONRPC2Server(t)
+	resp := server.Handle([]byte(`[{"jsonrpc":"2.0","id":1,"method":"calc_add","params":[1,2]},{"jsonrpc":"2.0","method":"calc_add","params":[1,2]},{"jsonrpc":"2.0","id":2,"method":"calc_fail"}]`))
+	assert.Equal(t, `[{"jsonrpc":"2.0","id":1,"result":3},{"jsonrpc":"2.0","id":2,"error":{"code":-32000,"message":"failed"}}]`, string(resp))
+
+	assert.Nil(t, server.Handle([]byte(`[{"jsonrpc":"2.0","method":"calc_add","params":[1,2]}]`)))
+	assert.Equal(t, `{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"empty batch"}}`, string(server.Handle([]byte(`[]`))))
+}
+
+func TestJSONRPC2HTTP(t *testing.T) {
+	server := newTestJSONRPC2Server(t)
+	ts := httptest.NewServer(newCorsHandler(server, []string{"*"}))
+	defer ts.Close()
+
+	resp, err := http.Post(ts.URL, "application/json", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"calc_add","params":[1,2]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("content-type"))
+
+	resp, err = http.Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
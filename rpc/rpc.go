@@ -17,17 +17,45 @@
 package rpc
 
 import (
+	"io/ioutil"
 	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/UranusBlockStack/uranus/common/log"
 )
 
-// StartRPCAndHTTP start RPC and HTTP service
-func StartRPCAndHTTP(endpoint string, apis []API, cors []string) (net.Listener, *Server, error) {
+// StartRPCAndHTTP start RPC and HTTP service with the APIs allowed by modules,
+// requests must carry a JWT token signed by jwtSecret if it isn't empty.
+func StartRPCAndHTTP(endpoint string, apis []API, modules []string, cors []string, jwtSecret []byte) (net.Listener, *Server, error) {
 	var (
 		listener net.Listener
 		err      error
 		server   = NewServer()
 	)
-	for _, api := range apis {
+	for _, api := range filterAPIs(apis, false, modules) {
+		if err := server.RegisterName(api.Namespace, api.Service); err != nil {
+			return nil, nil, err
+		}
+	}
+	if listener, err = net.Listen("tcp", endpoint); err != nil {
+		return nil, nil, err
+	}
+	go (&http.Server{Handler: newHTTPHandler(server, cors, jwtSecret)}).Serve(listener)
+
+	return listener, server, nil
+}
+
+// StartEthRPC start the ethereum compatible JSON-RPC 2.0 service over HTTP
+func StartEthRPC(endpoint string, apis []API, modules []string, cors []string, jwtSecret []byte) (net.Listener, *JSONRPC2Server, error) {
+	var (
+		listener net.Listener
+		err      error
+		server   = NewJSONRPC2Server()
+	)
+	for _, api := range filterAPIs(apis, true, modules) {
 		if err := server.RegisterName(api.Namespace, api.Service); err != nil {
 			return nil, nil, err
 		}
@@ -35,7 +63,90 @@ func StartRPCAndHTTP(endpoint string, apis []API, cors []string) (net.Listener,
 	if listener, err = net.Listen("tcp", endpoint); err != nil {
 		return nil, nil, err
 	}
-	go NewHTTPServer(server, cors).Serve(listener)
+	go (&http.Server{Handler: newHTTPHandler(server, cors, jwtSecret)}).Serve(listener)
+
+	return listener, server, nil
+}
+
+// StartIPC start RPC service with all the native APIs over a unix domain socket.
+func StartIPC(path string, apis []API) (net.Listener, *Server, error) {
+	var (
+		listener net.Listener
+		err      error
+		server   = NewServer()
+	)
+	for _, api := range apis {
+		if api.Ethereum {
+			continue
+		}
+		if err := server.RegisterName(api.Namespace, api.Service); err != nil {
+			return nil, nil, err
+		}
+	}
+	// remove the stale socket of a previous run
+	os.Remove(path)
+	if listener, err = listenIPC(path); err != nil {
+		return nil, nil, err
+	}
+	go server.Accept(listener)
 
 	return listener, server, nil
 }
+
+// ipcListener removes the socket on close, the listener only knows the path it was
+// created at.
+type ipcListener struct {
+	net.Listener
+	path string
+}
+
+func (l *ipcListener) Close() error {
+	err := l.Listener.Close()
+	os.Remove(l.path)
+	return err
+}
+
+// listenIPC creates the socket in a private directory, restricts it to the owner and
+// moves it to the path, so it's never reachable by the others.
+func listenIPC(path string) (net.Listener, error) {
+	dir, err := ioutil.TempDir(filepath.Dir(path), ".ipc")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	tmp := filepath.Join(dir, filepath.Base(path))
+	listener, err := net.Listen("unix", tmp)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(tmp, 0600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return &ipcListener{Listener: listener, path: path}, nil
+}
+
+// filterAPIs returns the APIs of the endpoint whose namespace is in modules.
+func filterAPIs(apis []API, ethereum bool, modules []string) []API {
+	allowed := make(map[string]bool)
+	for _, module := range modules {
+		allowed[strings.ToLower(module)] = true
+	}
+	var result []API
+	for _, api := range apis {
+		if api.Ethereum != ethereum {
+			continue
+		}
+		if !allowed[strings.ToLower(api.Namespace)] {
+			log.Debugf("RPC namespace %s is not exposed", api.Namespace)
+			continue
+		}
+		result = append(result, api)
+	}
+	return result
+}
@@ -20,4 +20,5 @@ type API struct {
 	Namespace string
 	Version   string
 	Service   interface{}
+	Ethereum  bool // served on the ethereum compatible JSON-RPC 2.0 endpoint
 }
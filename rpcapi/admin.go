@@ -16,7 +16,12 @@
 
 package rpcapi
 
-import "github.com/UranusBlockStack/uranus/p2p"
+import (
+	"time"
+
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/p2p"
+)
 
 // AdminAPI exposes methods for the RPC interface
 type AdminAPI struct {
@@ -53,3 +58,49 @@ func (api *AdminAPI) NodeInfo(ignore string, reply **p2p.NodeInfo) (err error) {
 	*reply, err = api.b.NodeInfo()
 	return err
 }
+
+// BanPeerArgs is the node, given by its enode url or id, and the ban duration in seconds.
+type BanPeerArgs struct {
+	Node     string
+	Duration uint64
+}
+
+// BanPeer disconnects the node and refuses its connections for the duration, 24 hours if it isn't given.
+func (api *AdminAPI) BanPeer(args BanPeerArgs, reply *bool) error {
+	duration := 24 * time.Hour
+	if args.Duration > 0 {
+		duration = time.Duration(args.Duration) * time.Second
+	}
+	err := api.b.BanPeer(args.Node, duration)
+	*reply = err == nil
+	return err
+}
+
+// UnbanPeer lifts the ban of the node, given by its enode url or id.
+func (api *AdminAPI) UnbanPeer(node string, reply *bool) error {
+	err := api.b.UnbanPeer(node)
+	*reply = err == nil
+	return err
+}
+
+// ListBans retrieves the nodes which are banned at the moment.
+func (api *AdminAPI) ListBans(ignore string, reply *[]*p2p.BanInfo) (err error) {
+	*reply, err = api.b.Bans()
+	return err
+}
+
+// SetHeadArgs is the height the chain is rewound to, forced below the confirmed height.
+type SetHeadArgs struct {
+	Height uint64
+	Force  bool
+}
+
+// SetHead rewinds the canonical chain to the height and returns the hash of the new head.
+func (api *AdminAPI) SetHead(args SetHeadArgs, reply *utils.Hash) error {
+	head, err := api.b.SetHead(args.Height, args.Force)
+	if err != nil {
+		return err
+	}
+	*reply = head.Hash()
+	return nil
+}
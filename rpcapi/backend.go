@@ -19,12 +19,14 @@ package rpcapi
 import (
 	"context"
 	"math/big"
+	"time"
 
 	"github.com/UranusBlockStack/uranus/common/utils"
 	"github.com/UranusBlockStack/uranus/core"
 	"github.com/UranusBlockStack/uranus/core/state"
 	"github.com/UranusBlockStack/uranus/core/types"
 	"github.com/UranusBlockStack/uranus/core/vm"
+	"github.com/UranusBlockStack/uranus/node/protocols"
 	"github.com/UranusBlockStack/uranus/p2p"
 	"github.com/UranusBlockStack/uranus/wallet"
 )
@@ -41,6 +43,8 @@ type Backend interface {
 	GetLogs(ctx context.Context, blockHash utils.Hash) ([][]*types.Log, error)
 	GetTd(blockHash utils.Hash) *big.Int
 	GetTransaction(txHash utils.Hash) *types.StorageTx
+	Syncing() (protocols.SyncProgress, bool)
+	SetHead(height uint64, force bool) (*types.Block, error)
 	// txpool backend
 	SendTx(ctx context.Context, signedTx *types.Transaction) error
 	GetPoolTransactions() (types.Transactions, error)
@@ -59,6 +63,7 @@ type Backend interface {
 
 	// forecast backend
 	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
 	// evm
 	GetEVM(ctx context.Context, from utils.Address, tx *types.Transaction, state *state.StateDB, bheader *types.BlockHeader, vmCfg vm.Config) (*vm.EVM, func() error, error)
 
@@ -67,6 +72,9 @@ type Backend interface {
 	RemovePeer(url string) error
 	Peers() ([]*p2p.PeerInfo, error)
 	NodeInfo() (*p2p.NodeInfo, error)
+	BanPeer(node string, duration time.Duration) error
+	UnbanPeer(node string) error
+	Bans() ([]*p2p.BanInfo, error)
 
 	//miner
 	Start(int32) error
@@ -19,6 +19,7 @@ package rpcapi
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/UranusBlockStack/uranus/common/utils"
 	"github.com/UranusBlockStack/uranus/core/types"
@@ -34,6 +35,33 @@ func NewBlockChainAPI(b Backend) *BlockChainAPI {
 	return &BlockChainAPI{b}
 }
 
+// SyncStatus is the progress of the block synchronisation.
+type SyncStatus struct {
+	Syncing         bool          `json:"syncing"`
+	StartingBlock   utils.Uint64  `json:"startingBlock"`
+	CurrentBlock    utils.Uint64  `json:"currentBlock"`
+	HighestBlock    utils.Uint64  `json:"highestBlock"`
+	PendingBlocks   utils.Uint64  `json:"pendingBlocks"`
+	ProcessedBlocks utils.Uint64  `json:"processedBlocks"`
+	Elapsed         time.Duration `json:"elapsed"`
+}
+
+// Syncing returns the progress of the block synchronisation, the last one if the node is
+// not syncing at the moment.
+func (s *BlockChainAPI) Syncing(ignore string, reply *SyncStatus) error {
+	progress, syncing := s.b.Syncing()
+	*reply = SyncStatus{
+		Syncing:         syncing,
+		StartingBlock:   utils.Uint64(progress.StartingBlock),
+		CurrentBlock:    utils.Uint64(progress.CurrentBlock),
+		HighestBlock:    utils.Uint64(progress.HighestBlock),
+		PendingBlocks:   utils.Uint64(progress.PendingBlocks),
+		ProcessedBlocks: utils.Uint64(progress.ProcessedBlocks),
+		Elapsed:         progress.Elapsed,
+	}
+	return nil
+}
+
 type GetBlockByHeightArgs struct {
 	BlockHeight *BlockHeight
 	FullTx      bool
@@ -141,6 +169,56 @@ func (s *BlockChainAPI) GetTransactionReceipt(Hash utils.Hash, reply *map[string
 	return nil
 }
 
+// GetTransactionsByAddressArgs selects a page of the transactions of the address from the
+// height, the cursor returned by the previous page overrides the height.
+type GetTransactionsByAddressArgs struct {
+	Address    utils.Address
+	FromHeight utils.Uint64
+	Limit      int
+	Cursor     utils.Bytes
+	FullTx     bool
+}
+
+// AddressTransaction is a transaction of an address, the transaction of a dpos action is
+// the one which delayed the action.
+type AddressTransaction struct {
+	BlockHeight utils.Uint64    `json:"blockHeight"`
+	Index       utils.Uint64    `json:"index"`
+	Hash        utils.Hash      `json:"hash"`
+	Transaction *RPCTransaction `json:"transaction,omitempty"`
+}
+
+// AddressTransactions is a page of the transactions of an address.
+type AddressTransactions struct {
+	Transactions []*AddressTransaction `json:"transactions"`
+	Next         utils.Bytes           `json:"next,omitempty"`
+}
+
+// GetTransactionsByAddress returns a page of the transactions sent, received, created or
+// acted by the address from the address index, the next page starts at the returned cursor.
+func (s *BlockChainAPI) GetTransactionsByAddress(args GetTransactionsByAddressArgs, reply *AddressTransactions) error {
+	txs, next, err := s.b.BlockChain().GetTransactionsByAddress(args.Address, uint64(args.FromHeight), args.Limit, args.Cursor)
+	if err != nil {
+		return err
+	}
+	result := AddressTransactions{Transactions: make([]*AddressTransaction, 0, len(txs)), Next: next}
+	for _, tx := range txs {
+		atx := &AddressTransaction{
+			BlockHeight: utils.Uint64(tx.BlockHeight),
+			Index:       utils.Uint64(tx.Index),
+			Hash:        tx.TxHash,
+		}
+		if args.FullTx {
+			if stx := s.b.GetTransaction(tx.TxHash); stx != nil {
+				atx.Transaction = newRPCTransaction(stx.Tx, stx.BlockHash, stx.BlockHeight, stx.TxIndex)
+			}
+		}
+		result.Transactions = append(result.Transactions, atx)
+	}
+	*reply = result
+	return nil
+}
+
 func (s *BlockChainAPI) rpcOutputBlock(b *types.Block, inclTx bool, fullTx bool) (map[string]interface{}, error) {
 	fields, err := RPCMarshalBlock(b, inclTx, fullTx)
 	if err != nil {
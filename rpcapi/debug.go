@@ -0,0 +1,142 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpcapi
+
+import (
+	"context"
+
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/consensus/dpos"
+	"github.com/UranusBlockStack/uranus/core/state"
+	"github.com/UranusBlockStack/uranus/core/types"
+)
+
+const (
+	maxDumpAccounts = 256  // accounts returned at most by a page of DumpState
+	maxStorageRange = 1024 // slots returned at most by a page of StorageRangeAt
+)
+
+// DebugAPI exposes methods inspecting the historical state for the RPC interface
+type DebugAPI struct {
+	b Backend
+}
+
+// NewDebugAPI creates a new API definition for the debug methods of the node itself.
+func NewDebugAPI(b Backend) *DebugAPI {
+	return &DebugAPI{b}
+}
+
+func (api *DebugAPI) stateAt(height *BlockHeight) (*state.StateDB, *types.Block, error) {
+	h := LatestBlockHeight
+	if height != nil {
+		h = *height
+	}
+	block, err := api.b.BlockByHeight(context.Background(), h)
+	if err != nil {
+		return nil, nil, err
+	}
+	if block == nil {
+		return nil, nil, errBlockNotFound
+	}
+	statedb, err := api.b.BlockChain().StateAt(block.StateRoot())
+	return statedb, block, err
+}
+
+// DumpStateArgs selects a page of the accounts from the hashed key start or a single account.
+type DumpStateArgs struct {
+	BlockHeight *BlockHeight
+	Address     *utils.Address
+	Start       utils.Bytes
+	Max         int
+}
+
+// StateDump is a page of the accounts at a block, the dpos context is only dumped in the
+// first page.
+type StateDump struct {
+	Height   *utils.Big          `json:"height"`
+	Root     utils.Hash          `json:"root"`
+	Accounts []state.DumpAccount `json:"accounts"`
+	Next     utils.Bytes         `json:"next,omitempty"`
+	Dpos     *dpos.ContextDump   `json:"dpos,omitempty"`
+}
+
+// DumpState retrieves a page of the accounts and the dpos context at specified block, the
+// next page starts at the returned next key.
+func (api *DebugAPI) DumpState(args DumpStateArgs, reply *StateDump) error {
+	statedb, block, err := api.stateAt(args.BlockHeight)
+	if err != nil {
+		return err
+	}
+	result := StateDump{
+		Height:   (*utils.Big)(block.Height()),
+		Root:     block.StateRoot(),
+		Accounts: []state.DumpAccount{},
+	}
+	if args.Address != nil {
+		account, ok, err := statedb.DumpAddress(*args.Address)
+		if err != nil {
+			return err
+		}
+		if ok {
+			result.Accounts = append(result.Accounts, account)
+		}
+		*reply = result
+		return nil
+	}
+
+	max := args.Max
+	if max <= 0 || max > maxDumpAccounts {
+		max = maxDumpAccounts
+	}
+	if result.Accounts, result.Next, err = statedb.DumpRange(args.Start, max); err != nil {
+		return err
+	}
+	if len(args.Start) == 0 {
+		dposContext, err := types.NewDposContextFromProto(statedb.Database().TrieDB(), block.BlockHeader().DposContext)
+		if err != nil {
+			return err
+		}
+		if result.Dpos, err = dpos.DumpContext(dposContext, statedb); err != nil {
+			return err
+		}
+	}
+	*reply = result
+	return nil
+}
+
+// StorageRangeArgs selects a page of the storage of the account from the hashed key start.
+type StorageRangeArgs struct {
+	BlockHeight *BlockHeight
+	Address     utils.Address
+	Start       utils.Hash
+	Max         int
+}
+
+// StorageRangeAt retrieves a page of the storage of the account at specified block, the next
+// page starts at the returned next key.
+func (api *DebugAPI) StorageRangeAt(args StorageRangeArgs, reply *state.StorageRange) (err error) {
+	statedb, _, err := api.stateAt(args.BlockHeight)
+	if err != nil {
+		return err
+	}
+	max := args.Max
+	if max <= 0 || max > maxStorageRange {
+		max = maxStorageRange
+	}
+	*reply, err = statedb.StorageRangeAt(args.Address, args.Start.Bytes(), max)
+	return err
+}
@@ -0,0 +1,756 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpcapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/UranusBlockStack/uranus/common/crypto"
+	"github.com/UranusBlockStack/uranus/common/log"
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/core/executor"
+	"github.com/UranusBlockStack/uranus/core/state"
+	"github.com/UranusBlockStack/uranus/core/types"
+	"github.com/UranusBlockStack/uranus/core/vm"
+	"github.com/UranusBlockStack/uranus/params"
+	"github.com/UranusBlockStack/uranus/rpc"
+)
+
+const (
+	ethCallTimeout     = 5 * time.Second
+	maxLogsBlockRange  = 10000
+	ethRevertErrorCode = 3
+)
+
+var (
+	errBlockNotFound     = errors.New("block not found")
+	errLogsRangeTooLarge = errors.New("block range too large")
+)
+
+// EthAPI exposes the ethereum compatible "eth" namespace over the JSON-RPC 2.0 endpoint.
+type EthAPI struct {
+	b Backend
+}
+
+// NewEthAPI creates a new RPC service with methods compatible with the ethereum eth namespace.
+func NewEthAPI(b Backend) *EthAPI {
+	return &EthAPI{b}
+}
+
+// ChainId returns the chain id used to sign ethereum transactions.
+func (s *EthAPI) ChainId() (*utils.Big, error) {
+	return (*utils.Big)(new(big.Int).Set(s.b.BlockChain().Config().ChainID)), nil
+}
+
+// BlockNumber returns the height of the current block.
+func (s *EthAPI) BlockNumber() (utils.Uint64, error) {
+	return utils.Uint64(s.b.CurrentBlock().Height().Uint64()), nil
+}
+
+// GasPrice returns the suggested gas price.
+func (s *EthAPI) GasPrice() (*utils.Big, error) {
+	price, err := s.b.SuggestGasPrice(context.Background())
+	return (*utils.Big)(price), err
+}
+
+// MaxPriorityFeePerGas returns the suggested priority fee of dynamic fee transaction.
+func (s *EthAPI) MaxPriorityFeePerGas() (*utils.Big, error) {
+	tip, err := s.b.SuggestGasTipCap(context.Background())
+	return (*utils.Big)(tip), err
+}
+
+// GetBalance returns the balance of the address at the given block.
+func (s *EthAPI) GetBalance(addr utils.Address, height *BlockHeight) (*utils.Big, error) {
+	state, _, err := s.stateAt(height)
+	if err != nil {
+		return nil, err
+	}
+	return (*utils.Big)(state.GetBalance(addr)), nil
+}
+
+// GetTransactionCount returns the nonce of the address at the given block.
+func (s *EthAPI) GetTransactionCount(addr utils.Address, height *BlockHeight) (utils.Uint64, error) {
+	if height != nil && *height == PendingBlockHeight {
+		nonce, err := s.b.GetPoolNonce(context.Background(), addr)
+		return utils.Uint64(nonce), err
+	}
+	state, _, err := s.stateAt(height)
+	if err != nil {
+		return 0, err
+	}
+	return utils.Uint64(state.GetNonce(addr)), nil
+}
+
+// GetCode returns the contract code of the address at the given block.
+func (s *EthAPI) GetCode(addr utils.Address, height *BlockHeight) (utils.Bytes, error) {
+	state, _, err := s.stateAt(height)
+	if err != nil {
+		return nil, err
+	}
+	return state.GetCode(addr), nil
+}
+
+// GetStorageAt returns the storage of the address at the given key and block.
+func (s *EthAPI) GetStorageAt(addr utils.Address, key string, height *BlockHeight) (utils.Bytes, error) {
+	state, _, err := s.stateAt(height)
+	if err != nil {
+		return nil, err
+	}
+	value := state.GetState(addr, utils.HexToHash(key))
+	return value[:], nil
+}
+
+// GetBlockByNumber returns the block of the given height, null if not found.
+func (s *EthAPI) GetBlockByNumber(height BlockHeight, fullTx bool) (map[string]interface{}, error) {
+	block, err := s.b.BlockByHeight(context.Background(), height)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, nil
+	}
+	return s.marshalBlock(block, fullTx), nil
+}
+
+// GetBlockByHash returns the block of the given hash, null if not found.
+func (s *EthAPI) GetBlockByHash(hash utils.Hash, fullTx bool) (map[string]interface{}, error) {
+	block, err := s.b.BlockByHash(context.Background(), hash)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, nil
+	}
+	return s.marshalBlock(block, fullTx), nil
+}
+
+// GetTransactionByHash returns the transaction of the given hash, null if not found.
+func (s *EthAPI) GetTransactionByHash(hash utils.Hash) (*EthRPCTransaction, error) {
+	if stx := s.b.GetTransaction(hash); stx != nil {
+		return s.newTransaction(stx.Tx, stx.BlockHash, stx.BlockHeight, stx.TxIndex), nil
+	}
+	if tx := s.b.GetPoolTransaction(hash); tx != nil {
+		return s.newTransaction(tx, utils.Hash{}, 0, 0), nil
+	}
+	return nil, nil
+}
+
+// GetTransactionReceipt returns the receipt of the given transaction hash, null if not found.
+func (s *EthAPI) GetTransactionReceipt(hash utils.Hash) (map[string]interface{}, error) {
+	stx := s.b.GetTransaction(hash)
+	if stx == nil {
+		return nil, nil
+	}
+	receipt, err := s.b.GetReceipt(context.Background(), hash)
+	if err != nil || receipt == nil {
+		return nil, err
+	}
+	var baseFee *big.Int
+	if block, _ := s.b.BlockByHash(context.Background(), stx.BlockHash); block != nil {
+		baseFee = block.BaseFee()
+	}
+	from, _ := stx.Tx.Sender(types.Signer{})
+	logs := make([]*EthRPCLog, len(receipt.Logs))
+	for i, l := range receipt.Logs {
+		logs[i] = newEthRPCLog(l, stx.BlockHash, stx.BlockHeight)
+	}
+	fields := map[string]interface{}{
+		"transactionHash":   hash,
+		"transactionIndex":  utils.Uint64(stx.TxIndex),
+		"blockHash":         stx.BlockHash,
+		"blockNumber":       utils.Uint64(stx.BlockHeight),
+		"from":              from,
+		"to":                ethTo(stx.Tx),
+		"cumulativeGasUsed": utils.Uint64(receipt.CumulativeGasUsed),
+		"gasUsed":           utils.Uint64(receipt.GasUsed),
+		"effectiveGasPrice": (*utils.Big)(stx.Tx.EffectiveGasPrice(baseFee)),
+		"contractAddress":   nil,
+		"logs":              logs,
+		"logsBloom":         receipt.LogsBloom,
+		"status":            utils.Uint(receipt.Status),
+		"type":              ethTxType(stx.Tx),
+	}
+	if receipt.ContractAddress != (utils.Address{}) {
+		fields["contractAddress"] = receipt.ContractAddress
+	}
+	return fields, nil
+}
+
+// EthCallArgs represents the ethereum arguments for a call or gas estimation.
+type EthCallArgs struct {
+	From                 *utils.Address `json:"from"`
+	To                   *utils.Address `json:"to"`
+	Gas                  *utils.Uint64  `json:"gas"`
+	GasPrice             *utils.Big     `json:"gasPrice"`
+	MaxFeePerGas         *utils.Big     `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *utils.Big     `json:"maxPriorityFeePerGas"`
+	Value                *utils.Big     `json:"value"`
+	Data                 *utils.Bytes   `json:"data"`
+	Input                *utils.Bytes   `json:"input"`
+}
+
+func (args *EthCallArgs) from() utils.Address {
+	if args.From == nil {
+		return utils.Address{}
+	}
+	return *args.From
+}
+
+func (args *EthCallArgs) data() []byte {
+	if args.Input != nil {
+		return *args.Input
+	}
+	if args.Data != nil {
+		return *args.Data
+	}
+	return nil
+}
+
+func (args *EthCallArgs) toTransaction(nonce uint64, gas uint64) *types.Transaction {
+	var (
+		value = new(big.Int)
+		tos   []*utils.Address
+	)
+	if args.Value != nil {
+		value = args.Value.ToInt()
+	}
+	if args.To != nil {
+		tos = append(tos, args.To)
+	}
+	if args.MaxFeePerGas != nil || args.MaxPriorityFeePerGas != nil {
+		feeCap, tip := new(big.Int), new(big.Int)
+		if args.MaxFeePerGas != nil {
+			feeCap = args.MaxFeePerGas.ToInt()
+		}
+		if args.MaxPriorityFeePerGas != nil {
+			tip = args.MaxPriorityFeePerGas.ToInt()
+		}
+		return types.NewDynamicFeeTransaction(types.Binary, nonce, value, gas, feeCap, tip, args.data(), tos...)
+	}
+	gasPrice := new(big.Int)
+	if args.GasPrice != nil {
+		gasPrice = args.GasPrice.ToInt()
+	}
+	return types.NewTransaction(types.Binary, nonce, value, gas, gasPrice, args.data(), tos...)
+}
+
+// Call executes the given transaction on the state of the given block without creating a transaction.
+func (s *EthAPI) Call(args EthCallArgs, height *BlockHeight) (utils.Bytes, error) {
+	block, err := s.blockAt(height)
+	if err != nil {
+		return nil, err
+	}
+	gas := block.GasLimit()
+	if args.Gas != nil {
+		gas = uint64(*args.Gas)
+	}
+	ret, _, failed, err := s.doCall(args, block, gas)
+	if err != nil {
+		return nil, err
+	}
+	if failed {
+		return nil, newRevertError(ret)
+	}
+	return ret, nil
+}
+
+// EstimateGas returns the lowest gas limit allowing the transaction to execute successfully.
+func (s *EthAPI) EstimateGas(args EthCallArgs, height *BlockHeight) (utils.Uint64, error) {
+	block, err := s.blockAt(height)
+	if err != nil {
+		return 0, err
+	}
+	lo, hi := params.TxGas-1, block.GasLimit()
+	if args.Gas != nil && uint64(*args.Gas) >= params.TxGas {
+		hi = uint64(*args.Gas)
+	}
+	executable := func(gas uint64) (bool, []byte, error) {
+		ret, _, failed, err := s.doCall(args, block, gas)
+		if err != nil {
+			if err == vm.ErrInsufficientBalance {
+				return false, nil, err
+			}
+			return false, nil, nil // gas too low or intrinsic gas error, raise the limit
+		}
+		return !failed, ret, nil
+	}
+	ok, ret, err := executable(hi)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		if len(ret) > 0 {
+			return 0, newRevertError(ret)
+		}
+		return 0, errors.New("gas required exceeds allowance or always failing transaction")
+	}
+	for lo+1 < hi {
+		mid := (hi + lo) / 2
+		ok, _, err := executable(mid)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	return utils.Uint64(hi), nil
+}
+
+// SendRawTransaction decodes an ethereum signed transaction and adds it to the transaction pool.
+func (s *EthAPI) SendRawTransaction(raw utils.Bytes) (utils.Hash, error) {
+	tx, err := types.DecodeEthTransaction(raw)
+	if err != nil {
+		return utils.Hash{}, err
+	}
+	return submitTransaction(context.Background(), s.b, tx)
+}
+
+// GetLogs returns the logs of the blocks matching the given filter criteria.
+func (s *EthAPI) GetLogs(crit EthFilterCriteria) ([]*EthRPCLog, error) {
+	var blocks []*types.Block
+	if crit.BlockHash != nil {
+		block, err := s.b.BlockByHash(context.Background(), *crit.BlockHash)
+		if err != nil {
+			return nil, err
+		}
+		if block == nil {
+			return nil, errBlockNotFound
+		}
+		blocks = append(blocks, block)
+	} else {
+		current := s.b.CurrentBlock().Height().Uint64()
+		from, to := current, current
+		if crit.FromBlock != nil && *crit.FromBlock >= 0 {
+			from = uint64(*crit.FromBlock)
+		}
+		if crit.ToBlock != nil && *crit.ToBlock >= 0 {
+			to = uint64(*crit.ToBlock)
+		}
+		if to > current {
+			to = current
+		}
+		if from <= to && to-from >= maxLogsBlockRange {
+			return nil, errLogsRangeTooLarge
+		}
+		for height := from; height <= to; height++ {
+			block, err := s.b.BlockByHeight(context.Background(), BlockHeight(height))
+			if err != nil {
+				return nil, err
+			}
+			if block != nil {
+				blocks = append(blocks, block)
+			}
+		}
+	}
+
+	result := []*EthRPCLog{}
+	for _, block := range blocks {
+		logs, err := s.b.GetLogs(context.Background(), block.Hash())
+		if err != nil {
+			return nil, err
+		}
+		for _, txLogs := range logs {
+			for _, l := range txLogs {
+				if crit.match(l) {
+					result = append(result, newEthRPCLog(l, block.Hash(), block.Height().Uint64()))
+				}
+			}
+		}
+	}
+	return result, nil
+}
+
+// Accounts returns the addresses of the wallet.
+func (s *EthAPI) Accounts() ([]utils.Address, error) {
+	accounts, err := s.b.Accounts()
+	if err != nil {
+		return nil, err
+	}
+	addrs := make([]utils.Address, len(accounts))
+	for i, account := range accounts {
+		addrs[i] = account.Address
+	}
+	return addrs, nil
+}
+
+// Syncing returns false if the node isn't syncing, otherwise the progress of the
+// block synchronisation.
+func (s *EthAPI) Syncing() (interface{}, error) {
+	progress, syncing := s.b.Syncing()
+	if !syncing {
+		return false, nil
+	}
+	return map[string]interface{}{
+		"startingBlock": utils.Uint64(progress.StartingBlock),
+		"currentBlock":  utils.Uint64(progress.CurrentBlock),
+		"highestBlock":  utils.Uint64(progress.HighestBlock),
+	}, nil
+}
+
+func (s *EthAPI) blockAt(height *BlockHeight) (*types.Block, error) {
+	h := LatestBlockHeight
+	if height != nil {
+		h = *height
+	}
+	block, err := s.b.BlockByHeight(context.Background(), h)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, errBlockNotFound
+	}
+	return block, nil
+}
+
+func (s *EthAPI) stateAt(height *BlockHeight) (*state.StateDB, *types.Block, error) {
+	block, err := s.blockAt(height)
+	if err != nil {
+		return nil, nil, err
+	}
+	state, err := s.b.BlockChain().StateAt(block.StateRoot())
+	return state, block, err
+}
+
+// doCall executes the call on the state of the given block, it returns the result, used gas and
+// whether the evm failed.
+func (s *EthAPI) doCall(args EthCallArgs, block *types.Block, gas uint64) ([]byte, uint64, bool, error) {
+	defer func(start time.Time) { log.Debugf("Executing EVM call finished runtime: %v", time.Since(start)) }(time.Now())
+	state, err := s.b.BlockChain().StateAt(block.StateRoot())
+	if err != nil {
+		return nil, 0, false, err
+	}
+	from := args.from()
+	tx := args.toTransaction(state.GetNonce(from), gas)
+
+	ctx, cancel := context.WithTimeout(context.Background(), ethCallTimeout)
+	defer cancel()
+
+	evm, vmError, err := s.b.GetEVM(ctx, from, tx, state, block.BlockHeader(), vm.Config{})
+	if err != nil {
+		return nil, 0, false, err
+	}
+	go func() {
+		<-ctx.Done()
+		evm.Cancel()
+	}()
+
+	gp := new(utils.GasPool).AddGas(gas)
+	ret, usedGas, failed, err := executor.NewStateTransitionForApi(evm, from, tx, gp).TransitionDb()
+	if err := vmError(); err != nil {
+		return nil, 0, false, err
+	}
+	return ret, usedGas, failed, err
+}
+
+func (s *EthAPI) marshalBlock(b *types.Block, fullTx bool) map[string]interface{} {
+	head := b.BlockHeader()
+	fields := map[string]interface{}{
+		"number":           (*utils.Big)(head.Height),
+		"hash":             b.Hash(),
+		"parentHash":       head.PreviousHash,
+		"nonce":            head.Nonce,
+		"mixHash":          utils.Hash{},
+		"sha3Uncles":       utils.Hash{},
+		"logsBloom":        head.LogsBloom,
+		"stateRoot":        head.StateRoot,
+		"miner":            head.Miner,
+		"difficulty":       (*utils.Big)(head.Difficulty),
+		"totalDifficulty":  (*utils.Big)(s.b.GetTd(b.Hash())),
+		"extraData":        utils.Bytes(head.ExtraData),
+		"size":             utils.Uint64(b.Size()),
+		"gasLimit":         utils.Uint64(head.GasLimit),
+		"gasUsed":          utils.Uint64(head.GasUsed),
+		"timestamp":        (*utils.Big)(head.TimeStamp),
+		"transactionsRoot": head.TransactionsRoot,
+		"receiptsRoot":     head.ReceiptsRoot,
+		"uncles":           []utils.Hash{},
+	}
+	if head.BaseFee != nil {
+		fields["baseFeePerGas"] = (*utils.Big)(head.BaseFee)
+	}
+	txs := b.Transactions()
+	transactions := make([]interface{}, len(txs))
+	for i, tx := range txs {
+		if fullTx {
+			transactions[i] = s.newTransaction(tx, b.Hash(), head.Height.Uint64(), uint64(i))
+		} else {
+			transactions[i] = tx.Hash()
+		}
+	}
+	fields["transactions"] = transactions
+	return fields
+}
+
+// EthRPCTransaction is the ethereum representation of a transaction.
+type EthRPCTransaction struct {
+	BlockHash            *utils.Hash    `json:"blockHash"`
+	BlockNumber          *utils.Big     `json:"blockNumber"`
+	From                 utils.Address  `json:"from"`
+	Gas                  utils.Uint64   `json:"gas"`
+	GasPrice             *utils.Big     `json:"gasPrice"`
+	MaxFeePerGas         *utils.Big     `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *utils.Big     `json:"maxPriorityFeePerGas,omitempty"`
+	Hash                 utils.Hash     `json:"hash"`
+	Input                utils.Bytes    `json:"input"`
+	Nonce                utils.Uint64   `json:"nonce"`
+	To                   *utils.Address `json:"to"`
+	TransactionIndex     *utils.Uint64  `json:"transactionIndex"`
+	Value                *utils.Big     `json:"value"`
+	Type                 utils.Uint64   `json:"type"`
+	ChainID              *utils.Big     `json:"chainId,omitempty"`
+	V                    *utils.Big     `json:"v"`
+	R                    *utils.Big     `json:"r"`
+	S                    *utils.Big     `json:"s"`
+}
+
+func (s *EthAPI) newTransaction(tx *types.Transaction, blockHash utils.Hash, blockHeight uint64, index uint64) *EthRPCTransaction {
+	from, _ := tx.Sender(types.Signer{})
+	r, sv, v, _ := types.Signer{}.SignatureValues(tx, tx.Signature())
+	result := &EthRPCTransaction{
+		From:     from,
+		Gas:      utils.Uint64(tx.Gas()),
+		GasPrice: (*utils.Big)(tx.GasPrice()),
+		Hash:     tx.Hash(),
+		Input:    utils.Bytes(tx.Payload()),
+		Nonce:    utils.Uint64(tx.Nonce()),
+		To:       ethTo(tx),
+		Value:    (*utils.Big)(tx.Value()),
+		Type:     ethTxType(tx),
+		V:        (*utils.Big)(v),
+		R:        (*utils.Big)(r),
+		S:        (*utils.Big)(sv),
+	}
+	if chainID := tx.EthChainID(); chainID != 0 {
+		result.ChainID = (*utils.Big)(new(big.Int).SetUint64(chainID))
+		if tx.IsDynamicFee() {
+			// y parity
+			result.V = (*utils.Big)(v.Sub(v, big.NewInt(27)))
+		} else {
+			// recovery id + 35 + chainID * 2
+			result.V = (*utils.Big)(v.Add(v, new(big.Int).SetUint64(8+2*chainID)))
+		}
+	}
+	if tx.IsDynamicFee() {
+		result.MaxFeePerGas = (*utils.Big)(tx.GasFeeCap())
+		result.MaxPriorityFeePerGas = (*utils.Big)(tx.GasTipCap())
+	}
+	if blockHash != (utils.Hash{}) {
+		idx := utils.Uint64(index)
+		result.BlockHash = &blockHash
+		result.BlockNumber = (*utils.Big)(new(big.Int).SetUint64(blockHeight))
+		result.TransactionIndex = &idx
+		if block, _ := s.b.BlockByHash(context.Background(), blockHash); block != nil {
+			result.GasPrice = (*utils.Big)(tx.EffectiveGasPrice(block.BaseFee()))
+		}
+	}
+	return result
+}
+
+// ethTo returns the first recipient of the transaction, nil for contract creation.
+func ethTo(tx *types.Transaction) *utils.Address {
+	if tos := tx.Tos(); len(tos) > 0 {
+		return tos[0]
+	}
+	return nil
+}
+
+func ethTxType(tx *types.Transaction) utils.Uint64 {
+	if tx.IsDynamicFee() {
+		return 2
+	}
+	return 0
+}
+
+// EthRPCLog is the ethereum representation of a log.
+type EthRPCLog struct {
+	Address          utils.Address `json:"address"`
+	Topics           []utils.Hash  `json:"topics"`
+	Data             utils.Bytes   `json:"data"`
+	BlockNumber      utils.Uint64  `json:"blockNumber"`
+	BlockHash        utils.Hash    `json:"blockHash"`
+	TransactionHash  utils.Hash    `json:"transactionHash"`
+	TransactionIndex utils.Uint64  `json:"transactionIndex"`
+	LogIndex         utils.Uint64  `json:"logIndex"`
+	Removed          bool          `json:"removed"`
+}
+
+func newEthRPCLog(l *types.Log, blockHash utils.Hash, blockHeight uint64) *EthRPCLog {
+	topics := l.Topics
+	if topics == nil {
+		topics = []utils.Hash{}
+	}
+	return &EthRPCLog{
+		Address:          l.Address,
+		Topics:           topics,
+		Data:             l.Data,
+		BlockNumber:      utils.Uint64(blockHeight),
+		BlockHash:        blockHash,
+		TransactionHash:  l.TransactionHash,
+		TransactionIndex: utils.Uint64(l.TransactionIndex),
+		LogIndex:         utils.Uint64(l.LogIndex),
+		Removed:          l.Removed,
+	}
+}
+
+func newRevertError(ret []byte) *rpc.Error {
+	return &rpc.Error{Code: ethRevertErrorCode, Message: "execution reverted", Data: utils.Bytes(ret)}
+}
+
+// NetAPI exposes the ethereum compatible "net" namespace.
+type NetAPI struct {
+	b Backend
+}
+
+// NewNetAPI creates a new RPC service with methods compatible with the ethereum net namespace.
+func NewNetAPI(b Backend) *NetAPI {
+	return &NetAPI{b}
+}
+
+// Version returns the network id, which is the chain id.
+func (s *NetAPI) Version() (string, error) {
+	return s.b.BlockChain().Config().ChainID.String(), nil
+}
+
+// Listening returns true, the node is always listening for network connections.
+func (s *NetAPI) Listening() (bool, error) {
+	return true, nil
+}
+
+// PeerCount returns the number of connected peers.
+func (s *NetAPI) PeerCount() (utils.Uint, error) {
+	peers, err := s.b.Peers()
+	return utils.Uint(len(peers)), err
+}
+
+// Web3API exposes the ethereum compatible "web3" namespace.
+type Web3API struct{}
+
+// NewWeb3API creates a new RPC service with methods compatible with the ethereum web3 namespace.
+func NewWeb3API() *Web3API {
+	return &Web3API{}
+}
+
+// ClientVersion returns the node client version.
+func (s *Web3API) ClientVersion() (string, error) {
+	return "uranus/v" + params.VersionFunc, nil
+}
+
+// Sha3 returns the keccak256 hash of the input.
+func (s *Web3API) Sha3(input utils.Bytes) (utils.Bytes, error) {
+	return crypto.Keccak256(input), nil
+}
+
+// EthFilterCriteria represents the ethereum filter of eth_getLogs.
+type EthFilterCriteria struct {
+	BlockHash *utils.Hash
+	FromBlock *BlockHeight
+	ToBlock   *BlockHeight
+	Addresses []utils.Address
+	Topics    [][]utils.Hash // nil entry matches any topic at its position
+}
+
+// UnmarshalJSON accepts a single or a list of addresses and nested topics.
+func (crit *EthFilterCriteria) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		BlockHash *utils.Hash       `json:"blockHash"`
+		FromBlock *BlockHeight      `json:"fromBlock"`
+		ToBlock   *BlockHeight      `json:"toBlock"`
+		Address   json.RawMessage   `json:"address"`
+		Topics    []json.RawMessage `json:"topics"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if raw.BlockHash != nil && (raw.FromBlock != nil || raw.ToBlock != nil) {
+		return errors.New("cannot specify both blockHash and fromBlock/toBlock")
+	}
+	crit.BlockHash, crit.FromBlock, crit.ToBlock = raw.BlockHash, raw.FromBlock, raw.ToBlock
+
+	if len(raw.Address) > 0 && string(raw.Address) != "null" {
+		if raw.Address[0] == '[' {
+			if err := json.Unmarshal(raw.Address, &crit.Addresses); err != nil {
+				return err
+			}
+		} else {
+			var addr utils.Address
+			if err := json.Unmarshal(raw.Address, &addr); err != nil {
+				return err
+			}
+			crit.Addresses = []utils.Address{addr}
+		}
+	}
+
+	crit.Topics = make([][]utils.Hash, len(raw.Topics))
+	for i, topic := range raw.Topics {
+		switch {
+		case string(topic) == "null":
+		case len(topic) > 0 && topic[0] == '[':
+			if err := json.Unmarshal(topic, &crit.Topics[i]); err != nil {
+				return err
+			}
+		default:
+			var hash utils.Hash
+			if err := json.Unmarshal(topic, &hash); err != nil {
+				return err
+			}
+			crit.Topics[i] = []utils.Hash{hash}
+		}
+	}
+	return nil
+}
+
+// match returns whether the log matches the addresses and topics of the filter.
+func (crit *EthFilterCriteria) match(l *types.Log) bool {
+	if len(crit.Addresses) > 0 {
+		found := false
+		for _, addr := range crit.Addresses {
+			if addr == l.Address {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(crit.Topics) > len(l.Topics) {
+		return false
+	}
+	for i, sub := range crit.Topics {
+		if len(sub) == 0 {
+			continue
+		}
+		found := false
+		for _, topic := range sub {
+			if topic == l.Topics[i] {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -98,6 +98,9 @@ type RPCTransaction struct {
 	TransactionIndex utils.Uint       `json:"transactionIndex"`
 	Value            *utils.Big       `json:"value"`
 	Signature        utils.Bytes      `json:"signature"`
+
+	MaxFeePerGas         *utils.Big `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *utils.Big `json:"maxPriorityFeePerGas,omitempty"`
 }
 
 // newRPCTransaction returns a transaction that will serialize to the RPC representation
@@ -116,6 +119,10 @@ func newRPCTransaction(tx *types.Transaction, blockHash utils.Hash, blockHeight
 		Value:     (*utils.Big)(tx.Value()),
 		Signature: utils.Bytes(tx.Signature()),
 	}
+	if tx.IsDynamicFee() {
+		result.MaxFeePerGas = (*utils.Big)(tx.GasFeeCap())
+		result.MaxPriorityFeePerGas = (*utils.Big)(tx.GasTipCap())
+	}
 	if blockHash != (utils.Hash{}) {
 		result.BlockHash = blockHash
 		result.BlockHeight = (*utils.Big)(new(big.Int).SetUint64(blockHeight))
@@ -177,6 +184,9 @@ func RPCMarshalBlock(b *types.Block, inclTx bool, fullTx bool) (map[string]inter
 		"transactionsRoot": head.TransactionsRoot,
 		"receiptsRoot":     head.ReceiptsRoot,
 	}
+	if head.BaseFee != nil {
+		fields["baseFee"] = (*utils.Big)(head.BaseFee)
+	}
 
 	if inclTx {
 		formatTx := func(tx *types.Transaction) (interface{}, error) {
@@ -52,6 +52,16 @@ func (u *UranusAPI) SuggestGasPrice(ignore string, reply *utils.Big) error {
 	return nil
 }
 
+// SuggestGasTipCap return suggest priority fee of dynamic fee transaction.
+func (u *UranusAPI) SuggestGasTipCap(ignore string, reply *utils.Big) error {
+	tip, err := u.b.SuggestGasTipCap(context.Background())
+	if err != nil {
+		return err
+	}
+	*reply = *(*utils.Big)(tip)
+	return nil
+}
+
 type GetBalanceArgs struct {
 	Address     utils.Address
 	BlockHeight *BlockHeight
@@ -79,8 +89,16 @@ type GetNonceArgs struct {
 	GetBalanceArgs
 }
 
-// GetNonce returns nonce for the given address
+// GetNonce returns nonce for the given address, the pending height returns the next nonce of the transaction pool.
 func (u *UranusAPI) GetNonce(args GetNonceArgs, reply *utils.Uint64) error {
+	if args.getBlockHeight() == PendingBlockHeight {
+		nonce, err := u.b.GetPoolNonce(context.Background(), args.Address)
+		if err != nil {
+			return err
+		}
+		*reply = (utils.Uint64)(nonce)
+		return nil
+	}
 	state, err := u.getState(args.getBlockHeight())
 	if err != nil {
 		return err
@@ -116,6 +134,10 @@ type SendTxArgs struct {
 	Data       *utils.Bytes
 	TxType     *utils.Uint64
 	Passphrase string
+
+	// dynamic fee transaction
+	MaxFeePerGas         *utils.Big
+	MaxPriorityFeePerGas *utils.Big
 }
 
 // check is a helper function that fills in default values for unspecified tx fields.
@@ -129,7 +151,26 @@ func (args *SendTxArgs) check(ctx context.Context, b Backend) error {
 		args.Gas = new(utils.Uint64)
 		*(*uint64)(args.Gas) = 90000
 	}
-	if args.GasPrice == nil {
+	if args.MaxFeePerGas != nil || args.MaxPriorityFeePerGas != nil {
+		if args.GasPrice != nil {
+			return errors.New("both gasPrice and (maxFeePerGas or maxPriorityFeePerGas) specified")
+		}
+		if args.MaxPriorityFeePerGas == nil {
+			tip, err := b.SuggestGasTipCap(ctx)
+			if err != nil {
+				return err
+			}
+			args.MaxPriorityFeePerGas = (*utils.Big)(tip)
+		}
+		if args.MaxFeePerGas == nil {
+			// leave room for the base fee to grow, maxFee = 2 * baseFee + tip
+			feeCap := new(big.Int).Set((*big.Int)(args.MaxPriorityFeePerGas))
+			if baseFee := b.CurrentBlock().BaseFee(); baseFee != nil {
+				feeCap.Add(feeCap, baseFee.Mul(baseFee, big.NewInt(2)))
+			}
+			args.MaxFeePerGas = (*utils.Big)(feeCap)
+		}
+	} else if args.GasPrice == nil {
 		price, err := b.SuggestGasPrice(ctx)
 		if err != nil {
 			return err
@@ -167,6 +208,9 @@ func (args *SendTxArgs) toTransaction() *types.Transaction {
 	if args.Data != nil {
 		input = *args.Data
 	}
+	if args.MaxFeePerGas != nil {
+		return types.NewDynamicFeeTransaction(types.TxType(uint64(*args.TxType)), uint64(*args.Nonce), (*big.Int)(args.Value), uint64(*args.Gas), (*big.Int)(args.MaxFeePerGas), (*big.Int)(args.MaxPriorityFeePerGas), input, args.Tos...)
+	}
 	if args.Tos == nil {
 		return types.NewTransaction(types.TxType(uint64(*args.TxType)), uint64(*args.Nonce), (*big.Int)(args.Value), uint64(*args.Gas), (*big.Int)(args.GasPrice), input)
 	}
@@ -21,7 +21,10 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"strings"
+	"time"
 
+	"github.com/UranusBlockStack/uranus/common/log"
 	"github.com/UranusBlockStack/uranus/common/math"
 	"github.com/UranusBlockStack/uranus/common/utils"
 	"github.com/UranusBlockStack/uranus/consensus/dpos"
@@ -30,6 +33,7 @@ import (
 	"github.com/UranusBlockStack/uranus/core/state"
 	"github.com/UranusBlockStack/uranus/core/types"
 	"github.com/UranusBlockStack/uranus/core/vm"
+	"github.com/UranusBlockStack/uranus/node/protocols"
 	"github.com/UranusBlockStack/uranus/p2p"
 	"github.com/UranusBlockStack/uranus/p2p/discover"
 	"github.com/UranusBlockStack/uranus/rpcapi"
@@ -142,6 +146,11 @@ func (api *APIBackend) GetPoolNonce(ctx context.Context, addr utils.Address) (ui
 	return api.u.txPool.State().GetNonce(addr), nil
 }
 
+// Syncing returns the progress of the block synchronisation and whether it is running.
+func (api *APIBackend) Syncing() (protocols.SyncProgress, bool) {
+	return api.u.protocolManager.Syncing()
+}
+
 // TxPoolStats get transaction pool stats.
 func (api *APIBackend) TxPoolStats() (pending int, queued int) {
 	return api.u.txPool.Stats()
@@ -200,6 +209,11 @@ func (api *APIBackend) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
 	return api.gp.SuggestPrice(ctx)
 }
 
+// SuggestGasTipCap suggest priority fee of dynamic fee transaction
+func (api *APIBackend) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return api.gp.SuggestTipCap(ctx)
+}
+
 func (api *APIBackend) GetEVM(ctx context.Context, from utils.Address, tx *types.Transaction, state *state.StateDB, bheader *types.BlockHeader, vmCfg vm.Config) (*vm.EVM, func() error, error) {
 
 	state.SetBalance(from, math.MaxBig256)
@@ -214,7 +228,7 @@ func (api *APIBackend) GetEVM(ctx context.Context, from utils.Address, tx *types
 		Time:        new(big.Int).Set(bheader.TimeStamp),
 		Difficulty:  new(big.Int).Set(bheader.Difficulty),
 		GasLimit:    bheader.GasLimit,
-		GasPrice:    new(big.Int).Set(tx.GasPrice()),
+		GasPrice:    tx.EffectiveGasPrice(bheader.BaseFee),
 	}
 	context.GetHash = func(n uint64) utils.Hash {
 		for header := api.u.BlockChain().Ledger.GetHeader(bheader.PreviousHash); header != nil; header = api.u.BlockChain().Ledger.GetHeader(header.PreviousHash) {
@@ -253,6 +267,56 @@ func (api *APIBackend) NodeInfo() (*p2p.NodeInfo, error) {
 	return api.srv.NodeInfo(), nil
 }
 
+func (api *APIBackend) BanPeer(node string, duration time.Duration) error {
+	id, err := parseNodeID(node)
+	if err != nil {
+		return err
+	}
+	return api.srv.BanPeer(id, duration)
+}
+
+func (api *APIBackend) UnbanPeer(node string) error {
+	id, err := parseNodeID(node)
+	if err != nil {
+		return err
+	}
+	return api.srv.UnbanPeer(id)
+}
+
+func (api *APIBackend) Bans() ([]*p2p.BanInfo, error) {
+	return api.srv.Bans(), nil
+}
+
+// SetHead rewinds the canonical chain to the height and reinjects the transactions of the
+// dropped blocks into the txpool.
+func (api *APIBackend) SetHead(height uint64, force bool) (*types.Block, error) {
+	txs, err := api.u.blockchain.SetHead(height, force)
+	if err != nil {
+		return nil, err
+	}
+	head := api.u.blockchain.CurrentBlock()
+	if err := api.u.txPool.Reinject(head, txs); err != nil {
+		log.Errorf("Failed to reinject rewound transactions err: %v", err)
+	}
+	return head, nil
+}
+
+// parseNodeID accepts an enode url or a hex node id.
+func parseNodeID(node string) (discover.NodeID, error) {
+	if strings.HasPrefix(node, "enode://") {
+		n, err := discover.ParseNode(node)
+		if err != nil {
+			return discover.NodeID{}, fmt.Errorf("invalid enode: %v", err)
+		}
+		return n.ID, nil
+	}
+	id, err := discover.HexID(node)
+	if err != nil {
+		return discover.NodeID{}, fmt.Errorf("invalid node id: %v", err)
+	}
+	return id, nil
+}
+
 func (api *APIBackend) Start(threads int32) error {
 	return api.u.miner.Start()
 }
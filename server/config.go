@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"time"
 
+	"github.com/UranusBlockStack/uranus/common/mclock"
 	"github.com/UranusBlockStack/uranus/consensus/miner"
 	"github.com/UranusBlockStack/uranus/core/ledger"
 	"github.com/UranusBlockStack/uranus/core/txpool"
@@ -45,6 +46,9 @@ type UranusConfig struct {
 
 	// miner config
 	MinerConfig *miner.Config
+
+	// Clock is the time source of the consensus, the system clock if nil
+	Clock mclock.Clock `json:"-"`
 }
 
 func (c UranusConfig) String() string {
@@ -67,8 +67,24 @@ func NewForecast(f GetBlock, cfg *Config) *Forecast {
 	return forecast
 }
 
-// SuggestPrice returns the recommended gas price.
+// SuggestPrice returns the recommended gas price, it is the recommended tip plus the latest base fee.
 func (gpf *Forecast) SuggestPrice(ctx context.Context) (*big.Int, error) {
+	tip, err := gpf.SuggestTipCap(ctx)
+	if err != nil {
+		return tip, err
+	}
+	block, err := gpf.getBlockFunc(ctx, rpcapi.LatestBlockHeight)
+	if err != nil {
+		return nil, err
+	}
+	if baseFee := block.BaseFee(); baseFee != nil {
+		return baseFee.Add(baseFee, tip), nil
+	}
+	return tip, nil
+}
+
+// SuggestTipCap returns the recommended priority fee, it is the gas price before the base fee fork.
+func (gpf *Forecast) SuggestTipCap(ctx context.Context) (*big.Int, error) {
 
 	var (
 		lastBlockHash utils.Hash
@@ -151,17 +167,30 @@ func (gpf *Forecast) SuggestPrice(ctx context.Context) (*big.Int, error) {
 	return price, nil
 }
 
-// getBlockPrices calculates the lowest transaction gas price in a given block
-// and sends it to the result channel. If the block is empty, price is nil.
+// txsByTipToHigh transactions sort by the effective tip paid under the block base fee, from low to high
+type txsByTipToHigh struct {
+	txs     []*types.Transaction
+	baseFee *big.Int
+}
+
+func (t txsByTipToHigh) Len() int      { return len(t.txs) }
+func (t txsByTipToHigh) Swap(i, j int) { t.txs[i], t.txs[j] = t.txs[j], t.txs[i] }
+func (t txsByTipToHigh) Less(i, j int) bool {
+	return t.txs[i].EffectiveGasTip(t.baseFee).Cmp(t.txs[j].EffectiveGasTip(t.baseFee)) < 0
+}
+
+// getBlockPrices calculates the lowest transaction tip (gas price before the base fee fork)
+// in a given block and sends it to the result channel. If the block is empty, price is nil.
 func (gpf *Forecast) getBlockPrices(ctx context.Context, block *types.Block, ch chan getBlockPricesResult) {
 	txs := make([]*types.Transaction, len(block.Transactions()))
 	copy(txs, block.Transactions())
-	sort.Sort(types.TxsByPriceToHigh(txs))
+	baseFee := block.BaseFee()
+	sort.Sort(txsByTipToHigh{txs, baseFee})
 
 	for _, tx := range txs {
 		sender, err := tx.Sender(types.Signer{})
 		if err == nil && sender != block.Miner() {
-			ch <- getBlockPricesResult{tx.GasPrice(), nil}
+			ch <- getBlockPricesResult{tx.EffectiveGasTip(baseFee), nil}
 			return
 		}
 	}
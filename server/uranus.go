@@ -22,12 +22,15 @@ import (
 
 	"github.com/UranusBlockStack/uranus/common/db"
 	"github.com/UranusBlockStack/uranus/common/log"
+	"github.com/UranusBlockStack/uranus/common/mclock"
+	"github.com/UranusBlockStack/uranus/common/utils"
 	"github.com/UranusBlockStack/uranus/consensus"
 	"github.com/UranusBlockStack/uranus/consensus/dpos"
 	"github.com/UranusBlockStack/uranus/consensus/miner"
 	"github.com/UranusBlockStack/uranus/consensus/pow/cpuminer"
 	"github.com/UranusBlockStack/uranus/core"
 	"github.com/UranusBlockStack/uranus/core/ledger"
+	"github.com/UranusBlockStack/uranus/core/state"
 	"github.com/UranusBlockStack/uranus/core/txpool"
 	"github.com/UranusBlockStack/uranus/core/vm"
 	"github.com/UranusBlockStack/uranus/feed"
@@ -90,18 +93,15 @@ func New(ctx *node.Context, config *UranusConfig) (*Uranus, error) {
 	// engine
 	cpu := cpuminer.NewCpuMiner()
 	_ = cpu
-	dpos.Option.BlockInterval = chainCfg.BlockInterval
-	dpos.Option.BlockRepeat = chainCfg.BlockRepeat
-	dpos.Option.MaxValidatorSize = chainCfg.MaxValidatorSize
-	dpos.Option.MinStartQuantity = chainCfg.MinStartQuantity
-	if chainCfg.DelayEpcho > 0 {
-		dpos.Option.DelayEpcho = chainCfg.DelayEpcho
+	clock := config.Clock
+	if clock == nil {
+		clock = mclock.System{}
 	}
-	dpos := dpos.NewDpos(mux, chainDb, statedb, uranus.wallet.SignHash)
+	dpos := dpos.NewDpos(chainCfg, mux, chainDb, statedb, uranus.wallet.SignHash, clock)
 
 	// blockchain
 	log.Debugf("Initialised chain configuration: %v", chainCfg)
-	uranus.blockchain, err = core.NewBlockChain(config.LedgerConfig, uranus.chainConfig, statedb, chainDb, dpos, &vm.Config{})
+	uranus.blockchain, err = core.NewBlockChain(ledgerConfig(ctx, config), uranus.chainConfig, statedb, chainDb, dpos, &vm.Config{})
 	if err != nil {
 		return nil, err
 	}
@@ -125,6 +125,48 @@ func New(ctx *node.Context, config *UranusConfig) (*Uranus, error) {
 	return uranus, nil
 }
 
+// OpenChain opens the blockchain of the data directory without the networking, the
+// blockchain has to be stopped before the returned database is closed.
+func OpenChain(ctx *node.Context, config *UranusConfig) (*core.BlockChain, db.Database, error) {
+	chainDb, err := CreateDB(ctx, config, "chaindata")
+	if err != nil {
+		return nil, nil, err
+	}
+	chainCfg, statedb, _, err := ledger.SetupGenesis(config.Genesis, ledger.NewChain(chainDb))
+	if err != nil {
+		chainDb.Close()
+		return nil, nil, err
+	}
+	dpos := dpos.NewDpos(chainCfg, new(feed.TypeMux), chainDb, statedb, nil, mclock.System{})
+	blockchain, err := core.NewBlockChain(ledgerConfig(ctx, config), chainCfg, statedb, chainDb, dpos, &vm.Config{})
+	if err != nil {
+		chainDb.Close()
+		return nil, nil, err
+	}
+	dpos.Init(blockchain)
+	return blockchain, chainDb, nil
+}
+
+// OpenLedger opens the ledger of the data directory without the blockchain, the head block
+// isn't loaded so a broken chain can be checked and repaired. The ledger has to be closed
+// before the returned database is closed.
+func OpenLedger(ctx *node.Context, config *UranusConfig) (*ledger.Ledger, db.Database, error) {
+	chainDb, err := CreateDB(ctx, config, "chaindata")
+	if err != nil {
+		return nil, nil, err
+	}
+	stateCache := state.NewDatabase(chainDb)
+	l, err := ledger.New(ledgerConfig(ctx, config), chainDb, func(hash utils.Hash) bool {
+		_, err := stateCache.OpenTrie(hash)
+		return err == nil
+	})
+	if err != nil {
+		chainDb.Close()
+		return nil, nil, err
+	}
+	return l, chainDb, nil
+}
+
 // Protocols implements node.Service.
 func (u *Uranus) Protocols() []*p2p.Protocol {
 	return u.protocolManager.SubProtocols
@@ -168,6 +210,29 @@ func (u *Uranus) APIs() []rpc.API {
 			Version:   "0.0.1",
 			Service:   rpcapi.NewDposAPI(u.uranusAPI),
 		},
+		{
+			Namespace: "Debug",
+			Version:   "0.0.1",
+			Service:   rpcapi.NewDebugAPI(u.uranusAPI),
+		},
+		{
+			Namespace: "eth",
+			Version:   "0.0.1",
+			Service:   rpcapi.NewEthAPI(u.uranusAPI),
+			Ethereum:  true,
+		},
+		{
+			Namespace: "net",
+			Version:   "0.0.1",
+			Service:   rpcapi.NewNetAPI(u.uranusAPI),
+			Ethereum:  true,
+		},
+		{
+			Namespace: "web3",
+			Version:   "0.0.1",
+			Service:   rpcapi.NewWeb3API(),
+			Ethereum:  true,
+		},
 	}
 }
 
@@ -175,7 +240,7 @@ func (u *Uranus) APIs() []rpc.API {
 func (u *Uranus) Start(p2p *p2p.Server) error {
 	log.Info("start uranus service...")
 	// start p2p
-	u.protocolManager.Start(p2p.MaxPeers)
+	u.protocolManager.Start(p2p)
 	// start miner
 	if u.config.StartMiner {
 		u.miner.Start()
@@ -188,6 +253,7 @@ func (u *Uranus) Start(p2p *p2p.Server) error {
 func (u *Uranus) Stop() error {
 	u.miner.Stop()
 	u.txPool.Stop()
+	u.blockchain.Stop()
 	u.chainDb.Close()
 	u.protocolManager.Stop()
 	close(u.shutdownChan)
@@ -196,3 +262,12 @@ func (u *Uranus) Stop() error {
 
 // BlockChain returns blcokchain.
 func (u *Uranus) BlockChain() *core.BlockChain { return u.blockchain }
+
+// Engine returns the consensus engine.
+func (u *Uranus) Engine() consensus.Engine { return u.engine }
+
+// Miner returns the miner.
+func (u *Uranus) Miner() *miner.UMiner { return u.miner }
+
+// TxPool returns the transaction pool.
+func (u *Uranus) TxPool() *txpool.TxPool { return u.txPool }
@@ -23,6 +23,7 @@ import (
 	"github.com/UranusBlockStack/uranus/common/log"
 	"github.com/UranusBlockStack/uranus/common/utils"
 	"github.com/UranusBlockStack/uranus/consensus/miner"
+	"github.com/UranusBlockStack/uranus/core/ledger"
 	"github.com/UranusBlockStack/uranus/node"
 	"github.com/UranusBlockStack/uranus/params"
 	"github.com/UranusBlockStack/uranus/wallet"
@@ -37,6 +38,19 @@ func CreateDB(ctx *node.Context, config *UranusConfig, name string) (db.Database
 	return db, nil
 }
 
+// ledgerConfig returns the ledger config with the freezer in the data directory by default,
+// there is no default freezer for an in-memory chain database.
+func ledgerConfig(ctx *node.Context, config *UranusConfig) *ledger.Config {
+	cfg := ledger.Config{FreezeThreshold: ledger.DefaultFreezeThreshold, Snapshot: true}
+	if config.LedgerConfig != nil {
+		cfg = *config.LedgerConfig
+	}
+	if cfg.AncientDir == "" && !ctx.DatabaseInMemory("chaindata") {
+		cfg.AncientDir = ctx.ResolvePath("chaindata/ancient")
+	}
+	return &cfg
+}
+
 func checkMinerConfig(cfg *miner.Config, wallet *wallet.Wallet) *miner.Config {
 	// extra data
 	if uint64(len([]byte(cfg.ExtraData))) > params.MaxExtraDataSize {
@@ -0,0 +1,510 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package simulation runs a network of uranus nodes in one process. The nodes are
+// connected by in-memory pipes and mint on a simulated clock, partitions, latency and
+// crashes are scripted by the tests.
+package simulation
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/UranusBlockStack/uranus/common/crypto"
+	"github.com/UranusBlockStack/uranus/common/mclock"
+	"github.com/UranusBlockStack/uranus/consensus/dpos"
+	"github.com/UranusBlockStack/uranus/consensus/miner"
+	"github.com/UranusBlockStack/uranus/core/ledger"
+	"github.com/UranusBlockStack/uranus/core/txpool"
+	"github.com/UranusBlockStack/uranus/core/types"
+	"github.com/UranusBlockStack/uranus/node"
+	"github.com/UranusBlockStack/uranus/p2p"
+	"github.com/UranusBlockStack/uranus/p2p/discover"
+	"github.com/UranusBlockStack/uranus/params"
+	"github.com/UranusBlockStack/uranus/server"
+	"github.com/UranusBlockStack/uranus/wallet"
+)
+
+const basePort = 30300 // port of the first node in the enode urls
+
+const (
+	idlePolls    = 3                      // polls without activity before the nodes are idle
+	idleInterval = 200 * time.Microsecond // real time between two polls
+	idleTimeout  = 100 * time.Millisecond // real time a step waits at most for the nodes
+)
+
+// Config is the layout of the simulated network.
+type Config struct {
+	Nodes      int           // count of the nodes
+	Validators []int         // indexes of the nodes minting, the genesis candidates in order
+	Latency    time.Duration // latency of the links
+	Start      time.Time     // start of the simulated clock, the current time if zero
+	Step       time.Duration // clock advance between two yields to the nodes, a tenth of the block interval if zero
+}
+
+// Node is a node of the network.
+type Node struct {
+	Index  int
+	ID     discover.NodeID
+	Stack  *node.Node
+	Uranus *server.Uranus
+
+	p2pConfig *p2p.Config
+	addr      *net.TCPAddr
+	listener  *pipeListener
+	running   bool
+}
+
+type link struct{ a, b int }
+
+func newLink(a, b int) link {
+	if a > b {
+		a, b = b, a
+	}
+	return link{a, b}
+}
+
+// Network is a set of nodes running in the process on a simulated clock.
+type Network struct {
+	Clock *mclock.Simulated
+	Nodes []*Node
+
+	dir     string
+	config  Config
+	genesis *ledger.Genesis
+	writes  uint64 // count of the writes to the pipes, accessed atomically
+
+	lock      sync.Mutex
+	ids       map[discover.NodeID]int
+	latencies map[link]time.Duration
+	groups    map[int]int // partition group of every node, nil if the network is connected
+	conns     map[link][]*pipeConn
+}
+
+// NewNetwork creates the nodes in a temporary directory and starts them.
+func NewNetwork(config Config) (*Network, error) {
+	if config.Start.IsZero() {
+		config.Start = time.Now()
+	}
+	if config.Step == 0 {
+		config.Step = time.Duration(params.DefaultChainConfig.BlockInterval / 10)
+	}
+	keys := make([]*ecdsa.PrivateKey, config.Nodes)
+	for i := range keys {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = key
+	}
+	// the validators are the candidates of the genesis, the first ones up to the
+	// validator size mint in the first epoch
+	genesis := ledger.DefaultGenesis()
+	for _, i := range config.Validators {
+		if i < 0 || i >= config.Nodes {
+			return nil, fmt.Errorf("validator %d out of the %d nodes", i, config.Nodes)
+		}
+		genesis.Candidates = append(genesis.Candidates, ledger.GenesisCandidate{Address: crypto.PubkeyToAddress(keys[i].PublicKey)})
+	}
+
+	dir, err := ioutil.TempDir("", "uranus-simulation")
+	if err != nil {
+		return nil, err
+	}
+	n := &Network{
+		Clock:     mclock.NewSimulated(config.Start),
+		dir:       dir,
+		config:    config,
+		genesis:   genesis,
+		ids:       make(map[discover.NodeID]int),
+		latencies: make(map[link]time.Duration),
+		conns:     make(map[link][]*pipeConn),
+	}
+	for i, key := range keys {
+		if err := n.addNode(i, key); err != nil {
+			n.Close()
+			return nil, err
+		}
+	}
+	for i := range n.Nodes {
+		if err := n.Restart(i); err != nil {
+			n.Close()
+			return nil, err
+		}
+	}
+	return n, nil
+}
+
+// addNode creates the node of the key, it dials the nodes with a lower index.
+func (n *Network) addNode(i int, key *ecdsa.PrivateKey) error {
+	datadir := filepath.Join(n.dir, fmt.Sprintf("node%d", i))
+	nd := &Node{
+		Index: i,
+		ID:    discover.PubkeyID(&key.PublicKey),
+		addr:  &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: basePort + i},
+	}
+	nd.p2pConfig = &p2p.Config{
+		PrivateKey:  key,
+		MaxPeers:    n.config.Nodes,
+		Name:        fmt.Sprintf("sim%d", i),
+		NoDiscovery: true,
+		Dialer:      &pipeDialer{net: n, from: i},
+	}
+	for _, peer := range n.Nodes {
+		nd.p2pConfig.StaticNodes = append(nd.p2pConfig.StaticNodes, discover.NewNode(peer.ID, peer.addr.IP, 0, uint16(peer.addr.Port)))
+	}
+
+	validator := false
+	for _, v := range n.config.Validators {
+		validator = validator || v == i
+	}
+	coinbase := crypto.PubkeyToAddress(key.PublicKey)
+	if validator {
+		if err := writeValidatorKey(filepath.Join(datadir, node.DefaultName, "keystore"), key); err != nil {
+			return err
+		}
+	}
+	uranusConfig := &server.UranusConfig{
+		Genesis:      n.genesis,
+		DBHandles:    16,
+		DBCache:      16,
+		StartMiner:   validator,
+		TxPoolConfig: new(txpool.Config),
+		MinerConfig:  &miner.Config{CoinBaseAddr: coinbase.Hex(), MinerThreads: 1},
+		Clock:        n.Clock,
+	}
+	*uranusConfig.TxPoolConfig = txpool.DefaultTxPoolConfig
+
+	nd.Stack = node.New(&node.Config{Name: node.DefaultName, DataDir: datadir, P2P: nd.p2pConfig})
+	if err := nd.Stack.Register(func(ctx *node.Context) (node.Service, error) {
+		return server.New(ctx, uranusConfig)
+	}); err != nil {
+		return err
+	}
+
+	n.lock.Lock()
+	n.Nodes = append(n.Nodes, nd)
+	n.ids[nd.ID] = i
+	n.lock.Unlock()
+	return nil
+}
+
+// writeValidatorKey stores the key of the validator where the miner signs with it.
+func writeValidatorKey(keydir string, key *ecdsa.PrivateKey) error {
+	if err := os.MkdirAll(keydir, 0700); err != nil {
+		return err
+	}
+	account := wallet.Account{Address: crypto.PubkeyToAddress(key.PublicKey), PrivateKey: key}
+	ks := wallet.NewKeyStore(keydir)
+	return ks.PutKey(account, ks.JoinPath(account.Address.Hex()+".json"), "coinbase")
+}
+
+// Close stops the nodes and removes their directories.
+func (n *Network) Close() {
+	for i := range n.Nodes {
+		n.Crash(i)
+	}
+	os.RemoveAll(n.dir)
+}
+
+// Crash stops the node abruptly, its connections are dropped.
+func (n *Network) Crash(i int) error {
+	nd := n.Nodes[i]
+	n.lock.Lock()
+	if !nd.running {
+		n.lock.Unlock()
+		return node.ErrNodeStopped
+	}
+	nd.running = false
+	nd.listener.Close()
+	n.lock.Unlock()
+
+	n.dropConns(func(l link) bool { return l.a == i || l.b == i })
+	return nd.Stack.Stop()
+}
+
+// Restart starts the stopped node on its database.
+func (n *Network) Restart(i int) error {
+	nd := n.Nodes[i]
+	n.lock.Lock()
+	if nd.running {
+		n.lock.Unlock()
+		return node.ErrNodeRunning
+	}
+	nd.listener = newPipeListener(nd.addr)
+	nd.p2pConfig.Listener = nd.listener
+	n.lock.Unlock()
+
+	if err := nd.Stack.Start(); err != nil {
+		return err
+	}
+	var uranus *server.Uranus
+	if err := nd.Stack.Service(&uranus); err != nil {
+		return err
+	}
+	n.lock.Lock()
+	nd.Uranus = uranus
+	nd.running = true
+	n.lock.Unlock()
+	return nil
+}
+
+// Partition splits the network into the groups of node indexes, a node missing from the
+// groups is isolated. The connections between the groups are dropped.
+func (n *Network) Partition(groups ...[]int) {
+	n.lock.Lock()
+	n.groups = make(map[int]int)
+	for i := range n.Nodes {
+		n.groups[i] = -1 - i
+	}
+	for g, group := range groups {
+		for _, i := range group {
+			n.groups[i] = g
+		}
+	}
+	n.lock.Unlock()
+
+	n.dropConns(func(l link) bool { return n.groups[l.a] != n.groups[l.b] })
+}
+
+// Heal removes the partition, the nodes redial each other.
+func (n *Network) Heal() {
+	n.lock.Lock()
+	n.groups = nil
+	n.lock.Unlock()
+}
+
+// SetLatency sets the latency of the link between the nodes.
+func (n *Network) SetLatency(a, b int, latency time.Duration) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.latencies[newLink(a, b)] = latency
+}
+
+func (n *Network) latency(l link) time.Duration {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if latency, ok := n.latencies[l]; ok {
+		return latency
+	}
+	return n.config.Latency
+}
+
+func (n *Network) reachable(a, b int) bool {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	return n.groups == nil || n.groups[a] == n.groups[b]
+}
+
+// connect opens a pipe from the node to the node of the id and hands the remote end to
+// its listener.
+func (n *Network) connect(from int, id discover.NodeID) (net.Conn, error) {
+	n.lock.Lock()
+	to, ok := n.ids[id]
+	if !ok || !n.Nodes[from].running || !n.Nodes[to].running {
+		n.lock.Unlock()
+		return nil, errUnreachable
+	}
+	listener := n.Nodes[to].listener
+	n.lock.Unlock()
+	if !n.reachable(from, to) {
+		return nil, errUnreachable
+	}
+
+	l := newLink(from, to)
+	local, remote := newPipe(n.Clock, n.Nodes[from].addr, n.Nodes[to].addr, func() time.Duration { return n.latency(l) })
+	local.onClose = func() { n.forgetConn(l, local) }
+	local.onWrite = func() { atomic.AddUint64(&n.writes, 1) }
+	remote.onWrite = local.onWrite
+	n.lock.Lock()
+	n.conns[l] = append(n.conns[l], local)
+	n.lock.Unlock()
+
+	if err := listener.deliver(remote); err != nil {
+		local.Close()
+		return nil, errUnreachable
+	}
+	return local, nil
+}
+
+func (n *Network) forgetConn(l link, c *pipeConn) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	conns := n.conns[l]
+	for i := range conns {
+		if conns[i] == c {
+			n.conns[l] = append(conns[:i], conns[i+1:]...)
+			break
+		}
+	}
+}
+
+// dropConns closes the connections of the matching links, match is called with the lock held.
+func (n *Network) dropConns(match func(link) bool) {
+	var drop []*pipeConn
+	n.lock.Lock()
+	for l, conns := range n.conns {
+		if match(l) {
+			drop = append(drop, conns...)
+		}
+	}
+	n.lock.Unlock()
+
+	for _, c := range drop {
+		c.Close()
+	}
+}
+
+// Run advances the simulated clock by the duration, the nodes are idle before every step.
+func (n *Network) Run(d time.Duration) {
+	for d > 0 {
+		step := n.config.Step
+		if step > d {
+			step = d
+		}
+		n.Clock.Run(step)
+		n.waitIdle()
+		d -= step
+	}
+}
+
+// waitIdle waits until the nodes handled the step: the fired timers are received, the
+// arrived messages are read and nothing is sent or scheduled for a few polls. A timer
+// abandoned by a stopped node holds the step up to idleTimeout.
+func (n *Network) waitIdle() {
+	deadline := time.Now().Add(idleTimeout)
+	last := n.activity()
+	for quiet := 0; quiet < idlePolls && time.Now().Before(deadline); {
+		time.Sleep(idleInterval)
+		activity := n.activity()
+		if activity == last && n.Clock.Idle() && !n.inFlight() {
+			quiet++
+		} else {
+			quiet = 0
+		}
+		last = activity
+	}
+}
+
+// activity returns a count changing whenever a node writes to a pipe or schedules a timer.
+func (n *Network) activity() uint64 {
+	return atomic.LoadUint64(&n.writes) + n.Clock.Scheduled()
+}
+
+// inFlight reports whether a message arrived which isn't read yet.
+func (n *Network) inFlight() bool {
+	var all []*pipeConn
+	n.lock.Lock()
+	for _, conns := range n.conns {
+		all = append(all, conns...)
+	}
+	n.lock.Unlock()
+
+	// the pipes are locked without the network lock, a write takes them in the other order
+	now := n.Clock.Now()
+	for _, c := range all {
+		if c.in.due(now) || c.out.due(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// RunUntil advances the clock until the condition holds, it fails after the timeout of
+// simulated time.
+func (n *Network) RunUntil(timeout time.Duration, cond func() bool) error {
+	for elapsed := time.Duration(0); !cond(); elapsed += n.config.Step {
+		if elapsed >= timeout {
+			return fmt.Errorf("condition not reached after %v", timeout)
+		}
+		n.Run(n.config.Step)
+	}
+	return nil
+}
+
+// Head returns the head block of the node.
+func (n *Network) Head(i int) *types.Block {
+	return n.uranus(i).BlockChain().CurrentBlock()
+}
+
+// ConfirmedHeight returns the confirmed height of the node.
+func (n *Network) ConfirmedHeight(i int) uint64 {
+	height, _ := n.uranus(i).Engine().(*dpos.Dpos).GetConfirmedBlockNumber()
+	return height.Uint64()
+}
+
+// PeerCount returns the count of the peers of the node.
+func (n *Network) PeerCount(i int) int {
+	if srv := n.Nodes[i].Stack.Server(); srv != nil {
+		return srv.PeerCount()
+	}
+	return 0
+}
+
+// WaitHeight runs the clock until the head of every node reaches the height.
+func (n *Network) WaitHeight(height uint64, timeout time.Duration, nodes ...int) error {
+	return n.RunUntil(timeout, func() bool {
+		for _, i := range nodes {
+			if n.Head(i).Height().Uint64() < height {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// CheckSameHead returns an error if the nodes don't have the same head block.
+func (n *Network) CheckSameHead(nodes ...int) error {
+	if len(nodes) == 0 {
+		return nil
+	}
+	want := n.Head(nodes[0])
+	for _, i := range nodes[1:] {
+		if head := n.Head(i); head.Hash() != want.Hash() {
+			return fmt.Errorf("node %d head #%v [%x] differs from node %d head #%v [%x]",
+				i, head.Height(), head.Hash().Bytes()[:4], nodes[0], want.Height(), want.Hash().Bytes()[:4])
+		}
+	}
+	return nil
+}
+
+// CheckConfirmed returns an error if the confirmed height of a node is below the height.
+func (n *Network) CheckConfirmed(height uint64, nodes ...int) error {
+	for _, i := range nodes {
+		if confirmed := n.ConfirmedHeight(i); confirmed < height {
+			return fmt.Errorf("node %d confirmed height %d below %d", i, confirmed, height)
+		}
+	}
+	return nil
+}
+
+func (n *Network) uranus(i int) *server.Uranus {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	return n.Nodes[i].Uranus
+}
@@ -0,0 +1,123 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package simulation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/UranusBlockStack/uranus/common/crypto"
+	"github.com/UranusBlockStack/uranus/common/utils"
+	"github.com/UranusBlockStack/uranus/params"
+)
+
+func TestNetworkPartitionAndCrash(t *testing.T) {
+	net, err := NewNetwork(Config{Nodes: 3, Validators: []int{0}, Latency: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer net.Close()
+
+	if err := net.WaitHeight(3, time.Minute, 0, 1, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := net.RunUntil(5*time.Second, func() bool { return net.CheckSameHead(0, 1, 2) == nil }); err != nil {
+		t.Fatal(net.CheckSameHead(0, 1, 2))
+	}
+
+	// the isolated node stops following the validator
+	net.Partition([]int{0, 1}, []int{2})
+	isolated := net.Head(2).Height().Uint64()
+	if err := net.WaitHeight(isolated+5, time.Minute, 0, 1); err != nil {
+		t.Fatal(err)
+	}
+	if height := net.Head(2).Height().Uint64(); height > isolated+1 {
+		t.Fatalf("isolated node advanced from %d to %d", isolated, height)
+	}
+
+	// it catches up once the partition is healed
+	net.Heal()
+	if err := net.RunUntil(time.Minute, func() bool { return net.CheckSameHead(0, 1, 2) == nil }); err != nil {
+		t.Fatal(net.CheckSameHead(0, 1, 2))
+	}
+	if err := net.CheckConfirmed(isolated, 0, 1, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	// the chain halts when the only validator crashes
+	if err := net.Crash(0); err != nil {
+		t.Fatal(err)
+	}
+	if err := net.RunUntil(time.Minute, func() bool { return net.CheckSameHead(1, 2) == nil }); err != nil {
+		t.Fatal(net.CheckSameHead(1, 2))
+	}
+	halted := net.Head(1).Height().Uint64()
+	net.Run(5 * time.Second)
+	if height := net.Head(1).Height().Uint64(); height != halted {
+		t.Fatalf("chain advanced from %d to %d without validator", halted, height)
+	}
+}
+
+func TestNetworkValidatorsForkUnderPartition(t *testing.T) {
+	net, err := NewNetwork(Config{Nodes: 4, Validators: []int{0, 1, 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer net.Close()
+	config := params.DefaultChainConfig
+	epoch := time.Duration(config.BlockInterval * config.BlockRepeat * config.MaxValidatorSize)
+
+	// the validators mint in turn across the epochs
+	if err := net.WaitHeight(1, time.Minute, 0, 1, 2, 3); err != nil {
+		t.Fatal(err)
+	}
+	net.Run(epoch + epoch/2)
+	if err := net.RunUntil(5*time.Second, func() bool { return net.CheckSameHead(0, 1, 2, 3) == nil }); err != nil {
+		t.Fatal(net.CheckSameHead(0, 1, 2, 3))
+	}
+	miners := make(map[utils.Address]bool)
+	for block := net.Head(3); block.Height().Sign() > 0; block = net.uranus(3).BlockChain().GetBlockByHash(block.PreviousHash()) {
+		miners[block.Miner()] = true
+	}
+	if len(miners) != 3 {
+		t.Fatalf("blocks minted by %d validators, want 3", len(miners))
+	}
+
+	// the isolated validator forks off in its own slots
+	net.Partition([]int{0, 1, 3}, []int{2})
+	split := net.Head(2).Height().Uint64()
+	net.Run(epoch)
+	if net.CheckSameHead(0, 2) == nil {
+		t.Fatal("no fork under the partition")
+	}
+	fork := net.Head(2)
+	if fork.Height().Uint64() <= split || fork.Miner() != crypto.PubkeyToAddress(net.Nodes[2].p2pConfig.PrivateKey.PublicKey) {
+		t.Fatalf("isolated validator didn't mint, head #%v", fork.Height())
+	}
+	if major := net.Head(0).Height().Uint64(); major <= fork.Height().Uint64() {
+		t.Fatalf("majority chain #%d isn't longer than the fork #%v", major, fork.Height())
+	}
+
+	// the fork is dropped for the chain of the majority once healed
+	net.Heal()
+	if err := net.RunUntil(time.Minute, func() bool { return net.CheckSameHead(0, 1, 2, 3) == nil }); err != nil {
+		t.Fatal(net.CheckSameHead(0, 1, 2, 3))
+	}
+	if block := net.uranus(2).BlockChain().GetBlockByHeight(fork.Height().Uint64()); block == nil || block.Hash() == fork.Hash() {
+		t.Fatalf("fork block #%v is still canonical", fork.Height())
+	}
+}
@@ -0,0 +1,224 @@
+// Copyright 2018 The uranus Authors
+// This file is part of the uranus library.
+//
+// The uranus library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The uranus library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the uranus library. If not, see <http://www.gnu.org/licenses/>.
+
+package simulation
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/UranusBlockStack/uranus/common/mclock"
+	"github.com/UranusBlockStack/uranus/p2p/discover"
+)
+
+const dialTimeout = 5 * time.Second // simulated time an unreachable node takes to fail a dial
+
+var (
+	errListenerClosed = errors.New("listener closed")
+	errUnreachable    = errors.New("node unreachable")
+)
+
+// chunk is a write delivered to the reader once the clock reaches at.
+type chunk struct {
+	data []byte
+	at   time.Time
+}
+
+// pipeBuffer is one direction of a pipe, the writes never block.
+type pipeBuffer struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	chunks []chunk
+	closed chan struct{}
+	once   sync.Once
+}
+
+func newPipeBuffer() *pipeBuffer {
+	b := &pipeBuffer{closed: make(chan struct{})}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+func (b *pipeBuffer) close() {
+	b.once.Do(func() {
+		b.mu.Lock()
+		close(b.closed)
+		b.cond.Broadcast()
+		b.mu.Unlock()
+	})
+}
+
+// due reports whether a write arrived which the reader didn't read yet.
+func (b *pipeBuffer) due(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return !b.isClosed() && len(b.chunks) > 0 && !b.chunks[0].at.After(now)
+}
+
+func (b *pipeBuffer) isClosed() bool {
+	select {
+	case <-b.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// pipeConn is an in-memory connection between two nodes, the writes arrive after the
+// latency of the link on the simulated clock. The deadlines are ignored.
+type pipeConn struct {
+	local, remote *net.TCPAddr
+	in, out       *pipeBuffer
+	clock         mclock.Clock
+	latency       func() time.Duration
+	onWrite       func()
+	onClose       func()
+}
+
+// newPipe returns both ends of a connection between the addresses.
+func newPipe(clock mclock.Clock, a, b *net.TCPAddr, latency func() time.Duration) (*pipeConn, *pipeConn) {
+	ab, ba := newPipeBuffer(), newPipeBuffer()
+	return &pipeConn{local: a, remote: b, in: ba, out: ab, clock: clock, latency: latency},
+		&pipeConn{local: b, remote: a, in: ab, out: ba, clock: clock, latency: latency}
+}
+
+func (c *pipeConn) Read(p []byte) (int, error) {
+	b := c.in
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for {
+		if b.isClosed() {
+			return 0, io.EOF
+		}
+		if len(b.chunks) == 0 {
+			b.cond.Wait()
+			continue
+		}
+		head := &b.chunks[0]
+		if wait := head.at.Sub(c.clock.Now()); wait > 0 {
+			b.mu.Unlock()
+			select {
+			case <-c.clock.After(wait):
+			case <-b.closed:
+			}
+			b.mu.Lock()
+			continue
+		}
+		n := copy(p, head.data)
+		if head.data = head.data[n:]; len(head.data) == 0 {
+			b.chunks = b.chunks[1:]
+		}
+		return n, nil
+	}
+}
+
+func (c *pipeConn) Write(p []byte) (int, error) {
+	b := c.out
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.isClosed() {
+		return 0, io.ErrClosedPipe
+	}
+	data := make([]byte, len(p))
+	copy(data, p)
+	b.chunks = append(b.chunks, chunk{data: data, at: c.clock.Now().Add(c.latency())})
+	b.cond.Broadcast()
+	if c.onWrite != nil {
+		c.onWrite()
+	}
+	return len(p), nil
+}
+
+// Close shuts both directions down, the pending writes are lost.
+func (c *pipeConn) Close() error {
+	c.in.close()
+	c.out.close()
+	if c.onClose != nil {
+		c.onClose()
+	}
+	return nil
+}
+
+func (c *pipeConn) LocalAddr() net.Addr                { return c.local }
+func (c *pipeConn) RemoteAddr() net.Addr               { return c.remote }
+func (c *pipeConn) SetDeadline(t time.Time) error      { return nil }
+func (c *pipeConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *pipeConn) SetWriteDeadline(t time.Time) error { return nil }
+
+// pipeListener accepts the pipes dialed to a node.
+type pipeListener struct {
+	addr   *net.TCPAddr
+	conns  chan net.Conn
+	closed chan struct{}
+	once   sync.Once
+}
+
+func newPipeListener(addr *net.TCPAddr) *pipeListener {
+	return &pipeListener{
+		addr:   addr,
+		conns:  make(chan net.Conn),
+		closed: make(chan struct{}),
+	}
+}
+
+func (l *pipeListener) Accept() (net.Conn, error) {
+	select {
+	case c := <-l.conns:
+		return c, nil
+	case <-l.closed:
+		return nil, errListenerClosed
+	}
+}
+
+// deliver hands the connection to Accept, it fails if the listener is closed.
+func (l *pipeListener) deliver(c net.Conn) error {
+	select {
+	case l.conns <- c:
+		return nil
+	case <-l.closed:
+		return errListenerClosed
+	}
+}
+
+func (l *pipeListener) Close() error {
+	l.once.Do(func() { close(l.closed) })
+	return nil
+}
+
+func (l *pipeListener) Addr() net.Addr { return l.addr }
+
+// pipeDialer implements p2p.Dialer for a node of the network.
+type pipeDialer struct {
+	net  *Network
+	from int
+}
+
+// Dial connects to the node over a pipe, the dial fails after dialTimeout if the node is
+// crashed or on the other side of a partition.
+func (d *pipeDialer) Dial(dest *discover.Node) (net.Conn, error) {
+	local, err := d.net.connect(d.from, dest.ID)
+	if err != nil {
+		d.net.Clock.Sleep(dialTimeout)
+		return nil, err
+	}
+	return local, nil
+}